@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"os"
@@ -16,10 +17,12 @@ import (
 func main() {
 	// Parse command line flags
 	var (
-		port    = flag.Int("port", pkgtcp.DefaultEchoPort, "Server port to connect to")
-		host    = flag.String("host", "localhost", "Server host to connect to")
-		verbose = flag.Bool("verbose", false, "Enable verbose logging")
-		message = flag.String("message", "", "Single message to send (non-interactive mode)")
+		port     = flag.Int("port", pkgtcp.DefaultEchoPort, "Server port to connect to")
+		host     = flag.String("host", "localhost", "Server host to connect to")
+		verbose  = flag.Bool("verbose", false, "Enable verbose logging")
+		message  = flag.String("message", "", "Single message to send (non-interactive mode)")
+		useTLS   = flag.Bool("tls", false, "Connect over TLS")
+		insecure = flag.Bool("insecure", false, "Skip TLS certificate verification")
 	)
 	flag.Parse()
 
@@ -34,6 +37,9 @@ func main() {
 
 	// Create dialer
 	dialer := tcp.NewDialer()
+	if *useTLS {
+		dialer = tcp.NewTLSDialer(&tls.Config{InsecureSkipVerify: *insecure})
+	}
 
 	// Connect to server
 	logger.Info("Connecting to TCP Echo Server at %s", address)
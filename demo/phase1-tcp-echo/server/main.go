@@ -19,6 +19,8 @@ func main() {
 		port    = flag.Int("port", pkgtcp.DefaultEchoPort, "Port to listen on")
 		host    = flag.String("host", "localhost", "Host to bind to")
 		verbose = flag.Bool("verbose", false, "Enable verbose logging")
+		cert    = flag.String("cert", "", "TLS certificate file (enables TLS with -key)")
+		key     = flag.String("key", "", "TLS private key file")
 	)
 	flag.Parse()
 
@@ -31,8 +33,18 @@ func main() {
 	// Create server address
 	address := fmt.Sprintf("%s:%d", *host, *port)
 
-	// Create TCP server
-	server, err := tcp.NewServer("tcp", address)
+	// Create TCP server, serving TLS when a certificate is given
+	config := tcp.DefaultServerConfig()
+	if *cert != "" || *key != "" {
+		tlsConfig, err := tcp.LoadTLSConfig(*cert, *key)
+		if err != nil {
+			logger.Error("Failed to load TLS certificate: %v", err)
+			os.Exit(1)
+		}
+		config.TLSConfig = tlsConfig
+	}
+
+	server, err := tcp.NewServerWithConfig("tcp", address, config)
 	if err != nil {
 		logger.Error("Failed to create server: %v", err)
 		os.Exit(1)
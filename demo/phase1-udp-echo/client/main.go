@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	"github.com/ganyariya/tinyserver/internal/udp"
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+func main() {
+	// Parse command line flags
+	var (
+		port    = flag.Int("port", pkgudp.DefaultEchoPort, "Server port to send to")
+		host    = flag.String("host", "localhost", "Server host to send to")
+		verbose = flag.Bool("verbose", false, "Enable verbose logging")
+		message = flag.String("message", "", "Single message to send (non-interactive mode)")
+	)
+	flag.Parse()
+
+	// Set up logger
+	logger := common.NewDefaultLogger()
+	if *verbose {
+		logger.SetLevel(common.LogLevelDebug)
+	}
+
+	// Create server address
+	address := fmt.Sprintf("%s:%d", *host, *port)
+
+	client, err := udp.NewClient(pkgudp.NetworkUDP)
+	if err != nil {
+		logger.Error("Failed to create client: %v", err)
+		os.Exit(1)
+	}
+
+	// UDP has no handshake, so this only fixes the peer
+	if err := client.Connect(address); err != nil {
+		logger.Error("Failed to connect to server: %v", err)
+		os.Exit(1)
+	}
+	defer client.Disconnect()
+
+	// Check if we're in single message mode
+	if *message != "" {
+		response, err := exchange(client, *message)
+		if err != nil {
+			logger.Error("Echo failed: %v", err)
+			os.Exit(1)
+		}
+		logger.Info("Echo response: %q", response)
+		return
+	}
+
+	// Interactive mode
+	fmt.Println("UDP Echo Client - Interactive Mode")
+	fmt.Println("==================================")
+	fmt.Println("Type your message and press Enter. Type 'quit' to exit.")
+	fmt.Println()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			break
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		if input == "quit" || input == "exit" {
+			fmt.Println("Goodbye!")
+			break
+		}
+		if input == "" {
+			continue
+		}
+
+		// A lost datagram is reported rather than retried
+		response, err := exchange(client, input)
+		if err != nil {
+			logger.Warn("No echo received: %v", err)
+			continue
+		}
+		fmt.Printf("Echo: %s\n\n", response)
+	}
+}
+
+// exchange sends one datagram and waits for the reply
+func exchange(client pkgudp.Client, message string) (string, error) {
+	if err := client.Send([]byte(message)); err != nil {
+		return "", err
+	}
+
+	buffer := make([]byte, pkgudp.MaxDatagramSize)
+	n, err := client.Receive(buffer)
+	if err != nil {
+		return "", err
+	}
+	return string(buffer[:n]), nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	"github.com/ganyariya/tinyserver/internal/udp"
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+func main() {
+	// Parse command line flags
+	var (
+		port    = flag.Int("port", pkgudp.DefaultEchoPort, "Port to listen on")
+		host    = flag.String("host", "localhost", "Host to bind to")
+		verbose = flag.Bool("verbose", false, "Enable verbose logging")
+	)
+	flag.Parse()
+
+	// Set up logger
+	logger := common.NewDefaultLogger()
+	if *verbose {
+		logger.SetLevel(common.LogLevelDebug)
+	}
+
+	// Create server address
+	address := fmt.Sprintf("%s:%d", *host, *port)
+
+	// Create UDP server
+	server, err := udp.NewServer(pkgudp.NetworkUDP, address)
+	if err != nil {
+		logger.Error("Failed to create server: %v", err)
+		os.Exit(1)
+	}
+
+	// Set up echo handler that logs each datagram
+	echo := udp.EchoHandler()
+	server.SetHandler(func(conn pkgudp.PacketConn, packet pkgudp.Packet) {
+		logger.Debug("Received from %s: %q", packet.Addr, string(packet.Data))
+		echo(conn, packet)
+	})
+
+	// Start server
+	logger.Info("Starting UDP Echo Server on %s", address)
+	if err := server.Start(); err != nil {
+		logger.Error("Failed to start server: %v", err)
+		os.Exit(1)
+	}
+
+	logger.Info("UDP Echo Server is running...")
+	logger.Info("Press Ctrl+C to stop the server")
+
+	// Wait for shutdown signal
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	<-signalChan
+
+	logger.Info("Shutting down server...")
+	if err := server.Stop(); err != nil {
+		logger.Error("Error during server shutdown: %v", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Server stopped successfully")
+}
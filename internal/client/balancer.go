@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// BalancingPolicy selects which upstream serves the next request
+type BalancingPolicy int
+
+const (
+	// RoundRobin sends requests to each upstream in turn
+	RoundRobin BalancingPolicy = iota
+
+	// LeastConnections sends requests to the upstream with the fewest in flight
+	LeastConnections
+)
+
+// upstream is one replica behind a Balancer
+type upstream struct {
+	base           *url.URL
+	active         int
+	unhealthyUntil time.Time
+}
+
+// Balancer spreads origin-form requests over a set of base URLs. Upstreams that
+// fail are passively marked unhealthy and skipped until a cooldown passes.
+type Balancer struct {
+	upstreams []*upstream
+	policy    BalancingPolicy
+	cooldown  time.Duration
+	next      int
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewBalancer creates a balancer over the given http(s) base URLs
+func NewBalancer(policy BalancingPolicy, baseURLs ...string) (*Balancer, error) {
+	if len(baseURLs) == 0 {
+		return nil, common.InvalidInputError(ErrNoUpstreams)
+	}
+
+	b := &Balancer{
+		policy:   policy,
+		cooldown: DefaultUnhealthyCooldown,
+		now:      time.Now,
+	}
+
+	for _, rawURL := range baseURLs {
+		base, err := url.Parse(rawURL)
+		if err != nil || base.Host == "" ||
+			(base.Scheme != pkghttp.SchemeHTTP && base.Scheme != pkghttp.SchemeHTTPS) {
+			return nil, common.InvalidInputErrorWithCause(ErrInvalidURL+": "+rawURL, err)
+		}
+		b.upstreams = append(b.upstreams, &upstream{base: base})
+	}
+
+	return b, nil
+}
+
+// SetCooldown sets how long a failed upstream is skipped
+func (b *Balancer) SetCooldown(cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cooldown = cooldown
+}
+
+// Interceptor returns a client interceptor that sends origin-form requests to a
+// chosen upstream. Requests with an absolute URL pass through unchanged.
+func (b *Balancer) Interceptor() pkghttp.InterceptorFunc {
+	return func(next pkghttp.RoundTripFunc) pkghttp.RoundTripFunc {
+		return func(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+			target, err := url.Parse(req.Path())
+			if err != nil || target.IsAbs() {
+				return next(ctx, req)
+			}
+
+			u := b.pick()
+			resp, err := next(ctx, requestWithPath(req, u.resolve(target)))
+			if err != nil {
+				b.release(u, ctx.Err() == nil)
+				return nil, err
+			}
+
+			failed := isUpstreamFailure(resp.StatusCode())
+			if resp.Body() == nil {
+				b.release(u, failed)
+				return resp, nil
+			}
+
+			// The request stays in flight until its body is finished
+			resp.SetBody(&balancedBody{body: resp.Body(), done: func() { b.release(u, failed) }})
+			return resp, nil
+		}
+	}
+}
+
+// pick selects the upstream for the next request and counts it as in flight
+func (b *Balancer) pick() *upstream {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	candidates := make([]int, 0, len(b.upstreams))
+	for offset := range b.upstreams {
+		i := (b.next + offset) % len(b.upstreams)
+		if !now.Before(b.upstreams[i].unhealthyUntil) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	// With every upstream failing, trying one beats failing outright
+	if len(candidates) == 0 {
+		for offset := range b.upstreams {
+			candidates = append(candidates, (b.next+offset)%len(b.upstreams))
+		}
+	}
+
+	chosen := candidates[0]
+	if b.policy == LeastConnections {
+		for _, i := range candidates[1:] {
+			if b.upstreams[i].active < b.upstreams[chosen].active {
+				chosen = i
+			}
+		}
+	}
+
+	b.next = (chosen + 1) % len(b.upstreams)
+	b.upstreams[chosen].active++
+	return b.upstreams[chosen]
+}
+
+// release ends a request on u, marking u unhealthy if it failed
+func (b *Balancer) release(u *upstream, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	u.active--
+	if failed {
+		u.unhealthyUntil = b.now().Add(b.cooldown)
+	} else {
+		u.unhealthyUntil = time.Time{}
+	}
+}
+
+// resolve places an origin-form target under the upstream base URL
+func (u *upstream) resolve(target *url.URL) string {
+	resolved := *u.base
+	resolved.Path = strings.TrimSuffix(u.base.Path, "/") + target.Path
+	resolved.RawPath = ""
+	resolved.RawQuery = target.RawQuery
+	return resolved.String()
+}
+
+// isUpstreamFailure reports whether a status shows the upstream itself is unavailable
+func isUpstreamFailure(status pkghttp.StatusCode) bool {
+	return status == pkghttp.StatusBadGateway ||
+		status == pkghttp.StatusServiceUnavailable ||
+		status == pkghttp.StatusGatewayTimeout
+}
+
+// requestWithPath copies req with a different request target
+func requestWithPath(req pkghttp.Request, path string) pkghttp.Request {
+	copied := pkghttp.NewRequestWithBody(req.Method(), path, req.Version(), req.Body())
+	for _, name := range req.HeaderNames() {
+		for _, value := range req.Headers()[name] {
+			copied.AddHeader(name, value)
+		}
+	}
+	copied.SetContext(req.Context())
+	return copied
+}
+
+// balancedBody reports the end of a response body to the balancer once
+type balancedBody struct {
+	body io.Reader
+	done func()
+	once sync.Once
+}
+
+// Read reads from the body, finishing the request at the end of the body
+func (b *balancedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if err != nil {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+// Close closes the body and finishes the request
+func (b *balancedBody) Close() error {
+	defer b.once.Do(b.done)
+	if closer, ok := b.body.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// attachCancel passes the request cancel function to the wrapped body
+func (b *balancedBody) attachCancel(cancel context.CancelFunc) bool {
+	if body, ok := b.body.(cancelAttacher); ok {
+		return body.attachCancel(cancel)
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// nameHandler responds with a fixed upstream name
+func nameHandler(name string) pkghttp.RequestHandler {
+	return func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, name+" "+req.Path())
+	}
+}
+
+// closedURL returns a URL on which nothing is listening
+func closedURL(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	return "http://" + address
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	balancer, err := NewBalancer(RoundRobin,
+		startTestServer(t, nameHandler("a")),
+		startTestServer(t, nameHandler("b"))+"/v1/",
+		startTestServer(t, nameHandler("c")))
+	if err != nil {
+		t.Fatalf("NewBalancer failed: %v", err)
+	}
+
+	client := newTestClient(t)
+	client.Use(balancer.Interceptor())
+
+	expected := []string{"a /items?id=1", "b /v1/items?id=1", "c /items?id=1", "a /items?id=1"}
+	for i, want := range expected {
+		resp, err := client.Get("/items?id=1")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if got := readBody(t, resp); got != want {
+			t.Errorf("request %d: expected %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestBalancerPassiveHealth(t *testing.T) {
+	balancer, err := NewBalancer(RoundRobin, closedURL(t), startTestServer(t, nameHandler("up")))
+	if err != nil {
+		t.Fatalf("NewBalancer failed: %v", err)
+	}
+	now := time.Now()
+	balancer.now = func() time.Time { return now }
+
+	client := newTestClient(t)
+	client.Use(balancer.Interceptor())
+
+	if _, err := client.Get("/"); err == nil {
+		t.Fatal("Expected the first request to hit the closed upstream and fail")
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("/")
+		if err != nil {
+			t.Fatalf("request %d should skip the unhealthy upstream: %v", i, err)
+		}
+		if got := readBody(t, resp); got != "up /" {
+			t.Errorf("request %d: expected the healthy upstream, got %q", i, got)
+		}
+	}
+
+	// After the cooldown the failed upstream is tried again
+	now = now.Add(DefaultUnhealthyCooldown + time.Second)
+	if _, err := client.Get("/"); err == nil {
+		t.Error("Expected the recovered upstream to be retried")
+	}
+}
+
+func TestBalancerLeastConnections(t *testing.T) {
+	balancer, err := NewBalancer(LeastConnections, "http://a", "http://b", "http://c")
+	if err != nil {
+		t.Fatalf("NewBalancer failed: %v", err)
+	}
+
+	first := balancer.pick()
+	second := balancer.pick()
+	third := balancer.pick()
+	if first == second || second == third || first == third {
+		t.Fatal("Expected each idle upstream to be picked once")
+	}
+
+	balancer.release(second, false)
+	if next := balancer.pick(); next != second {
+		t.Errorf("Expected the upstream with no requests in flight, got %s", next.base.Host)
+	}
+}
+
+func TestBalancerPassesAbsoluteURLs(t *testing.T) {
+	direct := startTestServer(t, nameHandler("direct"))
+	balancer, err := NewBalancer(RoundRobin, "http://unused.invalid")
+	if err != nil {
+		t.Fatalf("NewBalancer failed: %v", err)
+	}
+
+	client := newTestClient(t)
+	client.Use(balancer.Interceptor())
+
+	resp, err := client.Get(direct + "/x")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got := readBody(t, resp); got != "direct /x" {
+		t.Errorf("Expected the absolute URL to be used, got %q", got)
+	}
+}
+
+func TestNewBalancerValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		urls []string
+	}{
+		{name: "no upstreams"},
+		{name: "missing host", urls: []string{"http://"}},
+		{name: "unsupported scheme", urls: []string{"ftp://example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBalancer(RoundRobin, tt.urls...); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestUpstreamResolve(t *testing.T) {
+	base, _ := url.Parse("https://api.example.com/v2/")
+	target, _ := url.Parse("/users?id=7")
+
+	if got := (&upstream{base: base}).resolve(target); got != "https://api.example.com/v2/users?id=7" {
+		t.Errorf("Unexpected resolved URL %q", got)
+	}
+}
@@ -0,0 +1,416 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// cacheEntry is a stored GET response with its freshness metadata
+type cacheEntry struct {
+	key        string
+	status     pkghttp.StatusCode
+	version    pkghttp.Version
+	headers    pkghttp.Header
+	body       []byte
+	vary       map[string]string
+	storedAt   time.Time
+	initialAge time.Duration
+	lifetime   time.Duration
+	noCache    bool
+
+	// staleWindow is how long past its lifetime the entry may be served while
+	// it is revalidated in the background (RFC 5861 stale-while-revalidate)
+	staleWindow time.Duration
+}
+
+// Cache is a private HTTP cache for client GET responses. Fresh entries are
+// served without a request; stale ones are revalidated with their ETag or
+// Last-Modified validators. Within a stale-while-revalidate window the stale
+// entry is served at once and revalidated in the background, one refresh per
+// entry at a time.
+type Cache struct {
+	entries    map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	now        func() time.Time
+	refreshing map[string]struct{}
+	refreshes  sync.WaitGroup
+	mu         sync.Mutex
+}
+
+// NewCache creates a cache holding at most maxEntries responses.
+// Zero or less uses DefaultCacheEntries.
+func NewCache(maxEntries int) *Cache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheEntries
+	}
+
+	return &Cache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		now:        time.Now,
+		refreshing: make(map[string]struct{}),
+	}
+}
+
+// Len returns the number of stored responses
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Interceptor returns a client interceptor that answers GET requests from the cache
+func (c *Cache) Interceptor() pkghttp.InterceptorFunc {
+	return func(next pkghttp.RoundTripFunc) pkghttp.RoundTripFunc {
+		return func(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+			if req.Method() != pkghttp.MethodGet {
+				return next(ctx, req)
+			}
+
+			target, err := requestURL(req)
+			if err != nil {
+				return next(ctx, req)
+			}
+			key := target.String()
+
+			// A caller's own conditional request expects to see the 304 itself
+			requestDirectives := parseCacheControl(req.GetHeader(pkghttp.HeaderCacheControl))
+			_, noStore := requestDirectives[cacheDirectiveNoStore]
+			if noStore || req.HasHeader(pkghttp.HeaderIfNoneMatch) || req.HasHeader(pkghttp.HeaderIfModifiedSince) {
+				return next(ctx, req)
+			}
+
+			entry := c.lookup(key, req)
+			if entry != nil {
+				_, forceRevalidate := requestDirectives[cacheDirectiveNoCache]
+				if !forceRevalidate && c.isFresh(entry) {
+					return c.cachedResponse(entry), nil
+				}
+				if !forceRevalidate && c.isStaleServable(entry) {
+					c.revalidateInBackground(ctx, next, key, req, entry)
+					return c.cachedResponse(entry), nil
+				}
+				req = c.conditionalRequest(req, entry)
+			}
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+
+			if entry != nil && resp.StatusCode() == pkghttp.StatusNotModified {
+				CloseBody(resp)
+				c.refresh(entry, resp)
+				return c.cachedResponse(entry), nil
+			}
+
+			return c.store(key, req, resp)
+		}
+	}
+}
+
+// lookup returns the entry for key if its Vary headers match req
+func (c *Cache) lookup(key string, req pkghttp.Request) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*cacheEntry)
+	for name, value := range entry.vary {
+		if req.GetHeader(name) != value {
+			return nil
+		}
+	}
+
+	c.order.MoveToFront(element)
+	return entry
+}
+
+// isFresh reports whether entry can be served without revalidation
+func (c *Cache) isFresh(entry *cacheEntry) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !entry.noCache && c.age(entry) < entry.lifetime
+}
+
+// isStaleServable reports whether a stale entry is still within its
+// stale-while-revalidate window
+func (c *Cache) isStaleServable(entry *cacheEntry) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !entry.noCache && c.age(entry) < entry.lifetime+entry.staleWindow
+}
+
+// revalidateInBackground refreshes a stale entry without holding up the
+// caller. Nothing starts while a refresh for key is already running, and a
+// failed refresh leaves the entry to be revalidated by a later request.
+func (c *Cache) revalidateInBackground(ctx context.Context, next pkghttp.RoundTripFunc, key string, req pkghttp.Request, entry *cacheEntry) {
+	c.mu.Lock()
+	if _, running := c.refreshing[key]; running {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = struct{}{}
+	c.refreshes.Add(1)
+	c.mu.Unlock()
+
+	conditional := c.conditionalRequest(req, entry)
+	go func() {
+		defer c.refreshes.Done()
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+
+		// The caller already has its response and may cancel ctx at any time
+		resp, err := next(context.WithoutCancel(ctx), conditional)
+		if err != nil {
+			return
+		}
+
+		if resp.StatusCode() == pkghttp.StatusNotModified {
+			CloseBody(resp)
+			c.refresh(entry, resp)
+			return
+		}
+
+		if stored, err := c.store(key, conditional, resp); err == nil {
+			CloseBody(stored)
+		}
+	}()
+}
+
+// age returns how old the stored response is, including the age it arrived with
+func (c *Cache) age(entry *cacheEntry) time.Duration {
+	return entry.initialAge + c.now().Sub(entry.storedAt)
+}
+
+// cachedResponse builds a response from a stored entry
+func (c *Cache) cachedResponse(entry *cacheEntry) pkghttp.Response {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp := pkghttp.NewResponseWithBody(entry.status, entry.version, bytes.NewReader(entry.body))
+	for _, name := range entry.headers.OrderedNames(nil) {
+		for _, value := range entry.headers[name] {
+			resp.AddHeader(name, value)
+		}
+	}
+	resp.SetHeader(pkghttp.HeaderAge, strconv.Itoa(int(c.age(entry)/time.Second)))
+
+	return resp
+}
+
+// refresh updates a stored entry from a 304 response
+func (c *Cache) refresh(entry *cacheEntry, notModified pkghttp.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, values := range notModified.Headers() {
+		if strings.EqualFold(name, pkghttp.HeaderContentLength) {
+			continue
+		}
+		deleteHeader(entry.headers, name)
+		entry.headers[name] = append([]string(nil), values...)
+	}
+
+	entry.storedAt = c.now()
+	entry.initialAge = responseAge(notModified)
+	entry.lifetime, entry.noCache = freshness(entry.headers)
+	entry.staleWindow = staleWhileRevalidate(entry.headers)
+}
+
+// store saves a cacheable response and returns a response the caller can read.
+// Bodies over maxCacheBodySize are passed through uncached.
+func (c *Cache) store(key string, req pkghttp.Request, resp pkghttp.Response) (pkghttp.Response, error) {
+	if !isCacheable(resp) {
+		return resp, nil
+	}
+
+	var body []byte
+	if resp.Body() != nil {
+		data, err := io.ReadAll(io.LimitReader(resp.Body(), maxCacheBodySize+1))
+		if err != nil {
+			CloseBody(resp)
+			return nil, common.IOErrorWithCause("failed to read response body", err)
+		}
+		if len(data) > maxCacheBodySize {
+			resp.SetBody(&prefixedBody{Reader: io.MultiReader(bytes.NewReader(data), resp.Body()), body: resp.Body()})
+			return resp, nil
+		}
+		CloseBody(resp)
+		body = data
+	}
+
+	entry := &cacheEntry{
+		key:        key,
+		status:     resp.StatusCode(),
+		version:    resp.Version(),
+		headers:    make(pkghttp.Header),
+		body:       body,
+		vary:       make(map[string]string),
+		storedAt:   c.now(),
+		initialAge: responseAge(resp),
+	}
+	for name, values := range resp.Headers() {
+		entry.headers[name] = append([]string(nil), values...)
+	}
+	for _, name := range strings.Split(resp.GetHeader(pkghttp.HeaderVary), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			entry.vary[name] = req.GetHeader(name)
+		}
+	}
+	entry.lifetime, entry.noCache = freshness(entry.headers)
+	entry.staleWindow = staleWhileRevalidate(entry.headers)
+
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+	c.mu.Unlock()
+
+	resp.SetBody(bytes.NewReader(body))
+	return resp, nil
+}
+
+// isCacheable reports whether resp may be stored and later reused or revalidated
+func isCacheable(resp pkghttp.Response) bool {
+	if resp.StatusCode() != pkghttp.StatusOK {
+		return false
+	}
+	if strings.TrimSpace(resp.GetHeader(pkghttp.HeaderVary)) == "*" {
+		return false
+	}
+
+	directives := parseCacheControl(resp.GetHeader(pkghttp.HeaderCacheControl))
+	if _, noStore := directives[cacheDirectiveNoStore]; noStore {
+		return false
+	}
+
+	lifetime, _ := freshness(resp.Headers())
+	return lifetime > 0 ||
+		resp.HasHeader(pkghttp.HeaderETag) ||
+		resp.HasHeader(pkghttp.HeaderLastModified)
+}
+
+// freshness returns the freshness lifetime from Cache-Control max-age or Expires,
+// and whether every reuse must be revalidated
+func freshness(headers pkghttp.Header) (time.Duration, bool) {
+	directives := parseCacheControl(headerValue(headers, pkghttp.HeaderCacheControl))
+	_, noCache := directives[cacheDirectiveNoCache]
+
+	if maxAge, ok := directives[cacheDirectiveMaxAge]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds < 0 {
+			return 0, noCache
+		}
+		return time.Duration(seconds) * time.Second, noCache
+	}
+
+	expires, err := common.ParseHTTPDate(headerValue(headers, pkghttp.HeaderExpires))
+	if err != nil {
+		return 0, noCache
+	}
+	date, err := common.ParseHTTPDate(headerValue(headers, pkghttp.HeaderDate))
+	if err != nil {
+		date = time.Now()
+	}
+	if lifetime := expires.Sub(date); lifetime > 0 {
+		return lifetime, noCache
+	}
+	return 0, noCache
+}
+
+// staleWhileRevalidate returns how long past its lifetime a response may be
+// served while it is revalidated in the background
+func staleWhileRevalidate(headers pkghttp.Header) time.Duration {
+	directives := parseCacheControl(headerValue(headers, pkghttp.HeaderCacheControl))
+	seconds, err := strconv.Atoi(directives[cacheDirectiveStaleWhileRevalidate])
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// responseAge returns the Age the response arrived with
+func responseAge(resp pkghttp.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.GetHeader(pkghttp.HeaderAge))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// conditionalRequest copies req with the stored entry's validators added
+func (c *Cache) conditionalRequest(req pkghttp.Request, entry *cacheEntry) pkghttp.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conditional := requestWithPath(req, req.Path())
+	if etag := headerValue(entry.headers, pkghttp.HeaderETag); etag != "" {
+		conditional.SetHeader(pkghttp.HeaderIfNoneMatch, etag)
+	}
+	if modified := headerValue(entry.headers, pkghttp.HeaderLastModified); modified != "" {
+		conditional.SetHeader(pkghttp.HeaderIfModifiedSince, modified)
+	}
+	return conditional
+}
+
+// parseCacheControl splits a Cache-Control value into lowercase directives and their arguments
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		name, argument, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(argument, `"`)
+	}
+	return directives
+}
+
+// headerValue returns the first value of name, matching case-insensitively
+func headerValue(headers pkghttp.Header, name string) string {
+	for key, values := range headers {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// prefixedBody replays bytes already read ahead of the rest of a body
+type prefixedBody struct {
+	io.Reader
+	body io.Reader
+}
+
+// Close closes the underlying body
+func (b *prefixedBody) Close() error {
+	if closer, ok := b.body.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// startCachingServer serves a versioned resource with the given Cache-Control
+// and answers matching If-None-Match requests with 304
+func startCachingServer(t *testing.T, cacheControl string, hits *int32) string {
+	t.Helper()
+
+	return startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		count := atomic.AddInt32(hits, 1)
+
+		if req.GetHeader(pkghttp.HeaderIfNoneMatch) == `"v1"` {
+			resp := pkghttp.NewResponse(pkghttp.StatusNotModified, pkghttp.Version11)
+			resp.SetHeader(pkghttp.HeaderETag, `"v1"`)
+			resp.SetHeader(pkghttp.HeaderCacheControl, cacheControl)
+			return resp
+		}
+
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "body "+strconv.Itoa(int(count)))
+		resp.SetHeader(pkghttp.HeaderETag, `"v1"`)
+		if cacheControl != "" {
+			resp.SetHeader(pkghttp.HeaderCacheControl, cacheControl)
+		}
+		return resp
+	})
+}
+
+// newCachingClient returns a client using cache with a controllable clock
+func newCachingClient(t *testing.T, cache *Cache, now *time.Time) *Client {
+	t.Helper()
+
+	cache.now = func() time.Time { return *now }
+	client := newTestClient(t)
+	client.Use(cache.Interceptor())
+	return client
+}
+
+// getBody performs a GET and returns the body
+func getBody(t *testing.T, client *Client, req pkghttp.Request) (pkghttp.Response, string) {
+	t.Helper()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	return resp, readBody(t, resp)
+}
+
+func TestCacheServesFreshAndRevalidatesStale(t *testing.T) {
+	var hits int32
+	baseURL := startCachingServer(t, "max-age=60", &hits)
+	now := time.Now()
+	client := newCachingClient(t, NewCache(0), &now)
+
+	newGet := func() pkghttp.Request {
+		return pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/resource", pkghttp.Version11)
+	}
+
+	if _, body := getBody(t, client, newGet()); body != "body 1" {
+		t.Fatalf("Unexpected first body %q", body)
+	}
+
+	now = now.Add(30 * time.Second)
+	resp, body := getBody(t, client, newGet())
+	if body != "body 1" || atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("Expected a fresh cache hit, got %q after %d requests", body, atomic.LoadInt32(&hits))
+	}
+	if age := resp.GetHeader(pkghttp.HeaderAge); age != "30" {
+		t.Errorf("Expected Age 30, got %q", age)
+	}
+
+	// Once stale the entry is revalidated and the 304 refreshes it
+	now = now.Add(time.Minute)
+	resp, body = getBody(t, client, newGet())
+	if body != "body 1" || resp.StatusCode() != pkghttp.StatusOK || atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Expected a revalidated cached body, got %d %q after %d requests", resp.StatusCode(), body, atomic.LoadInt32(&hits))
+	}
+
+	if _, body := getBody(t, client, newGet()); body != "body 1" || atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Expected the refreshed entry to be fresh again, got %q after %d requests", body, atomic.LoadInt32(&hits))
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		count := atomic.AddInt32(&hits, 1)
+		if count > 1 {
+			<-release
+		}
+
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "body "+strconv.Itoa(int(count)))
+		resp.SetHeader(pkghttp.HeaderETag, `"v`+strconv.Itoa(int(count))+`"`)
+		resp.SetHeader(pkghttp.HeaderCacheControl, "max-age=60, stale-while-revalidate=60")
+		return resp
+	})
+	now := time.Now()
+	cache := NewCache(0)
+	client := newCachingClient(t, cache, &now)
+
+	newGet := func() pkghttp.Request {
+		return pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/resource", pkghttp.Version11)
+	}
+
+	if _, body := getBody(t, client, newGet()); body != "body 1" {
+		t.Fatalf("Unexpected first body %q", body)
+	}
+
+	// Stale but within the window: served at once while one refresh waits on the server
+	now = now.Add(90 * time.Second)
+	for i := 0; i < 3; i++ {
+		resp, body := getBody(t, client, newGet())
+		if body != "body 1" {
+			close(release)
+			t.Fatalf("Expected the stale body, got %q", body)
+		}
+		if age := resp.GetHeader(pkghttp.HeaderAge); age != "90" {
+			t.Errorf("Expected Age 90, got %q", age)
+		}
+	}
+
+	close(release)
+	cache.refreshes.Wait()
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("Expected a single background refresh, got %d requests", got)
+	}
+
+	if _, body := getBody(t, client, newGet()); body != "body 2" || atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Expected the refreshed entry from the cache, got %q after %d requests", body, atomic.LoadInt32(&hits))
+	}
+
+	// Past the window the entry is revalidated before responding
+	now = now.Add(3 * time.Minute)
+	if _, body := getBody(t, client, newGet()); body != "body 3" || atomic.LoadInt32(&hits) != 3 {
+		t.Errorf("Expected a synchronous refetch, got %q after %d requests", body, atomic.LoadInt32(&hits))
+	}
+}
+
+func TestCacheDirectives(t *testing.T) {
+	tests := []struct {
+		name           string
+		cacheControl   string
+		requestControl string
+		expectedHits   int32
+		expectedBody   string
+	}{
+		{name: "no-store is never cached", cacheControl: "no-store", expectedHits: 2, expectedBody: "body 2"},
+		{name: "no-cache revalidates every time", cacheControl: "no-cache", expectedHits: 2, expectedBody: "body 1"},
+		{name: "validators alone require revalidation", expectedHits: 2, expectedBody: "body 1"},
+		{name: "request no-cache forces revalidation", cacheControl: "max-age=60", requestControl: "no-cache", expectedHits: 2, expectedBody: "body 1"},
+		{name: "request no-store bypasses the cache", cacheControl: "max-age=60", requestControl: "no-store", expectedHits: 2, expectedBody: "body 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			baseURL := startCachingServer(t, tt.cacheControl, &hits)
+			now := time.Now()
+			client := newCachingClient(t, NewCache(0), &now)
+
+			var body string
+			for i := 0; i < 2; i++ {
+				req := pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11)
+				if tt.requestControl != "" {
+					req.SetHeader(pkghttp.HeaderCacheControl, tt.requestControl)
+				}
+				_, body = getBody(t, client, req)
+			}
+
+			if hits := atomic.LoadInt32(&hits); hits != tt.expectedHits {
+				t.Errorf("Expected %d server hits, got %d", tt.expectedHits, hits)
+			}
+			if body != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestCacheCallerConditionalRequest(t *testing.T) {
+	var hits int32
+	baseURL := startCachingServer(t, "max-age=60", &hits)
+	now := time.Now()
+	client := newCachingClient(t, NewCache(0), &now)
+
+	getBody(t, client, pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11))
+
+	req := pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderIfNoneMatch, `"v1"`)
+	if resp, _ := getBody(t, client, req); resp.StatusCode() != pkghttp.StatusNotModified {
+		t.Errorf("Expected the caller to receive 304, got %d", resp.StatusCode())
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	var hits int32
+	baseURL := startCachingServer(t, "max-age=60", &hits)
+	now := time.Now()
+	cache := NewCache(1)
+	client := newCachingClient(t, cache, &now)
+
+	for _, path := range []string{"/a", "/b", "/a"} {
+		getBody(t, client, pkghttp.NewRequest(pkghttp.MethodGet, baseURL+path, pkghttp.Version11))
+	}
+
+	if cache.Len() != 1 {
+		t.Errorf("Expected 1 entry, got %d", cache.Len())
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 3 {
+		t.Errorf("Expected /a to be evicted and fetched again, got %d hits", hits)
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		headers  pkghttp.Header
+		lifetime time.Duration
+		noCache  bool
+	}{
+		{name: "max-age", headers: pkghttp.Header{"Cache-Control": {"public, max-age=120"}}, lifetime: 2 * time.Minute},
+		{
+			name: "expires",
+			headers: pkghttp.Header{
+				"Date":    {date.Format("Mon, 02 Jan 2006 15:04:05 GMT")},
+				"Expires": {date.Add(time.Hour).Format("Mon, 02 Jan 2006 15:04:05 GMT")},
+			},
+			lifetime: time.Hour,
+		},
+		{name: "max-age wins over expires", headers: pkghttp.Header{"Cache-Control": {"max-age=5"}, "Expires": {"garbage"}}, lifetime: 5 * time.Second},
+		{name: "no-cache", headers: pkghttp.Header{"Cache-Control": {"no-cache"}}, noCache: true},
+		{name: "nothing", headers: pkghttp.Header{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifetime, noCache := freshness(tt.headers)
+			if lifetime != tt.lifetime || noCache != tt.noCache {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.lifetime, tt.noCache, lifetime, noCache)
+			}
+		})
+	}
+}
@@ -0,0 +1,607 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	"github.com/ganyariya/tinyserver/internal/tcp"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// Client is an HTTP/1.1 client that keeps idle connections open for reuse
+type Client struct {
+	dialer             pkgtcp.Dialer
+	pool               *connPool
+	proxy              ProxyFunc
+	checkRedirect      CheckRedirectFunc
+	disableCompression bool
+	tlsConfig          *tls.Config
+	unixSocket         string
+	transport          pkghttp.Transport
+	interceptors       []pkghttp.InterceptorFunc
+	timeout            time.Duration
+	headers            pkghttp.Header
+	mu                 sync.RWMutex
+}
+
+// NewClient creates a new HTTP client with default settings
+func NewClient() *Client {
+	return &Client{
+		dialer:  tcp.NewDialer(),
+		pool:    newConnPool(),
+		timeout: pkghttp.DefaultRequestTimeout,
+		headers: pkghttp.Header{pkghttp.HeaderUserAgent: {common.UserAgent}},
+	}
+}
+
+// Get sends a GET request
+func (c *Client) Get(rawURL string) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequest(pkghttp.MethodGet, rawURL, pkghttp.Version11))
+}
+
+// Post sends a POST request
+func (c *Client) Post(rawURL string, body io.Reader) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequestWithBody(pkghttp.MethodPost, rawURL, pkghttp.Version11, body))
+}
+
+// Put sends a PUT request
+func (c *Client) Put(rawURL string, body io.Reader) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequestWithBody(pkghttp.MethodPut, rawURL, pkghttp.Version11, body))
+}
+
+// Delete sends a DELETE request
+func (c *Client) Delete(rawURL string) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequest(pkghttp.MethodDelete, rawURL, pkghttp.Version11))
+}
+
+// Head sends a HEAD request
+func (c *Client) Head(rawURL string) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequest(pkghttp.MethodHead, rawURL, pkghttp.Version11))
+}
+
+// Options sends an OPTIONS request
+func (c *Client) Options(rawURL string) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequest(pkghttp.MethodOptions, rawURL, pkghttp.Version11))
+}
+
+// Patch sends a PATCH request
+func (c *Client) Patch(rawURL string, body io.Reader) (pkghttp.Response, error) {
+	return c.Do(pkghttp.NewRequestWithBody(pkghttp.MethodPatch, rawURL, pkghttp.Version11, body))
+}
+
+// PostForm sends a POST request with values as a URL-encoded form body
+func (c *Client) PostForm(rawURL string, values url.Values) (pkghttp.Response, error) {
+	req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, rawURL, pkghttp.Version11, strings.NewReader(values.Encode()))
+	req.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeForm)
+	return c.Do(req)
+}
+
+// PostJSON sends a POST request with v encoded as a JSON body
+func (c *Client) PostJSON(rawURL string, v interface{}) (pkghttp.Response, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, common.InvalidInputErrorWithCause("failed to encode JSON body", err)
+	}
+
+	req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, rawURL, pkghttp.Version11, bytes.NewReader(data))
+	req.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeJSON)
+	return c.Do(req)
+}
+
+// Do sends req under the request's own context and returns the response.
+// The request path is either an absolute http(s) URL or an origin-form path
+// with a Host header. The response body must be read to the end or closed so its
+// connection can be reused.
+func (c *Client) Do(req pkghttp.Request) (pkghttp.Response, error) {
+	return c.DoWithContext(req.Context(), req)
+}
+
+// DoWithContext sends req and returns the response. Cancelling ctx, or reaching
+// its deadline, aborts the request, including a response body still being read.
+// The client timeout still applies when it is the earlier deadline.
+func (c *Client) DoWithContext(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	resp, err := c.followRedirects(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The request context lives until the body is consumed or closed
+	if body, ok := resp.Body().(cancelAttacher); !ok || !body.attachCancel(cancel) {
+		cancel()
+	}
+
+	return resp, nil
+}
+
+// send is the built-in transport: a single exchange for req over a pooled
+// connection, without following redirects
+func (c *Client) send(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+	target, err := requestURL(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.routeFor(target)
+	if err != nil {
+		return nil, err
+	}
+
+	outbound, err := c.outboundRequest(req, target, r)
+	if err != nil {
+		return nil, err
+	}
+
+	compressionRequested := c.requestsCompression(req)
+	if compressionRequested {
+		outbound.SetHeader(pkghttp.HeaderAcceptEncoding, strings.Join(internalhttp.ContentCodings(), ", "))
+	}
+
+	resp, err := c.roundTrip(ctx, outbound, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if compressionRequested {
+		decodeContent(resp)
+	}
+
+	return resp, nil
+}
+
+// SetTimeout sets the deadline for a whole exchange, including reading the body.
+// Zero disables the timeout.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = timeout
+}
+
+// SetHeader sets a default header sent with every request that does not set it
+func (c *Client) SetHeader(name, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers.Set(name, value)
+}
+
+// SetMaxIdleConnsPerHost limits how many idle connections are kept for each host.
+// Zero disables connection reuse.
+func (c *Client) SetMaxIdleConnsPerHost(max int) {
+	c.pool.setMaxIdlePerHost(max)
+}
+
+// SetIdleConnTimeout sets how long an idle connection is kept before it is closed.
+// Zero keeps idle connections until the server closes them.
+func (c *Client) SetIdleConnTimeout(timeout time.Duration) {
+	c.pool.setIdleTimeout(timeout)
+}
+
+// CloseIdleConnections closes every connection that is not carrying a request
+func (c *Client) CloseIdleConnections() {
+	c.pool.closeIdle()
+}
+
+// roundTrip sends the request over a pooled or new connection for r
+func (c *Client) roundTrip(ctx context.Context, req pkghttp.Request, r route) (pkghttp.Response, error) {
+	if pc := c.pool.get(r.key()); pc != nil {
+		resp, err := c.exchange(ctx, pc, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		// The server may have closed the idle connection before the request
+		// reached it. Without a response the server may also have processed
+		// it, so only an idempotent request with no body to replay is resent.
+		if pc.responded || !isIdempotent(req) || req.Body() != nil || contextErr(ctx) != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := c.connect(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.exchange(ctx, newPersistConn(conn, r.key()), req)
+}
+
+// dial opens a new connection to address on network within the context deadline
+func (c *Client) dial(ctx context.Context, network, address string) (pkgtcp.Connection, error) {
+	var conn pkgtcp.Connection
+	var err error
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn, err = c.dialer.DialTimeout(network, address, time.Until(deadline))
+	} else {
+		conn, err = c.dialer.Dial(network, address)
+	}
+
+	if ctxErr := contextErr(ctx); ctxErr != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, contextError(ctxErr)
+	}
+	if err != nil {
+		return nil, common.ClientErrorWithCause(ErrRequestFailed, err)
+	}
+
+	return conn, nil
+}
+
+// exchange writes req to pc and reads the response head. Cancelling ctx
+// closes the connection, interrupting any read or write in progress.
+func (c *Client) exchange(ctx context.Context, pc *persistConn, req pkghttp.Request) (pkghttp.Response, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		pc.conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, pc.close)
+	pc.responded = false
+
+	fail := func(err error) (pkghttp.Response, error) {
+		stop()
+		pc.close()
+		if ctxErr := contextErr(ctx); ctxErr != nil {
+			return nil, contextError(ctxErr)
+		}
+		return nil, common.ClientErrorWithCause(ErrRequestFailed, err)
+	}
+
+	if err := internalhttp.WriteRequest(pc.writer, req); err != nil {
+		return fail(err)
+	}
+	if err := pc.writer.Flush(); err != nil {
+		return fail(err)
+	}
+
+	if _, err := pc.reader.Peek(1); err != nil {
+		return fail(err)
+	}
+	pc.responded = true
+
+	resp, err := internalhttp.ReadResponseForMethod(pc.reader, req.Method())
+	if err != nil {
+		return fail(err)
+	}
+
+	reusable := canReuse(req, resp)
+	if resp.Body() == nil {
+		c.release(pc, stop, reusable)
+		return resp, nil
+	}
+
+	resp.SetBody(&responseBody{reader: resp.Body(), pc: pc, client: c, stop: stop, ctx: ctx, reusable: reusable})
+	return resp, nil
+}
+
+// release returns pc to the pool, or closes it when it cannot carry another request.
+// stop detaches the cancellation hook; if it already fired the connection is closed.
+func (c *Client) release(pc *persistConn, stop func() bool, reusable bool) {
+	if !stop() || !reusable {
+		pc.close()
+		return
+	}
+
+	pc.conn.SetDeadline(time.Time{})
+	c.pool.put(pc)
+}
+
+// withTimeout applies the client timeout to ctx
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := c.getTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// outboundRequest builds the request written to the connection: origin form,
+// or absolute form when it goes to a proxy without a tunnel
+func (c *Client) outboundRequest(req pkghttp.Request, target *url.URL, r route) (pkghttp.Request, error) {
+	body, contentLength := requestBody(req)
+
+	requestTarget := target.RequestURI()
+	if r.absoluteForm() {
+		requestTarget = target.Scheme + "://" + target.Host + requestTarget
+	}
+
+	outbound := pkghttp.NewRequestWithBody(req.Method(), requestTarget, pkghttp.Version11, body)
+	for _, name := range req.HeaderNames() {
+		if strings.EqualFold(name, pkghttp.HeaderContentLength) ||
+			strings.EqualFold(name, pkghttp.HeaderTransferEncoding) {
+			continue
+		}
+		for _, value := range req.Headers()[name] {
+			outbound.AddHeader(name, value)
+		}
+	}
+
+	c.mu.RLock()
+	for _, name := range c.headers.OrderedNames(nil) {
+		if !outbound.HasHeader(name) {
+			for _, value := range c.headers[name] {
+				outbound.AddHeader(name, value)
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	if !outbound.HasHeader(pkghttp.HeaderHost) {
+		outbound.SetHeader(pkghttp.HeaderHost, target.Host)
+	}
+	if authorization := r.proxyAuthorization(); authorization != "" && r.absoluteForm() {
+		outbound.SetHeader(pkghttp.HeaderProxyAuthorization, authorization)
+	}
+	switch {
+	case contentLength == unknownLength:
+		outbound.SetHeader(pkghttp.HeaderTransferEncoding, pkghttp.TransferEncodingChunked)
+	case body != nil || methodExpectsBody(req.Method()):
+		outbound.SetHeader(pkghttp.HeaderContentLength, strconv.FormatInt(contentLength, 10))
+	}
+
+	return outbound, nil
+}
+
+// getTimeout returns the configured exchange timeout
+func (c *Client) getTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.timeout
+}
+
+// responseBody streams a response body straight from the connection and hands
+// the connection back once the body is consumed or closed
+type responseBody struct {
+	reader   io.Reader
+	pc       *persistConn
+	client   *Client
+	ctx      context.Context
+	stop     func() bool
+	cancel   context.CancelFunc
+	reusable bool
+	reading  bool
+	done     bool
+	closed   bool
+	mu       sync.Mutex
+}
+
+// cancelAttacher is implemented by bodies that release the request context when finished
+type cancelAttacher interface {
+	attachCancel(cancel context.CancelFunc) bool
+}
+
+// attachCancel makes finishing the body cancel the request context
+func (b *responseBody) attachCancel(cancel context.CancelFunc) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.done {
+		return false
+	}
+	b.cancel = cancel
+	return true
+}
+
+// Read reads from the connection, releasing it at the end of the body.
+// The lock is not held during I/O so Close can interrupt a blocked Read.
+func (b *responseBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return 0, common.IOError(ErrBodyClosed)
+	}
+	if b.done {
+		b.mu.Unlock()
+		return 0, io.EOF
+	}
+	b.reading = true
+	b.mu.Unlock()
+
+	n, err := b.reader.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reading = false
+
+	switch {
+	case b.closed:
+		// Close already released the connection
+		if err != nil {
+			err = common.IOError(ErrBodyClosed)
+		}
+	case err == io.EOF:
+		b.finish(b.reusable)
+	case err != nil:
+		b.finish(false)
+		if ctxErr := contextErr(b.ctx); ctxErr != nil {
+			err = contextError(ctxErr)
+		}
+	}
+
+	return n, err
+}
+
+// Close stops the stream. A small unread remainder is discarded so the
+// connection can be reused; otherwise the connection is closed, which also
+// interrupts a Read blocked in another goroutine.
+func (b *responseBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	if b.done {
+		return nil
+	}
+
+	if !b.reusable || b.reading {
+		b.finish(false)
+		return nil
+	}
+
+	_, err := io.CopyN(io.Discard, b.reader, maxBodyDrainSize+1)
+	b.finish(err == io.EOF)
+	return nil
+}
+
+// finish releases the connection and the request context exactly once
+func (b *responseBody) finish(reusable bool) {
+	b.done = true
+	b.client.release(b.pc, b.stop, reusable)
+	if b.cancel != nil {
+		b.cancel()
+	}
+}
+
+// CloseBody closes the body of a client response, if it has one, releasing its
+// connection. Bodies read to the end release their connection without it.
+func CloseBody(resp pkghttp.Response) error {
+	if resp.Body() == nil {
+		return nil
+	}
+	return resp.Body().Close()
+}
+
+// contextErr returns why ctx ended. A connection deadline taken from ctx can
+// expire before the context timer fires, so a passed deadline counts as ended.
+func contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// contextError reports a request aborted by its context, keeping the context error as cause
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return common.TimeoutErrorWithCause(ErrRequestTimeout, err)
+	}
+	return common.ClientErrorWithCause(ErrRequestCanceled, err)
+}
+
+// requestURL resolves the request target to an absolute http(s) URL
+func requestURL(req pkghttp.Request) (*url.URL, error) {
+	target, err := url.Parse(req.Path())
+	if err != nil {
+		return nil, common.InvalidInputErrorWithCause(ErrInvalidURL, err)
+	}
+
+	if target.Scheme == "" {
+		target.Scheme = pkghttp.SchemeHTTP
+		target.Host = req.GetHeader(pkghttp.HeaderHost)
+	}
+
+	if target.Scheme != pkghttp.SchemeHTTP && target.Scheme != pkghttp.SchemeHTTPS {
+		return nil, common.InvalidInputError(ErrUnsupportedScheme + ": " + target.Scheme)
+	}
+	if target.Host == "" {
+		return nil, common.InvalidInputError(ErrInvalidURL)
+	}
+
+	return target, nil
+}
+
+// hostAddress returns the host:port to dial for target
+func hostAddress(target *url.URL) string {
+	if target.Port() != "" {
+		return target.Host
+	}
+	return net.JoinHostPort(target.Hostname(), strconv.Itoa(defaultPort(target.Scheme)))
+}
+
+// defaultPort returns the well-known port for scheme
+func defaultPort(scheme string) int {
+	if scheme == pkghttp.SchemeHTTPS {
+		return pkghttp.DefaultHTTPSPort
+	}
+	return pkghttp.DefaultHTTPPort
+}
+
+// requestBody returns the body to send and its length. The length comes from an
+// explicit Content-Length header or a body that knows its size; otherwise it is
+// unknownLength and the body is sent chunked as it is read.
+func requestBody(req pkghttp.Request) (io.Reader, int64) {
+	body := req.Body()
+	if body == nil {
+		return nil, 0
+	}
+
+	if sized, ok := pkghttp.BodyReader(body).(interface{ Len() int }); ok {
+		return body, int64(sized.Len())
+	}
+
+	if req.HasHeader(pkghttp.HeaderContentLength) {
+		if contentLength := req.ContentLength(); contentLength >= 0 {
+			return io.LimitReader(body, contentLength), contentLength
+		}
+	}
+
+	return body, unknownLength
+}
+
+// methodExpectsBody reports whether an empty body should still be framed with Content-Length: 0
+func methodExpectsBody(method pkghttp.Method) bool {
+	return method == pkghttp.MethodPost || method == pkghttp.MethodPut || method == pkghttp.MethodPatch
+}
+
+// canReuse reports whether the connection can carry another request after resp.
+// An HTTP/1.0 server keeps the connection only when it answers "Connection: keep-alive".
+func canReuse(req pkghttp.Request, resp pkghttp.Response) bool {
+	if resp.Version() == pkghttp.Version10 &&
+		!hasToken(resp.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionKeepAlive) {
+		return false
+	}
+	if hasToken(req.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionClose) ||
+		hasToken(resp.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionClose) {
+		return false
+	}
+
+	// Without framing the body is delimited by the connection closing
+	return resp.Body() == nil ||
+		resp.HasHeader(pkghttp.HeaderContentLength) ||
+		resp.HasHeader(pkghttp.HeaderTransferEncoding)
+}
+
+// isIdempotent reports whether sending req twice has the same effect as
+// sending it once: GET, HEAD, OPTIONS, TRACE, PUT and DELETE are, and so is
+// any request carrying an Idempotency-Key
+func isIdempotent(req pkghttp.Request) bool {
+	switch req.Method() {
+	case pkghttp.MethodGet, pkghttp.MethodHead, pkghttp.MethodOptions, methodTrace,
+		pkghttp.MethodPut, pkghttp.MethodDelete:
+		return true
+	}
+	return req.HasHeader(pkghttp.HeaderIdempotencyKey)
+}
+
+// hasToken reports whether a comma-separated header value contains token
+func hasToken(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
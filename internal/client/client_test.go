@@ -0,0 +1,736 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	"github.com/ganyariya/tinyserver/internal/server"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// startTestServer starts an HTTP server on a free port and stops it when the test ends
+func startTestServer(t *testing.T, handler pkghttp.RequestHandler) string {
+	t.Helper()
+
+	srv, err := server.NewServer("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	srv.SetHandler(handler)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop() })
+
+	return "http://" + srv.Addr().String()
+}
+
+// newTestClient creates a client whose idle connections are closed when the test ends
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	client := NewClient()
+	t.Cleanup(client.CloseIdleConnections)
+	return client
+}
+
+// remoteAddrHandler responds with the client address the server saw
+func remoteAddrHandler(req pkghttp.Request) pkghttp.Response {
+	return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.RemoteAddr().String())
+}
+
+// readBody reads and closes a response body
+func readBody(t *testing.T, resp pkghttp.Response) string {
+	t.Helper()
+
+	if resp.Body() == nil {
+		return ""
+	}
+	data, err := io.ReadAll(resp.Body())
+	if err != nil {
+		t.Fatalf("reading body failed: %v", err)
+	}
+	if closer, ok := resp.Body().(io.Closer); ok {
+		closer.Close()
+	}
+	return string(data)
+}
+
+func TestClientGet(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			req.Path()+" "+req.GetHeader(pkghttp.HeaderUserAgent)+" "+req.GetHeader("X-Default"))
+	})
+
+	client := newTestClient(t)
+	client.SetHeader("X-Default", "yes")
+
+	resp, err := client.Get(baseURL + "/hello?name=tiny")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode())
+	}
+	if body := readBody(t, resp); !strings.HasPrefix(body, "/hello?name=tiny TinyServer/") || !strings.HasSuffix(body, " yes") {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestClientPost(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		var data []byte
+		if req.Body() != nil {
+			data, _ = io.ReadAll(req.Body())
+		}
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			string(req.Method())+" "+req.GetHeader(pkghttp.HeaderContentLength)+" "+string(data))
+	})
+
+	client := newTestClient(t)
+
+	tests := []struct {
+		name     string
+		send     func() (pkghttp.Response, error)
+		expected string
+	}{
+		{
+			name:     "sized body",
+			send:     func() (pkghttp.Response, error) { return client.Post(baseURL+"/", strings.NewReader("hello")) },
+			expected: "POST 5 hello",
+		},
+		{
+			name: "declared length",
+			send: func() (pkghttp.Response, error) {
+				req := pkghttp.NewRequestWithBody(pkghttp.MethodPut, baseURL+"/", pkghttp.Version11,
+					io.MultiReader(strings.NewReader("ab"), strings.NewReader("cd")))
+				req.SetHeader(pkghttp.HeaderContentLength, "4")
+				return client.Do(req)
+			},
+			expected: "PUT 4 abcd",
+		},
+		{
+			name:     "nil body",
+			send:     func() (pkghttp.Response, error) { return client.Post(baseURL+"/", nil) },
+			expected: "POST 0 ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.send()
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if body := readBody(t, resp); body != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	baseURL := startTestServer(t, remoteAddrHandler)
+	client := newTestClient(t)
+
+	first, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("first Get failed: %v", err)
+	}
+	firstAddr := readBody(t, first)
+
+	second, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("second Get failed: %v", err)
+	}
+	if secondAddr := readBody(t, second); secondAddr != firstAddr {
+		t.Errorf("Expected connection %s to be reused, got %s", firstAddr, secondAddr)
+	}
+}
+
+func TestCanReuse(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    pkghttp.Version
+		connection string
+		expected   bool
+	}{
+		{name: "HTTP/1.1", version: pkghttp.Version11, expected: true},
+		{name: "HTTP/1.1 close", version: pkghttp.Version11, connection: pkghttp.ConnectionClose},
+		{name: "HTTP/1.0", version: pkghttp.Version10},
+		{name: "HTTP/1.0 keep-alive", version: pkghttp.Version10, connection: "Keep-Alive", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+			resp := pkghttp.NewResponse(pkghttp.StatusOK, tt.version)
+			if tt.connection != "" {
+				resp.SetHeader(pkghttp.HeaderConnection, tt.connection)
+			}
+
+			if got := canReuse(req, resp); got != tt.expected {
+				t.Errorf("canReuse = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientConnectionReuseDisabled(t *testing.T) {
+	baseURL := startTestServer(t, remoteAddrHandler)
+	client := newTestClient(t)
+	client.SetMaxIdleConnsPerHost(0)
+
+	first, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("first Get failed: %v", err)
+	}
+	firstAddr := readBody(t, first)
+
+	second, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("second Get failed: %v", err)
+	}
+	if secondAddr := readBody(t, second); secondAddr == firstAddr {
+		t.Errorf("Expected a new connection, got %s again", secondAddr)
+	}
+}
+
+func TestClientDoesNotReuseClosedConnection(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		resp := remoteAddrHandler(req)
+		resp.SetHeader(pkghttp.HeaderConnection, pkghttp.ConnectionClose)
+		return resp
+	})
+	client := newTestClient(t)
+
+	first, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("first Get failed: %v", err)
+	}
+	firstAddr := readBody(t, first)
+
+	second, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("second Get failed: %v", err)
+	}
+	if secondAddr := readBody(t, second); secondAddr == firstAddr {
+		t.Errorf("Expected a new connection after Connection: close, got %s again", secondAddr)
+	}
+}
+
+func TestClientRetriesStaleConnection(t *testing.T) {
+	tests := []struct {
+		name      string
+		newReq    func(url string) pkghttp.Request
+		wantRetry bool
+	}{
+		{
+			name:      "GET",
+			newReq:    func(url string) pkghttp.Request { return pkghttp.NewRequest(pkghttp.MethodGet, url, pkghttp.Version11) },
+			wantRetry: true,
+		},
+		{
+			name: "DELETE",
+			newReq: func(url string) pkghttp.Request {
+				return pkghttp.NewRequest(pkghttp.MethodDelete, url, pkghttp.Version11)
+			},
+			wantRetry: true,
+		},
+		{
+			name: "POST",
+			newReq: func(url string) pkghttp.Request {
+				return pkghttp.NewRequest(pkghttp.MethodPost, url, pkghttp.Version11)
+			},
+		},
+		{
+			name: "PATCH",
+			newReq: func(url string) pkghttp.Request {
+				return pkghttp.NewRequest(pkghttp.MethodPatch, url, pkghttp.Version11)
+			},
+		},
+		{
+			name: "POST with an idempotency key",
+			newReq: func(url string) pkghttp.Request {
+				req := pkghttp.NewRequest(pkghttp.MethodPost, url, pkghttp.Version11)
+				req.SetHeader(pkghttp.HeaderIdempotencyKey, "order-1")
+				return req
+			},
+			wantRetry: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL := startTestServer(t, remoteAddrHandler)
+			client := newTestClient(t)
+
+			first, err := client.Get(baseURL + "/")
+			if err != nil {
+				t.Fatalf("first Get failed: %v", err)
+			}
+			readBody(t, first)
+
+			// Simulate the server dropping the idle connection
+			pc := client.pool.get(baseURL)
+			if pc == nil {
+				t.Fatal("Expected an idle connection in the pool")
+			}
+			pc.close()
+			client.pool.put(pc)
+
+			second, err := client.Do(tt.newReq(baseURL + "/"))
+			if !tt.wantRetry {
+				if err == nil {
+					readBody(t, second)
+					t.Fatal("A non-idempotent request on a stale connection should not be retried")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Request on a stale connection should be retried: %v", err)
+			}
+			if resp := readBody(t, second); resp == "" {
+				t.Error("Expected a response body")
+			}
+		})
+	}
+}
+
+func TestClientDoesNotRetryAfterPartialResponse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	// The first request gets a full response; the second only part of one
+	accepted := make(chan struct{}, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for _, reply := range []string{
+					"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok",
+					"HTTP/1.1 200 OK\r\n",
+				} {
+					if _, err := internalhttp.ReadRequest(reader, conn.RemoteAddr()); err != nil {
+						return
+					}
+					io.WriteString(conn, reply)
+				}
+			}(conn)
+		}
+	}()
+
+	client := newTestClient(t)
+	baseURL := "http://" + listener.Addr().String()
+
+	first, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("first Get failed: %v", err)
+	}
+	readBody(t, first)
+
+	if _, err := client.Get(baseURL + "/"); err == nil {
+		t.Fatal("Expected the truncated response to fail")
+	}
+	if n := len(accepted); n != 1 {
+		t.Errorf("Expected no retry after response bytes were read, got %d connections", n)
+	}
+}
+
+func TestClientRequestURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		host        string
+		expected    string
+		expectError bool
+	}{
+		{name: "absolute", path: "http://example.com/a?b=c", expected: "example.com:80"},
+		{name: "explicit port", path: "http://example.com:8080/", expected: "example.com:8080"},
+		{name: "origin form with host", path: "/a", host: "localhost:9000", expected: "localhost:9000"},
+		{name: "origin form without host", path: "/a", expectError: true},
+		{name: "unsupported scheme", path: "ftp://example.com/", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11)
+			if tt.host != "" {
+				req.SetHeader(pkghttp.HeaderHost, tt.host)
+			}
+
+			target, err := requestURL(req)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if address := hostAddress(target); address != tt.expected {
+				t.Errorf("Expected address %s, got %s", tt.expected, address)
+			}
+		})
+	}
+}
+
+// startBlockingServer starts a server whose handler waits until the test ends
+func startBlockingServer(t *testing.T) string {
+	t.Helper()
+
+	release := make(chan struct{})
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		<-release
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "late")
+	})
+	t.Cleanup(func() { close(release) })
+
+	return baseURL
+}
+
+// startStallingServer starts a server that streams prefix and then stalls until the test ends
+func startStallingServer(t *testing.T, prefix string) string {
+	t.Helper()
+
+	release := make(chan struct{})
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.Write([]byte(prefix))
+			<-release
+			writer.Close()
+		}()
+		return pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, reader)
+	})
+	t.Cleanup(func() { close(release) })
+
+	return baseURL
+}
+
+func TestClientDoWithContextCancel(t *testing.T) {
+	baseURL := startBlockingServer(t)
+	client := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.DoWithContext(ctx, pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Cancellation took too long: %v", elapsed)
+	}
+}
+
+func TestClientDoWithContextDeadline(t *testing.T) {
+	baseURL := startBlockingServer(t)
+	client := newTestClient(t)
+
+	tests := []struct {
+		name string
+		send func() error
+	}{
+		{
+			name: "context deadline",
+			send: func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				_, err := client.DoWithContext(ctx, pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11))
+				return err
+			},
+		},
+		{
+			name: "request context",
+			send: func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				req := pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11)
+				req.SetContext(ctx)
+				_, err := client.Do(req)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.send(); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClientDoWithContextAlreadyCanceled(t *testing.T) {
+	client := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.DoWithContext(ctx, pkghttp.NewRequest(pkghttp.MethodGet, "http://127.0.0.1:1/", pkghttp.Version11))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestClientCancelWhileReadingBody(t *testing.T) {
+	baseURL := startStallingServer(t, "first")
+	client := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resp, err := client.DoWithContext(ctx, pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11))
+	if err != nil {
+		t.Fatalf("DoWithContext failed: %v", err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := io.ReadAll(resp.Body()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected body read to fail with context.Canceled, got %v", err)
+	}
+}
+
+func TestClientChunkedRequestBody(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		br := bufio.NewReader(conn)
+		req, err := internalhttp.ReadRequest(br, conn.RemoteAddr())
+		if err != nil {
+			received <- "error: " + err.Error()
+			return
+		}
+		body, _ := io.ReadAll(internalhttp.NewChunkedReader(br))
+		received <- req.GetHeader(pkghttp.HeaderTransferEncoding) + " " + req.GetHeader(pkghttp.HeaderContentLength) + " " + string(body)
+
+		io.WriteString(conn, "HTTP/1.1 204 No Content\r\n\r\n")
+	}()
+
+	client := newTestClient(t)
+
+	// A pipe has no length, so the body must be streamed
+	reader, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("hello "))
+		writer.Write([]byte("chunks"))
+		writer.Close()
+	}()
+
+	resp, err := client.Post("http://"+listener.Addr().String()+"/upload", reader)
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if resp.StatusCode() != pkghttp.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", resp.StatusCode())
+	}
+
+	if got := <-received; got != "chunked  hello chunks" {
+		t.Errorf("Unexpected request seen by server: %q", got)
+	}
+}
+
+func TestClientStreamsResponseBody(t *testing.T) {
+	proceed := make(chan struct{})
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.Write([]byte("first"))
+			<-proceed
+			writer.Write([]byte("second"))
+			writer.Close()
+		}()
+		return pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, reader)
+	})
+	client := newTestClient(t)
+	client.SetTimeout(5 * time.Second)
+
+	resp, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// The first part arrives before the server has finished the body
+	first := make([]byte, 5)
+	if _, err := io.ReadFull(resp.Body(), first); err != nil {
+		t.Fatalf("reading the first part failed: %v", err)
+	}
+	close(proceed)
+
+	if rest := readBody(t, resp); string(first)+rest != "firstsecond" {
+		t.Errorf("Unexpected body %q", string(first)+rest)
+	}
+}
+
+func TestClientCloseBodyReuse(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		reused   bool
+		readSize int
+	}{
+		{name: "small remainder is drained", size: 1024, readSize: 10, reused: true},
+		{name: "large remainder closes the connection", size: maxBodyDrainSize * 4, readSize: 10, reused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+				resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, strings.Repeat("x", tt.size))
+				resp.SetHeader("X-Remote", req.RemoteAddr().String())
+				return resp
+			})
+			client := newTestClient(t)
+
+			first, err := client.Get(baseURL + "/")
+			if err != nil {
+				t.Fatalf("first Get failed: %v", err)
+			}
+			io.ReadFull(first.Body(), make([]byte, tt.readSize))
+			if err := CloseBody(first); err != nil {
+				t.Fatalf("CloseBody failed: %v", err)
+			}
+			if _, err := first.Body().Read(make([]byte, 1)); err == nil {
+				t.Error("Expected Read after Close to fail")
+			}
+
+			second, err := client.Get(baseURL + "/")
+			if err != nil {
+				t.Fatalf("second Get failed: %v", err)
+			}
+			CloseBody(second)
+
+			if reused := first.GetHeader("X-Remote") == second.GetHeader("X-Remote"); reused != tt.reused {
+				t.Errorf("Expected reused=%v, got %v", tt.reused, reused)
+			}
+		})
+	}
+}
+
+func TestClientCloseInterruptsBodyRead(t *testing.T) {
+	streamURL := startStallingServer(t, "partial")
+	client := newTestClient(t)
+
+	resp, err := client.Get(streamURL + "/")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	io.ReadFull(resp.Body(), make([]byte, len("partial")))
+
+	time.AfterFunc(50*time.Millisecond, func() { CloseBody(resp) })
+
+	start := time.Now()
+	if _, err := resp.Body().Read(make([]byte, 16)); err == nil {
+		t.Error("Expected the blocked Read to fail after Close")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close did not interrupt the Read promptly: %v", elapsed)
+	}
+}
+
+func TestClientConvenienceHelpers(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		var body []byte
+		if req.Body() != nil {
+			body, _ = io.ReadAll(req.Body())
+		}
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			string(req.Method())+"|"+req.GetHeader(pkghttp.HeaderContentType)+"|"+string(body))
+		resp.SetHeader("X-Method", string(req.Method()))
+		return resp
+	})
+	client := newTestClient(t)
+
+	tests := []struct {
+		name     string
+		send     func() (pkghttp.Response, error)
+		expected string
+	}{
+		{
+			name:     "Head",
+			send:     func() (pkghttp.Response, error) { return client.Head(baseURL + "/") },
+			expected: "",
+		},
+		{
+			name:     "Options",
+			send:     func() (pkghttp.Response, error) { return client.Options(baseURL + "/") },
+			expected: "OPTIONS||",
+		},
+		{
+			name:     "Patch",
+			send:     func() (pkghttp.Response, error) { return client.Patch(baseURL+"/", strings.NewReader("delta")) },
+			expected: "PATCH||delta",
+		},
+		{
+			name: "PostForm",
+			send: func() (pkghttp.Response, error) {
+				return client.PostForm(baseURL+"/", url.Values{"name": {"tiny server"}, "page": {"2"}})
+			},
+			expected: "POST|application/x-www-form-urlencoded|name=tiny+server&page=2",
+		},
+		{
+			name: "PostJSON",
+			send: func() (pkghttp.Response, error) {
+				return client.PostJSON(baseURL+"/", map[string]interface{}{"id": 7, "ok": true})
+			},
+			expected: `POST|application/json|{"id":7,"ok":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.send()
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode() != pkghttp.StatusOK {
+				t.Errorf("Expected status 200, got %d", resp.StatusCode())
+			}
+			if body := readBody(t, resp); body != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestClientPostJSONEncodeError(t *testing.T) {
+	client := newTestClient(t)
+
+	if _, err := client.PostJSON("http://127.0.0.1:1/", make(chan int)); err == nil {
+		t.Error("Expected an encoding error, got nil")
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// Connection pool settings
+const (
+	// DefaultMaxIdleConnsPerHost is how many idle connections are kept per host
+	DefaultMaxIdleConnsPerHost = 2
+
+	// DefaultIdleConnTimeout is how long an idle connection may wait for reuse
+	DefaultIdleConnTimeout = 90 * time.Second
+
+	// connectionReaderSize is the buffer size used to read responses
+	connectionReaderSize = 4096
+
+	// connectionWriterSize is the buffer size used to write requests
+	connectionWriterSize = 4096
+
+	// maxBodyDrainSize is how much unread response body we discard to reuse a connection
+	maxBodyDrainSize = 256 * 1024
+
+	// unknownLength marks a request body that is sent with chunked encoding
+	unknownLength = -1
+)
+
+// Retry settings
+const (
+	// methodTrace is the TRACE method, which is idempotent but not among the standard methods
+	methodTrace pkghttp.Method = "TRACE"
+)
+
+// Load balancing settings
+const (
+	// DefaultUnhealthyCooldown is how long a failed upstream is skipped
+	DefaultUnhealthyCooldown = 10 * time.Second
+)
+
+// Response cache settings
+const (
+	// DefaultCacheEntries is how many responses a cache holds by default
+	DefaultCacheEntries = 256
+
+	// maxCacheBodySize is the largest body the cache stores
+	maxCacheBodySize = 1024 * 1024
+
+	// cacheDirectiveMaxAge limits how long a response stays fresh
+	cacheDirectiveMaxAge = "max-age"
+	// cacheDirectiveNoCache requires revalidation before every reuse
+	cacheDirectiveNoCache = "no-cache"
+	// cacheDirectiveNoStore forbids storing the response
+	cacheDirectiveNoStore = "no-store"
+	// cacheDirectiveStaleWhileRevalidate allows serving a stale response while it is revalidated
+	cacheDirectiveStaleWhileRevalidate = "stale-while-revalidate"
+)
+
+// Redirect settings
+const (
+	// DefaultMaxRedirects is how many redirects the default policy follows
+	DefaultMaxRedirects = 10
+)
+
+// Client error messages
+const (
+	// ErrInvalidURL indicates the request target could not be resolved to a host
+	ErrInvalidURL = "invalid request URL"
+	// ErrUnsupportedScheme indicates a URL scheme the client cannot speak
+	ErrUnsupportedScheme = "unsupported URL scheme"
+	// ErrRequestFailed indicates the exchange with the server failed
+	ErrRequestFailed = "request failed"
+	// ErrRequestCanceled indicates the request context was cancelled
+	ErrRequestCanceled = "request canceled"
+	// ErrRequestTimeout indicates the request deadline passed
+	ErrRequestTimeout = "request timed out"
+	// ErrInvalidProxy indicates a proxy URL the client cannot use
+	ErrInvalidProxy = "invalid proxy URL"
+	// ErrProxyConnect indicates the proxy refused or failed to open a tunnel
+	ErrProxyConnect = "proxy CONNECT failed"
+	// ErrTLSHandshake indicates the TLS handshake with the origin failed
+	ErrTLSHandshake = "TLS handshake failed"
+	// ErrInvalidRootCA indicates a root CA file without usable certificates
+	ErrInvalidRootCA = "no certificates found in root CA file"
+	// ErrInvalidClientCert indicates a client certificate or key that cannot be loaded
+	ErrInvalidClientCert = "invalid client certificate"
+	// ErrTooManyRedirects indicates the default redirect policy gave up
+	ErrTooManyRedirects = "stopped after too many redirects"
+	// ErrInvalidRedirect indicates a redirect without a usable Location
+	ErrInvalidRedirect = "invalid redirect location"
+	// ErrNoUpstreams indicates a balancer created without base URLs
+	ErrNoUpstreams = "no upstream URLs configured"
+	// ErrInvalidUnixSocket indicates a socket address not in "unix:///path" form
+	ErrInvalidUnixSocket = "invalid unix socket address"
+	// ErrBodyClosed indicates a read from a response body after Close
+	ErrBodyClosed = "read on closed response body"
+)
+
+// Proxy environment variables
+const (
+	// envHTTPProxy names the proxy for http requests
+	envHTTPProxy = "HTTP_PROXY"
+	// envHTTPSProxy names the proxy for https requests
+	envHTTPSProxy = "HTTPS_PROXY"
+	// envNoProxy lists hosts that bypass the proxy
+	envNoProxy = "NO_PROXY"
+	// noProxyWildcard in NO_PROXY disables proxying entirely
+	noProxyWildcard = "*"
+)
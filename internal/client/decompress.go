@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// SetDisableCompression stops the client from requesting compressed responses.
+// Bodies are then returned exactly as the server sent them.
+func (c *Client) SetDisableCompression(disable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disableCompression = disable
+}
+
+// requestsCompression reports whether the client should ask for a compressed
+// response to req. A caller that sets Accept-Encoding or Range itself gets the
+// raw bytes.
+func (c *Client) requestsCompression(req pkghttp.Request) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return !c.disableCompression &&
+		req.Method() != pkghttp.MethodHead &&
+		!req.HasHeader(pkghttp.HeaderAcceptEncoding) &&
+		!req.HasHeader(pkghttp.HeaderRange)
+}
+
+// decodeContent replaces a body in any registered coding, such as gzip or a
+// registered br, with its decompressed stream. The encoding and length headers
+// are removed since they describe the raw bytes.
+func decodeContent(resp pkghttp.Response) {
+	name := strings.TrimSpace(resp.GetHeader(pkghttp.HeaderContentEncoding))
+	if resp.Body() == nil || name == "" {
+		return
+	}
+
+	coding, ok := internalhttp.LookupContentCoding(name)
+	if !ok {
+		return
+	}
+
+	deleteHeader(resp.Headers(), pkghttp.HeaderContentEncoding)
+	deleteHeader(resp.Headers(), pkghttp.HeaderContentLength)
+	resp.SetBody(&decodedBody{raw: resp.Body(), name: strings.ToLower(name), coding: coding})
+}
+
+// decodedBody decompresses a response body as it is read
+type decodedBody struct {
+	raw    io.Reader
+	name   string
+	coding internalhttp.ContentCoding
+	reader io.ReadCloser
+	err    error
+}
+
+// Read reads decompressed data, reading the coding's header on first use
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	if b.reader == nil {
+		reader, err := b.coding.NewReader(b.raw)
+		if err != nil {
+			b.err = common.IOErrorWithCause("invalid "+b.name+" response body", err)
+			return 0, b.err
+		}
+		b.reader = reader
+	}
+
+	n, err := b.reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = common.IOErrorWithCause("invalid "+b.name+" response body", err)
+	}
+	if err != nil {
+		b.err = err
+	}
+
+	return n, err
+}
+
+// Close closes the raw body, releasing its connection
+func (b *decodedBody) Close() error {
+	if b.reader != nil {
+		b.reader.Close()
+	}
+	if closer, ok := b.raw.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// attachCancel passes the request cancel function to the raw body
+func (b *decodedBody) attachCancel(cancel context.CancelFunc) bool {
+	if body, ok := b.raw.(cancelAttacher); ok {
+		return body.attachCancel(cancel)
+	}
+	return false
+}
+
+// deleteHeader removes every spelling of name from headers
+func deleteHeader(headers pkghttp.Header, name string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+}
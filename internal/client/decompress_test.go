@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	"github.com/ganyariya/tinyserver/internal/server"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// gzipHandler serves a gzip-encoded body when the client accepts it
+func gzipHandler(req pkghttp.Request) pkghttp.Response {
+	accepted := internalhttp.NegotiateContentCoding(req.GetHeader(pkghttp.HeaderAcceptEncoding), []string{common.EncodingGzip})
+	if accepted == "" {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "plain")
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte("compressed hello"))
+	writer.Close()
+
+	resp := pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, &buf)
+	resp.SetHeader(pkghttp.HeaderContentEncoding, common.EncodingGzip)
+	resp.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(buf.Len()))
+	return resp
+}
+
+func TestClientGzipResponses(t *testing.T) {
+	baseURL := startTestServer(t, gzipHandler)
+
+	tests := []struct {
+		name             string
+		disable          bool
+		acceptEncoding   string
+		expectedBody     string
+		expectedEncoding string
+	}{
+		{name: "decoded by default", expectedBody: "compressed hello"},
+		{name: "compression disabled", disable: true, expectedBody: "plain"},
+		{name: "explicit Accept-Encoding keeps raw bytes", acceptEncoding: common.EncodingGzip, expectedEncoding: common.EncodingGzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t)
+			client.SetDisableCompression(tt.disable)
+
+			req := pkghttp.NewRequest(pkghttp.MethodGet, baseURL+"/", pkghttp.Version11)
+			if tt.acceptEncoding != "" {
+				req.SetHeader(pkghttp.HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do failed: %v", err)
+			}
+			body := readBody(t, resp)
+
+			if encoding := resp.GetHeader(pkghttp.HeaderContentEncoding); encoding != tt.expectedEncoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.expectedEncoding, encoding)
+			}
+			if tt.expectedBody != "" && body != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+			}
+			if tt.expectedEncoding != "" && !bytes.HasPrefix([]byte(body), []byte{0x1f, 0x8b}) {
+				t.Errorf("Expected raw gzip bytes, got %q", body)
+			}
+		})
+	}
+}
+
+func TestClientGzipReusesConnection(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		resp := gzipHandler(req)
+		resp.SetHeader("X-Remote", req.RemoteAddr().String())
+		return resp
+	})
+	client := newTestClient(t)
+
+	first, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("first Get failed: %v", err)
+	}
+	readBody(t, first)
+
+	second, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("second Get failed: %v", err)
+	}
+	readBody(t, second)
+
+	if first.GetHeader("X-Remote") != second.GetHeader("X-Remote") {
+		t.Error("Expected the connection to be reused after a decoded body")
+	}
+}
+
+func TestClientDecodesRegisteredCodings(t *testing.T) {
+	// base64 stands in for a brotli implementation registered by the application
+	internalhttp.RegisterContentCoding(common.EncodingBrotli, internalhttp.ContentCoding{
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return base64.NewEncoder(base64.StdEncoding, w), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+		},
+	})
+
+	var acceptEncoding string
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		acceptEncoding = req.GetHeader(pkghttp.HeaderAcceptEncoding)
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, base64.StdEncoding.EncodeToString([]byte("brotli hello")))
+		resp.SetHeader(pkghttp.HeaderContentEncoding, common.EncodingBrotli)
+		return resp
+	})
+	client := newTestClient(t)
+
+	resp, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if body := readBody(t, resp); body != "brotli hello" {
+		t.Errorf("Expected decoded body, got %q", body)
+	}
+	if acceptEncoding != "br, gzip, deflate" {
+		t.Errorf("Expected br to be advertised first, got %q", acceptEncoding)
+	}
+	if resp.HasHeader(pkghttp.HeaderContentEncoding) {
+		t.Errorf("Expected Content-Encoding to be removed, got %q", resp.GetHeader(pkghttp.HeaderContentEncoding))
+	}
+}
+
+func TestClientNegotiatesRegisteredCodingWithServer(t *testing.T) {
+	// A stub br coding, as an application with a brotli library would register
+	var encoded, decoded atomic.Bool
+	internalhttp.RegisterContentCoding(common.EncodingBrotli, internalhttp.ContentCoding{
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			encoded.Store(true)
+			return base64.NewEncoder(base64.StdEncoding, w), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			decoded.Store(true)
+			return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+		},
+	})
+
+	compress := server.Compress(server.CompressConfig{MinSize: 1})
+	baseURL := startTestServer(t, compress(func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "negotiated hello")
+	}))
+	client := newTestClient(t)
+
+	resp, err := client.Get(baseURL + "/")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if body := readBody(t, resp); body != "negotiated hello" {
+		t.Errorf("Expected decoded body, got %q", body)
+	}
+	if !encoded.Load() || !decoded.Load() {
+		t.Errorf("Expected br to be negotiated end to end, encoded=%v decoded=%v", encoded.Load(), decoded.Load())
+	}
+}
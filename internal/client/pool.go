@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bufio"
+	"sync"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// persistConn is a client connection that can carry several requests in turn
+type persistConn struct {
+	conn      pkgtcp.Connection
+	reader    *bufio.Reader
+	writer    *bufio.Writer
+	key       string
+	idleSince time.Time
+	responded bool // the current exchange has read response bytes
+}
+
+// newPersistConn wraps a freshly dialed connection
+func newPersistConn(conn pkgtcp.Connection, key string) *persistConn {
+	return &persistConn{
+		conn:   conn,
+		reader: bufio.NewReaderSize(conn, connectionReaderSize),
+		writer: bufio.NewWriterSize(conn, connectionWriterSize),
+		key:    key,
+	}
+}
+
+// close closes the underlying connection
+func (pc *persistConn) close() {
+	pc.conn.Close()
+}
+
+// connPool keeps idle connections per host for reuse
+type connPool struct {
+	idle           map[string][]*persistConn
+	maxIdlePerHost int
+	idleTimeout    time.Duration
+	now            func() time.Time
+	mu             sync.Mutex
+}
+
+// newConnPool creates a pool with the default limits
+func newConnPool() *connPool {
+	return &connPool{
+		idle:           make(map[string][]*persistConn),
+		maxIdlePerHost: DefaultMaxIdleConnsPerHost,
+		idleTimeout:    DefaultIdleConnTimeout,
+		now:            time.Now,
+	}
+}
+
+// get returns the most recently used idle connection for key, or nil
+func (p *connPool) get(key string) *persistConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := p.idle[key]
+	for len(conns) > 0 {
+		pc := conns[len(conns)-1]
+		conns = conns[:len(conns)-1]
+
+		if p.idleTimeout > 0 && p.now().Sub(pc.idleSince) > p.idleTimeout {
+			pc.close()
+			continue
+		}
+
+		p.setIdle(key, conns)
+		return pc
+	}
+
+	p.setIdle(key, conns)
+	return nil
+}
+
+// put offers a connection for reuse, closing it if the host already has enough idle ones
+func (p *connPool) put(pc *persistConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := p.idle[pc.key]
+	if len(conns) >= p.maxIdlePerHost {
+		pc.close()
+		return
+	}
+
+	pc.idleSince = p.now()
+	p.idle[pc.key] = append(conns, pc)
+}
+
+// setIdle stores the idle list for key, dropping empty lists
+func (p *connPool) setIdle(key string, conns []*persistConn) {
+	if len(conns) == 0 {
+		delete(p.idle, key)
+		return
+	}
+	p.idle[key] = conns
+}
+
+// setMaxIdlePerHost changes the per-host limit, closing idle connections beyond it
+func (p *connPool) setMaxIdlePerHost(max int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxIdlePerHost = max
+	for key, conns := range p.idle {
+		for len(conns) > max {
+			conns[0].close()
+			conns = conns[1:]
+		}
+		p.setIdle(key, conns)
+	}
+}
+
+// setIdleTimeout changes how long idle connections stay reusable
+func (p *connPool) setIdleTimeout(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idleTimeout = timeout
+}
+
+// closeIdle closes every idle connection
+func (p *connPool) closeIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, conns := range p.idle {
+		for _, pc := range conns {
+			pc.close()
+		}
+		delete(p.idle, key)
+	}
+}
+
+// idleCount returns the number of idle connections for key
+func (p *connPool) idleCount(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle[key])
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/tcp"
+)
+
+// newTestPersistConn returns a pooled connection backed by an in-memory pipe
+func newTestPersistConn(t *testing.T, key string) *persistConn {
+	t.Helper()
+
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+
+	return newPersistConn(tcp.NewConnection(local), key)
+}
+
+func TestConnPoolGetPut(t *testing.T) {
+	pool := newConnPool()
+
+	if pc := pool.get("a:80"); pc != nil {
+		t.Fatal("Expected empty pool")
+	}
+
+	first := newTestPersistConn(t, "a:80")
+	second := newTestPersistConn(t, "a:80")
+	pool.put(first)
+	pool.put(second)
+
+	if pc := pool.get("a:80"); pc != second {
+		t.Error("Expected the most recently used connection first")
+	}
+	if pc := pool.get("a:80"); pc != first {
+		t.Error("Expected the remaining connection")
+	}
+	if pc := pool.get("a:80"); pc != nil {
+		t.Error("Expected the pool to be drained")
+	}
+}
+
+func TestConnPoolMaxIdlePerHost(t *testing.T) {
+	pool := newConnPool()
+	pool.setMaxIdlePerHost(1)
+
+	pool.put(newTestPersistConn(t, "a:80"))
+	pool.put(newTestPersistConn(t, "a:80"))
+	pool.put(newTestPersistConn(t, "b:80"))
+
+	if count := pool.idleCount("a:80"); count != 1 {
+		t.Errorf("Expected 1 idle connection for a:80, got %d", count)
+	}
+	if count := pool.idleCount("b:80"); count != 1 {
+		t.Errorf("Expected 1 idle connection for b:80, got %d", count)
+	}
+
+	pool.setMaxIdlePerHost(0)
+	if count := pool.idleCount("a:80"); count != 0 {
+		t.Errorf("Expected lowering the limit to close idle connections, got %d", count)
+	}
+}
+
+func TestConnPoolIdleTimeout(t *testing.T) {
+	pool := newConnPool()
+	pool.setIdleTimeout(time.Minute)
+
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	pool.put(newTestPersistConn(t, "a:80"))
+
+	now = now.Add(2 * time.Minute)
+	if pc := pool.get("a:80"); pc != nil {
+		t.Error("Expected the expired connection to be discarded")
+	}
+	if count := pool.idleCount("a:80"); count != 0 {
+		t.Errorf("Expected no idle connections, got %d", count)
+	}
+}
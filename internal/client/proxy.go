@@ -0,0 +1,271 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// ProxyFunc returns the proxy to use for a request URL, or nil to connect directly
+type ProxyFunc func(target *url.URL) (*url.URL, error)
+
+// ProxyURL returns a ProxyFunc that sends every request through proxy
+func ProxyURL(proxy *url.URL) ProxyFunc {
+	return func(*url.URL) (*url.URL, error) {
+		return proxy, nil
+	}
+}
+
+// ProxyFromEnvironment picks a proxy from HTTP_PROXY or HTTPS_PROXY according to
+// the target scheme, unless NO_PROXY excludes the host. Lowercase names are also
+// accepted. Requests to localhost and loopback addresses are never proxied.
+func ProxyFromEnvironment(target *url.URL) (*url.URL, error) {
+	return proxyFromEnv(target, os.Getenv)
+}
+
+// proxyFromEnv implements ProxyFromEnvironment using getenv
+func proxyFromEnv(target *url.URL, getenv func(string) string) (*url.URL, error) {
+	var rawProxy string
+	switch target.Scheme {
+	case pkghttp.SchemeHTTP:
+		rawProxy = lookupEnv(getenv, envHTTPProxy)
+	case pkghttp.SchemeHTTPS:
+		rawProxy = lookupEnv(getenv, envHTTPSProxy)
+	}
+
+	if rawProxy == "" || !useProxy(target, lookupEnv(getenv, envNoProxy)) {
+		return nil, nil
+	}
+
+	// Proxy settings often omit the scheme
+	if !strings.Contains(rawProxy, "://") {
+		rawProxy = pkghttp.SchemeHTTP + "://" + rawProxy
+	}
+
+	proxy, err := url.Parse(rawProxy)
+	if err != nil || proxy.Host == "" {
+		return nil, common.InvalidInputErrorWithCause(ErrInvalidProxy, err)
+	}
+	if proxy.Scheme != pkghttp.SchemeHTTP {
+		return nil, common.InvalidInputError(ErrInvalidProxy + ": " + proxy.Scheme)
+	}
+
+	return proxy, nil
+}
+
+// lookupEnv returns the uppercase variable, falling back to its lowercase spelling
+func lookupEnv(getenv func(string) string, name string) string {
+	if value := getenv(name); value != "" {
+		return value
+	}
+	return getenv(strings.ToLower(name))
+}
+
+// useProxy reports whether target should go through a proxy given a NO_PROXY list.
+// Entries match a host and its subdomains, optionally restricted to a port.
+func useProxy(target *url.URL, noProxy string) bool {
+	host := strings.ToLower(target.Hostname())
+	if host == "localhost" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return false
+	}
+
+	port := target.Port()
+	if port == "" {
+		port = strconv.Itoa(defaultPort(target.Scheme))
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == noProxyWildcard {
+			return false
+		}
+
+		if entryHost, entryPort, err := net.SplitHostPort(entry); err == nil {
+			if entryPort != port {
+				continue
+			}
+			entry = entryHost
+		}
+
+		entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// route describes how a connection reaches the origin server
+type route struct {
+	scheme  string
+	address string
+	socket  string
+	proxy   *url.URL
+}
+
+// key identifies connections that can be shared between requests on this route.
+// Plain HTTP through a proxy reuses the proxy connection for any origin.
+func (r route) key() string {
+	if r.proxy != nil && !r.tunnel() {
+		return r.proxy.Scheme + "://" + r.proxyAddress()
+	}
+
+	key := r.scheme + "://" + r.address
+	if r.socket != "" {
+		key = pkghttp.SchemeUnix + "://" + r.socket + "|" + key
+	}
+	if r.proxy != nil {
+		key = r.proxy.Scheme + "://" + r.proxyAddress() + "|" + key
+	}
+	return key
+}
+
+// tunnel reports whether the route needs a CONNECT tunnel through the proxy
+func (r route) tunnel() bool {
+	return r.proxy != nil && r.scheme == pkghttp.SchemeHTTPS
+}
+
+// absoluteForm reports whether requests on this route use an absolute-form target
+func (r route) absoluteForm() bool {
+	return r.proxy != nil && !r.tunnel()
+}
+
+// proxyAddress returns the host:port of the proxy
+func (r route) proxyAddress() string {
+	return hostAddress(r.proxy)
+}
+
+// network returns the network of the first hop
+func (r route) network() string {
+	if r.socket != "" {
+		return pkgtcp.NetworkUnix
+	}
+	return pkgtcp.NetworkTCP
+}
+
+// dialAddress returns the address of the first hop
+func (r route) dialAddress() string {
+	if r.socket != "" {
+		return r.socket
+	}
+	if r.proxy != nil {
+		return r.proxyAddress()
+	}
+	return r.address
+}
+
+// proxyAuthorization returns the Proxy-Authorization value for the proxy URL credentials
+func (r route) proxyAuthorization() string {
+	if r.proxy == nil || r.proxy.User == nil {
+		return ""
+	}
+
+	password, _ := r.proxy.User.Password()
+	credentials := r.proxy.User.Username() + ":" + password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
+
+// SetProxy sets how the client picks a proxy for each request. Nil connects directly.
+func (c *Client) SetProxy(proxy ProxyFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.proxy = proxy
+}
+
+// routeFor resolves the route a request to target takes
+func (c *Client) routeFor(target *url.URL) (route, error) {
+	c.mu.RLock()
+	proxy := c.proxy
+	socket := c.unixSocket
+	c.mu.RUnlock()
+
+	r := route{scheme: target.Scheme, address: hostAddress(target), socket: socket}
+
+	// A local socket is always reached directly
+	if proxy == nil || socket != "" {
+		return r, nil
+	}
+
+	proxyURL, err := proxy(target)
+	if err != nil {
+		return route{}, err
+	}
+	r.proxy = proxyURL
+
+	return r, nil
+}
+
+// connect opens a connection along r: directly or to the proxy, then through a
+// CONNECT tunnel and TLS as the route requires
+func (c *Client) connect(ctx context.Context, r route) (pkgtcp.Connection, error) {
+	conn, err := c.dial(ctx, r.network(), r.dialAddress())
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if r.tunnel() {
+		if err := establishTunnel(conn, r.address, r.proxyAuthorization()); err != nil {
+			conn.Close()
+			if ctxErr := contextErr(ctx); ctxErr != nil {
+				return nil, contextError(ctxErr)
+			}
+			return nil, err
+		}
+	}
+
+	if r.scheme != pkghttp.SchemeHTTPS {
+		return conn, nil
+	}
+
+	return c.handshakeTLS(ctx, conn, r.address)
+}
+
+// establishTunnel asks the proxy on conn to open a tunnel to address
+func establishTunnel(conn pkgtcp.Connection, address, authorization string) error {
+	req := pkghttp.NewRequest(pkghttp.MethodConnect, address, pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderHost, address)
+	if authorization != "" {
+		req.SetHeader(pkghttp.HeaderProxyAuthorization, authorization)
+	}
+
+	if err := internalhttp.WriteRequest(conn, req); err != nil {
+		return common.ClientErrorWithCause(ErrProxyConnect, err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := internalhttp.ReadResponseForMethod(br, pkghttp.MethodConnect)
+	if err != nil {
+		return common.ClientErrorWithCause(ErrProxyConnect, err)
+	}
+	if !pkghttp.IsSuccess(resp.StatusCode()) {
+		return common.ClientError(ErrProxyConnect + ": " + strconv.Itoa(int(resp.StatusCode())))
+	}
+
+	// The origin speaks only after our first write, so nothing may follow the head
+	if br.Buffered() > 0 {
+		return common.ClientError(ErrProxyConnect + ": unexpected data after response")
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/server"
+	"github.com/ganyariya/tinyserver/internal/tcp"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// startProxyServer starts a forward proxy and returns its URL
+func startProxyServer(t *testing.T) *url.URL {
+	t.Helper()
+
+	srv, err := server.NewServer("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	srv.EnableForwardProxy(nil)
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop() })
+
+	return &url.URL{Scheme: pkghttp.SchemeHTTP, Host: srv.Addr().String()}
+}
+
+// startEchoUpstream starts a TCP listener that echoes everything back
+func startEchoUpstream(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		target   string
+		expected string
+	}{
+		{
+			name:     "http proxy",
+			env:      map[string]string{"HTTP_PROXY": "http://proxy:3128"},
+			target:   "http://example.com/",
+			expected: "http://proxy:3128",
+		},
+		{
+			name:     "lowercase and no scheme",
+			env:      map[string]string{"http_proxy": "proxy:3128"},
+			target:   "http://example.com/",
+			expected: "http://proxy:3128",
+		},
+		{
+			name:     "https uses HTTPS_PROXY",
+			env:      map[string]string{"HTTP_PROXY": "http://plain:1", "HTTPS_PROXY": "http://secure:2"},
+			target:   "https://example.com/",
+			expected: "http://secure:2",
+		},
+		{
+			name:   "no proxy for https",
+			env:    map[string]string{"HTTP_PROXY": "http://plain:1"},
+			target: "https://example.com/",
+		},
+		{
+			name:   "NO_PROXY domain suffix",
+			env:    map[string]string{"HTTP_PROXY": "http://proxy:3128", "NO_PROXY": "internal, .example.com"},
+			target: "http://api.example.com/",
+		},
+		{
+			name:   "NO_PROXY exact host",
+			env:    map[string]string{"HTTP_PROXY": "http://proxy:3128", "NO_PROXY": "example.com"},
+			target: "http://example.com/",
+		},
+		{
+			name:     "NO_PROXY port mismatch",
+			env:      map[string]string{"HTTP_PROXY": "http://proxy:3128", "NO_PROXY": "example.com:8080"},
+			target:   "http://example.com/",
+			expected: "http://proxy:3128",
+		},
+		{
+			name:   "NO_PROXY wildcard",
+			env:    map[string]string{"HTTP_PROXY": "http://proxy:3128", "NO_PROXY": "*"},
+			target: "http://example.com/",
+		},
+		{
+			name:   "loopback bypasses proxy",
+			env:    map[string]string{"HTTP_PROXY": "http://proxy:3128"},
+			target: "http://127.0.0.1:8080/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, _ := url.Parse(tt.target)
+			proxy, err := proxyFromEnv(target, func(name string) string { return tt.env[name] })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			actual := ""
+			if proxy != nil {
+				actual = proxy.String()
+			}
+			if actual != tt.expected {
+				t.Errorf("Expected proxy %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestClientThroughForwardProxy(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.Path()+" "+req.GetHeader(pkghttp.HeaderProxyAuthorization))
+	})
+	proxy := startProxyServer(t)
+	proxy.User = url.UserPassword("user", "secret")
+
+	client := newTestClient(t)
+	client.SetProxy(ProxyURL(proxy))
+
+	resp, err := client.Get(baseURL + "/through?x=1")
+	if err != nil {
+		t.Fatalf("Get through proxy failed: %v", err)
+	}
+
+	if via := resp.GetHeader(pkghttp.HeaderVia); via == "" {
+		t.Error("Expected a Via header added by the proxy")
+	}
+	// The proxy consumes its own credentials
+	if body := readBody(t, resp); body != "/through?x=1 " {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestRouteRequestForm(t *testing.T) {
+	proxy := &url.URL{Scheme: pkghttp.SchemeHTTP, Host: "proxy:3128"}
+
+	tests := []struct {
+		name         string
+		route        route
+		expectedKey  string
+		absoluteForm bool
+		tunnel       bool
+	}{
+		{
+			name:        "direct",
+			route:       route{scheme: pkghttp.SchemeHTTP, address: "example.com:80"},
+			expectedKey: "http://example.com:80",
+		},
+		{
+			name:         "http through proxy",
+			route:        route{scheme: pkghttp.SchemeHTTP, address: "example.com:80", proxy: proxy},
+			expectedKey:  "http://proxy:3128",
+			absoluteForm: true,
+		},
+		{
+			name:        "https through proxy",
+			route:       route{scheme: pkghttp.SchemeHTTPS, address: "example.com:443", proxy: proxy},
+			expectedKey: "http://proxy:3128|https://example.com:443",
+			tunnel:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if key := tt.route.key(); key != tt.expectedKey {
+				t.Errorf("Expected key %q, got %q", tt.expectedKey, key)
+			}
+			if tt.route.absoluteForm() != tt.absoluteForm {
+				t.Errorf("Expected absoluteForm %v", tt.absoluteForm)
+			}
+			if tt.route.tunnel() != tt.tunnel {
+				t.Errorf("Expected tunnel %v", tt.tunnel)
+			}
+		})
+	}
+}
+
+func TestEstablishTunnel(t *testing.T) {
+	proxy := startProxyServer(t)
+	upstream := startEchoUpstream(t)
+
+	conn, err := tcp.NewDialer().Dial("tcp", proxy.Host)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err := establishTunnel(conn, upstream, ""); err != nil {
+		t.Fatalf("establishTunnel failed: %v", err)
+	}
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write through tunnel failed: %v", err)
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatalf("Read through tunnel failed: %v", err)
+	}
+	if string(reply) != "ping" {
+		t.Errorf("Expected echo %q, got %q", "ping", reply)
+	}
+}
+
+func TestEstablishTunnelRefused(t *testing.T) {
+	proxy := startProxyServer(t)
+
+	// Reserve a port and release it so nothing is listening there
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	closedAddress := listener.Addr().String()
+	listener.Close()
+
+	conn, err := tcp.NewDialer().Dial("tcp", proxy.Host)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	err = establishTunnel(conn, closedAddress, "")
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Expected a 502 tunnel error, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// CheckRedirectFunc decides whether the client follows a redirect to req.
+// via holds the requests already made, oldest first. Returning an error stops
+// the chain; ErrUseLastResponse returns the redirect response itself instead.
+type CheckRedirectFunc func(req pkghttp.Request, via []pkghttp.Request) error
+
+// ErrUseLastResponse can be returned by a CheckRedirectFunc to stop following
+// redirects and hand the most recent response, body unread, to the caller
+var ErrUseLastResponse = errors.New("use last response")
+
+// SetCheckRedirect sets the redirect policy. Nil restores the default policy,
+// which follows up to DefaultMaxRedirects redirects.
+func (c *Client) SetCheckRedirect(check CheckRedirectFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkRedirect = check
+}
+
+// defaultCheckRedirect stops after DefaultMaxRedirects redirects
+func defaultCheckRedirect(req pkghttp.Request, via []pkghttp.Request) error {
+	if len(via) >= DefaultMaxRedirects {
+		return common.ClientError(ErrTooManyRedirects + " (" + strconv.Itoa(DefaultMaxRedirects) + ")")
+	}
+	return nil
+}
+
+// followRedirects sends req and follows redirects the policy allows
+func (c *Client) followRedirects(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+	c.mu.RLock()
+	check := c.checkRedirect
+	c.mu.RUnlock()
+	if check == nil {
+		check = defaultCheckRedirect
+	}
+
+	roundTrip := c.roundTripper()
+
+	var via []pkghttp.Request
+	for {
+		resp, err := roundTrip(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		next, err := redirectRequest(req, resp)
+		if err != nil {
+			CloseBody(resp)
+			return nil, err
+		}
+		if next == nil {
+			return resp, nil
+		}
+
+		via = append(via, req)
+		if err := check(next, via); err != nil {
+			if errors.Is(err, ErrUseLastResponse) {
+				return resp, nil
+			}
+			CloseBody(resp)
+			return nil, err
+		}
+
+		CloseBody(resp)
+		req = next
+	}
+}
+
+// redirectRequest builds the request that follows resp, or returns nil when
+// resp is not a redirect the client can follow
+func redirectRequest(req pkghttp.Request, resp pkghttp.Response) (pkghttp.Request, error) {
+	method := req.Method()
+	keepBody := false
+
+	switch resp.StatusCode() {
+	case pkghttp.StatusMovedPermanently, pkghttp.StatusFound, pkghttp.StatusSeeOther:
+		// 303 always means GET; browsers treat 301 and 302 after POST the same way
+		if method != pkghttp.MethodGet && method != pkghttp.MethodHead &&
+			(resp.StatusCode() == pkghttp.StatusSeeOther || method == pkghttp.MethodPost) {
+			method = pkghttp.MethodGet
+		}
+		keepBody = method == req.Method()
+	case pkghttp.StatusTemporaryRedirect, pkghttp.StatusPermanentRedirect:
+		keepBody = true
+	default:
+		return nil, nil
+	}
+
+	location := resp.GetHeader(pkghttp.HeaderLocation)
+	if location == "" {
+		return nil, nil
+	}
+
+	current, err := requestURL(req)
+	if err != nil {
+		return nil, err
+	}
+	target, err := current.Parse(location)
+	if err != nil {
+		return nil, common.ClientErrorWithCause(ErrInvalidRedirect, err)
+	}
+	target.Fragment = ""
+
+	var body io.Reader
+	if keepBody && req.Body() != nil {
+		// The body was already sent once; only a body that can rewind is replayed
+		seeker, ok := pkghttp.BodyReader(req.Body()).(io.Seeker)
+		if !ok {
+			return nil, nil
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, nil
+		}
+		body = req.Body()
+	}
+
+	next := pkghttp.NewRequestWithBody(method, target.String(), req.Version(), body)
+	next.SetContext(req.Context())
+	copyRedirectHeaders(next, req, body != nil, sameHost(current, target))
+
+	return next, nil
+}
+
+// copyRedirectHeaders copies the headers of req that still apply to next.
+// Credentials are not forwarded to another host.
+func copyRedirectHeaders(next, req pkghttp.Request, keepBody, sameHost bool) {
+	for _, name := range req.HeaderNames() {
+		switch {
+		case strings.EqualFold(name, pkghttp.HeaderHost):
+			continue
+		case !keepBody && isBodyHeader(name):
+			continue
+		case !sameHost && isCredentialHeader(name):
+			continue
+		}
+
+		for _, value := range req.Headers()[name] {
+			next.AddHeader(name, value)
+		}
+	}
+}
+
+// isBodyHeader reports whether name describes the request body
+func isBodyHeader(name string) bool {
+	return strings.EqualFold(name, pkghttp.HeaderContentType) ||
+		strings.EqualFold(name, pkghttp.HeaderContentLength) ||
+		strings.EqualFold(name, pkghttp.HeaderContentEncoding) ||
+		strings.EqualFold(name, pkghttp.HeaderTransferEncoding)
+}
+
+// isCredentialHeader reports whether name carries credentials for the original host
+func isCredentialHeader(name string) bool {
+	return strings.EqualFold(name, pkghttp.HeaderAuthorization) ||
+		strings.EqualFold(name, pkghttp.HeaderCookie)
+}
+
+// sameHost reports whether both URLs address the same host and port
+func sameHost(a, b *url.URL) bool {
+	return strings.EqualFold(hostAddress(a), hostAddress(b))
+}
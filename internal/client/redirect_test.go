@@ -0,0 +1,142 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// redirectHandler redirects /redirect/<status> to /echo and reports what /echo received
+func redirectHandler(req pkghttp.Request) pkghttp.Response {
+	path := req.Path()
+
+	switch {
+	case path == "/echo":
+		var body []byte
+		if req.Body() != nil {
+			body, _ = io.ReadAll(req.Body())
+		}
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			string(req.Method())+" "+string(body)+" "+req.GetHeader(pkghttp.HeaderAuthorization))
+	case path == "/loop":
+		return internalhttp.BuildRedirectResponse(pkghttp.StatusFound, "/loop")
+	case strings.HasPrefix(path, "/redirect/"):
+		if req.Body() != nil {
+			io.Copy(io.Discard, req.Body())
+		}
+		status, _ := strconv.Atoi(strings.TrimPrefix(path, "/redirect/"))
+		return internalhttp.BuildRedirectResponse(pkghttp.StatusCode(status), "/echo")
+	}
+
+	return pkghttp.NewTextResponse(pkghttp.StatusNotFound, pkghttp.Version11, "not found")
+}
+
+func TestClientFollowsRedirects(t *testing.T) {
+	baseURL := startTestServer(t, redirectHandler)
+	client := newTestClient(t)
+
+	tests := []struct {
+		name     string
+		method   pkghttp.Method
+		status   string
+		body     string
+		expected string
+	}{
+		{name: "302 GET", method: pkghttp.MethodGet, status: "302", expected: "GET  "},
+		{name: "301 POST becomes GET", method: pkghttp.MethodPost, status: "301", body: "data", expected: "GET  "},
+		{name: "303 PUT becomes GET", method: pkghttp.MethodPut, status: "303", body: "data", expected: "GET  "},
+		{name: "307 keeps POST and body", method: pkghttp.MethodPost, status: "307", body: "data", expected: "POST data "},
+		{name: "308 keeps PUT and body", method: pkghttp.MethodPut, status: "308", body: "data", expected: "PUT data "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+
+			resp, err := client.Do(pkghttp.NewRequestWithBody(tt.method, baseURL+"/redirect/"+tt.status, pkghttp.Version11, body))
+			if err != nil {
+				t.Fatalf("Do failed: %v", err)
+			}
+			if resp.StatusCode() != pkghttp.StatusOK {
+				t.Errorf("Expected status 200, got %d", resp.StatusCode())
+			}
+			if got := readBody(t, resp); got != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClientRedirectLimit(t *testing.T) {
+	baseURL := startTestServer(t, redirectHandler)
+	client := newTestClient(t)
+
+	_, err := client.Get(baseURL + "/loop")
+	if err == nil || !strings.Contains(err.Error(), ErrTooManyRedirects) {
+		t.Errorf("Expected too many redirects error, got %v", err)
+	}
+}
+
+func TestClientCheckRedirect(t *testing.T) {
+	baseURL := startTestServer(t, redirectHandler)
+	client := newTestClient(t)
+
+	t.Run("use last response", func(t *testing.T) {
+		client.SetCheckRedirect(func(req pkghttp.Request, via []pkghttp.Request) error {
+			return ErrUseLastResponse
+		})
+
+		resp, err := client.Get(baseURL + "/redirect/302")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		readBody(t, resp)
+
+		if resp.StatusCode() != pkghttp.StatusFound {
+			t.Errorf("Expected status 302, got %d", resp.StatusCode())
+		}
+	})
+
+	t.Run("custom error", func(t *testing.T) {
+		stop := errors.New("no redirects")
+		var seen []string
+		client.SetCheckRedirect(func(req pkghttp.Request, via []pkghttp.Request) error {
+			seen = append(seen, via[len(via)-1].Path()+" -> "+req.Path())
+			return stop
+		})
+
+		if _, err := client.Get(baseURL + "/redirect/301"); !errors.Is(err, stop) {
+			t.Errorf("Expected the policy error, got %v", err)
+		}
+		if len(seen) != 1 || seen[0] != baseURL+"/redirect/301 -> "+baseURL+"/echo" {
+			t.Errorf("Unexpected redirect chain %v", seen)
+		}
+	})
+}
+
+func TestClientRedirectDropsCredentialsAcrossHosts(t *testing.T) {
+	echoURL := startTestServer(t, redirectHandler)
+	redirectURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return internalhttp.BuildRedirectResponse(pkghttp.StatusFound, echoURL+"/echo")
+	})
+	client := newTestClient(t)
+
+	req := pkghttp.NewRequest(pkghttp.MethodGet, redirectURL+"/", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderAuthorization, "Bearer secret")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got := readBody(t, resp); got != "GET  " {
+		t.Errorf("Expected Authorization to be dropped, got %q", got)
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	"github.com/ganyariya/tinyserver/internal/tcp"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// TLSOptions describes how the client verifies servers and identifies itself
+type TLSOptions struct {
+	// RootCAFile is a PEM bundle of trusted CAs; empty uses the system roots
+	RootCAFile string
+
+	// CertFile and KeyFile hold the client certificate presented for mutual TLS
+	CertFile string
+	KeyFile  string
+
+	// ServerName overrides the name sent for SNI and checked against the certificate
+	ServerName string
+
+	// InsecureSkipVerify disables certificate verification; use it only in tests
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig builds a client TLS configuration from PEM files
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         options.ServerName,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if options.RootCAFile != "" {
+		pem, err := os.ReadFile(options.RootCAFile)
+		if err != nil {
+			return nil, common.IOErrorWithCause("failed to read root CA file", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, common.InvalidInputError(ErrInvalidRootCA + ": " + options.RootCAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if options.CertFile != "" || options.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, common.InvalidInputErrorWithCause(ErrInvalidClientCert, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// SetTLSConfig sets the TLS configuration for https requests. The server name
+// defaults to the request host when config leaves it empty. Nil restores the defaults.
+func (c *Client) SetTLSConfig(config *tls.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tlsConfig = config
+}
+
+// handshakeTLS starts TLS on conn for the origin at address
+func (c *Client) handshakeTLS(ctx context.Context, conn pkgtcp.Connection, address string) (pkgtcp.Connection, error) {
+	c.mu.RLock()
+	config := c.tlsConfig
+	c.mu.RUnlock()
+
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	config = config.Clone()
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		if ctxErr := contextErr(ctx); ctxErr != nil {
+			return nil, contextError(ctxErr)
+		}
+		return nil, common.ClientErrorWithCause(ErrTLSHandshake, err)
+	}
+
+	return tcp.NewConnection(tlsConn), nil
+}
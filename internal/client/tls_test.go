@@ -0,0 +1,231 @@
+package client
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+)
+
+// testCertificate is a generated certificate with its key
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	tlsCert tls.Certificate
+}
+
+// issueTestCertificate creates a certificate signed by parent, or self-signed when parent is nil
+func issueTestCertificate(t *testing.T, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		tlsCert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert},
+	}
+}
+
+// newTestCA creates a self-signed certificate authority
+func newTestCA(t *testing.T) *testCertificate {
+	return issueTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "tinyserver test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+// startTLSServer serves one response per connection, reporting the client certificate name
+func startTLSServer(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+				if _, err := internalhttp.ReadRequest(bufio.NewReader(conn), conn.RemoteAddr()); err != nil {
+					return
+				}
+
+				peer := "anonymous"
+				if certs := conn.(*tls.Conn).ConnectionState().PeerCertificates; len(certs) > 0 {
+					peer = certs[0].Subject.CommonName
+				}
+				conn.Write([]byte("HTTP/1.1 200 OK\r\nConnection: close\r\nContent-Length: " +
+					strconv.Itoa(len(peer)) + "\r\n\r\n" + peer))
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// writePEM writes a PEM block to a file in dir
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestClientTLS(t *testing.T) {
+	ca := newTestCA(t)
+	server := issueTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "tiny.test"},
+		DNSNames:    []string{"tiny.test"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	client := issueTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "tiny-client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	address := startTLSServer(t, &tls.Config{
+		Certificates: []tls.Certificate{server.tlsCert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    roots,
+	})
+
+	tests := []struct {
+		name        string
+		config      *tls.Config
+		expected    string
+		expectError bool
+	}{
+		{name: "unknown authority", config: nil, expectError: true},
+		{name: "custom roots", config: &tls.Config{RootCAs: roots}, expected: "anonymous"},
+		{name: "insecure skip verify", config: &tls.Config{InsecureSkipVerify: true}, expected: "anonymous"},
+		{name: "SNI override", config: &tls.Config{RootCAs: roots, ServerName: "tiny.test"}, expected: "anonymous"},
+		{name: "wrong server name", config: &tls.Config{RootCAs: roots, ServerName: "other.test"}, expectError: true},
+		{
+			name:     "client certificate",
+			config:   &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{client.tlsCert}},
+			expected: "tiny-client",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			c.SetTLSConfig(tt.config)
+
+			resp, err := c.Get("https://" + address + "/")
+			if tt.expectError {
+				if err == nil {
+					readBody(t, resp)
+					t.Fatal("Expected a TLS error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if body := readBody(t, resp); body != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	ca := newTestCA(t)
+	client := issueTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "tiny-client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	dir := t.TempDir()
+	keyDER, err := x509.MarshalECPrivateKey(client.key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", ca.cert.Raw)
+	certFile := writePEM(t, dir, "client.pem", "CERTIFICATE", client.cert.Raw)
+	keyFile := writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+	badFile := writePEM(t, dir, "bad.pem", "GARBAGE", []byte("nope"))
+
+	tests := []struct {
+		name        string
+		options     TLSOptions
+		expectError bool
+	}{
+		{name: "roots and client certificate", options: TLSOptions{RootCAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "tiny.test"}},
+		{name: "missing root file", options: TLSOptions{RootCAFile: filepath.Join(dir, "missing.pem")}, expectError: true},
+		{name: "root file without certificates", options: TLSOptions{RootCAFile: badFile}, expectError: true},
+		{name: "certificate without key", options: TLSOptions{CertFile: certFile}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewTLSConfig(tt.options)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTLSConfig failed: %v", err)
+			}
+
+			if config.RootCAs == nil || len(config.Certificates) != 1 || config.ServerName != "tiny.test" {
+				t.Errorf("Unexpected config: roots=%v certs=%d server=%q", config.RootCAs != nil, len(config.Certificates), config.ServerName)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// SetTransport replaces the network layer used for each exchange. Interceptors
+// and redirect handling still apply. Nil restores the built-in transport.
+func (c *Client) SetTransport(transport pkghttp.Transport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transport = transport
+}
+
+// Use appends interceptors that wrap every exchange, including each redirect hop.
+// The first interceptor added is the outermost.
+func (c *Client) Use(interceptors ...pkghttp.InterceptorFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interceptors = append(c.interceptors, interceptors...)
+}
+
+// roundTripper returns the transport wrapped by the configured interceptors
+func (c *Client) roundTripper() pkghttp.RoundTripFunc {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	roundTrip := pkghttp.RoundTripFunc(c.send)
+	if c.transport != nil {
+		roundTrip = c.transport.RoundTrip
+	}
+
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		roundTrip = c.interceptors[i](roundTrip)
+	}
+
+	return roundTrip
+}
+
+// HeaderInterceptor returns an interceptor that sets a header on every request
+// that does not already carry it
+func HeaderInterceptor(name, value string) pkghttp.InterceptorFunc {
+	return func(next pkghttp.RoundTripFunc) pkghttp.RoundTripFunc {
+		return func(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+			if !req.HasHeader(name) {
+				req.SetHeader(name, value)
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// recordingInterceptor appends name to calls before and after each exchange
+func recordingInterceptor(name string, calls *[]string) pkghttp.InterceptorFunc {
+	return func(next pkghttp.RoundTripFunc) pkghttp.RoundTripFunc {
+		return func(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+			*calls = append(*calls, name+" before")
+			resp, err := next(ctx, req)
+			*calls = append(*calls, name+" after")
+			return resp, err
+		}
+	}
+}
+
+func TestClientInterceptorOrder(t *testing.T) {
+	var calls []string
+
+	client := newTestClient(t)
+	client.SetTransport(pkghttp.RoundTripFunc(func(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+		calls = append(calls, "transport")
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "canned"), nil
+	}))
+	client.Use(recordingInterceptor("outer", &calls), recordingInterceptor("inner", &calls))
+
+	resp, err := client.Get("http://example.invalid/")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if body := readBody(t, resp); body != "canned" {
+		t.Errorf("Expected the custom transport response, got %q", body)
+	}
+
+	expected := "outer before,inner before,transport,inner after,outer after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected calls %s, got %s", expected, got)
+	}
+}
+
+func TestClientInterceptorsWrapNetworkTransport(t *testing.T) {
+	baseURL := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		if req.Path() == "/start" {
+			return internalhttp.BuildRedirectResponse(pkghttp.StatusFound, "/end")
+		}
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.GetHeader(pkghttp.HeaderAuthorization))
+	})
+
+	var paths []string
+	client := newTestClient(t)
+	client.Use(
+		HeaderInterceptor(pkghttp.HeaderAuthorization, "Bearer token"),
+		func(next pkghttp.RoundTripFunc) pkghttp.RoundTripFunc {
+			return func(ctx context.Context, req pkghttp.Request) (pkghttp.Response, error) {
+				paths = append(paths, req.Path())
+				resp, err := next(ctx, req)
+				if err == nil {
+					resp.SetHeader("X-Intercepted", "yes")
+				}
+				return resp, err
+			}
+		},
+	)
+
+	resp, err := client.Get(baseURL + "/start")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if body := readBody(t, resp); body != "Bearer token" {
+		t.Errorf("Expected injected Authorization header, got %q", body)
+	}
+	if resp.GetHeader("X-Intercepted") != "yes" {
+		t.Error("Expected the interceptor to rewrite the response")
+	}
+	if len(paths) != 2 || paths[0] != baseURL+"/start" || paths[1] != baseURL+"/end" {
+		t.Errorf("Expected interceptors to see both redirect hops, got %v", paths)
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+)
+
+// SetUnixSocket sends every request over the Unix domain socket at address,
+// given as "unix:///path/to.sock", as when talking to a local daemon. Request
+// URLs still supply the path and Host header, e.g. "http://localhost/info".
+// An empty address restores TCP connections.
+func (c *Client) SetUnixSocket(address string) error {
+	var socket string
+	if address != "" {
+		path, ok := internalhttp.UnixSocketPath(address)
+		if !ok {
+			return common.InvalidInputError(ErrInvalidUnixSocket + ": " + address)
+		}
+		socket = path
+	}
+
+	c.mu.Lock()
+	c.unixSocket = socket
+	c.mu.Unlock()
+
+	c.pool.closeIdle()
+	return nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ganyariya/tinyserver/internal/server"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestClientUnixSocket(t *testing.T) {
+	address := "unix://" + filepath.Join(t.TempDir(), "http.sock")
+
+	srv, err := server.NewServer("tcp", address)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	srv.SetHandler(func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			req.GetHeader(pkghttp.HeaderHost)+" "+req.Path())
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop() })
+
+	if network := srv.Addr().Network(); network != "unix" {
+		t.Fatalf("Expected a unix listener, got %q", network)
+	}
+
+	client := newTestClient(t)
+	if err := client.SetUnixSocket(address); err != nil {
+		t.Fatalf("SetUnixSocket failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://localhost/info")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if body := readBody(t, resp); body != "localhost /info" {
+			t.Errorf("Expected body %q, got %q", "localhost /info", body)
+		}
+	}
+
+	key := route{scheme: "http", address: "localhost:80", socket: strings.TrimPrefix(address, "unix://")}.key()
+	if idle := client.pool.idleCount(key); idle != 1 {
+		t.Errorf("Expected the socket connection to be reused, got %d idle", idle)
+	}
+}
+
+func TestClientSetUnixSocketInvalid(t *testing.T) {
+	tests := []string{
+		"/var/run/app.sock",
+		"unix://",
+		"http://localhost",
+	}
+
+	client := newTestClient(t)
+	for _, address := range tests {
+		if err := client.SetUnixSocket(address); err == nil {
+			t.Errorf("SetUnixSocket(%q): expected error, got nil", address)
+		}
+	}
+
+	if err := client.SetUnixSocket(""); err != nil {
+		t.Errorf("Expected an empty address to reset the socket, got %v", err)
+	}
+}
@@ -105,6 +105,12 @@ const (
 
 	// ErrMsgIOFailure represents an I/O failure error message
 	ErrMsgIOFailure = "I/O operation failed"
+
+	// ErrMsgInvalidHTTPDate represents a date in none of the HTTP date formats
+	ErrMsgInvalidHTTPDate = "invalid HTTP date"
+
+	// ErrMsgUnsafePath represents a path that would resolve outside its root directory
+	ErrMsgUnsafePath = "path escapes root directory"
 )
 
 // MIME types
@@ -132,6 +138,24 @@ const (
 
 	// EncodingDeflate represents deflate encoding
 	EncodingDeflate = "deflate"
+
+	// EncodingBrotli represents brotli encoding (RFC 7932)
+	EncodingBrotli = "br"
+
+	// EncodingIdentity represents the absence of any content coding
+	EncodingIdentity = "identity"
+)
+
+// Date formats
+const (
+	// HTTPDateFormat is the preferred IMF-fixdate layout for HTTP date headers
+	HTTPDateFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+	// RFC850DateFormat is the obsolete RFC 850 layout recipients must still accept
+	RFC850DateFormat = "Monday, 02-Jan-06 15:04:05 GMT"
+
+	// ANSICDateFormat is the obsolete asctime() layout recipients must still accept
+	ANSICDateFormat = "Mon Jan _2 15:04:05 2006"
 )
 
 // Line endings and separators
@@ -0,0 +1,29 @@
+package common
+
+import "time"
+
+// httpDateFormats lists the layouts RFC 7231 section 7.1.1.1 requires
+// recipients to accept, preferred first
+var httpDateFormats = []string{HTTPDateFormat, RFC850DateFormat, ANSICDateFormat}
+
+// FormatHTTPDate formats a time for HTTP Date header
+func FormatHTTPDate() string {
+	return FormatHTTPTime(time.Now())
+}
+
+// FormatHTTPTime formats t in the IMF-fixdate layout used by Date,
+// Last-Modified and Expires headers
+func FormatHTTPTime(t time.Time) string {
+	return t.UTC().Format(HTTPDateFormat)
+}
+
+// ParseHTTPDate parses a header date in IMF-fixdate, RFC 850 or asctime
+// form. The result is in UTC.
+func ParseHTTPDate(value string) (time.Time, error) {
+	for _, layout := range httpDateFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, InvalidInputError(ErrMsgInvalidHTTPDate + ": " + value)
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHTTPDate(t *testing.T) {
+	expected := time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "IMF-fixdate", value: "Sun, 06 Nov 1994 08:49:37 GMT"},
+		{name: "RFC 850", value: "Sunday, 06-Nov-94 08:49:37 GMT"},
+		{name: "asctime", value: "Sun Nov  6 08:49:37 1994"},
+		{name: "empty", value: "", wantErr: true},
+		{name: "numeric zone", value: "Sun, 06 Nov 1994 08:49:37 +0000", wantErr: true},
+		{name: "garbage", value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHTTPDate(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHTTPDate(%q) failed: %v", tt.value, err)
+			}
+			if !got.Equal(expected) || got.Location() != time.UTC {
+				t.Errorf("ParseHTTPDate(%q) = %v, expected %v", tt.value, got, expected)
+			}
+		})
+	}
+}
+
+func TestFormatHTTPTime(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	value := time.Date(1994, time.November, 6, 17, 49, 37, 0, tokyo)
+
+	if got := FormatHTTPTime(value); got != "Sun, 06 Nov 1994 08:49:37 GMT" {
+		t.Errorf("FormatHTTPTime = %q", got)
+	}
+
+	parsed, err := ParseHTTPDate(FormatHTTPTime(value))
+	if err != nil || !parsed.Equal(value) {
+		t.Errorf("Round trip failed: %v, %v", parsed, err)
+	}
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SafeJoin joins the slash-separated, already decoded path name onto root
+// and returns an error instead of a path outside root. It rejects NUL bytes,
+// ".." segments, backslashes and volume names that Windows would honour, and
+// segments still percent-encoded after decoding, such as "%2e%2e" or "%2f",
+// which a second decoding elsewhere could turn into a traversal. A leading
+// slash is allowed, so a URL path can be passed as is.
+func SafeJoin(root, name string) (string, error) {
+	relative, err := SafePath(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, filepath.FromSlash(relative)), nil
+}
+
+// SafePath checks name as SafeJoin does and returns it cleaned in the form
+// io/fs expects: relative, slash-separated and "." for the root itself
+func SafePath(name string) (string, error) {
+	if strings.IndexByte(name, 0) >= 0 || strings.IndexByte(name, '\\') >= 0 {
+		return "", InvalidInputError(ErrMsgUnsafePath + ": " + name)
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." || !isSafeSegment(segment) {
+			return "", InvalidInputError(ErrMsgUnsafePath + ": " + name)
+		}
+	}
+
+	relative := strings.TrimLeft(name, "/")
+	if relative == "" {
+		return ".", nil
+	}
+	if !filepath.IsLocal(filepath.FromSlash(relative)) {
+		return "", InvalidInputError(ErrMsgUnsafePath + ": " + name)
+	}
+
+	return path.Clean(relative), nil
+}
+
+// isSafeSegment reports whether decoding segment again cannot produce a
+// traversal or a separator
+func isSafeSegment(segment string) bool {
+	if !strings.Contains(segment, "%") {
+		return true
+	}
+
+	decoded, err := url.PathUnescape(segment)
+	if err != nil {
+		return true
+	}
+	return decoded != ".." && !strings.ContainsAny(decoded, "/\\\x00") && isSafeSegment(decoded)
+}
+
+// SafeJoinNoSymlinkEscape is SafeJoin that also resolves symbolic links and
+// rejects a path whose target lies outside root. A path that does not exist
+// yet is returned as joined, since there is no link to follow.
+func SafeJoinNoSymlinkEscape(root, name string) (string, error) {
+	joined, err := SafeJoin(root, name)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(joined)
+	if os.IsNotExist(err) {
+		return joined, nil
+	}
+	if err != nil {
+		return "", IOErrorWithCause(ErrMsgIOFailure, err)
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", IOErrorWithCause(ErrMsgIOFailure, err)
+	}
+
+	if !isWithin(resolvedRoot, resolved) {
+		return "", InvalidInputError(ErrMsgUnsafePath + ": " + name)
+	}
+	return joined, nil
+}
+
+// isWithin reports whether path is root or lies beneath it
+func isWithin(root, path string) bool {
+	relative, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(relative)
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.FromSlash("/srv/www")
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "plain file", path: "index.html", expected: "/srv/www/index.html"},
+		{name: "leading slash", path: "/css/site.css", expected: "/srv/www/css/site.css"},
+		{name: "root", path: "/", expected: "/srv/www"},
+		{name: "empty", path: "", expected: "/srv/www"},
+		{name: "dot segment", path: "/css/./site.css", expected: "/srv/www/css/site.css"},
+		{name: "literal percent", path: "/100%.txt", expected: "/srv/www/100%.txt"},
+		{name: "parent", path: "../etc/passwd", wantErr: true},
+		{name: "nested parent", path: "/css/../../etc/passwd", wantErr: true},
+		{name: "parent inside root", path: "/css/../index.html", wantErr: true},
+		{name: "encoded parent", path: "/%2e%2e/etc/passwd", wantErr: true},
+		{name: "mixed encoded parent", path: "/.%2E/etc/passwd", wantErr: true},
+		{name: "double encoded parent", path: "/%252e%252e/etc/passwd", wantErr: true},
+		{name: "encoded slash", path: "/..%2fetc/passwd", wantErr: true},
+		{name: "encoded backslash", path: "/..%5cetc", wantErr: true},
+		{name: "backslash", path: "/..\\etc\\passwd", wantErr: true},
+		{name: "NUL byte", path: "/index.html\x00.png", wantErr: true},
+		{name: "encoded NUL byte", path: "/index.html%00.png", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafeJoin(root, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %q", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SafeJoin(%q) failed: %v", tt.path, err)
+			}
+			if expected := filepath.FromSlash(tt.expected); got != expected {
+				t.Errorf("SafeJoin(%q) = %q, expected %q", tt.path, got, expected)
+			}
+		})
+	}
+}
+
+func TestSafePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "root", path: "/", expected: "."},
+		{name: "empty", path: "", expected: "."},
+		{name: "file", path: "/assets/app.js", expected: "assets/app.js"},
+		{name: "trailing slash", path: "/assets/", expected: "assets"},
+		{name: "repeated slashes", path: "//assets//./app.js", expected: "assets/app.js"},
+		{name: "parent", path: "/assets/../../app.js", wantErr: true},
+		{name: "encoded parent", path: "/%2e%2e/app.js", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafePath(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %q", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SafePath(%q) failed: %v", tt.path, err)
+			}
+			if got != tt.expected {
+				t.Errorf("SafePath(%q) = %q, expected %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSafeJoinNoSymlinkEscape(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "secret.txt")
+
+	for _, dir := range []string{root, filepath.Join(root, "assets")} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	for _, file := range []string{outside, filepath.Join(root, "assets", "app.js")} {
+		if err := os.WriteFile(file, []byte("data"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(root, "inside.js"):  filepath.Join(root, "assets", "app.js"),
+		filepath.Join(root, "current"):    filepath.Join(root, "assets"),
+		filepath.Join(root, "escape.txt"): outside,
+		filepath.Join(root, "parent"):     base,
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("Symbolic links unavailable: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "regular file", path: "/assets/app.js"},
+		{name: "link to file inside root", path: "/inside.js"},
+		{name: "link to directory inside root", path: "/current/app.js"},
+		{name: "missing file", path: "/missing.js"},
+		{name: "link to file outside root", path: "/escape.txt", wantErr: true},
+		{name: "link to directory outside root", path: "/parent/secret.txt", wantErr: true},
+		{name: "traversal", path: "/../secret.txt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafeJoinNoSymlinkEscape(root, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %q", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SafeJoinNoSymlinkEscape(%q) failed: %v", tt.path, err)
+			}
+			if expected := filepath.Join(root, filepath.FromSlash(tt.path)); got != expected {
+				t.Errorf("SafeJoinNoSymlinkEscape(%q) = %q, expected %q", tt.path, got, expected)
+			}
+		})
+	}
+}
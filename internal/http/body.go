@@ -0,0 +1,58 @@
+package http
+
+import (
+	"io"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+)
+
+// framedBody is a message body read from a connection up to the end of its framing
+type framedBody struct {
+	r      io.Reader
+	closed bool
+	err    error
+}
+
+// NewBody wraps a body read from a connection so that Close leaves the
+// connection at the start of the next message. Close discards up to
+// MaxBodyDrainSize unread bytes and reports an error when the body was longer
+// or broken, in which case the connection must not be reused.
+func NewBody(r io.Reader) io.ReadCloser {
+	return &framedBody{r: r}
+}
+
+// Read reads from the body until its framing ends
+func (b *framedBody) Read(p []byte) (int, error) {
+	if b.closed {
+		return 0, common.IOError(ErrBodyClosed)
+	}
+
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// Close discards the unread remainder of the body. Closing again returns the
+// first result.
+func (b *framedBody) Close() error {
+	if b.closed {
+		return b.err
+	}
+	b.closed = true
+
+	if b.err != nil {
+		return b.err
+	}
+
+	_, err := io.CopyN(io.Discard, b.r, MaxBodyDrainSize+1)
+	switch err {
+	case io.EOF:
+	case nil:
+		b.err = common.IOError(ErrBodyNotDrained)
+	default:
+		b.err = err
+	}
+	return b.err
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBodyCloseDrainsRemainder(t *testing.T) {
+	tests := []struct {
+		name        string
+		length      int
+		read        int
+		expectError bool
+	}{
+		{name: "fully read", length: 10, read: 10},
+		{name: "partly read", length: 10, read: 3},
+		{name: "unread", length: 10},
+		{name: "remainder over the drain limit", length: MaxBodyDrainSize + 10, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReader(strings.NewReader(strings.Repeat("a", tt.length) + "NEXT"))
+			body := NewBody(NewContentLengthReader(br, int64(tt.length)))
+
+			if tt.read > 0 {
+				if _, err := io.ReadFull(body, make([]byte, tt.read)); err != nil {
+					t.Fatalf("Read failed: %v", err)
+				}
+			}
+
+			err := body.Close()
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			// The reader is left at the start of the next message
+			if next, _ := io.ReadAll(br); string(next) != "NEXT" {
+				t.Errorf("Expected NEXT after the body, got %q", next)
+			}
+		})
+	}
+}
+
+func TestBodyReadAfterClose(t *testing.T) {
+	body := NewBody(strings.NewReader("data"))
+	if err := body.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := body.Read(make([]byte, 4)); err == nil {
+		t.Error("Expected error reading a closed body")
+	}
+	if err := body.Close(); err != nil {
+		t.Errorf("Expected second Close to succeed, got %v", err)
+	}
+}
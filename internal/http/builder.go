@@ -0,0 +1,91 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	"github.com/ganyariya/tinyserver/pkg/http/mime"
+)
+
+// responseBuilder implements pkghttp.ResponseBuilder
+type responseBuilder struct{}
+
+// NewResponseBuilder creates a builder whose encoded responses use the
+// registered body codecs
+func NewResponseBuilder() pkghttp.ResponseBuilder {
+	return &responseBuilder{}
+}
+
+// Build builds a response with the given headers and body
+func (b *responseBuilder) Build(statusCode pkghttp.StatusCode, headers pkghttp.Header, body io.Reader) pkghttp.Response {
+	resp := pkghttp.NewResponseWithBody(statusCode, pkghttp.Version11, body)
+	for _, name := range headers.OrderedNames(nil) {
+		for _, value := range headers[name] {
+			resp.AddHeader(name, value)
+		}
+	}
+	return resp
+}
+
+// BuildText builds a text response
+func (b *responseBuilder) BuildText(statusCode pkghttp.StatusCode, text string) pkghttp.Response {
+	return BuildTextResponse(statusCode, text)
+}
+
+// BuildJSON builds a JSON response
+func (b *responseBuilder) BuildJSON(statusCode pkghttp.StatusCode, v interface{}) pkghttp.Response {
+	return b.BuildEncoded(statusCode, pkghttp.MimeTypeJSON, v)
+}
+
+// BuildXML builds an XML response
+func (b *responseBuilder) BuildXML(statusCode pkghttp.StatusCode, v interface{}) pkghttp.Response {
+	return b.BuildEncoded(statusCode, pkghttp.MimeTypeXML, v)
+}
+
+// BuildEncoded encodes v with the codec registered for contentType. A type
+// without a codec, or a value the codec cannot encode, yields 500.
+func (b *responseBuilder) BuildEncoded(statusCode pkghttp.StatusCode, contentType string, v interface{}) pkghttp.Response {
+	data, err := EncodeBody(contentType, v)
+	if err != nil {
+		return BuildErrorResponse(pkghttp.StatusInternalServerError, "")
+	}
+
+	resp := pkghttp.NewResponseWithBody(statusCode, pkghttp.Version11, bytes.NewReader(data))
+	resp.SetHeader(pkghttp.HeaderContentType, contentType)
+	resp.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(len(data)))
+	return resp
+}
+
+// BuildError builds an error response
+func (b *responseBuilder) BuildError(statusCode pkghttp.StatusCode, message string) pkghttp.Response {
+	return BuildErrorResponse(statusCode, message)
+}
+
+// BuildFile builds a response streaming the file at path, typed by its
+// extension. A file that cannot be opened yields 404.
+func (b *responseBuilder) BuildFile(statusCode pkghttp.StatusCode, path string) pkghttp.Response {
+	file, err := os.Open(path)
+	if err != nil {
+		return BuildErrorResponse(pkghttp.StatusNotFound, "")
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return BuildErrorResponse(pkghttp.StatusNotFound, "")
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = pkghttp.MimeTypeOctetStream
+	}
+
+	resp := pkghttp.NewResponseWithBody(statusCode, pkghttp.Version11, file)
+	resp.SetHeader(pkghttp.HeaderContentType, contentType)
+	resp.SetHeader(pkghttp.HeaderContentLength, strconv.FormatInt(info.Size(), 10))
+	return resp
+}
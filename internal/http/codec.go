@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"strings"
+	"sync"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// BodyCodec encodes values into bodies of one media type and decodes them back
+type BodyCodec struct {
+	// Marshal encodes v as a body
+	Marshal func(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes a body into v
+	Unmarshal func(data []byte, v interface{}) error
+}
+
+// bodyCodecs holds the codecs used by the response builder and body binding
+var bodyCodecs = struct {
+	sync.RWMutex
+	byType map[string]BodyCodec
+}{byType: map[string]BodyCodec{
+	pkghttp.MimeTypeJSON: {Marshal: json.Marshal, Unmarshal: unmarshalJSON},
+	pkghttp.MimeTypeXML:  {Marshal: xml.Marshal, Unmarshal: xml.Unmarshal},
+}}
+
+// structuredSuffixes map structured syntax suffixes (RFC 6839) to the media
+// type whose codec decodes them, so application/problem+json is read as JSON
+var structuredSuffixes = map[string]string{
+	"+json": pkghttp.MimeTypeJSON,
+	"+xml":  pkghttp.MimeTypeXML,
+}
+
+// RegisterBodyCodec makes a codec such as MessagePack available for a media
+// type, replacing any codec registered for it
+func RegisterBodyCodec(mediaType string, codec BodyCodec) {
+	bodyCodecs.Lock()
+	defer bodyCodecs.Unlock()
+	bodyCodecs.byType[strings.ToLower(mediaType)] = codec
+}
+
+// LookupBodyCodec returns the codec for a Content-Type value. Parameters such
+// as charset are ignored, and a type with a structured suffix falls back to
+// the codec of its base syntax.
+func LookupBodyCodec(contentType string) (BodyCodec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return BodyCodec{}, false
+	}
+
+	bodyCodecs.RLock()
+	defer bodyCodecs.RUnlock()
+
+	if codec, ok := bodyCodecs.byType[mediaType]; ok {
+		return codec, true
+	}
+	for suffix, base := range structuredSuffixes {
+		if strings.HasSuffix(mediaType, suffix) {
+			codec, ok := bodyCodecs.byType[base]
+			return codec, ok
+		}
+	}
+	return BodyCodec{}, false
+}
+
+// EncodeBody encodes v with the codec for contentType
+func EncodeBody(contentType string, v interface{}) ([]byte, error) {
+	codec, ok := LookupBodyCodec(contentType)
+	if !ok {
+		return nil, common.InvalidInputError(ErrUnsupportedMediaType + ": " + contentType)
+	}
+	return codec.Marshal(v)
+}
+
+// DecodeBody decodes data into v with the codec for contentType
+func DecodeBody(contentType string, data []byte, v interface{}) error {
+	codec, ok := LookupBodyCodec(contentType)
+	if !ok {
+		return common.InvalidInputError(ErrUnsupportedMediaType + ": " + contentType)
+	}
+	return codec.Unmarshal(data, v)
+}
+
+// unmarshalJSON decodes a single JSON value, rejecting anything after it
+func unmarshalJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return common.InvalidInputError(ErrTrailingData)
+	}
+	return nil
+}
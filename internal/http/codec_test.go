@@ -0,0 +1,146 @@
+package http
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+type codecTarget struct {
+	XMLName xml.Name `xml:"item" json:"-"`
+	Name    string   `xml:"name" json:"name"`
+}
+
+func TestBodyCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		expectError bool
+	}{
+		{name: "json", contentType: pkghttp.MimeTypeJSON},
+		{name: "json with charset", contentType: "application/json; charset=utf-8"},
+		{name: "json structured suffix", contentType: "application/problem+json"},
+		{name: "xml", contentType: pkghttp.MimeTypeXML},
+		{name: "xml structured suffix", contentType: "application/atom+xml"},
+		{name: "unregistered type", contentType: "application/msgpack", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := EncodeBody(tt.contentType, codecTarget{Name: "gopher"})
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EncodeBody failed: %v", err)
+			}
+
+			var decoded codecTarget
+			if err := DecodeBody(tt.contentType, data, &decoded); err != nil {
+				t.Fatalf("DecodeBody failed: %v", err)
+			}
+			if decoded.Name != "gopher" {
+				t.Errorf("Expected round trip of %q, got %+v", "gopher", decoded)
+			}
+		})
+	}
+}
+
+func TestRegisterBodyCodec(t *testing.T) {
+	const mediaType = "application/x-test-upper"
+	RegisterBodyCodec(mediaType, BodyCodec{
+		Marshal: func(v interface{}) ([]byte, error) {
+			return []byte(strings.ToUpper(v.(string))), nil
+		},
+		Unmarshal: func(data []byte, v interface{}) error {
+			*v.(*string) = strings.ToLower(string(data))
+			return nil
+		},
+	})
+
+	resp := NewResponseBuilder().BuildEncoded(pkghttp.StatusOK, mediaType, "hello")
+	if resp.GetHeader(pkghttp.HeaderContentType) != mediaType {
+		t.Errorf("Unexpected Content-Type %q", resp.GetHeader(pkghttp.HeaderContentType))
+	}
+	body, _ := io.ReadAll(resp.Body())
+	if string(body) != "HELLO" {
+		t.Errorf("Expected encoded body HELLO, got %q", body)
+	}
+
+	var decoded string
+	if err := DecodeBody(mediaType, body, &decoded); err != nil || decoded != "hello" {
+		t.Errorf("Expected decoded hello, got %q (%v)", decoded, err)
+	}
+}
+
+func TestDecodeJSONRejectsTrailingData(t *testing.T) {
+	var decoded codecTarget
+	if err := DecodeBody(pkghttp.MimeTypeJSON, []byte(`{} {}`), &decoded); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
+
+func TestResponseBuilder(t *testing.T) {
+	builder := NewResponseBuilder()
+
+	tests := []struct {
+		name         string
+		resp         pkghttp.Response
+		expectedType string
+		expectedBody string
+		status       pkghttp.StatusCode
+	}{
+		{
+			name:         "xml",
+			resp:         builder.BuildXML(pkghttp.StatusOK, codecTarget{Name: "gopher"}),
+			expectedType: pkghttp.MimeTypeXML,
+			expectedBody: "<item><name>gopher</name></item>",
+			status:       pkghttp.StatusOK,
+		},
+		{
+			name:         "json",
+			resp:         builder.BuildJSON(pkghttp.StatusCreated, codecTarget{Name: "gopher"}),
+			expectedType: pkghttp.MimeTypeJSON,
+			expectedBody: `{"name":"gopher"}`,
+			status:       pkghttp.StatusCreated,
+		},
+		{
+			name:   "unencodable value",
+			resp:   builder.BuildJSON(pkghttp.StatusOK, make(chan int)),
+			status: pkghttp.StatusInternalServerError,
+		},
+		{
+			name:   "unregistered type",
+			resp:   builder.BuildEncoded(pkghttp.StatusOK, "application/msgpack", 1),
+			status: pkghttp.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.resp.StatusCode() != tt.status {
+				t.Fatalf("Expected %d, got %d", tt.status, tt.resp.StatusCode())
+			}
+			if tt.expectedType == "" {
+				return
+			}
+
+			if tt.resp.GetHeader(pkghttp.HeaderContentType) != tt.expectedType {
+				t.Errorf("Unexpected Content-Type %q", tt.resp.GetHeader(pkghttp.HeaderContentType))
+			}
+			body, _ := io.ReadAll(tt.resp.Body())
+			if string(body) != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, body)
+			}
+			if tt.resp.ContentLength() != int64(len(body)) {
+				t.Errorf("Content-Length %d does not match body length %d", tt.resp.ContentLength(), len(body))
+			}
+		})
+	}
+}
@@ -16,9 +16,18 @@ const (
 	// MaxHeaderLineLength is the maximum length of a header line
 	MaxHeaderLineLength = 4096
 
+	// MaxInterimResponses is how many 1xx responses may precede a final response
+	MaxInterimResponses = 16
+
+	// MaxBodyDrainSize is how much unread body Close discards to keep a connection reusable
+	MaxBodyDrainSize = 256 * 1024
+
 	// MaxChunkSize is the maximum size of a chunk in chunked encoding
 	MaxChunkSize = 1 << 16 // 64KB
 
+	// maxChunkSizeDigits is the longest chunk size line accepted, in hex digits
+	maxChunkSizeDigits = 16
+
 	// ParserTimeout is the default timeout for parsing operations
 	ParserTimeout = 5 * time.Second
 
@@ -29,6 +38,12 @@ const (
 	ReadTimeout = 5 * time.Second
 )
 
+// Request target forms
+const (
+	// AsteriskTarget is the asterisk-form target of a server-wide OPTIONS request
+	AsteriskTarget = "*"
+)
+
 // Parser state constants
 const (
 	// ParserStateRequestLine indicates parsing request line
@@ -83,6 +98,8 @@ const (
 	ErrInvalidContentLength = "invalid content length"
 	// ErrRequestTooLarge indicates request is too large
 	ErrRequestTooLarge = "request too large"
+	// ErrRequestBodyTooLarge indicates a request body over the parser's limit
+	ErrRequestBodyTooLarge = "request body too large"
 	// ErrHeaderTooLarge indicates header is too large
 	ErrHeaderTooLarge = "header too large"
 	// ErrChunkedEncodingInvalid indicates invalid chunked encoding
@@ -91,4 +108,52 @@ const (
 	ErrUnexpectedEOF = "unexpected end of input"
 	// ErrParseTimeout indicates parsing timeout
 	ErrParseTimeout = "parsing timeout"
+	// ErrInvalidLineEnding indicates a line not terminated by CRLF
+	ErrInvalidLineEnding = "invalid line ending"
+	// ErrObsoleteLineFolding indicates a header continued on the next line (obs-fold)
+	ErrObsoleteLineFolding = "obsolete header line folding"
+	// ErrConflictingFraming indicates a request with both Transfer-Encoding and Content-Length
+	ErrConflictingFraming = "both Transfer-Encoding and Content-Length present"
+	// ErrInvalidTransferEncoding indicates a Transfer-Encoding chain not ending in a single chunked
+	ErrInvalidTransferEncoding = "invalid Transfer-Encoding"
+	// ErrConflictingContentLength indicates Content-Length values that disagree
+	ErrConflictingContentLength = "conflicting Content-Length values"
+	// ErrInvalidHost indicates a repeated or malformed Host header
+	ErrInvalidHost = "invalid Host header"
+	// ErrMissingHost indicates an HTTP/1.1 request without a Host header
+	ErrMissingHost = "missing Host header"
+	// ErrTargetTooLong indicates a request target over the validator's length limit
+	ErrTargetTooLong = "request target too long"
+	// ErrMissingRequiredHeader indicates a request without a header the validator requires
+	ErrMissingRequiredHeader = "missing required header"
+	// ErrInvalidHeaderValue indicates a header value with characters outside field-value
+	ErrInvalidHeaderValue = "invalid header value"
+	// ErrUnexpectedBody indicates a body on a response whose status forbids one
+	ErrUnexpectedBody = "status does not allow a body"
+	// ErrUnexpectedFraming indicates framing headers on a 1xx or 204 response
+	ErrUnexpectedFraming = "status does not allow Content-Length or Transfer-Encoding"
+	// ErrContentLengthMismatch indicates a Content-Length that differs from the body size
+	ErrContentLengthMismatch = "Content-Length does not match body size"
+	// ErrTooManyInterimResponses indicates a server that kept sending 1xx responses
+	ErrTooManyInterimResponses = "too many interim responses"
+	// ErrInvalidDigest indicates a Content-MD5 or Digest header that cannot be decoded
+	ErrInvalidDigest = "invalid digest header"
+	// ErrDigestMismatch indicates a body whose digest differs from the one declared
+	ErrDigestMismatch = "digest does not match body"
+	// ErrBodyClosed indicates a read from a body after it was closed
+	ErrBodyClosed = "body closed"
+	// ErrBodyNotDrained indicates a closed body with more unread data than Close discards
+	ErrBodyNotDrained = "unread body too large to discard"
+	// ErrUnsupportedMediaType indicates a media type without a registered body codec
+	ErrUnsupportedMediaType = "no codec for media type"
+	// ErrTrailingData indicates extra data after a decoded value
+	ErrTrailingData = "unexpected data after value"
+)
+
+// Digest algorithms (RFC 3230, RFC 5843)
+const (
+	// DigestAlgorithmSHA256 is the SHA-256 instance digest algorithm
+	DigestAlgorithmSHA256 = "SHA-256"
+	// DigestAlgorithmMD5 is the MD5 instance digest algorithm
+	DigestAlgorithmMD5 = "MD5"
 )
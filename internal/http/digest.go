@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// digestFuncs computes each supported Digest algorithm, keyed by upper-case name
+var digestFuncs = map[string]func([]byte) []byte{
+	DigestAlgorithmSHA256: func(data []byte) []byte { sum := sha256.Sum256(data); return sum[:] },
+	DigestAlgorithmMD5:    func(data []byte) []byte { sum := md5.Sum(data); return sum[:] },
+}
+
+// bodyMessage is the part of a request or response the digest helpers need
+type bodyMessage interface {
+	Headers() pkghttp.Header
+	Body() io.ReadCloser
+	SetBody(io.Reader)
+}
+
+// ContentMD5 returns the Content-MD5 header value for data (RFC 1864)
+func ContentMD5(data []byte) string {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ContentDigest returns a Digest header value for data using SHA-256 (RFC 3230)
+func ContentDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return DigestAlgorithmSHA256 + "=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// HasDigest reports whether header declares a Content-MD5 or Digest value
+func HasDigest(header pkghttp.Header) bool {
+	return header.Has(pkghttp.HeaderContentMD5) || header.Has(pkghttp.HeaderDigest)
+}
+
+// VerifyDigest checks data against the Content-MD5 and Digest values in header.
+// Digest algorithms other than SHA-256 and MD5 are ignored, as RFC 3230 asks;
+// a message without any digest header verifies trivially.
+func VerifyDigest(header pkghttp.Header, data []byte) error {
+	for _, value := range header.Values(pkghttp.HeaderContentMD5) {
+		if err := compareDigest(DigestAlgorithmMD5, strings.TrimSpace(value), data); err != nil {
+			return err
+		}
+	}
+
+	for _, line := range header.Values(pkghttp.HeaderDigest) {
+		for _, instance := range strings.Split(line, ",") {
+			algorithm, value, found := strings.Cut(strings.TrimSpace(instance), "=")
+			if !found || algorithm == "" {
+				return common.HTTPError(ErrInvalidDigest + ": " + instance)
+			}
+
+			algorithm = strings.ToUpper(algorithm)
+			if _, ok := digestFuncs[algorithm]; !ok {
+				continue
+			}
+			if err := compareDigest(algorithm, value, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compareDigest checks data against a base64 encoded digest computed with algorithm
+func compareDigest(algorithm, encoded string, data []byte) error {
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return common.HTTPErrorWithCause(ErrInvalidDigest, err)
+	}
+
+	if subtle.ConstantTimeCompare(expected, digestFuncs[algorithm](data)) != 1 {
+		return common.HTTPError(ErrDigestMismatch + ": " + algorithm)
+	}
+	return nil
+}
+
+// SetRequestDigest sets Content-MD5 and Digest on req from its body
+func SetRequestDigest(req pkghttp.Request) error {
+	return setDigest(req)
+}
+
+// SetResponseDigest sets Content-MD5 and Digest on resp from its body
+func SetResponseDigest(resp pkghttp.Response) error {
+	return setDigest(resp)
+}
+
+// VerifyRequestDigest checks the body of req against its digest headers
+func VerifyRequestDigest(req pkghttp.Request) error {
+	return verifyMessageDigest(req)
+}
+
+// VerifyResponseDigest checks the body of resp against its digest headers
+func VerifyResponseDigest(resp pkghttp.Response) error {
+	return verifyMessageDigest(resp)
+}
+
+// setDigest buffers the body of msg, sets its digest headers and restores the body
+func setDigest(msg bodyMessage) error {
+	data, err := bufferBody(msg)
+	if err != nil {
+		return err
+	}
+
+	msg.Headers().Set(pkghttp.HeaderContentMD5, ContentMD5(data))
+	msg.Headers().Set(pkghttp.HeaderDigest, ContentDigest(data))
+	return nil
+}
+
+// verifyMessageDigest buffers the body of msg and verifies it, leaving the body readable
+func verifyMessageDigest(msg bodyMessage) error {
+	data, err := bufferBody(msg)
+	if err != nil {
+		return err
+	}
+	return VerifyDigest(msg.Headers(), data)
+}
+
+// bufferBody reads the whole body of msg and replaces it with an in-memory
+// copy, closing the original
+func bufferBody(msg bodyMessage) ([]byte, error) {
+	body := msg.Body()
+	if body == nil {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	body.Close()
+	msg.SetBody(bytes.NewReader(data))
+
+	return data, nil
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestContentDigests(t *testing.T) {
+	data := []byte("hello world")
+
+	if got, want := ContentMD5(data), "XrY7u+Ae7tCTyyK7j1rNww=="; got != want {
+		t.Errorf("ContentMD5 = %q, want %q", got, want)
+	}
+	if got, want := ContentDigest(data), "SHA-256=uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="; got != want {
+		t.Errorf("ContentDigest = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	data := []byte("hello world")
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		expectError string
+	}{
+		{name: "no digest headers"},
+		{name: "matching Content-MD5", headers: map[string]string{"Content-MD5": ContentMD5(data)}},
+		{name: "matching Digest", headers: map[string]string{"Digest": ContentDigest(data)}},
+		{name: "algorithm is case-insensitive", headers: map[string]string{"Digest": "sha-256=uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="}},
+		{name: "unknown algorithm ignored", headers: map[string]string{"Digest": "UNIXsum=30637, " + ContentDigest(data)}},
+		{name: "Digest MD5 instance", headers: map[string]string{"Digest": "MD5=" + ContentMD5(data)}},
+		{
+			name:        "mismatched Content-MD5",
+			headers:     map[string]string{"Content-MD5": ContentMD5([]byte("other"))},
+			expectError: ErrDigestMismatch,
+		},
+		{
+			name:        "mismatched Digest",
+			headers:     map[string]string{"Digest": ContentDigest([]byte("other"))},
+			expectError: ErrDigestMismatch,
+		},
+		{
+			name:        "bad base64",
+			headers:     map[string]string{"Digest": "SHA-256=not base64!"},
+			expectError: ErrInvalidDigest,
+		},
+		{
+			name:        "missing value",
+			headers:     map[string]string{"Digest": "SHA-256"},
+			expectError: ErrInvalidDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := pkghttp.Header{}
+			for name, value := range tt.headers {
+				header.Set(name, value)
+			}
+
+			err := VerifyDigest(header, data)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestMessageDigest(t *testing.T) {
+	t.Run("response round trip", func(t *testing.T) {
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "payload")
+		if err := SetResponseDigest(resp); err != nil {
+			t.Fatalf("SetResponseDigest failed: %v", err)
+		}
+		if resp.GetHeader(pkghttp.HeaderDigest) != ContentDigest([]byte("payload")) {
+			t.Errorf("Unexpected Digest %q", resp.GetHeader(pkghttp.HeaderDigest))
+		}
+		if err := VerifyResponseDigest(resp); err != nil {
+			t.Errorf("VerifyResponseDigest failed: %v", err)
+		}
+
+		// Both helpers leave the body readable
+		body, _ := io.ReadAll(resp.Body())
+		if string(body) != "payload" {
+			t.Errorf("Expected body to be restored, got %q", body)
+		}
+	})
+
+	t.Run("tampered request", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodPut, "/upload", pkghttp.Version11)
+		req.SetBody(strings.NewReader("original"))
+		if err := SetRequestDigest(req); err != nil {
+			t.Fatalf("SetRequestDigest failed: %v", err)
+		}
+
+		req.SetBody(strings.NewReader("tampered"))
+		if err := VerifyRequestDigest(req); err == nil {
+			t.Error("Expected a digest mismatch")
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"bytes"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// DumpRequest returns req as it would appear on the wire, with headers in
+// serialization order. With body set the body is included, chunk-encoded if
+// Transfer-Encoding says so, and req is left with an in-memory copy that can
+// still be read.
+func DumpRequest(req pkghttp.Request, body bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteRequestHead(&buf, req); err != nil {
+		return nil, err
+	}
+
+	if body {
+		if err := dumpBody(&buf, req, isChunked(req.GetHeader(pkghttp.HeaderTransferEncoding))); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DumpResponse returns resp as it would appear on the wire, like DumpRequest
+func DumpResponse(resp pkghttp.Response, body bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteResponseHead(&buf, resp); err != nil {
+		return nil, err
+	}
+
+	if body {
+		if err := dumpBody(&buf, resp, isChunked(resp.GetHeader(pkghttp.HeaderTransferEncoding))); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// dumpBody appends the body of msg to buf, leaving msg with a readable copy
+func dumpBody(buf *bytes.Buffer, msg bodyMessage, chunked bool) error {
+	if msg.Body() == nil {
+		return nil
+	}
+
+	data, err := bufferBody(msg)
+	if err != nil {
+		return err
+	}
+	return writeFramedBody(buf, bytes.NewReader(data), chunked)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestDumpRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunked  bool
+		body     bool
+		expected string
+	}{
+		{
+			name: "with body",
+			body: true,
+			expected: "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\n" +
+				"hello",
+		},
+		{
+			name:     "without body",
+			expected: "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\n",
+		},
+		{
+			name:    "chunked body",
+			chunked: true,
+			body:    true,
+			expected: "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"5\r\nhello\r\n0\r\n\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, "/upload", pkghttp.Version11, strings.NewReader("hello"))
+			req.SetHeader(pkghttp.HeaderHost, "example.com")
+			if tt.chunked {
+				req.SetHeader(pkghttp.HeaderTransferEncoding, pkghttp.TransferEncodingChunked)
+			} else {
+				req.SetHeader(pkghttp.HeaderContentLength, "5")
+			}
+
+			dump, err := DumpRequest(req, tt.body)
+			if err != nil {
+				t.Fatalf("DumpRequest failed: %v", err)
+			}
+			if string(dump) != tt.expected {
+				t.Errorf("Unexpected dump:\n%q\nwant:\n%q", dump, tt.expected)
+			}
+
+			// The body stays readable whether or not it was dumped
+			if body, _ := io.ReadAll(req.Body()); string(body) != "hello" {
+				t.Errorf("Expected body to remain readable, got %q", body)
+			}
+		})
+	}
+}
+
+func TestDumpResponse(t *testing.T) {
+	resp := pkghttp.NewTextResponse(pkghttp.StatusNotFound, pkghttp.Version11, "missing")
+	resp.SetHeader(pkghttp.HeaderCacheControl, "no-store")
+
+	dump, err := DumpResponse(resp, true)
+	if err != nil {
+		t.Fatalf("DumpResponse failed: %v", err)
+	}
+
+	expected := "HTTP/1.1 404 Not Found\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Cache-Control: no-store\r\n" +
+		"Content-Length: 7\r\n" +
+		"\r\n" +
+		"missing"
+	if string(dump) != expected {
+		t.Errorf("Unexpected dump:\n%q\nwant:\n%q", dump, expected)
+	}
+
+	// Dumping twice gives the same bytes since the body was re-wrapped
+	again, _ := DumpResponse(resp, true)
+	if string(again) != expected {
+		t.Errorf("Second dump differs:\n%q", again)
+	}
+}
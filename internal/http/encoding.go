@@ -0,0 +1,152 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+)
+
+// ContentCoding compresses and decompresses bodies for one Content-Encoding.
+// Only gzip and deflate are built in; br works only after an application
+// registers a brotli implementation with RegisterContentCoding.
+type ContentCoding struct {
+	// NewWriter returns a writer that compresses into w; Close flushes it
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader that decompresses r
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// preferredCodings orders codings that clients accept equally, best first.
+// br is ranked here but is neither offered nor decoded until a coding for it
+// is registered, since the standard library has no brotli implementation.
+var preferredCodings = []string{common.EncodingBrotli, common.EncodingGzip, common.EncodingDeflate}
+
+// contentCodings holds the codings available to servers and clients
+var contentCodings = struct {
+	sync.RWMutex
+	byName map[string]ContentCoding
+}{byName: map[string]ContentCoding{
+	common.EncodingGzip: {
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	},
+	// HTTP deflate is the zlib format (RFC 7230 section 4.2.2)
+	common.EncodingDeflate: {
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) },
+	},
+}}
+
+// RegisterContentCoding makes a coding available for response compression
+// and client decompression, replacing any coding of that name. Registering a
+// brotli implementation under br is the only way to enable br.
+func RegisterContentCoding(name string, coding ContentCoding) {
+	contentCodings.Lock()
+	defer contentCodings.Unlock()
+	contentCodings.byName[strings.ToLower(name)] = coding
+}
+
+// LookupContentCoding returns the coding registered under name
+func LookupContentCoding(name string) (ContentCoding, bool) {
+	contentCodings.RLock()
+	defer contentCodings.RUnlock()
+	coding, ok := contentCodings.byName[strings.ToLower(name)]
+	return coding, ok
+}
+
+// ContentCodings returns the available coding names, most preferred first
+func ContentCodings() []string {
+	contentCodings.RLock()
+	defer contentCodings.RUnlock()
+
+	var names []string
+	for _, name := range preferredCodings {
+		if _, ok := contentCodings.byName[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	var others []string
+	for name := range contentCodings.byName {
+		if !isPreferredCoding(name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+
+	return append(names, others...)
+}
+
+// isPreferredCoding reports whether name is ranked in preferredCodings
+func isPreferredCoding(name string) bool {
+	for _, preferred := range preferredCodings {
+		if name == preferred {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateContentCoding picks the coding from available that an
+// Accept-Encoding value rates highest, breaking ties by the order of
+// available. It returns "" when the identity coding should be used.
+func NegotiateContentCoding(acceptEncoding string, available []string) string {
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		name, weight, ok := parseCodingWeight(item)
+		if !ok {
+			continue
+		}
+		if name == "*" {
+			wildcard = weight
+			continue
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, name := range available {
+		weight, listed := weights[strings.ToLower(name)]
+		if !listed {
+			weight = wildcard
+		}
+		if weight > bestWeight {
+			best, bestWeight = name, weight
+		}
+	}
+
+	return best
+}
+
+// parseCodingWeight splits an Accept-Encoding item into its lowercase coding
+// and q-value, which defaults to 1
+func parseCodingWeight(item string) (string, float64, bool) {
+	name, params, _ := strings.Cut(item, ";")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", 0, false
+	}
+
+	weight := 1.0
+	for _, param := range strings.Split(params, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return "", 0, false
+		}
+		weight = q
+	}
+
+	return name, weight, true
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+)
+
+// base64Coding stands in for a third-party coding such as brotli
+var base64Coding = ContentCoding{
+	NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	},
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+	},
+}
+
+func TestNegotiateContentCoding(t *testing.T) {
+	available := []string{common.EncodingBrotli, common.EncodingGzip, common.EncodingDeflate}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		expected       string
+	}{
+		{name: "no header", acceptEncoding: "", expected: ""},
+		{name: "single coding", acceptEncoding: "gzip", expected: common.EncodingGzip},
+		{name: "ties follow server preference", acceptEncoding: "deflate, gzip, br", expected: common.EncodingBrotli},
+		{name: "highest q wins", acceptEncoding: "br;q=0.5, gzip;q=0.8", expected: common.EncodingGzip},
+		{name: "case insensitive", acceptEncoding: "GZIP;Q=1", expected: common.EncodingGzip},
+		{name: "q=0 refuses", acceptEncoding: "gzip;q=0", expected: ""},
+		{name: "wildcard", acceptEncoding: "*", expected: common.EncodingBrotli},
+		{name: "wildcard with exclusion", acceptEncoding: "*, br;q=0", expected: common.EncodingGzip},
+		{name: "identity only", acceptEncoding: "identity", expected: ""},
+		{name: "unknown codings", acceptEncoding: "compress, zstd", expected: ""},
+		{name: "malformed q ignored", acceptEncoding: "br;q=2, deflate", expected: common.EncodingDeflate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateContentCoding(tt.acceptEncoding, available); got != tt.expected {
+				t.Errorf("NegotiateContentCoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestContentCodings(t *testing.T) {
+	for _, name := range []string{common.EncodingGzip, common.EncodingDeflate} {
+		coding, ok := LookupContentCoding(name)
+		if !ok {
+			t.Fatalf("Expected built-in coding %s", name)
+		}
+
+		var buf bytes.Buffer
+		writer, _ := coding.NewWriter(&buf)
+		writer.Write([]byte("hello " + name))
+		writer.Close()
+
+		reader, err := coding.NewReader(&buf)
+		if err != nil {
+			t.Fatalf("%s NewReader failed: %v", name, err)
+		}
+		data, _ := io.ReadAll(reader)
+		if string(data) != "hello "+name {
+			t.Errorf("%s round trip produced %q", name, data)
+		}
+	}
+
+	if _, ok := LookupContentCoding(common.EncodingBrotli); ok {
+		t.Fatal("Expected br to be unavailable until registered")
+	}
+
+	RegisterContentCoding("BR", base64Coding)
+	names := ContentCodings()
+	if len(names) != 3 || names[0] != common.EncodingBrotli || names[1] != common.EncodingGzip {
+		t.Errorf("Expected br to be preferred once registered, got %v", names)
+	}
+}
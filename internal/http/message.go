@@ -0,0 +1,111 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// messageReader implements pkghttp.MessageReader on top of the parser
+type messageReader struct {
+	options ParserOptions
+}
+
+// NewMessageReader creates a reader that parses messages under options, or
+// strictly when omitted. Bodies are streamed from the reader, so pass the
+// same *bufio.Reader to every call when reading several messages from one
+// connection; any other reader is wrapped in a new buffer on each call.
+func NewMessageReader(options ...ParserOptions) pkghttp.MessageReader {
+	mr := &messageReader{}
+	if len(options) > 0 {
+		mr.options = options[0]
+	}
+	return mr
+}
+
+// ReadRequest reads a request head and attaches its framed body
+func (mr *messageReader) ReadRequest(r io.Reader) (pkghttp.Request, error) {
+	return ReadRequestWithOptions(bufferedReader(r), nil, mr.options)
+}
+
+// ReadResponse reads the final response after any interim ones and attaches its framed body
+func (mr *messageReader) ReadResponse(r io.Reader) (pkghttp.Response, error) {
+	return ReadResponse(bufferedReader(r))
+}
+
+// ReadHeaders reads header lines up to and including the blank line
+func (mr *messageReader) ReadHeaders(r io.Reader) (pkghttp.Header, error) {
+	headers, _, err := readHeaders(bufferedReader(r), mr.options)
+	return headers, err
+}
+
+// ReadStatusLine reads a status line, returning its version, code and reason phrase
+func (mr *messageReader) ReadStatusLine(r io.Reader) (pkghttp.Version, pkghttp.StatusCode, string, error) {
+	line, err := readLine(bufferedReader(r), MaxRequestLineLength, ErrHeaderTooLarge, mr.options)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	version, status, err := parseStatusLine(line)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	reason := ""
+	if parts := strings.SplitN(line, " ", 3); len(parts) == 3 {
+		reason = parts[2]
+	}
+	return version, status, reason, nil
+}
+
+// bufferedReader reuses r when it is already buffered
+func bufferedReader(r io.Reader) *bufio.Reader {
+	if br, ok := r.(*bufio.Reader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+// messageWriter implements pkghttp.MessageWriter
+type messageWriter struct{}
+
+// NewMessageWriter creates a writer that serializes messages with headers in
+// their insertion order and bodies framed as their headers announce
+func NewMessageWriter() pkghttp.MessageWriter {
+	return &messageWriter{}
+}
+
+// WriteRequest writes the request head and body
+func (mw *messageWriter) WriteRequest(w io.Writer, req pkghttp.Request) error {
+	return WriteRequest(w, req)
+}
+
+// WriteResponse writes the response head and body, chunk-encoding the body
+// when Transfer-Encoding says so
+func (mw *messageWriter) WriteResponse(w io.Writer, resp pkghttp.Response) error {
+	if err := WriteResponseHead(w, resp); err != nil {
+		return err
+	}
+	if resp.Body() == nil {
+		return nil
+	}
+	return writeFramedBody(w, resp.Body(), isChunked(resp.GetHeader(pkghttp.HeaderTransferEncoding)))
+}
+
+// WriteHeaders writes the header lines followed by the blank separator line
+func (mw *messageWriter) WriteHeaders(w io.Writer, headers pkghttp.Header) error {
+	return writeHeaderBlock(w, headers, headers.OrderedNames(nil))
+}
+
+// WriteStatusLine writes a status line with the registered reason phrase
+func (mw *messageWriter) WriteStatusLine(w io.Writer, version pkghttp.Version, status pkghttp.StatusCode) error {
+	statusLine := fmt.Sprintf("%s %d %s\r\n", version, status, pkghttp.StatusText(status))
+	if _, err := io.WriteString(w, statusLine); err != nil {
+		return common.HTTPError("failed to write status line")
+	}
+	return nil
+}
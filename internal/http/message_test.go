@@ -0,0 +1,130 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestMessageReaderReadsSequentialRequests(t *testing.T) {
+	raw := "POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 3\r\n\r\nabc" +
+		"GET /b HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+	reader := NewMessageReader()
+
+	first, err := reader.ReadRequest(br)
+	if err != nil {
+		t.Fatalf("ReadRequest failed: %v", err)
+	}
+	if body, _ := io.ReadAll(first.Body()); string(body) != "abc" {
+		t.Errorf("Expected body abc, got %q", body)
+	}
+
+	second, err := reader.ReadRequest(br)
+	if err != nil {
+		t.Fatalf("ReadRequest failed: %v", err)
+	}
+	if second.Method() != pkghttp.MethodGet || second.Path() != "/b" {
+		t.Errorf("Unexpected second request: %s %s", second.Method(), second.Path())
+	}
+}
+
+func TestMessageReaderReadStatusLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		status      pkghttp.StatusCode
+		reason      string
+		expectError bool
+	}{
+		{name: "with reason", input: "HTTP/1.1 404 Not Found\r\n", status: pkghttp.StatusNotFound, reason: "Not Found"},
+		{name: "custom reason", input: "HTTP/1.1 200 Fine\r\n", status: pkghttp.StatusOK, reason: "Fine"},
+		{name: "without reason", input: "HTTP/1.1 204\r\n", status: pkghttp.StatusNoContent},
+		{name: "malformed", input: "HTTP/1.1 abc\r\n", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, status, reason, err := NewMessageReader().ReadStatusLine(strings.NewReader(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadStatusLine failed: %v", err)
+			}
+			if version != pkghttp.Version11 || status != tt.status || reason != tt.reason {
+				t.Errorf("Got %s %d %q, want HTTP/1.1 %d %q", version, status, reason, tt.status, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMessageReaderReadHeaders(t *testing.T) {
+	headers, err := NewMessageReader().ReadHeaders(strings.NewReader("Host: example.com\r\nAccept: */*\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("ReadHeaders failed: %v", err)
+	}
+	if headers.Get(pkghttp.HeaderHost) != "example.com" || headers.Get(pkghttp.HeaderAccept) != "*/*" {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+}
+
+func TestMessageWriterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{name: "content length"},
+		{name: "chunked", chunked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, strings.NewReader("hello"))
+			if tt.chunked {
+				resp.SetHeader(pkghttp.HeaderTransferEncoding, pkghttp.TransferEncodingChunked)
+			} else {
+				resp.SetHeader(pkghttp.HeaderContentLength, "5")
+			}
+
+			var buf bytes.Buffer
+			if err := NewMessageWriter().WriteResponse(&buf, resp); err != nil {
+				t.Fatalf("WriteResponse failed: %v", err)
+			}
+
+			parsed, err := NewMessageReader().ReadResponse(&buf)
+			if err != nil {
+				t.Fatalf("ReadResponse failed: %v", err)
+			}
+			if body, _ := io.ReadAll(parsed.Body()); string(body) != "hello" {
+				t.Errorf("Expected body hello, got %q", body)
+			}
+		})
+	}
+}
+
+func TestMessageWriterStatusLineAndHeaders(t *testing.T) {
+	writer := NewMessageWriter()
+	headers := pkghttp.Header{}
+	headers.Set(pkghttp.HeaderContentType, "text/plain")
+
+	var buf bytes.Buffer
+	if err := writer.WriteStatusLine(&buf, pkghttp.Version11, pkghttp.StatusAccepted); err != nil {
+		t.Fatalf("WriteStatusLine failed: %v", err)
+	}
+	if err := writer.WriteHeaders(&buf, headers); err != nil {
+		t.Fatalf("WriteHeaders failed: %v", err)
+	}
+
+	expected := "HTTP/1.1 202 Accepted\r\nContent-Type: text/plain\r\n\r\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%q\nwant:\n%q", buf.String(), expected)
+	}
+}
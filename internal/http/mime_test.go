@@ -0,0 +1,61 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	"github.com/ganyariya/tinyserver/pkg/http/mime"
+)
+
+func TestTypeByExtension(t *testing.T) {
+	tests := []struct {
+		ext      string
+		expected string
+	}{
+		{ext: ".html", expected: pkghttp.MimeTypeTextHTML},
+		{ext: ".PNG", expected: pkghttp.MimeTypeImagePNG},
+		{ext: "woff2", expected: pkghttp.MimeTypeFontWOFF2},
+		{ext: ".unknown-extension", expected: ""},
+		{ext: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			if got := mime.TypeByExtension(tt.ext); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "png", data: "\x89PNG\r\n\x1a\n\x00\x00", expected: pkghttp.MimeTypeImagePNG},
+		{name: "jpeg", data: "\xff\xd8\xff\xe0", expected: pkghttp.MimeTypeImageJPEG},
+		{name: "gif", data: "GIF89a\x01\x00", expected: pkghttp.MimeTypeImageGIF},
+		{name: "webp", data: "RIFF\x10\x00\x00\x00WEBPVP8 ", expected: pkghttp.MimeTypeImageWebP},
+		{name: "wav", data: "RIFF\x10\x00\x00\x00WAVEfmt ", expected: pkghttp.MimeTypeAudioWAV},
+		{name: "mp4", data: "\x00\x00\x00\x18ftypmp42", expected: pkghttp.MimeTypeVideoMP4},
+		{name: "woff2", data: "wOF2\x00\x01", expected: pkghttp.MimeTypeFontWOFF2},
+		{name: "html after whitespace", data: "\n  <!DOCTYPE html><html>", expected: pkghttp.MimeTypeTextHTML},
+		{name: "html tag case", data: "<HTML><body>", expected: pkghttp.MimeTypeTextHTML},
+		{name: "svg", data: "<svg xmlns=\"http://www.w3.org/2000/svg\">", expected: pkghttp.MimeTypeImageSVG},
+		{name: "xml", data: "<?xml version=\"1.0\"?><feed/>", expected: pkghttp.MimeTypeXML},
+		{name: "plain text", data: "hello, world\n", expected: pkghttp.MimeTypeTextPlain},
+		{name: "binary", data: "\x00\x01\x02\x03", expected: pkghttp.MimeTypeOctetStream},
+		{name: "binary past the sniff length", data: strings.Repeat("a", mime.SniffLength) + "\x00", expected: pkghttp.MimeTypeTextPlain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mime.DetectContentType([]byte(tt.data)); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -4,28 +4,149 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/ganyariya/tinyserver/internal/common"
 	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
 )
 
+// ObsFoldPolicy selects how header lines continued with leading whitespace
+// (obs-fold, RFC 7230 section 3.2.4) are handled
+type ObsFoldPolicy int
+
+const (
+	// ObsFoldReject fails the message with ErrObsoleteLineFolding
+	ObsFoldReject ObsFoldPolicy = iota
+	// ObsFoldUnfold joins continuation lines onto the previous header value with a space
+	ObsFoldUnfold
+)
+
+// ParserOptions holds the rules a message is parsed under. The zero value
+// follows RFC 7230 strictly.
+type ParserOptions struct {
+	// LenientLineEndings accepts bare LF line endings and a header block cut
+	// short by the end of input
+	LenientLineEndings bool
+
+	// ObsFold selects how folded header lines are handled
+	ObsFold ObsFoldPolicy
+
+	// MaxBodySize limits request bodies in bytes; zero means MaxRequestBodySize
+	MaxBodySize int64
+
+	// MaxHeaderLines limits the number of header lines; zero means MaxHeaderLines
+	MaxHeaderLines int
+
+	// MaxLineLength limits the request line and each header line in bytes;
+	// zero keeps MaxRequestLineLength and MaxHeaderLineLength
+	MaxLineLength int
+
+	// AllowedMethods lists the methods accepted, which may include extension
+	// methods; nil accepts the standard methods
+	AllowedMethods []pkghttp.Method
+
+	// AllowUnknownVersions accepts any well-formed HTTP/x.y version, not only
+	// HTTP/1.0 and HTTP/1.1
+	AllowUnknownVersions bool
+}
+
+// maxBodySize returns the body limit in effect
+func (o ParserOptions) maxBodySize() int64 {
+	if o.MaxBodySize <= 0 {
+		return pkghttp.MaxRequestBodySize
+	}
+	return o.MaxBodySize
+}
+
+// maxHeaderLines returns the header line limit in effect
+func (o ParserOptions) maxHeaderLines() int {
+	if o.MaxHeaderLines <= 0 {
+		return MaxHeaderLines
+	}
+	return o.MaxHeaderLines
+}
+
+// maxRequestLineLength returns the request line limit in effect
+func (o ParserOptions) maxRequestLineLength() int {
+	if o.MaxLineLength <= 0 {
+		return MaxRequestLineLength
+	}
+	return o.MaxLineLength
+}
+
+// maxHeaderLineLength returns the header line limit in effect
+func (o ParserOptions) maxHeaderLineLength() int {
+	if o.MaxLineLength <= 0 {
+		return MaxHeaderLineLength
+	}
+	return o.MaxLineLength
+}
+
+// allowsMethod reports whether method may start a request
+func (o ParserOptions) allowsMethod(method pkghttp.Method) bool {
+	if o.AllowedMethods == nil {
+		return isValidMethod(method)
+	}
+
+	for _, allowed := range o.AllowedMethods {
+		if method == allowed {
+			return isToken(string(method))
+		}
+	}
+	return false
+}
+
+// allowsVersion reports whether version may appear in a request line
+func (o ParserOptions) allowsVersion(version pkghttp.Version) bool {
+	if o.AllowUnknownVersions {
+		return isWellFormedVersion(version)
+	}
+	return isValidVersion(version)
+}
+
+// strictParsing is the default configuration
+var strictParsing = ParserOptions{}
+
+// trailerParsing reads chunked trailers, whose lines ChunkedReader has always
+// accepted with bare LF endings
+var trailerParsing = ParserOptions{LenientLineEndings: true}
+
 // httpParser implements HTTP parsing functionality
 type httpParser struct {
-	logger *common.Logger
+	options ParserOptions
+	logger  *common.Logger
+}
+
+// NewParser creates a new HTTP parser. Without options it follows RFC 7230
+// strictly and requires CRLF line endings; otherwise the first options apply.
+func NewParser(options ...ParserOptions) pkghttp.RequestParser {
+	if len(options) > 0 {
+		return NewParserWithOptions(options[0])
+	}
+	return NewParserWithOptions(strictParsing)
 }
 
-// NewParser creates a new HTTP parser
-func NewParser() pkghttp.RequestParser {
+// NewLenientParser creates a parser that also accepts bare LF line endings
+// and input ending without the blank line after the headers, as some clients
+// and hand-written fixtures send
+func NewLenientParser() pkghttp.RequestParser {
+	return NewParserWithOptions(ParserOptions{LenientLineEndings: true})
+}
+
+// NewParserWithOptions creates a parser following options
+func NewParserWithOptions(options ParserOptions) pkghttp.RequestParser {
 	return &httpParser{
-		logger: common.NewDefaultLogger(),
+		options: options,
+		logger:  common.NewDefaultLogger(),
 	}
 }
 
 // Parse parses an HTTP request from a reader
 func (p *httpParser) Parse(r io.Reader) (pkghttp.Request, error) {
-	return ParseRequest(r, nil)
+	return parseRequest(r, nil, p.options)
 }
 
 // ParseWithTimeout parses with a timeout
@@ -77,7 +198,7 @@ func (p *httpParser) Validate(req pkghttp.Request) error {
 		return common.HTTPError(ErrInvalidPath)
 	}
 
-	if !isValidPath(req.Path()) {
+	if !isValidTarget(req.Method(), req.Path()) {
 		return common.HTTPError(ErrInvalidPath)
 	}
 
@@ -93,6 +214,10 @@ func (p *httpParser) Validate(req pkghttp.Request) error {
 		}
 	}
 
+	if err := checkHost(req); err != nil {
+		return err
+	}
+
 	// Validate content length consistency
 	contentLength := req.ContentLength()
 	if contentLength < 0 {
@@ -228,16 +353,23 @@ func (p *messageParser) ParseHTTPMessage(r io.Reader) ([]string, pkghttp.Header,
 
 // ChunkedReader handles chunked transfer encoding
 type ChunkedReader struct {
-	r      *bufio.Reader
-	n      int64 // bytes remaining in current chunk
-	err    error
-	logger *common.Logger
+	r          *bufio.Reader
+	n          int64 // bytes remaining in current chunk
+	err        error
+	extensions map[string]string
+	trailers   pkghttp.Header
+	logger     *common.Logger
 }
 
-// NewChunkedReader creates a new chunked reader
+// NewChunkedReader creates a new chunked reader. A *bufio.Reader is used
+// directly so bytes after the last chunk stay available to the caller.
 func NewChunkedReader(r io.Reader) *ChunkedReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
 	return &ChunkedReader{
-		r:      bufio.NewReader(r),
+		r:      br,
 		logger: common.NewDefaultLogger(),
 	}
 }
@@ -252,6 +384,9 @@ func (cr *ChunkedReader) Read(p []byte) (int, error) {
 		// Read next chunk size
 		line, _, err := cr.r.ReadLine()
 		if err != nil {
+			if err == io.EOF {
+				err = common.HTTPError(ErrUnexpectedEOF)
+			}
 			cr.err = err
 			return 0, err
 		}
@@ -263,14 +398,22 @@ func (cr *ChunkedReader) Read(p []byte) (int, error) {
 			return 0, cr.err
 		}
 
+		if err := cr.addExtensions(string(line)); err != nil {
+			cr.err = err
+			return 0, err
+		}
+
 		if chunkSize == 0 {
 			// End of chunks, read trailing headers if any
-			cr.readTrailers()
+			if err := cr.readTrailers(); err != nil {
+				cr.err = err
+				return 0, err
+			}
 			cr.err = io.EOF
 			return 0, io.EOF
 		}
 
-		if chunkSize > MaxChunkSize {
+		if chunkSize < 0 || chunkSize > MaxChunkSize {
 			cr.err = common.HTTPError(ErrChunkedEncodingInvalid)
 			return 0, cr.err
 		}
@@ -286,9 +429,13 @@ func (cr *ChunkedReader) Read(p []byte) (int, error) {
 	n, err := cr.r.Read(p)
 	cr.n -= int64(n)
 
+	if err == io.EOF {
+		err = common.HTTPError(ErrUnexpectedEOF)
+	}
+
 	if cr.n == 0 && err == nil {
-		// End of chunk, read trailing CRLF
-		cr.r.ReadLine()
+		// End of chunk, the data must be followed by exactly CRLF
+		err = cr.readChunkEnd()
 	}
 
 	if err != nil {
@@ -298,26 +445,154 @@ func (cr *ChunkedReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// readChunkEnd consumes the CRLF that ends a chunk's data. Anything else means
+// the chunk size did not match its data, and reading on would desynchronize
+// the message framing.
+func (cr *ChunkedReader) readChunkEnd() error {
+	var crlf [2]byte
+	if _, err := io.ReadFull(cr.r, crlf[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return common.HTTPError(ErrUnexpectedEOF)
+		}
+		return err
+	}
+	if crlf[0] != '\r' || crlf[1] != '\n' {
+		return common.HTTPError(ErrChunkedEncodingInvalid)
+	}
+	return nil
+}
+
+// Extensions returns the chunk extensions seen so far, keyed by lowercase
+// name. A name repeated on a later chunk keeps its last value. The set is
+// complete once Read has returned io.EOF.
+func (cr *ChunkedReader) Extensions() map[string]string {
+	return cr.extensions
+}
+
+// Trailers returns the trailer fields sent after the last chunk. It is nil
+// until Read has returned io.EOF.
+func (cr *ChunkedReader) Trailers() pkghttp.Header {
+	return cr.trailers
+}
+
+// addExtensions records the extensions of a chunk-size line
+func (cr *ChunkedReader) addExtensions(line string) error {
+	extensions, err := parseChunkExtensions(line)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range extensions {
+		if cr.extensions == nil {
+			cr.extensions = make(map[string]string)
+		}
+		cr.extensions[name] = value
+	}
+
+	return nil
+}
+
 // readTrailers reads any trailing headers after the last chunk
-func (cr *ChunkedReader) readTrailers() {
-	// Read trailing headers (usually empty)
-	for {
-		line, _, err := cr.r.ReadLine()
-		if err != nil || len(line) == 0 {
-			break
+func (cr *ChunkedReader) readTrailers() error {
+	trailers, _, err := readHeaders(cr.r, trailerParsing)
+	if err != nil {
+		return err
+	}
+
+	for name, values := range trailers {
+		cr.logger.Debug("Trailing header: %s: %s", name, strings.Join(values, ", "))
+	}
+	cr.trailers = trailers
+
+	return nil
+}
+
+// parseChunkExtensions parses the ";name=value" list after a chunk size.
+// Values may be tokens or quoted strings; a name without a value maps to "".
+func parseChunkExtensions(line string) (map[string]string, error) {
+	_, rest, found := strings.Cut(line, ";")
+	if !found {
+		return nil, nil
+	}
+
+	extensions := make(map[string]string)
+	for _, extension := range strings.Split(rest, ";") {
+		name, value, _ := strings.Cut(extension, "=")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !isToken(name) {
+			return nil, common.HTTPError(ErrChunkedEncodingInvalid)
 		}
-		// Log any trailing headers for debugging
-		cr.logger.Debug("Trailing header: %s", string(line))
+
+		if strings.HasPrefix(value, `"`) {
+			unquoted, ok := unquoteString(value)
+			if !ok {
+				return nil, common.HTTPError(ErrChunkedEncodingInvalid)
+			}
+			value = unquoted
+		} else if value != "" && !isToken(value) {
+			return nil, common.HTTPError(ErrChunkedEncodingInvalid)
+		}
+
+		extensions[strings.ToLower(name)] = value
 	}
+
+	return extensions, nil
 }
 
-// parseChunkSize parses hexadecimal chunk size
+// isToken reports whether s is a non-empty RFC 7230 token
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !((r >= 'a' && r <= 'z') ||
+			(r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') ||
+			strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unquoteString decodes an HTTP quoted-string, resolving backslash escapes
+func unquoteString(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i == len(s)-1 {
+				return "", false
+			}
+		case '"':
+			return "", false
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String(), true
+}
+
+// parseChunkSize parses hexadecimal chunk size. Sizes that are empty, longer
+// than maxChunkSizeDigits or do not fit in an int are rejected.
 func parseChunkSize(line string) (int, error) {
 	// Remove any chunk extensions (after semicolon)
 	if idx := bytes.IndexByte([]byte(line), ';'); idx >= 0 {
 		line = line[:idx]
 	}
 
+	if line == "" || len(line) > maxChunkSizeDigits {
+		return 0, common.HTTPError(ErrChunkedEncodingInvalid)
+	}
+
 	// Parse hexadecimal
 	var size int
 	for _, b := range []byte(line) {
@@ -330,11 +605,82 @@ func parseChunkSize(line string) (int, error) {
 		} else {
 			return 0, common.HTTPError(ErrChunkedEncodingInvalid)
 		}
+
+		// Sixteen digits can exceed an int; a wrapped size would turn negative
+		if size < 0 {
+			return 0, common.HTTPError(ErrChunkedEncodingInvalid)
+		}
 	}
 
 	return size, nil
 }
 
+// ChunkedWriter encodes data with chunked transfer encoding
+type ChunkedWriter struct {
+	w io.Writer
+}
+
+// NewChunkedWriter creates a new chunked writer
+func NewChunkedWriter(w io.Writer) *ChunkedWriter {
+	return &ChunkedWriter{w: w}
+}
+
+// Write emits p as a single chunk
+func (cw *ChunkedWriter) Write(p []byte) (int, error) {
+	// A zero-length chunk would terminate the body
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if _, err := fmt.Fprintf(cw.w, "%x%s", len(p), ChunkEnd); err != nil {
+		return 0, err
+	}
+
+	n, err := cw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	if _, err := io.WriteString(cw.w, ChunkEnd); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// Close writes the terminating zero-length chunk and an empty trailer
+func (cw *ChunkedWriter) Close() error {
+	_, err := io.WriteString(cw.w, ChunkTrailerStart+ChunkEnd)
+	return err
+}
+
+// limitedBodyReader fails with ErrRequestBodyTooLarge once more than limit
+// bytes have been read, for bodies whose size is not known up front
+type limitedBodyReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// Read implements io.Reader, failing when the body runs past the limit
+func (lr *limitedBodyReader) Read(p []byte) (int, error) {
+	if lr.remaining < 0 {
+		return 0, common.HTTPError(ErrRequestBodyTooLarge)
+	}
+
+	// Read one byte past the limit to tell an exact fit from an overflow
+	if int64(len(p)) > lr.remaining+1 {
+		p = p[:lr.remaining+1]
+	}
+
+	n, err := lr.r.Read(p)
+	lr.remaining -= int64(n)
+	if lr.remaining < 0 {
+		return n + int(lr.remaining), common.HTTPError(ErrRequestBodyTooLarge)
+	}
+
+	return n, err
+}
+
 // ContentLengthReader handles content-length based reading
 type ContentLengthReader struct {
 	r         io.Reader
@@ -364,6 +710,10 @@ func (clr *ContentLengthReader) Read(p []byte) (int, error) {
 	n, err := clr.r.Read(p)
 	clr.remaining -= int64(n)
 
+	if err == io.EOF && clr.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
 	return n, err
 }
 
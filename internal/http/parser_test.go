@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -263,6 +264,7 @@ func TestHttpParser(t *testing.T) {
 
 	t.Run("validate request", func(t *testing.T) {
 		req := pkghttp.NewRequest(pkghttp.MethodGet, "/test", pkghttp.Version11)
+		req.SetHeader(pkghttp.HeaderHost, "example.com")
 		err := parser.Validate(req)
 
 		if err != nil {
@@ -270,6 +272,39 @@ func TestHttpParser(t *testing.T) {
 		}
 	})
 
+	t.Run("validate Host", func(t *testing.T) {
+		tests := []struct {
+			version pkghttp.Version
+			hosts   []string
+			wantErr string
+		}{
+			{version: pkghttp.Version11, wantErr: ErrMissingHost},
+			{version: pkghttp.Version10},
+			{version: pkghttp.Version11, hosts: []string{"example.com:8080"}},
+			{version: pkghttp.Version11, hosts: []string{"exa mple.com"}, wantErr: ErrInvalidHost},
+			{version: pkghttp.Version11, hosts: []string{"example.com:http"}, wantErr: ErrInvalidHost},
+			{version: pkghttp.Version11, hosts: []string{"a.test", "b.test"}, wantErr: ErrInvalidHost},
+		}
+
+		for _, tt := range tests {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, "/test", tt.version)
+			for _, host := range tt.hosts {
+				req.AddHeader(pkghttp.HeaderHost, host)
+			}
+
+			err := parser.Validate(req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("%s %v: unexpected error %v", tt.version, tt.hosts, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s %v: expected %q error, got %v", tt.version, tt.hosts, tt.wantErr, err)
+			}
+		}
+	})
+
 	t.Run("validate invalid request", func(t *testing.T) {
 		req := pkghttp.NewRequest("", "", "")
 		err := parser.Validate(req)
@@ -312,6 +347,142 @@ func TestChunkedReader(t *testing.T) {
 			t.Error("Expected error for invalid chunk size")
 		}
 	})
+
+	t.Run("overflowing chunk size", func(t *testing.T) {
+		chunkedData := "8000000000000001\r\nabc\r\n0\r\n\r\n"
+
+		reader := NewChunkedReader(strings.NewReader(chunkedData))
+		if _, err := io.ReadAll(reader); err == nil {
+			t.Error("Expected error for overflowing chunk size")
+		}
+	})
+
+	t.Run("data longer than chunk size", func(t *testing.T) {
+		chunkedData := "3\r\nabcXYZ\r\n0\r\n\r\n"
+
+		reader := NewChunkedReader(strings.NewReader(chunkedData))
+		body, err := io.ReadAll(reader)
+		if err == nil {
+			t.Errorf("Expected error for data after the chunk, got body %q", body)
+		}
+	})
+
+	t.Run("extensions and trailers", func(t *testing.T) {
+		chunkedData := "5;sig=abc\r\nHello\r\n" +
+			"0;last\r\n" +
+			"X-Checksum: 8b1a9953\r\n" +
+			"Expires: never\r\n" +
+			"\r\n"
+
+		reader := NewChunkedReader(strings.NewReader(chunkedData))
+		if reader.Trailers() != nil {
+			t.Error("Expected no trailers before EOF")
+		}
+
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(body) != "Hello" {
+			t.Errorf("Expected Hello, got %q", body)
+		}
+
+		extensions := reader.Extensions()
+		if extensions["sig"] != "abc" {
+			t.Errorf("Expected sig=abc, got %q", extensions["sig"])
+		}
+		if value, ok := extensions["last"]; !ok || value != "" {
+			t.Errorf("Expected valueless last extension, got %q (present %v)", value, ok)
+		}
+
+		trailers := reader.Trailers()
+		if trailers.Get("x-checksum") != "8b1a9953" {
+			t.Errorf("Expected X-Checksum trailer, got %q", trailers.Get("x-checksum"))
+		}
+		if trailers.Get(pkghttp.HeaderExpires) != "never" {
+			t.Errorf("Expected Expires trailer, got %q", trailers.Get(pkghttp.HeaderExpires))
+		}
+	})
+
+	t.Run("malformed trailer", func(t *testing.T) {
+		chunkedData := "5\r\nHello\r\n0\r\nnot a header\r\n\r\n"
+
+		reader := NewChunkedReader(strings.NewReader(chunkedData))
+		if _, err := io.ReadAll(reader); err == nil {
+			t.Error("Expected error for malformed trailer")
+		}
+	})
+}
+
+func TestParseChunkExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{name: "no extensions", input: "a"},
+		{name: "token value", input: "a;name=value", expected: map[string]string{"name": "value"}},
+		{name: "no value", input: "a;flag", expected: map[string]string{"flag": ""}},
+		{
+			name:     "several with whitespace",
+			input:    "a ; Sig = abc ; n=1",
+			expected: map[string]string{"sig": "abc", "n": "1"},
+		},
+		{name: "quoted value", input: `a;note="a \"b\" c"`, expected: map[string]string{"note": `a "b" c`}},
+		{name: "empty name", input: "a;=value", wantErr: true},
+		{name: "unterminated quote", input: `a;note="abc`, wantErr: true},
+		{name: "invalid token", input: "a;name=a b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseChunkExtensions(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, result)
+			}
+			for name, value := range tt.expected {
+				if result[name] != value {
+					t.Errorf("Expected %s=%q, got %q", name, value, result[name])
+				}
+			}
+		})
+	}
+}
+
+func TestChunkedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewChunkedWriter(&buf)
+
+	writer.Write([]byte("Hello"))
+	writer.Write([]byte{})
+	writer.Write([]byte(" World"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	expected := "5\r\nHello\r\n6\r\n World\r\n0\r\n\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	decoded, err := io.ReadAll(NewChunkedReader(&buf))
+	if err != nil {
+		t.Fatalf("Round trip failed: %v", err)
+	}
+	if string(decoded) != "Hello World" {
+		t.Errorf("Expected round trip to decode, got %q", decoded)
+	}
 }
 
 func TestContentLengthReader(t *testing.T) {
@@ -427,6 +598,32 @@ func TestParseChunkSize(t *testing.T) {
 			expected: 0,
 			wantErr:  true,
 		},
+		{
+			name:     "largest size",
+			input:    "7fffffffffffffff",
+			expected: 1<<63 - 1,
+			wantErr:  false,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "only extension",
+			input:   ";name=value",
+			wantErr: true,
+		},
+		{
+			name:    "too many digits",
+			input:   "00000000000000001",
+			wantErr: true,
+		},
+		{
+			name:    "overflow",
+			input:   "8000000000000001",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -534,3 +731,300 @@ func TestValidationFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestLenientParser(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		strictErr  bool
+		lenientErr bool
+		body       string
+	}{
+		{
+			name: "CRLF line endings",
+			raw:  "GET /hello HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		},
+		{
+			name:      "bare LF line endings",
+			raw:       "GET /hello HTTP/1.1\nHost: example.com\n\n",
+			strictErr: true,
+		},
+		{
+			name:      "mixed line endings with body",
+			raw:       "POST /hello HTTP/1.1\r\nHost: example.com\nContent-Length: 5\n\nhello",
+			strictErr: true,
+			body:      "hello",
+		},
+		{
+			name:      "missing final CRLF",
+			raw:       "GET /hello HTTP/1.1\r\nHost: example.com\r\n",
+			strictErr: true,
+		},
+		{
+			name:      "unterminated last header",
+			raw:       "GET /hello HTTP/1.1\nHost: example.com",
+			strictErr: true,
+		},
+		{
+			name:       "empty input",
+			raw:        "",
+			strictErr:  true,
+			lenientErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewParser().Parse(strings.NewReader(tt.raw)); (err != nil) != tt.strictErr {
+				t.Errorf("strict parser: error = %v, wantErr %v", err, tt.strictErr)
+			}
+
+			req, err := NewLenientParser().Parse(strings.NewReader(tt.raw))
+			if (err != nil) != tt.lenientErr {
+				t.Fatalf("lenient parser: error = %v, wantErr %v", err, tt.lenientErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if req.Path() != "/hello" {
+				t.Errorf("Expected path /hello, got %q", req.Path())
+			}
+			if got := req.GetHeader(pkghttp.HeaderHost); got != "example.com" {
+				t.Errorf("Expected Host example.com, got %q", got)
+			}
+			if tt.body != "" {
+				body, _ := io.ReadAll(req.Body())
+				if string(body) != tt.body {
+					t.Errorf("Expected body %q, got %q", tt.body, body)
+				}
+			}
+		})
+	}
+}
+
+func TestObsFoldPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		policy   ObsFoldPolicy
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "rejected by default",
+			raw:     "GET / HTTP/1.1\r\nHost: example.com\r\nX-Note: first\r\n second\r\n\r\n",
+			policy:  ObsFoldReject,
+			wantErr: true,
+		},
+		{
+			name:     "unfolded with a space",
+			raw:      "GET / HTTP/1.1\r\nHost: example.com\r\nX-Note: first\r\n second\r\n\tthird\r\n\r\n",
+			policy:   ObsFoldUnfold,
+			expected: "first second third",
+		},
+		{
+			name:     "empty first line",
+			raw:      "GET / HTTP/1.1\r\nHost: example.com\r\nX-Note:\r\n  folded\r\n\r\n",
+			policy:   ObsFoldUnfold,
+			expected: "folded",
+		},
+		{
+			name:    "fold before any header",
+			raw:     "GET / HTTP/1.1\r\n folded\r\nHost: example.com\r\n\r\n",
+			policy:  ObsFoldUnfold,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParserWithOptions(ParserOptions{ObsFold: tt.policy})
+			req, err := parser.Parse(strings.NewReader(tt.raw))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), ErrObsoleteLineFolding) {
+					t.Fatalf("Expected %q error, got %v", ErrObsoleteLineFolding, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if got := req.GetHeader("X-Note"); got != tt.expected {
+				t.Errorf("Expected X-Note %q, got %q", tt.expected, got)
+			}
+			if got := req.GetHeader(pkghttp.HeaderHost); got != "example.com" {
+				t.Errorf("Expected Host example.com, got %q", got)
+			}
+		})
+	}
+}
+
+func TestParserMaxBodySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{
+			name: "declared within limit",
+			raw:  "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\nhello",
+		},
+		{
+			name:    "declared over limit",
+			raw:     "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 6\r\n\r\nhello!",
+			wantErr: true,
+		},
+		{
+			name:    "undeclared body over limit",
+			raw:     "POST / HTTP/1.1\r\nHost: example.com\r\n\r\nhello, world",
+			wantErr: true,
+		},
+		{
+			name: "chunked within limit",
+			raw:  "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nhel\r\n2\r\nlo\r\n0\r\n\r\n",
+		},
+		{
+			name:    "chunked over limit",
+			raw:     "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nhel\r\n3\r\nlo!\r\n0\r\n\r\n",
+			wantErr: true,
+		},
+	}
+
+	parser := NewParserWithOptions(ParserOptions{MaxBodySize: 5})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.Parse(strings.NewReader(tt.raw))
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Parse failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), ErrRequestBodyTooLarge) {
+				t.Errorf("Expected %q error, got %v", ErrRequestBodyTooLarge, err)
+			}
+		})
+	}
+}
+
+func TestParserOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options ParserOptions
+		raw     string
+		wantErr string
+	}{
+		{
+			name:    "header lines within limit",
+			options: ParserOptions{MaxHeaderLines: 2},
+			raw:     "GET / HTTP/1.1\r\nHost: example.com\r\nAccept: */*\r\n\r\n",
+		},
+		{
+			name:    "too many header lines",
+			options: ParserOptions{MaxHeaderLines: 2},
+			raw:     "GET / HTTP/1.1\r\nHost: example.com\r\nAccept: */*\r\nX-Extra: 1\r\n\r\n",
+			wantErr: ErrHeaderTooLarge,
+		},
+		{
+			name:    "long header line",
+			options: ParserOptions{MaxLineLength: 20},
+			raw:     "GET / HTTP/1.1\r\nHost: example.com\r\nX-Long: " + strings.Repeat("a", 20) + "\r\n\r\n",
+			wantErr: ErrHeaderTooLarge,
+		},
+		{
+			name:    "long request line",
+			options: ParserOptions{MaxLineLength: 20},
+			raw:     "GET /" + strings.Repeat("a", 20) + " HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			wantErr: ErrRequestTooLarge,
+		},
+		{
+			name:    "extension method allowed",
+			options: ParserOptions{AllowedMethods: []pkghttp.Method{pkghttp.MethodGet, "PROPFIND"}},
+			raw:     "PROPFIND / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		},
+		{
+			name:    "standard method not allowed",
+			options: ParserOptions{AllowedMethods: []pkghttp.Method{pkghttp.MethodGet}},
+			raw:     "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 0\r\n\r\n",
+			wantErr: ErrInvalidMethod,
+		},
+		{
+			name:    "extension method by default",
+			raw:     "PROPFIND / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			wantErr: ErrInvalidMethod,
+		},
+		{
+			name:    "unknown version by default",
+			raw:     "GET / HTTP/1.2\r\nHost: example.com\r\n\r\n",
+			wantErr: ErrInvalidVersion,
+		},
+		{
+			name:    "unknown version allowed",
+			options: ParserOptions{AllowUnknownVersions: true},
+			raw:     "GET / HTTP/1.2\r\nHost: example.com\r\n\r\n",
+		},
+		{
+			name:    "malformed version",
+			options: ParserOptions{AllowUnknownVersions: true},
+			raw:     "GET / HTTP/12\r\nHost: example.com\r\n\r\n",
+			wantErr: ErrInvalidVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser(tt.options).Parse(strings.NewReader(tt.raw))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMethodAndStatusRegistries(t *testing.T) {
+	raw := "MKCOL /dir HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	if _, err := NewParser().Parse(strings.NewReader(raw)); err == nil {
+		t.Fatal("Expected an unregistered method to be rejected")
+	}
+
+	pkghttp.RegisterMethod("MKCOL")
+	req, err := NewParser().Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse failed after registration: %v", err)
+	}
+	if req.Method() != "MKCOL" {
+		t.Errorf("Expected MKCOL, got %s", req.Method())
+	}
+
+	pkghttp.RegisterStatus(599, "Network Connect Timeout Error")
+	var buf bytes.Buffer
+	if err := WriteResponse(&buf, pkghttp.NewResponse(599, pkghttp.Version11)); err != nil {
+		t.Fatalf("WriteResponse failed: %v", err)
+	}
+	if line := strings.SplitN(buf.String(), "\r\n", 2)[0]; line != "HTTP/1.1 599 Network Connect Timeout Error" {
+		t.Errorf("Unexpected status line: %q", line)
+	}
+
+	for _, register := range []func(){
+		func() { pkghttp.RegisterMethod("BAD METHOD") },
+		func() { pkghttp.RegisterStatus(600, "Too High") },
+		func() { pkghttp.RegisterStatus(299, "Split\r\nLine") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected an invalid registration to panic")
+				}
+			}()
+			register()
+		}()
+	}
+}
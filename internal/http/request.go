@@ -3,9 +3,12 @@ package http
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/ganyariya/tinyserver/internal/common"
@@ -25,73 +28,293 @@ func NewRequestFromRaw(rawData []byte, remoteAddr net.Addr) (pkghttp.Request, er
 
 // ParseRequest parses an HTTP request from a reader
 func ParseRequest(r io.Reader, remoteAddr net.Addr) (pkghttp.Request, error) {
-	// Read entire request into buffer to properly separate headers and body
-	buf := &bytes.Buffer{}
-	if _, err := io.Copy(buf, r); err != nil {
+	return parseRequest(r, remoteAddr, strictParsing)
+}
+
+// parseRequest parses an HTTP request from a reader under options
+func parseRequest(r io.Reader, remoteAddr net.Addr, options ParserOptions) (pkghttp.Request, error) {
+	br := bufio.NewReader(r)
+
+	req, err := readRequestHead(br, remoteAddr, options)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := options.maxBodySize()
+	contentLength := req.ContentLength()
+	if contentLength > limit {
+		return nil, common.HTTPError(ErrRequestBodyTooLarge)
+	}
+
+	// The reader holds exactly one message, so everything left is the body
+	// validateFraming has checked that any Transfer-Encoding ends in chunked
+	var body io.Reader = br
+	chunked := req.HasHeader(pkghttp.HeaderTransferEncoding)
+	if chunked {
+		body = NewChunkedReader(br)
+	}
+
+	bodyData, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		var protocolErr *common.TinyServerError
+		if errors.As(err, &protocolErr) {
+			return nil, err
+		}
 		return nil, common.HTTPError("failed to read request: " + err.Error())
 	}
+	if int64(len(bodyData)) > limit {
+		return nil, common.HTTPError(ErrRequestBodyTooLarge)
+	}
+
+	if contentLength > 0 {
+		if int64(len(bodyData)) != contentLength {
+			return nil, common.HTTPError(ErrUnexpectedEOF)
+		}
+		req.SetBody(bytes.NewReader(bodyData))
+	} else if chunked {
+		req.SetBody(bytes.NewReader(bodyData))
+	}
+
+	return req, nil
+}
+
+// ReadRequest reads a single HTTP request from a buffered connection reader.
+// The body is streamed from br, and bytes after it are left unread so the
+// connection can carry further requests.
+func ReadRequest(br *bufio.Reader, remoteAddr net.Addr) (pkghttp.Request, error) {
+	return ReadRequestWithOptions(br, remoteAddr, strictParsing)
+}
 
-	data := buf.Bytes()
+// ReadRequestWithOptions acts like ReadRequest, parsing under options. A body
+// declared larger than the limit fails with ErrRequestBodyTooLarge before any
+// of it is read; a chunked body fails once it grows past the limit.
+func ReadRequestWithOptions(br *bufio.Reader, remoteAddr net.Addr, options ParserOptions) (pkghttp.Request, error) {
+	req, err := readRequestHead(br, remoteAddr, options)
+	if err != nil {
+		return nil, err
+	}
 
-	// Find the header-body separator (\r\n\r\n)
-	headerEndIndex := bytes.Index(data, []byte("\r\n\r\n"))
-	if headerEndIndex == -1 {
-		return nil, common.HTTPError(ErrInvalidRequestLine)
+	contentLength := req.ContentLength()
+	if contentLength > options.maxBodySize() {
+		return nil, common.HTTPError(ErrRequestBodyTooLarge)
 	}
 
-	headerData := data[:headerEndIndex]
-	bodyData := data[headerEndIndex+4:] // Skip \r\n\r\n
+	switch {
+	case req.HasHeader(pkghttp.HeaderTransferEncoding):
+		req.SetBody(NewBody(&limitedBodyReader{r: NewChunkedReader(br), remaining: options.maxBodySize()}))
+	case contentLength > 0:
+		req.SetBody(NewBody(NewContentLengthReader(br, contentLength)))
+	}
 
-	// Parse headers section
-	scanner := bufio.NewScanner(bytes.NewReader(headerData))
+	return req, nil
+}
 
-	// Parse request line
-	if !scanner.Scan() {
-		return nil, common.HTTPError(ErrInvalidRequestLine)
+// readRequestHead reads the request line and headers
+func readRequestHead(br *bufio.Reader, remoteAddr net.Addr, options ParserOptions) (*pkghttp.HTTPRequest, error) {
+	requestLine, err := readLine(br, options.maxRequestLineLength(), ErrRequestTooLarge, options)
+	if err != nil {
+		return nil, err
 	}
 
-	requestLine := scanner.Text()
-	method, path, version, err := parseRequestLine(requestLine)
+	method, path, version, err := parseRequestLine(requestLine, options)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create request
 	req := pkghttp.NewRequest(method, path, version).(*pkghttp.HTTPRequest)
 	req.SetRemoteAddr(remoteAddr)
 
-	// Parse headers
-	headers, err := parseHeaders(scanner)
+	headers, names, err := readHeaders(br, options)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set headers
-	for name, values := range headers {
-		for _, value := range values {
+	for _, name := range names {
+		for _, value := range headers[name] {
 			req.AddHeader(name, value)
 		}
 	}
 
-	// Parse body if present
-	contentLength := req.ContentLength()
-	if contentLength > 0 {
-		if int64(len(bodyData)) != contentLength {
-			return nil, common.HTTPError(ErrUnexpectedEOF)
-		}
-		req.SetBody(bytes.NewReader(bodyData))
+	if err := normalizeContentLength(req.Headers()); err != nil {
+		return nil, err
+	}
+	if err := validateFraming(req); err != nil {
+		return nil, err
+	}
+	if err := validateHost(req); err != nil {
+		return nil, err
 	}
 
+	applyTargetHost(req)
+
 	return req, nil
 }
 
+// validateFraming rejects requests whose body length could be read two ways,
+// the basis of request smuggling between servers that disagree on framing
+// (RFC 7230 section 3.3.3)
+func validateFraming(req *pkghttp.HTTPRequest) error {
+	if !req.HasHeader(pkghttp.HeaderTransferEncoding) {
+		return nil
+	}
+
+	if req.HasHeader(pkghttp.HeaderContentLength) {
+		return common.HTTPError(ErrConflictingFraming)
+	}
+
+	// The codings form one list however many header lines carry them
+	var codings []string
+	for _, value := range req.GetHeaders(pkghttp.HeaderTransferEncoding) {
+		codings = append(codings, strings.Split(value, ",")...)
+	}
+
+	for i, coding := range codings {
+		coding = strings.TrimSpace(coding)
+		if !isValidHeaderName(coding) {
+			return common.HTTPError(ErrInvalidTransferEncoding)
+		}
+
+		// chunked must be applied exactly once, and last
+		isFinal := i == len(codings)-1
+		if strings.EqualFold(coding, pkghttp.TransferEncodingChunked) != isFinal {
+			return common.HTTPError(ErrInvalidTransferEncoding)
+		}
+	}
+
+	return nil
+}
+
+// normalizeContentLength checks every Content-Length value, whether repeated
+// in lines or in a list, and collapses identical duplicates into one. Values
+// must be plain digits, so signs, spaces and differing lengths are rejected
+// (RFC 7230 section 3.3.2).
+func normalizeContentLength(headers pkghttp.Header) error {
+	values := headers.Values(pkghttp.HeaderContentLength)
+	if len(values) == 0 {
+		return nil
+	}
+
+	length := ""
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			element = strings.TrimSpace(element)
+			if !isDigits(element) {
+				return common.HTTPError(ErrInvalidContentLength)
+			}
+			if _, err := strconv.ParseInt(element, 10, 64); err != nil {
+				return common.HTTPError(ErrInvalidContentLength)
+			}
+			if length != "" && element != length {
+				return common.HTTPError(ErrConflictingContentLength)
+			}
+			length = element
+		}
+	}
+
+	headers.Set(pkghttp.HeaderContentLength, length)
+	return nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// validateHost rejects repeated or malformed Host headers, which proxies and
+// origins could otherwise resolve to different hosts, and lowercases the host
+func validateHost(req *pkghttp.HTTPRequest) error {
+	if err := checkHost(req); err != nil {
+		return err
+	}
+
+	if req.HasHeader(pkghttp.HeaderHost) {
+		req.SetHeader(pkghttp.HeaderHost, strings.ToLower(req.GetHeader(pkghttp.HeaderHost)))
+	}
+	return nil
+}
+
+// checkHost requires exactly one valid Host header, which only HTTP/1.0
+// requests may omit (RFC 7230 section 5.4)
+func checkHost(req pkghttp.Request) error {
+	hosts := req.GetHeaders(pkghttp.HeaderHost)
+	if len(hosts) == 0 {
+		if req.Version() == pkghttp.Version10 {
+			return nil
+		}
+		return common.HTTPError(ErrMissingHost)
+	}
+	if len(hosts) > 1 || !isValidHost(hosts[0]) {
+		return common.HTTPError(ErrInvalidHost)
+	}
+	return nil
+}
+
+// isValidHost checks a Host value: a host name or IP literal with an optional
+// numeric port. The empty value is allowed for targets without an authority.
+func isValidHost(host string) bool {
+	if host == "" {
+		return true
+	}
+
+	name, port := host, ""
+	if strings.HasPrefix(host, "[") {
+		end := strings.Index(host, "]")
+		if end == -1 || net.ParseIP(host[1:end]) == nil {
+			return false
+		}
+		name, port = "", host[end+1:]
+		if port != "" && !strings.HasPrefix(port, ":") {
+			return false
+		}
+	} else if colon := strings.LastIndex(host, ":"); colon != -1 {
+		name, port = host[:colon], host[colon:]
+	}
+
+	if port != "" {
+		digits := port[1:]
+		if !isDigits(digits) || len(digits) > 5 {
+			return false
+		}
+		if n, _ := strconv.Atoi(digits); n > 65535 {
+			return false
+		}
+	}
+
+	for _, r := range name {
+		if !((r >= 'a' && r <= 'z') ||
+			(r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') ||
+			r == '-' || r == '.' || r == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyTargetHost makes the Host header agree with the request target. An
+// absolute-form target replaces any Host header (RFC 7230 section 5.4), and an
+// authority-form CONNECT target supplies one if an HTTP/1.0 client sent none.
+func applyTargetHost(req *pkghttp.HTTPRequest) {
+	if req.Method() == pkghttp.MethodConnect {
+		if !req.HasHeader(pkghttp.HeaderHost) {
+			req.SetHeader(pkghttp.HeaderHost, req.Path())
+		}
+		return
+	}
+
+	if target, err := url.Parse(req.Path()); err == nil && IsAbsoluteTarget(req.Path()) {
+		req.SetHeader(pkghttp.HeaderHost, target.Host)
+	}
+}
+
 // parseRequestLine parses the HTTP request line
-func parseRequestLine(line string) (pkghttp.Method, string, pkghttp.Version, error) {
+func parseRequestLine(line string, options ParserOptions) (pkghttp.Method, string, pkghttp.Version, error) {
 	if line == "" {
 		return "", "", "", common.HTTPError(ErrInvalidRequestLine)
 	}
 
-	if len(line) > MaxRequestLineLength {
+	if len(line) > options.maxRequestLineLength() {
 		return "", "", "", common.HTTPError(ErrRequestTooLarge)
 	}
 
@@ -107,18 +330,18 @@ func parseRequestLine(line string) (pkghttp.Method, string, pkghttp.Version, err
 
 	// Validate method
 	method := pkghttp.Method(methodStr)
-	if !isValidMethod(method) {
+	if !options.allowsMethod(method) {
 		return "", "", "", common.HTTPError(ErrInvalidMethod)
 	}
 
 	// Validate path
-	if !isValidPath(path) {
+	if !isValidTarget(method, path) {
 		return "", "", "", common.HTTPError(ErrInvalidPath)
 	}
 
 	// Validate version
 	version := pkghttp.Version(versionStr)
-	if !isValidVersion(version) {
+	if !options.allowsVersion(version) {
 		return "", "", "", common.HTTPError(ErrInvalidVersion)
 	}
 
@@ -155,7 +378,7 @@ func parseHeaders(scanner *bufio.Scanner) (pkghttp.Header, error) {
 			return nil, err
 		}
 
-		headers[name] = append(headers[name], value)
+		headers.Add(name, value)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -165,6 +388,117 @@ func parseHeaders(scanner *bufio.Scanner) (pkghttp.Header, error) {
 	return headers, nil
 }
 
+// readHeaders reads header lines from br up to and including the blank line.
+// It also returns the canonical header names in the order they first appeared.
+func readHeaders(br *bufio.Reader, options ParserOptions) (pkghttp.Header, []string, error) {
+	headers := make(pkghttp.Header)
+	var names []string
+	headerCount := 0
+	lastName := ""
+
+	for {
+		line, err := readLine(br, options.maxHeaderLineLength(), ErrHeaderTooLarge, options)
+		if err != nil {
+			// Lenient parsing lets the input end where the blank line belongs
+			if options.LenientLineEndings && errors.Is(err, io.EOF) {
+				return headers, names, nil
+			}
+			return nil, nil, err
+		}
+
+		// Empty line indicates end of headers
+		if line == "" {
+			return headers, names, nil
+		}
+
+		if isFoldedLine(line) {
+			if options.ObsFold != ObsFoldUnfold || lastName == "" {
+				return nil, nil, common.HTTPError(ErrObsoleteLineFolding)
+			}
+			if len(line) > options.maxHeaderLineLength() {
+				return nil, nil, common.HTTPError(ErrHeaderTooLarge)
+			}
+			unfoldHeader(headers, lastName, line)
+			continue
+		}
+
+		headerCount++
+		if headerCount > options.maxHeaderLines() {
+			return nil, nil, common.HTTPError(ErrHeaderTooLarge)
+		}
+
+		name, value, err := parseHeader(line)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !headers.Has(name) {
+			names = append(names, pkghttp.CanonicalHeaderKey(name))
+		}
+		headers.Add(name, value)
+		lastName = name
+	}
+}
+
+// isFoldedLine reports whether line continues the previous header (obs-fold)
+func isFoldedLine(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// unfoldHeader appends a continuation line to the last value of the named header
+func unfoldHeader(headers pkghttp.Header, name, line string) {
+	values := headers.Values(name)
+	last := len(values) - 1
+	continuation := strings.TrimSpace(line)
+
+	switch {
+	case continuation == "":
+	case values[last] == "":
+		values[last] = continuation
+	default:
+		values[last] += " " + continuation
+	}
+}
+
+// readLine reads one CRLF-terminated line of at most maxLength bytes. Lenient
+// parsing also accepts a bare LF, or the end of input after a partial line.
+func readLine(br *bufio.Reader, maxLength int, tooLongMessage string, options ParserOptions) (string, error) {
+	var line []byte
+
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if len(line) > maxLength+len(pkghttp.HTTPSeparator) {
+			return "", common.HTTPError(tooLongMessage)
+		}
+
+		if err == nil {
+			break
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err == io.EOF && options.LenientLineEndings && len(line) > 0 {
+			return strings.TrimSuffix(string(line), "\r"), nil
+		}
+
+		return "", common.HTTPErrorWithCause(ErrUnexpectedEOF, err)
+	}
+
+	if bytes.HasSuffix(line, []byte(pkghttp.HTTPSeparator)) {
+		return string(line[:len(line)-len(pkghttp.HTTPSeparator)]), nil
+	}
+
+	if !options.LenientLineEndings {
+		return "", common.HTTPError(ErrInvalidLineEnding)
+	}
+
+	return string(line[:len(line)-1]), nil
+}
+
 // parseHeader parses a single header line
 func parseHeader(line string) (string, string, error) {
 	// Find colon separator
@@ -186,16 +520,9 @@ func parseHeader(line string) (string, string, error) {
 
 // Validation functions
 
-// isValidMethod checks if the method is valid
+// isValidMethod checks if the method is standard or registered
 func isValidMethod(method pkghttp.Method) bool {
-	switch method {
-	case pkghttp.MethodGet, pkghttp.MethodPost, pkghttp.MethodPut,
-		pkghttp.MethodDelete, pkghttp.MethodHead, pkghttp.MethodOptions,
-		pkghttp.MethodPatch:
-		return true
-	default:
-		return false
-	}
+	return pkghttp.IsKnownMethod(method)
 }
 
 // isValidPath checks if the path is valid
@@ -216,7 +543,65 @@ func isValidPath(path string) bool {
 		}
 	}
 
-	return true
+	return hasValidEscapes(path)
+}
+
+// hasValidEscapes reports whether the path of an origin-form target decodes
+// cleanly, without malformed escapes or an encoded NUL
+func hasValidEscapes(target string) bool {
+	path, _, _ := strings.Cut(target, "?")
+	_, ok := pkghttp.CleanPath(path)
+	return ok
+}
+
+// isValidTarget checks the request target against the forms the method permits:
+// authority form for CONNECT, asterisk form for OPTIONS, origin or absolute form otherwise
+func isValidTarget(method pkghttp.Method, target string) bool {
+	if method == pkghttp.MethodConnect {
+		return isValidAuthority(target)
+	}
+	if target == AsteriskTarget {
+		return method == pkghttp.MethodOptions
+	}
+	if IsAbsoluteTarget(target) {
+		return hasValidEscapes(OriginForm(target))
+	}
+	return isValidPath(target)
+}
+
+// IsAbsoluteTarget reports whether target is an absolute http(s) URI, as sent to proxies
+func IsAbsoluteTarget(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == pkghttp.SchemeHTTP || u.Scheme == pkghttp.SchemeHTTPS
+}
+
+// OriginForm returns the path and query of an absolute-form target, as sent to
+// an origin server. Other targets are returned unchanged.
+func OriginForm(target string) string {
+	if !IsAbsoluteTarget(target) {
+		return target
+	}
+
+	u, _ := url.Parse(target)
+	return u.RequestURI()
+}
+
+// isValidAuthority checks for a host:port target
+func isValidAuthority(target string) bool {
+	host, port, err := net.SplitHostPort(target)
+	return err == nil && host != "" && port != ""
+}
+
+// UnixSocketPath returns the socket path of a "unix:///path/to.sock" address
+func UnixSocketPath(address string) (string, bool) {
+	path := strings.TrimPrefix(address, pkghttp.SchemeUnix+"://")
+	if path == address || path == "" {
+		return "", false
+	}
+	return path, true
 }
 
 // isValidVersion checks if the HTTP version is valid
@@ -229,6 +614,14 @@ func isValidVersion(version pkghttp.Version) bool {
 	}
 }
 
+// isWellFormedVersion checks that version has the HTTP/x.y form, whether or
+// not the version is one this package implements
+func isWellFormedVersion(version pkghttp.Version) bool {
+	digits, ok := strings.CutPrefix(string(version), pkghttp.HTTPVersionPrefix)
+	return ok && len(digits) == 3 && digits[1] == '.' &&
+		isDigits(digits[:1]) && isDigits(digits[2:])
+}
+
 // isValidHeaderName checks if the header name is valid
 func isValidHeaderName(name string) bool {
 	if name == "" {
@@ -250,6 +643,40 @@ func isValidHeaderName(name string) bool {
 
 // WriteRequest writes an HTTP request to a writer
 func WriteRequest(w io.Writer, req pkghttp.Request) error {
+	if err := WriteRequestHead(w, req); err != nil {
+		return err
+	}
+
+	// Write body if present, framed as the headers announce
+	if req.Body() == nil {
+		return nil
+	}
+
+	return writeFramedBody(w, req.Body(), isChunked(req.GetHeader(pkghttp.HeaderTransferEncoding)))
+}
+
+// writeFramedBody writes body, encoding it in chunks when chunked is set
+func writeFramedBody(w io.Writer, body io.Reader, chunked bool) error {
+	if !chunked {
+		if _, err := io.Copy(w, body); err != nil {
+			return common.HTTPError("failed to write body")
+		}
+		return nil
+	}
+
+	chunkedWriter := NewChunkedWriter(w)
+	if _, err := io.Copy(chunkedWriter, body); err != nil {
+		return common.HTTPError("failed to write body")
+	}
+	if err := chunkedWriter.Close(); err != nil {
+		return common.HTTPError("failed to write body")
+	}
+
+	return nil
+}
+
+// WriteRequestHead writes the request line, headers and the blank separator line
+func WriteRequestHead(w io.Writer, req pkghttp.Request) error {
 	// Write request line
 	requestLine := fmt.Sprintf("%s %s %s\r\n",
 		req.Method(),
@@ -260,9 +687,13 @@ func WriteRequest(w io.Writer, req pkghttp.Request) error {
 		return common.HTTPError("failed to write request line")
 	}
 
-	// Write headers
-	for name, values := range req.Headers() {
-		for _, value := range values {
+	return writeHeaderBlock(w, req.Headers(), req.HeaderNames())
+}
+
+// writeHeaderBlock writes the named headers in order, then the blank separator line
+func writeHeaderBlock(w io.Writer, headers pkghttp.Header, names []string) error {
+	for _, name := range names {
+		for _, value := range headers[name] {
 			headerLine := fmt.Sprintf("%s: %s\r\n", name, value)
 			if _, err := w.Write([]byte(headerLine)); err != nil {
 				return common.HTTPError("failed to write header")
@@ -275,13 +706,6 @@ func WriteRequest(w io.Writer, req pkghttp.Request) error {
 		return common.HTTPError("failed to write header separator")
 	}
 
-	// Write body if present
-	if req.Body() != nil {
-		if _, err := io.Copy(w, req.Body()); err != nil {
-			return common.HTTPError("failed to write body")
-		}
-	}
-
 	return nil
 }
 
@@ -293,8 +717,8 @@ func FormatRequest(req pkghttp.Request) string {
 	fmt.Fprintf(&buf, "%s %s %s\n", req.Method(), req.Path(), req.Version())
 
 	// Headers
-	for name, values := range req.Headers() {
-		for _, value := range values {
+	for _, name := range req.HeaderNames() {
+		for _, value := range req.Headers()[name] {
 			fmt.Fprintf(&buf, "%s: %s\n", name, value)
 		}
 	}
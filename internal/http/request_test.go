@@ -1,6 +1,10 @@
 package http
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -43,6 +47,21 @@ func TestWriteRequest(t *testing.T) {
 				"\r\n" +
 				"{\"test\": true}",
 		},
+		{
+			name: "POST request with chunked body",
+			request: func() pkghttp.Request {
+				req := pkghttp.NewRequest(pkghttp.MethodPost, "/upload", pkghttp.Version11)
+				req.SetHeader("Host", "example.com")
+				req.SetHeader("Transfer-Encoding", "chunked")
+				req.SetBody(strings.NewReader("hello"))
+				return req
+			}(),
+			expected: "POST /upload HTTP/1.1\r\n" +
+				"Host: example.com\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello\r\n0\r\n\r\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -178,11 +197,37 @@ func TestParseRequestLine(t *testing.T) {
 			requestLine: "GET /hello HTTP/2.0",
 			wantErr:     true,
 		},
+		{
+			name:        "asterisk-form OPTIONS",
+			requestLine: "OPTIONS * HTTP/1.1",
+			wantMethod:  pkghttp.MethodOptions,
+			wantPath:    "*",
+			wantVersion: pkghttp.Version11,
+			wantErr:     false,
+		},
+		{
+			name:        "asterisk-form GET",
+			requestLine: "GET * HTTP/1.1",
+			wantErr:     true,
+		},
+		{
+			name:        "absolute-form GET",
+			requestLine: "GET http://example.com/a?b=c HTTP/1.1",
+			wantMethod:  pkghttp.MethodGet,
+			wantPath:    "http://example.com/a?b=c",
+			wantVersion: pkghttp.Version11,
+			wantErr:     false,
+		},
+		{
+			name:        "absolute-form with unsupported scheme",
+			requestLine: "GET ftp://example.com/ HTTP/1.1",
+			wantErr:     true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			method, path, version, err := parseRequestLine(tt.requestLine)
+			method, path, version, err := parseRequestLine(tt.requestLine, strictParsing)
 
 			if tt.wantErr {
 				if err == nil {
@@ -367,3 +412,592 @@ func TestParseRequestErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestReadRequestLeavesNextRequestUnread(t *testing.T) {
+	rawData := "POST /first HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Content-Length: 5\r\n" +
+		"\r\n" +
+		"hello" +
+		"GET /second HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"\r\n"
+
+	br := bufio.NewReader(strings.NewReader(rawData))
+
+	first, err := ReadRequest(br, nil)
+	if err != nil {
+		t.Fatalf("ReadRequest failed: %v", err)
+	}
+
+	body, err := io.ReadAll(first.Body())
+	if err != nil {
+		t.Fatalf("Reading body failed: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("Expected body hello, got %q", body)
+	}
+
+	second, err := ReadRequest(br, nil)
+	if err != nil {
+		t.Fatalf("ReadRequest for second request failed: %v", err)
+	}
+	if second.Path() != "/second" {
+		t.Errorf("Expected /second, got %s", second.Path())
+	}
+}
+
+func TestParseRequestChunkedBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "chunks are joined",
+			raw: "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"5\r\nHello\r\n6\r\n World\r\n0\r\n\r\n",
+			expected: "Hello World",
+		},
+		{
+			name: "chunk extensions and trailers are skipped",
+			raw: "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"5;name=value\r\nHello\r\n0\r\nX-Checksum: abc\r\n\r\n",
+			expected: "Hello",
+		},
+		{
+			name: "empty body",
+			raw:  "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+		},
+		{
+			name: "truncated chunk",
+			raw: "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"a\r\nHello",
+			wantErr: true,
+		},
+		{
+			name: "missing last chunk",
+			raw: "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"5\r\nHello\r\n",
+			wantErr: true,
+		},
+		{
+			name: "invalid chunk size",
+			raw: "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"zz\r\nHello\r\n0\r\n\r\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewParser().Parse(strings.NewReader(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			body, err := io.ReadAll(req.Body())
+			if err != nil {
+				t.Fatalf("Reading body failed: %v", err)
+			}
+			if string(body) != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestReadRequestChunkedBody(t *testing.T) {
+	rawData := "POST /first HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"3\r\nhel\r\n2\r\nlo\r\n0\r\n\r\n" +
+		"GET /second HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"\r\n"
+
+	br := bufio.NewReader(strings.NewReader(rawData))
+
+	first, err := ReadRequest(br, nil)
+	if err != nil {
+		t.Fatalf("ReadRequest failed: %v", err)
+	}
+
+	body, err := io.ReadAll(first.Body())
+	if err != nil {
+		t.Fatalf("Reading body failed: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("Expected body hello, got %q", body)
+	}
+
+	second, err := ReadRequest(br, nil)
+	if err != nil {
+		t.Fatalf("ReadRequest for second request failed: %v", err)
+	}
+	if second.Path() != "/second" {
+		t.Errorf("Expected /second, got %s", second.Path())
+	}
+
+	t.Run("body over limit", func(t *testing.T) {
+		raw := "POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+			"6\r\nhello!\r\n0\r\n\r\n"
+		req, err := ReadRequestWithOptions(bufio.NewReader(strings.NewReader(raw)), nil, ParserOptions{MaxBodySize: 5})
+		if err != nil {
+			t.Fatalf("ReadRequestWithOptions failed: %v", err)
+		}
+
+		_, err = io.ReadAll(req.Body())
+		if err == nil || !strings.Contains(err.Error(), ErrRequestBodyTooLarge) {
+			t.Errorf("Expected %q error, got %v", ErrRequestBodyTooLarge, err)
+		}
+	})
+}
+
+func TestReadRequestRequiresCRLF(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("GET / HTTP/1.1\nHost: example.com\n\n"))
+
+	if _, err := ReadRequest(br, nil); err == nil {
+		t.Error("Expected error for bare LF line endings")
+	}
+}
+
+func TestUnixSocketPath(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+		ok       bool
+	}{
+		{"unix:///var/run/app.sock", "/var/run/app.sock", true},
+		{"unix://relative.sock", "relative.sock", true},
+		{"unix://", "", false},
+		{"/var/run/app.sock", "", false},
+		{"127.0.0.1:8080", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			path, ok := UnixSocketPath(tt.address)
+			if path != tt.expected || ok != tt.ok {
+				t.Errorf("UnixSocketPath(%q) = %q, %v; want %q, %v", tt.address, path, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCanonicalHeaderKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"content-type", "Content-Type"},
+		{"CONTENT-LENGTH", "Content-Length"},
+		{"x-forwarded-for", "X-Forwarded-For"},
+		{"etag", "ETag"},
+		{"www-authenticate", "WWW-Authenticate"},
+		{"x-request-id", "X-Request-ID"},
+		{"te", "TE"},
+		{"Host", "Host"},
+		{"bad header", "bad header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkghttp.CanonicalHeaderKey(tt.name); got != tt.expected {
+				t.Errorf("CanonicalHeaderKey(%q) = %q, want %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRequestCaseInsensitiveHeaders(t *testing.T) {
+	raw := "POST /submit HTTP/1.1\r\n" +
+		"host: example.com\r\n" +
+		"content-type: text/plain\r\n" +
+		"CONTENT-LENGTH: 5\r\n" +
+		"x-custom: one\r\n" +
+		"X-CUSTOM: two\r\n" +
+		"\r\n" +
+		"hello"
+
+	req, err := ParseRequest(strings.NewReader(raw), nil)
+	if err != nil {
+		t.Fatalf("ParseRequest failed: %v", err)
+	}
+
+	if got := req.GetHeader("Content-Type"); got != "text/plain" {
+		t.Errorf("Expected Content-Type text/plain, got %q", got)
+	}
+	if got := req.GetHeader("HOST"); got != "example.com" {
+		t.Errorf("Expected Host example.com, got %q", got)
+	}
+	if req.ContentLength() != 5 {
+		t.Errorf("Expected content length 5, got %d", req.ContentLength())
+	}
+	if got := req.GetHeaders("x-custom"); len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("Expected both X-Custom values, got %v", got)
+	}
+	if _, exists := req.Headers()["X-Custom"]; !exists {
+		t.Error("Expected headers to be stored under their canonical name")
+	}
+
+	req.SetHeader("x-custom", "three")
+	if got := req.GetHeaders("X-Custom"); len(got) != 1 || got[0] != "three" {
+		t.Errorf("Expected SetHeader to replace both values, got %v", got)
+	}
+}
+
+func TestRequestQueryValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		key      string
+		first    string
+		all      []string
+		distinct int
+	}{
+		{"single value", "/search?q=go", "q", "go", []string{"go"}, 1},
+		{"repeated key", "/items?tag=a&tag=b&page=2", "tag", "a", []string{"a", "b"}, 2},
+		{"encoded value", "/search?q=tiny+server%21", "q", "tiny server!", []string{"tiny server!"}, 1},
+		{"missing key", "/items?page=2", "tag", "", nil, 1},
+		{"no query", "/items", "tag", "", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest(strings.NewReader("GET "+tt.path+" HTTP/1.1\r\nHost: example.com\r\n\r\n"), nil)
+			if err != nil {
+				t.Fatalf("ParseRequest failed: %v", err)
+			}
+
+			if got := req.QueryParam(tt.key); got != tt.first {
+				t.Errorf("QueryParam(%q) = %q, want %q", tt.key, got, tt.first)
+			}
+			if got := req.QueryParamsAll(tt.key); !reflect.DeepEqual(got, tt.all) {
+				t.Errorf("QueryParamsAll(%q) = %v, want %v", tt.key, got, tt.all)
+			}
+			if got := len(req.QueryValues()); got != tt.distinct {
+				t.Errorf("Expected %d distinct keys, got %d", tt.distinct, got)
+			}
+			if got := req.QueryParams()[tt.key]; got != tt.first {
+				t.Errorf("QueryParams()[%q] = %q, want %q", tt.key, got, tt.first)
+			}
+		})
+	}
+}
+
+func TestRequestSetPathReparsesQuery(t *testing.T) {
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/items?tag=a", pkghttp.Version11)
+	if got := req.QueryParam("tag"); got != "a" {
+		t.Fatalf("Expected tag a, got %q", got)
+	}
+
+	req.SetPath("/items?tag=b&tag=c")
+	if got := req.QueryParamsAll("tag"); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("Expected tags [b c] after SetPath, got %v", got)
+	}
+}
+
+func TestParseRequestTargetHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "absolute form replaces Host",
+			raw:      "GET http://example.com:8080/path HTTP/1.1\r\nHost: other.test\r\n\r\n",
+			expected: "example.com:8080",
+		},
+		{
+			name:     "absolute form supplies HTTP/1.0 Host",
+			raw:      "GET https://example.com/ HTTP/1.0\r\n\r\n",
+			expected: "example.com",
+		},
+		{
+			name:     "authority form supplies HTTP/1.0 Host",
+			raw:      "CONNECT example.com:443 HTTP/1.0\r\n\r\n",
+			expected: "example.com:443",
+		},
+		{
+			name:     "authority form keeps Host",
+			raw:      "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			expected: "example.com",
+		},
+		{
+			name:     "origin form keeps Host",
+			raw:      "GET /path HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			expected: "example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest(strings.NewReader(tt.raw), nil)
+			if err != nil {
+				t.Fatalf("ParseRequest failed: %v", err)
+			}
+			if got := req.GetHeader(pkghttp.HeaderHost); got != tt.expected {
+				t.Errorf("Expected Host %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOriginForm(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{"http://example.com/path?q=1", "/path?q=1"},
+		{"http://example.com", "/"},
+		{"https://example.com:8443/a/b", "/a/b"},
+		{"/already/origin", "/already/origin"},
+		{"*", "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := OriginForm(tt.target); got != tt.expected {
+				t.Errorf("OriginForm(%q) = %q, want %q", tt.target, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"/a/b", "/a/b", true},
+		{"/a/../b", "/b", true},
+		{"/a/./b/", "/a/b/", true},
+		{"/a/b/..", "/a/", true},
+		{"/a//b", "/a/b", true},
+		{"/../..", "/", true},
+		{"/%7Euser/caf%C3%A9", "/~user/café", true},
+		{"/a/%2E%2E/b", "/b", true},
+		{"/a%2Fb", "/a%2Fb", true},
+		{"/a%2fb/c%5cd", "/a%2Fb/c%5Cd", true},
+		{"/a/..%2F..%2Fb", "/a/..%2F..%2Fb", true},
+		{"/a%252Fb", "/a%252Fb", true},
+		{"/100%25", "/100%25", true},
+		{"/a%%2Fb", "", false},
+		{"/a%zz", "", false},
+		{"/a%00b", "", false},
+		{"*", "*", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := pkghttp.CleanPath(tt.path)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("CleanPath(%q) = %q, %v; want %q, %v", tt.path, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseRequestRejectsBadEscapes(t *testing.T) {
+	targets := []string{
+		"/a%zz",
+		"/files/a%00.txt",
+		"http://example.com/a%00",
+	}
+
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			raw := "GET " + target + " HTTP/1.1\r\nHost: example.com\r\n\r\n"
+			if _, err := ParseRequest(strings.NewReader(raw), nil); err == nil {
+				t.Errorf("Expected %q to be rejected", target)
+			}
+		})
+	}
+
+	req, err := ParseRequest(strings.NewReader("GET /a/../b%20c?q=%zz HTTP/1.1\r\nHost: example.com\r\n\r\n"), nil)
+	if err != nil {
+		t.Fatalf("ParseRequest failed: %v", err)
+	}
+	if got := req.(*pkghttp.HTTPRequest).PathWithoutQuery(); got != "/b c" {
+		t.Errorf("Expected PathWithoutQuery %q, got %q", "/b c", got)
+	}
+}
+
+func TestParseRequestSmugglingProtections(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers string
+		wantErr string
+	}{
+		{name: "chunked only", headers: "Transfer-Encoding: chunked\r\n"},
+		{name: "coding chain ending in chunked", headers: "Transfer-Encoding: gzip, chunked\r\n"},
+		{name: "chain across header lines", headers: "Transfer-Encoding: gzip\r\nTransfer-Encoding: chunked\r\n"},
+		{
+			name:    "both Transfer-Encoding and Content-Length",
+			headers: "Transfer-Encoding: chunked\r\nContent-Length: 5\r\n",
+			wantErr: ErrConflictingFraming,
+		},
+		{name: "chunked not last", headers: "Transfer-Encoding: chunked, gzip\r\n", wantErr: ErrInvalidTransferEncoding},
+		{name: "chunked twice", headers: "Transfer-Encoding: chunked, chunked\r\n", wantErr: ErrInvalidTransferEncoding},
+		{name: "empty coding", headers: "Transfer-Encoding: ,chunked\r\n", wantErr: ErrInvalidTransferEncoding},
+		{name: "malformed coding", headers: "Transfer-Encoding: gz/ip, chunked\r\n", wantErr: ErrInvalidTransferEncoding},
+		{name: "repeated Host", headers: "Host: other.test\r\n", wantErr: ErrInvalidHost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := "POST /upload HTTP/1.1\r\nHost: example.com\r\n" + tt.headers + "\r\n0\r\n\r\n"
+			_, err := ParseRequest(strings.NewReader(raw), nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseRequest failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseRequestHostValidation(t *testing.T) {
+	tests := []struct {
+		host     string
+		valid    bool
+		expected string
+	}{
+		{host: "Example.COM", valid: true, expected: "example.com"},
+		{host: "example.com:8080", valid: true, expected: "example.com:8080"},
+		{host: "127.0.0.1:80", valid: true, expected: "127.0.0.1:80"},
+		{host: "[::1]:8080", valid: true, expected: "[::1]:8080"},
+		{host: "", valid: true, expected: ""},
+		{host: "example.com:", valid: false},
+		{host: "example.com:99999", valid: false},
+		{host: "example.com:http", valid: false},
+		{host: "exa mple.com", valid: false},
+		{host: "user@example.com", valid: false},
+		{host: "example.com/path", valid: false},
+		{host: "[::1", valid: false},
+		{host: "[not-ip]:80", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			raw := "GET / HTTP/1.1\r\nHost: " + tt.host + "\r\n\r\n"
+			req, err := ParseRequest(strings.NewReader(raw), nil)
+			if !tt.valid {
+				if err == nil || !strings.Contains(err.Error(), ErrInvalidHost) {
+					t.Errorf("Expected %q error, got %v", ErrInvalidHost, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRequest failed: %v", err)
+			}
+			if got := req.GetHeader(pkghttp.HeaderHost); got != tt.expected {
+				t.Errorf("Expected Host %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := ParseRequest(strings.NewReader("GET / HTTP/1.1\r\n\r\n"), nil)
+		if err == nil || !strings.Contains(err.Error(), ErrMissingHost) {
+			t.Errorf("Expected %q error, got %v", ErrMissingHost, err)
+		}
+
+		if _, err := ParseRequest(strings.NewReader("GET / HTTP/1.0\r\n\r\n"), nil); err != nil {
+			t.Errorf("Expected HTTP/1.0 without Host to parse, got %v", err)
+		}
+	})
+}
+
+func TestParseRequestContentLengthValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  string
+		wantErr  string
+		expected int64
+	}{
+		{name: "single value", headers: "Content-Length: 5\r\n", expected: 5},
+		{name: "identical duplicates", headers: "Content-Length: 5\r\nContent-Length: 5\r\n", expected: 5},
+		{name: "identical list", headers: "Content-Length: 5, 5\r\n", expected: 5},
+		{name: "differing duplicates", headers: "Content-Length: 5\r\nContent-Length: 6\r\n", wantErr: ErrConflictingContentLength},
+		{name: "differing list", headers: "Content-Length: 5, 50\r\n", wantErr: ErrConflictingContentLength},
+		{name: "plus sign", headers: "Content-Length: +5\r\n", wantErr: ErrInvalidContentLength},
+		{name: "minus sign", headers: "Content-Length: -5\r\n", wantErr: ErrInvalidContentLength},
+		{name: "inner whitespace", headers: "Content-Length: 5 5\r\n", wantErr: ErrInvalidContentLength},
+		{name: "hexadecimal", headers: "Content-Length: 0x5\r\n", wantErr: ErrInvalidContentLength},
+		{name: "empty", headers: "Content-Length: \r\n", wantErr: ErrInvalidContentLength},
+		{name: "overflow", headers: "Content-Length: 99999999999999999999\r\n", wantErr: ErrInvalidContentLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := "POST /upload HTTP/1.1\r\nHost: example.com\r\n" + tt.headers + "\r\nhello"
+			req, err := ParseRequest(strings.NewReader(raw), nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected %q error, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRequest failed: %v", err)
+			}
+			if req.ContentLength() != tt.expected {
+				t.Errorf("Expected content length %d, got %d", tt.expected, req.ContentLength())
+			}
+			if values := req.GetHeaders(pkghttp.HeaderContentLength); len(values) != 1 {
+				t.Errorf("Expected duplicates to collapse into one value, got %v", values)
+			}
+		})
+	}
+}
+
+func TestRequestHeaderOrderRoundTrip(t *testing.T) {
+	raw := "POST /submit HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"User-Agent: test\r\n" +
+		"Content-Length: 2\r\n" +
+		"Accept: */*\r\n" +
+		"X-Trace: a\r\n" +
+		"X-Trace: b\r\n" +
+		"\r\n" +
+		"hi"
+
+	req, err := ReadRequest(bufio.NewReader(strings.NewReader(raw)), nil)
+	if err != nil {
+		t.Fatalf("ReadRequest failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRequest(&buf, req); err != nil {
+		t.Fatalf("WriteRequest failed: %v", err)
+	}
+
+	// Content-Length moves to the end, everything else keeps its place
+	expected := "POST /submit HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"User-Agent: test\r\n" +
+		"Accept: */*\r\n" +
+		"X-Trace: a\r\n" +
+		"X-Trace: b\r\n" +
+		"Content-Length: 2\r\n" +
+		"\r\n" +
+		"hi"
+	if buf.String() != expected {
+		t.Errorf("Unexpected request:\n%q\nwant:\n%q", buf.String(), expected)
+	}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -25,65 +26,133 @@ func NewResponseFromRaw(rawData []byte) (pkghttp.Response, error) {
 
 // ParseResponse parses an HTTP response from a reader
 func ParseResponse(r io.Reader) (pkghttp.Response, error) {
-	// Read entire response into buffer to properly separate headers and body
-	buf := &bytes.Buffer{}
-	if _, err := io.Copy(buf, r); err != nil {
+	br := bufio.NewReader(r)
+
+	resp, err := readResponseHead(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reader holds exactly one message, so everything left is the body
+	bodyData, err := io.ReadAll(br)
+	if err != nil {
 		return nil, common.HTTPError("failed to read response: " + err.Error())
 	}
 
-	data := buf.Bytes()
+	contentLength := resp.ContentLength()
+	if contentLength > 0 {
+		if int64(len(bodyData)) != contentLength {
+			return nil, common.HTTPError(ErrUnexpectedEOF)
+		}
+		resp.SetBody(bytes.NewReader(bodyData))
+	}
+
+	return resp, nil
+}
+
+// ReadResponse reads a single HTTP response from a buffered connection reader.
+// The body is streamed from br according to its framing headers.
+func ReadResponse(br *bufio.Reader) (pkghttp.Response, error) {
+	return ReadResponseForMethod(br, pkghttp.MethodGet)
+}
+
+// ReadResponseForMethod reads one response to a request made with method.
+// Interim 1xx responses such as 100 Continue and 103 Early Hints are skipped,
+// but 101 Switching Protocols is final. Responses to HEAD never carry a body,
+// whatever their framing headers say, and a successful CONNECT response is
+// followed by tunnel data, not a body.
+func ReadResponseForMethod(br *bufio.Reader, method pkghttp.Method) (pkghttp.Response, error) {
+	resp, err := readFinalResponseHead(br)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case method == pkghttp.MethodHead:
+		// Content-Length describes the body a GET would have returned
+	case method == pkghttp.MethodConnect && pkghttp.IsSuccess(resp.StatusCode()):
+		// The connection now carries the tunnel
+	case isChunked(resp.GetHeader(pkghttp.HeaderTransferEncoding)):
+		resp.SetBody(NewBody(NewChunkedReader(br)))
+	case resp.HasHeader(pkghttp.HeaderContentLength):
+		if contentLength := resp.ContentLength(); contentLength > 0 {
+			resp.SetBody(NewBody(NewContentLengthReader(br, contentLength)))
+		}
+	case BodyAllowedForStatus(resp.StatusCode()):
+		// No framing information: the body runs until the connection closes
+		resp.SetBody(NewBody(br))
+	}
+
+	return resp, nil
+}
 
-	// Find the header-body separator (\r\n\r\n)
-	headerEndIndex := bytes.Index(data, []byte("\r\n\r\n"))
-	if headerEndIndex == -1 {
-		return nil, common.HTTPError("invalid response format")
+// readFinalResponseHead reads response heads until one is not interim
+func readFinalResponseHead(br *bufio.Reader) (pkghttp.Response, error) {
+	for i := 0; i <= MaxInterimResponses; i++ {
+		resp, err := readResponseHead(br)
+		if err != nil {
+			return nil, err
+		}
+
+		// 1xx responses have no body, so the next response follows directly
+		if !IsInterimStatus(resp.StatusCode()) {
+			return resp, nil
+		}
 	}
 
-	headerData := data[:headerEndIndex]
-	bodyData := data[headerEndIndex+4:] // Skip \r\n\r\n
+	return nil, common.HTTPError(ErrTooManyInterimResponses)
+}
 
-	// Parse headers section
-	scanner := bufio.NewScanner(bytes.NewReader(headerData))
+// IsInterimStatus reports whether status is a 1xx response that precedes the
+// final one; 101 Switching Protocols ends HTTP on the connection instead
+func IsInterimStatus(status pkghttp.StatusCode) bool {
+	return pkghttp.IsInformational(status) && status != pkghttp.StatusSwitchingProtocols
+}
 
-	// Parse status line
-	if !scanner.Scan() {
-		return nil, common.HTTPError("invalid response status line")
+// readResponseHead reads the status line and headers
+func readResponseHead(br *bufio.Reader) (pkghttp.Response, error) {
+	statusLine, err := readLine(br, MaxRequestLineLength, ErrHeaderTooLarge, strictParsing)
+	if err != nil {
+		return nil, err
 	}
 
-	statusLine := scanner.Text()
 	version, statusCode, err := parseStatusLine(statusLine)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create response
 	resp := pkghttp.NewResponse(statusCode, version)
 
-	// Parse headers
-	headers, err := parseResponseHeaders(scanner)
+	headers, names, err := readHeaders(br, strictParsing)
 	if err != nil {
 		return nil, err
 	}
-
-	// Set headers
-	for name, values := range headers {
-		for _, value := range values {
-			resp.AddHeader(name, value)
-		}
+	if err := normalizeContentLength(headers); err != nil {
+		return nil, err
 	}
 
-	// Parse body if present
-	contentLength := resp.ContentLength()
-	if contentLength > 0 {
-		if int64(len(bodyData)) != contentLength {
-			return nil, common.HTTPError(ErrUnexpectedEOF)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			resp.AddHeader(name, value)
 		}
-		resp.SetBody(bytes.NewReader(bodyData))
 	}
 
 	return resp, nil
 }
 
+// isChunked reports whether the final transfer coding is chunked
+func isChunked(transferEncoding string) bool {
+	codings := strings.Split(transferEncoding, ",")
+	return strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), pkghttp.TransferEncodingChunked)
+}
+
+// BodyAllowedForStatus reports whether a response with this status may carry a body
+func BodyAllowedForStatus(status pkghttp.StatusCode) bool {
+	return !pkghttp.IsInformational(status) &&
+		status != pkghttp.StatusNoContent &&
+		status != pkghttp.StatusNotModified
+}
+
 // parseStatusLine parses the HTTP status line
 func parseStatusLine(line string) (pkghttp.Version, pkghttp.StatusCode, error) {
 	if line == "" {
@@ -124,29 +193,8 @@ func parseResponseHeaders(scanner *bufio.Scanner) (pkghttp.Header, error) {
 
 // WriteResponse writes an HTTP response to a writer
 func WriteResponse(w io.Writer, resp pkghttp.Response) error {
-	// Write status line
-	statusLine := fmt.Sprintf("%s %d %s\r\n",
-		resp.Version(),
-		resp.StatusCode(),
-		pkghttp.StatusText(resp.StatusCode()))
-
-	if _, err := w.Write([]byte(statusLine)); err != nil {
-		return common.HTTPError("failed to write status line")
-	}
-
-	// Write headers
-	for name, values := range resp.Headers() {
-		for _, value := range values {
-			headerLine := fmt.Sprintf("%s: %s\r\n", name, value)
-			if _, err := w.Write([]byte(headerLine)); err != nil {
-				return common.HTTPError("failed to write header")
-			}
-		}
-	}
-
-	// Write header-body separator
-	if _, err := w.Write([]byte("\r\n")); err != nil {
-		return common.HTTPError("failed to write header separator")
+	if err := WriteResponseHead(w, resp); err != nil {
+		return err
 	}
 
 	// Write body if present
@@ -159,6 +207,21 @@ func WriteResponse(w io.Writer, resp pkghttp.Response) error {
 	return nil
 }
 
+// WriteResponseHead writes the status line, headers and the blank separator line
+func WriteResponseHead(w io.Writer, resp pkghttp.Response) error {
+	// Write status line
+	statusLine := fmt.Sprintf("%s %d %s\r\n",
+		resp.Version(),
+		resp.StatusCode(),
+		pkghttp.StatusText(resp.StatusCode()))
+
+	if _, err := w.Write([]byte(statusLine)); err != nil {
+		return common.HTTPError("failed to write status line")
+	}
+
+	return writeHeaderBlock(w, resp.Headers(), resp.HeaderNames())
+}
+
 // BuildErrorResponse builds a standard error response
 func BuildErrorResponse(statusCode pkghttp.StatusCode, message string) pkghttp.Response {
 	if message == "" {
@@ -190,14 +253,17 @@ func BuildJSONErrorResponse(statusCode pkghttp.StatusCode, message string) pkght
 		message = pkghttp.StatusText(statusCode)
 	}
 
-	json := fmt.Sprintf(`{
+	// Marshal escapes quotes and control characters in the message
+	quoted, _ := json.Marshal(message)
+
+	body := fmt.Sprintf(`{
     "error": {
         "code": %d,
-        "message": "%s"
+        "message": %s
     }
-}`, statusCode, message)
+}`, statusCode, quoted)
 
-	return pkghttp.NewJSONResponse(statusCode, pkghttp.Version11, json)
+	return pkghttp.NewJSONResponse(statusCode, pkghttp.Version11, body)
 }
 
 // BuildTextResponse builds a simple text response
@@ -245,8 +311,8 @@ func FormatResponse(resp pkghttp.Response) string {
 		pkghttp.StatusText(resp.StatusCode()))
 
 	// Headers
-	for name, values := range resp.Headers() {
-		for _, value := range values {
+	for _, name := range resp.HeaderNames() {
+		for _, value := range resp.Headers()[name] {
 			fmt.Fprintf(&buf, "%s: %s\n", name, value)
 		}
 	}
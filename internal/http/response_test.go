@@ -1,6 +1,9 @@
 package http
 
 import (
+	"bufio"
+	"bytes"
+	"io"
 	"strings"
 	"testing"
 
@@ -408,6 +411,21 @@ func TestParseResponseErrors(t *testing.T) {
 				"Invalid header line\r\n" +
 				"\r\n",
 		},
+		{
+			name: "conflicting content lengths",
+			rawData: "HTTP/1.1 200 OK\r\n" +
+				"Content-Length: 5\r\n" +
+				"Content-Length: 7\r\n" +
+				"\r\n" +
+				"hello",
+		},
+		{
+			name: "signed content length",
+			rawData: "HTTP/1.1 200 OK\r\n" +
+				"Content-Length: +5\r\n" +
+				"\r\n" +
+				"hello",
+		},
 	}
 
 	for _, tt := range tests {
@@ -421,3 +439,178 @@ func TestParseResponseErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestReadResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawData  string
+		expected string
+	}{
+		{
+			name: "content-length body",
+			rawData: "HTTP/1.1 200 OK\r\n" +
+				"Content-Length: 5\r\n" +
+				"\r\n" +
+				"hello",
+			expected: "hello",
+		},
+		{
+			name: "chunked body",
+			rawData: "HTTP/1.1 200 OK\r\n" +
+				"Transfer-Encoding: chunked\r\n" +
+				"\r\n" +
+				"5\r\nhello\r\n6\r\n world\r\n0\r\n\r\n",
+			expected: "hello world",
+		},
+		{
+			name: "close-delimited body",
+			rawData: "HTTP/1.0 200 OK\r\n" +
+				"\r\n" +
+				"until close",
+			expected: "until close",
+		},
+		{
+			name: "no body for 204",
+			rawData: "HTTP/1.1 204 No Content\r\n" +
+				"\r\n",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := ReadResponse(bufio.NewReader(strings.NewReader(tt.rawData)))
+			if err != nil {
+				t.Fatalf("ReadResponse failed: %v", err)
+			}
+
+			var body []byte
+			if resp.Body() != nil {
+				body, err = io.ReadAll(resp.Body())
+				if err != nil {
+					t.Fatalf("Reading body failed: %v", err)
+				}
+			}
+
+			if string(body) != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestReadResponseForHead(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nHTTP/1.1 204 No Content\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(raw))
+
+	resp, err := ReadResponseForMethod(br, pkghttp.MethodHead)
+	if err != nil {
+		t.Fatalf("ReadResponseForMethod failed: %v", err)
+	}
+	if resp.Body() != nil {
+		t.Error("HEAD response should have no body")
+	}
+	if resp.ContentLength() != 5 {
+		t.Errorf("Expected Content-Length 5, got %d", resp.ContentLength())
+	}
+
+	next, err := ReadResponse(br)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+	if next.StatusCode() != pkghttp.StatusNoContent {
+		t.Errorf("Expected the following response to be intact, got %d", next.StatusCode())
+	}
+}
+
+func TestReadResponseSkipsInterimResponses(t *testing.T) {
+	t.Run("interim responses before final", func(t *testing.T) {
+		raw := "HTTP/1.1 100 Continue\r\n\r\n" +
+			"HTTP/1.1 103 Early Hints\r\nLink: </style.css>; rel=preload\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"
+
+		resp, err := ReadResponse(bufio.NewReader(strings.NewReader(raw)))
+		if err != nil {
+			t.Fatalf("ReadResponse failed: %v", err)
+		}
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Errorf("Expected the final 200, got %d", resp.StatusCode())
+		}
+		if resp.HasHeader(pkghttp.HeaderLink) {
+			t.Error("Interim headers should not leak into the final response")
+		}
+		if body, _ := io.ReadAll(resp.Body()); string(body) != "ok" {
+			t.Errorf("Expected body %q, got %q", "ok", body)
+		}
+	})
+
+	t.Run("101 is final", func(t *testing.T) {
+		raw := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"
+
+		resp, err := ReadResponse(bufio.NewReader(strings.NewReader(raw)))
+		if err != nil {
+			t.Fatalf("ReadResponse failed: %v", err)
+		}
+		if resp.StatusCode() != pkghttp.StatusSwitchingProtocols {
+			t.Errorf("Expected 101, got %d", resp.StatusCode())
+		}
+	})
+
+	t.Run("too many interim responses", func(t *testing.T) {
+		raw := strings.Repeat("HTTP/1.1 102 Processing\r\n\r\n", MaxInterimResponses+1) +
+			"HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"
+
+		_, err := ReadResponse(bufio.NewReader(strings.NewReader(raw)))
+		if err == nil || !strings.Contains(err.Error(), ErrTooManyInterimResponses) {
+			t.Errorf("Expected %q, got %v", ErrTooManyInterimResponses, err)
+		}
+	})
+}
+
+func TestWriteResponseHeaderOrder(t *testing.T) {
+	resp := pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, strings.NewReader("hi"))
+	resp.SetHeader(pkghttp.HeaderContentLength, "2")
+	resp.SetHeader("X-Zebra", "1")
+	resp.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeTextPlain)
+	resp.AddHeader("X-Zebra", "2")
+	resp.SetHeader(pkghttp.HeaderTransferEncoding, "identity")
+	resp.Headers()["X-Direct"] = []string{"b"}
+	resp.Headers()["X-Also-Direct"] = []string{"a"}
+	resp.SetHeader(pkghttp.HeaderDate, "Sun, 06 Nov 1994 08:49:37 GMT")
+
+	expected := "HTTP/1.1 200 OK\r\n" +
+		"X-Zebra: 1\r\n" +
+		"X-Zebra: 2\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Date: Sun, 06 Nov 1994 08:49:37 GMT\r\n" +
+		"X-Also-Direct: a\r\n" +
+		"X-Direct: b\r\n" +
+		"Content-Length: 2\r\n" +
+		"Transfer-Encoding: identity\r\n" +
+		"\r\n"
+
+	// Repeated writes must agree byte for byte
+	for i := 0; i < 5; i++ {
+		var buf bytes.Buffer
+		if err := WriteResponseHead(&buf, resp); err != nil {
+			t.Fatalf("WriteResponseHead failed: %v", err)
+		}
+		if buf.String() != expected {
+			t.Fatalf("Unexpected head:\n%q\nwant:\n%q", buf.String(), expected)
+		}
+	}
+}
+
+func TestReadResponsePreservesHeaderOrder(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nServer: test\r\nContent-Length: 0\r\nx-b: 1\r\nX-A: 2\r\nX-B: 3\r\nCache-Control: no-cache\r\n\r\n"
+
+	resp, err := ReadResponse(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+
+	expected := []string{"Server", "X-B", "X-A", "Cache-Control", "Content-Length"}
+	if got := resp.HeaderNames(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,236 @@
+package http
+
+import (
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// ValidatorOptions holds the rules a RequestValidator enforces. The zero value
+// accepts any request the strict parser would.
+type ValidatorOptions struct {
+	// AllowedMethods lists the methods accepted; nil accepts the standard and
+	// registered extension methods
+	AllowedMethods []pkghttp.Method
+
+	// AllowUnknownVersions accepts any well-formed HTTP/x.y version
+	AllowUnknownVersions bool
+
+	// MaxTargetLength limits the request target in bytes; zero means MaxRequestLineLength
+	MaxTargetLength int
+
+	// RequiredHeaders lists headers every request must carry
+	RequiredHeaders []string
+
+	// RejectObsText refuses header values containing bytes over 0x7F, which
+	// RFC 7230 keeps only for compatibility (obs-text)
+	RejectObsText bool
+}
+
+// requestValidator implements pkghttp.RequestValidator
+type requestValidator struct {
+	options ValidatorOptions
+}
+
+// NewRequestValidator creates a validator enforcing options, or the defaults when omitted
+func NewRequestValidator(options ...ValidatorOptions) pkghttp.RequestValidator {
+	v := &requestValidator{}
+	if len(options) > 0 {
+		v.options = options[0]
+	}
+	return v
+}
+
+// ValidateMethod checks that method is a token the validator accepts
+func (v *requestValidator) ValidateMethod(method pkghttp.Method) error {
+	parsing := ParserOptions{AllowedMethods: v.options.AllowedMethods}
+	if !parsing.allowsMethod(method) {
+		return common.HTTPError(ErrInvalidMethod + ": " + string(method))
+	}
+	return nil
+}
+
+// ValidatePath checks the length and form of an origin-form path
+func (v *requestValidator) ValidatePath(path string) error {
+	if err := v.validateTargetLength(path); err != nil {
+		return err
+	}
+	if !isValidPath(path) {
+		return common.HTTPError(ErrInvalidPath)
+	}
+	return nil
+}
+
+// ValidateHeaders checks that names are tokens, values are valid field
+// values and the required headers are present
+func (v *requestValidator) ValidateHeaders(headers pkghttp.Header) error {
+	for name, values := range headers {
+		if !isToken(name) {
+			return common.HTTPError(ErrInvalidHeader + ": " + name)
+		}
+		for _, value := range values {
+			if !isFieldValue(value, v.options.RejectObsText) {
+				return common.HTTPError(ErrInvalidHeaderValue + ": " + name)
+			}
+		}
+	}
+
+	for _, name := range v.options.RequiredHeaders {
+		if !headers.Has(name) {
+			return common.HTTPError(ErrMissingRequiredHeader + ": " + pkghttp.CanonicalHeaderKey(name))
+		}
+	}
+
+	return nil
+}
+
+// ValidateVersion checks that version is one the validator accepts
+func (v *requestValidator) ValidateVersion(version pkghttp.Version) error {
+	parsing := ParserOptions{AllowUnknownVersions: v.options.AllowUnknownVersions}
+	if !parsing.allowsVersion(version) {
+		return common.HTTPError(ErrInvalidVersion)
+	}
+	return nil
+}
+
+// ValidateRequest checks the method, target, version, headers and Host of req
+func (v *requestValidator) ValidateRequest(req pkghttp.Request) error {
+	if req == nil {
+		return common.HTTPError("request is nil")
+	}
+
+	if err := v.ValidateMethod(req.Method()); err != nil {
+		return err
+	}
+	if err := v.validateTargetLength(req.Path()); err != nil {
+		return err
+	}
+	if !isValidTarget(req.Method(), req.Path()) {
+		return common.HTTPError(ErrInvalidPath)
+	}
+	if err := v.ValidateVersion(req.Version()); err != nil {
+		return err
+	}
+	if err := v.ValidateHeaders(req.Headers()); err != nil {
+		return err
+	}
+
+	return checkHost(req)
+}
+
+// validateTargetLength checks target against the length limit
+func (v *requestValidator) validateTargetLength(target string) error {
+	limit := v.options.MaxTargetLength
+	if limit <= 0 {
+		limit = MaxRequestLineLength
+	}
+	if len(target) > limit {
+		return common.HTTPError(ErrTargetTooLong)
+	}
+	return nil
+}
+
+// isFieldValue reports whether value contains only visible characters, spaces,
+// tabs and, unless rejectObsText is set, obs-text (RFC 7230 section 3.2)
+func isFieldValue(value string, rejectObsText bool) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\t' || (c >= ' ' && c < 0x7f):
+		case c >= 0x80:
+			if rejectObsText {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// responseValidator implements pkghttp.ResponseValidator
+type responseValidator struct{}
+
+// NewResponseValidator creates a validator for outgoing responses, checking
+// that the status, framing headers and body agree
+func NewResponseValidator() pkghttp.ResponseValidator {
+	return &responseValidator{}
+}
+
+// ValidateStatusCode checks that status is a three-digit code from 100 to 599
+func (v *responseValidator) ValidateStatusCode(status pkghttp.StatusCode) error {
+	if status < 100 || status > 599 {
+		return common.HTTPError(ErrInvalidStatusCode)
+	}
+	return nil
+}
+
+// ValidateHeaders checks that names are tokens, values are valid field values
+// and the body is not framed twice
+func (v *responseValidator) ValidateHeaders(headers pkghttp.Header) error {
+	for name, values := range headers {
+		if !isToken(name) {
+			return common.HTTPError(ErrInvalidHeader + ": " + name)
+		}
+		for _, value := range values {
+			if !isFieldValue(value, false) {
+				return common.HTTPError(ErrInvalidHeaderValue + ": " + name)
+			}
+		}
+	}
+
+	if headers.Has(pkghttp.HeaderTransferEncoding) && headers.Has(pkghttp.HeaderContentLength) {
+		return common.HTTPError(ErrConflictingFraming)
+	}
+	// Check the Content-Length values on a copy so headers stay as they are
+	return normalizeContentLength(pkghttp.Header{pkghttp.HeaderContentLength: headers.Values(pkghttp.HeaderContentLength)})
+}
+
+// ValidateVersion checks that version is HTTP/1.0 or HTTP/1.1
+func (v *responseValidator) ValidateVersion(version pkghttp.Version) error {
+	if !isValidVersion(version) {
+		return common.HTTPError(ErrInvalidVersion)
+	}
+	return nil
+}
+
+// ValidateResponse checks resp as a whole: 1xx and 204 responses carry no
+// framing headers, 1xx, 204 and 304 responses carry no body, and a declared
+// Content-Length matches the body. The body is buffered to be measured and
+// left readable.
+func (v *responseValidator) ValidateResponse(resp pkghttp.Response) error {
+	if resp == nil {
+		return common.HTTPError("response is nil")
+	}
+
+	if err := v.ValidateStatusCode(resp.StatusCode()); err != nil {
+		return err
+	}
+	if err := v.ValidateVersion(resp.Version()); err != nil {
+		return err
+	}
+	if err := v.ValidateHeaders(resp.Headers()); err != nil {
+		return err
+	}
+
+	status := resp.StatusCode()
+	framed := resp.HasHeader(pkghttp.HeaderContentLength) || resp.HasHeader(pkghttp.HeaderTransferEncoding)
+	if (pkghttp.IsInformational(status) || status == pkghttp.StatusNoContent) && framed {
+		return common.HTTPError(ErrUnexpectedFraming)
+	}
+
+	body, err := bufferBody(resp)
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 && !BodyAllowedForStatus(status) {
+		return common.HTTPError(ErrUnexpectedBody)
+	}
+
+	// A 304 Content-Length describes the representation a GET would return
+	if resp.HasHeader(pkghttp.HeaderContentLength) && status != pkghttp.StatusNotModified &&
+		resp.ContentLength() != int64(len(body)) {
+		return common.HTTPError(ErrContentLengthMismatch)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestRequestValidator(t *testing.T) {
+	newRequest := func(method pkghttp.Method, path string, headers map[string]string) pkghttp.Request {
+		req := pkghttp.NewRequest(method, path, pkghttp.Version11)
+		req.SetHeader(pkghttp.HeaderHost, "example.com")
+		for name, value := range headers {
+			req.SetHeader(name, value)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name        string
+		options     ValidatorOptions
+		req         pkghttp.Request
+		expectError string
+	}{
+		{
+			name: "defaults accept a plain request",
+			req:  newRequest(pkghttp.MethodGet, "/index.html", nil),
+		},
+		{
+			name:        "method not allowed",
+			options:     ValidatorOptions{AllowedMethods: []pkghttp.Method{pkghttp.MethodGet}},
+			req:         newRequest(pkghttp.MethodPost, "/", nil),
+			expectError: ErrInvalidMethod,
+		},
+		{
+			name:        "target too long",
+			options:     ValidatorOptions{MaxTargetLength: 8},
+			req:         newRequest(pkghttp.MethodGet, "/a/long/path", nil),
+			expectError: ErrTargetTooLong,
+		},
+		{
+			name:        "invalid target",
+			req:         newRequest(pkghttp.MethodGet, "no-slash", nil),
+			expectError: ErrInvalidPath,
+		},
+		{
+			name:        "required header missing",
+			options:     ValidatorOptions{RequiredHeaders: []string{"x-api-key"}},
+			req:         newRequest(pkghttp.MethodGet, "/", nil),
+			expectError: ErrMissingRequiredHeader + ": X-Api-Key",
+		},
+		{
+			name:    "required header present",
+			options: ValidatorOptions{RequiredHeaders: []string{"X-API-Key"}},
+			req:     newRequest(pkghttp.MethodGet, "/", map[string]string{"x-api-key": "secret"}),
+		},
+		{
+			name:        "control character in value",
+			req:         newRequest(pkghttp.MethodGet, "/", map[string]string{"X-Note": "a\x00b"}),
+			expectError: ErrInvalidHeaderValue,
+		},
+		{
+			name: "obs-text accepted by default",
+			req:  newRequest(pkghttp.MethodGet, "/", map[string]string{"X-Note": "caf\xc3\xa9\tok"}),
+		},
+		{
+			name:        "obs-text rejected",
+			options:     ValidatorOptions{RejectObsText: true},
+			req:         newRequest(pkghttp.MethodGet, "/", map[string]string{"X-Note": "caf\xc3\xa9"}),
+			expectError: ErrInvalidHeaderValue,
+		},
+		{
+			name:        "missing Host",
+			req:         pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11),
+			expectError: ErrMissingHost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRequestValidator(tt.options).ValidateRequest(tt.req)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestRequestValidatorParts(t *testing.T) {
+	validator := NewRequestValidator()
+
+	if err := validator.ValidateVersion("HTTP/2.0"); err == nil {
+		t.Error("Expected HTTP/2.0 to be rejected by default")
+	}
+	if err := NewRequestValidator(ValidatorOptions{AllowUnknownVersions: true}).ValidateVersion("HTTP/2.0"); err != nil {
+		t.Errorf("Expected HTTP/2.0 to be allowed, got %v", err)
+	}
+	if err := validator.ValidatePath("/a%zz"); err == nil {
+		t.Error("Expected a malformed escape to be rejected")
+	}
+	if err := validator.ValidateHeaders(pkghttp.Header{"Bad Name": {"x"}}); err == nil {
+		t.Error("Expected a header name with a space to be rejected")
+	}
+}
+
+func TestResponseValidator(t *testing.T) {
+	withBody := func(status pkghttp.StatusCode, body string, headers map[string]string) pkghttp.Response {
+		resp := pkghttp.NewResponseWithBody(status, pkghttp.Version11, strings.NewReader(body))
+		for name, value := range headers {
+			resp.SetHeader(name, value)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name        string
+		resp        pkghttp.Response
+		expectError string
+	}{
+		{
+			name: "matching Content-Length",
+			resp: pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "hello"),
+		},
+		{
+			name:        "Content-Length too large",
+			resp:        withBody(pkghttp.StatusOK, "hello", map[string]string{"Content-Length": "10"}),
+			expectError: ErrContentLengthMismatch,
+		},
+		{
+			name: "chunked body without Content-Length",
+			resp: withBody(pkghttp.StatusOK, "hello", map[string]string{"Transfer-Encoding": "chunked"}),
+		},
+		{
+			name:        "both framing headers",
+			resp:        withBody(pkghttp.StatusOK, "hello", map[string]string{"Transfer-Encoding": "chunked", "Content-Length": "5"}),
+			expectError: ErrConflictingFraming,
+		},
+		{
+			name:        "204 with a body",
+			resp:        withBody(pkghttp.StatusNoContent, "oops", nil),
+			expectError: ErrUnexpectedBody,
+		},
+		{
+			name:        "204 with Content-Length",
+			resp:        withBody(pkghttp.StatusNoContent, "", map[string]string{"Content-Length": "0"}),
+			expectError: ErrUnexpectedFraming,
+		},
+		{
+			name: "204 with an empty body",
+			resp: withBody(pkghttp.StatusNoContent, "", nil),
+		},
+		{
+			name:        "304 with a body",
+			resp:        withBody(pkghttp.StatusNotModified, "stale", nil),
+			expectError: ErrUnexpectedBody,
+		},
+		{
+			name: "304 keeps the GET Content-Length",
+			resp: withBody(pkghttp.StatusNotModified, "", map[string]string{"Content-Length": "1024"}),
+		},
+		{
+			name:        "invalid status",
+			resp:        pkghttp.NewResponse(600, pkghttp.Version11),
+			expectError: ErrInvalidStatusCode,
+		},
+		{
+			name:        "header injection",
+			resp:        withBody(pkghttp.StatusOK, "", map[string]string{"Location": "/a\r\nSet-Cookie: x=1"}),
+			expectError: ErrInvalidHeaderValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewResponseValidator().ValidateResponse(tt.resp)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestResponseValidatorKeepsBodyReadable(t *testing.T) {
+	resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "hello")
+	if err := NewResponseValidator().ValidateResponse(resp); err != nil {
+		t.Fatalf("ValidateResponse failed: %v", err)
+	}
+
+	if body, _ := io.ReadAll(resp.Body()); string(body) != "hello" {
+		t.Errorf("Expected body to remain readable, got %q", body)
+	}
+}
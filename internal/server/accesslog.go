@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// AccessLogFormat selects how access log entries are rendered
+type AccessLogFormat int
+
+const (
+	// AccessLogCommon renders entries in the Common Log Format
+	AccessLogCommon AccessLogFormat = iota
+	// AccessLogCombined renders entries in the Combined Log Format
+	AccessLogCombined
+	// AccessLogJSON renders entries as one JSON object per line
+	AccessLogJSON
+)
+
+// AccessLogConfig configures the access log middleware
+type AccessLogConfig struct {
+	// Format selects the entry layout
+	Format AccessLogFormat
+
+	// Output receives one line per request; defaults to os.Stdout
+	Output io.Writer
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// AccessLogEntry describes one served request
+type AccessLogEntry struct {
+	RemoteAddr string        `json:"remote_addr"`
+	User       string        `json:"user,omitempty"`
+	Time       time.Time     `json:"time"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Version    string        `json:"version"`
+	Status     int           `json:"status"`
+	Bytes      int64         `json:"bytes"`
+	Latency    time.Duration `json:"latency_ns"`
+	Referer    string        `json:"referer,omitempty"`
+	UserAgent  string        `json:"user_agent,omitempty"`
+}
+
+// AccessLog returns middleware that writes an access log entry for every
+// request. A response with a body is logged once the server has sent it and
+// closed the body, with the number of body bytes actually sent, so streamed
+// and chunked responses are counted too.
+func AccessLog(config AccessLogConfig) pkghttp.MiddlewareFunc {
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	now := config.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	var mu sync.Mutex
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			start := now()
+			resp := next(req)
+
+			log := func(bytes int64) {
+				entry := newAccessLogEntry(req, resp, start, now().Sub(start), bytes)
+				line := entry.Format(config.Format)
+
+				mu.Lock()
+				io.WriteString(output, line+"\n")
+				mu.Unlock()
+			}
+
+			switch {
+			case resp == nil:
+				log(-1)
+			case resp.Body() == nil:
+				log(0)
+			default:
+				resp.SetBody(&countingBody{ReadCloser: resp.Body(), closed: log})
+			}
+			return resp
+		}
+	}
+}
+
+// newAccessLogEntry collects the loggable attributes of a request/response pair
+func newAccessLogEntry(req pkghttp.Request, resp pkghttp.Response, start time.Time, latency time.Duration, bytes int64) AccessLogEntry {
+	entry := AccessLogEntry{
+		RemoteAddr: remoteHost(req.RemoteAddr()),
+		Time:       start,
+		Method:     string(req.Method()),
+		Path:       req.Path(),
+		Version:    string(req.Version()),
+		Bytes:      bytes,
+		Latency:    latency,
+		Referer:    req.GetHeader(pkghttp.HeaderReferer),
+		UserAgent:  req.GetHeader(pkghttp.HeaderUserAgent),
+	}
+
+	if username, _, ok := ParseBasicAuth(req.GetHeader(pkghttp.HeaderAuthorization)); ok {
+		entry.User = username
+	}
+
+	if resp != nil {
+		entry.Status = int(resp.StatusCode())
+	}
+
+	return entry
+}
+
+// countingBody counts the bytes read from a response body and passes the
+// total to closed when the body is closed
+type countingBody struct {
+	io.ReadCloser
+	n      int64
+	closed func(n int64)
+	once   sync.Once
+}
+
+// Read reads from the body, counting the bytes returned
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// Close closes the body and reports the count the first time it is called
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.closed(b.n) })
+	return err
+}
+
+// Format renders the entry in the requested layout
+func (e AccessLogEntry) Format(format AccessLogFormat) string {
+	switch format {
+	case AccessLogJSON:
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Sprintf(`{"error":%q}`, err.Error())
+		}
+		return string(data)
+	case AccessLogCombined:
+		return fmt.Sprintf("%s %q %q", e.commonLogLine(), orEmptyField(e.Referer), orEmptyField(e.UserAgent))
+	default:
+		return e.commonLogLine()
+	}
+}
+
+// commonLogLine renders host ident authuser [date] "request" status bytes
+func (e AccessLogEntry) commonLogLine() string {
+	bytes := accessLogEmptyField
+	if e.Bytes >= 0 {
+		bytes = strconv.FormatInt(e.Bytes, 10)
+	}
+
+	return fmt.Sprintf("%s %s %s [%s] \"%s %s %s\" %d %s",
+		orEmptyField(e.RemoteAddr),
+		accessLogEmptyField,
+		orEmptyField(e.User),
+		e.Time.Format(commonLogTimeFormat),
+		e.Method, e.Path, e.Version,
+		e.Status,
+		bytes)
+}
+
+// remoteHost strips the port from a remote address
+func remoteHost(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// orEmptyField substitutes the CLF placeholder for empty values
+func orEmptyField(value string) string {
+	if value == "" {
+		return accessLogEmptyField
+	}
+	return value
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func newAccessLogRequest() pkghttp.Request {
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/index.html?x=1", pkghttp.Version11)
+	req.(*pkghttp.HTTPRequest).SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5555})
+	req.SetHeader(pkghttp.HeaderReferer, "http://example.com/")
+	req.SetHeader(pkghttp.HeaderUserAgent, "TinyClient/1.0")
+	return req
+}
+
+// sendResponse reads and closes a response body as the server does after writing it
+func sendResponse(resp pkghttp.Response) {
+	if resp.Body() != nil {
+		io.Copy(io.Discard, resp.Body())
+		resp.Body().Close()
+	}
+}
+
+func TestAccessLogFormats(t *testing.T) {
+	fixed := time.Date(2024, time.January, 15, 10, 30, 45, 0, time.UTC)
+	now := func() time.Time { return fixed }
+
+	tests := []struct {
+		name     string
+		format   AccessLogFormat
+		expected string
+	}{
+		{
+			name:     "common",
+			format:   AccessLogCommon,
+			expected: `192.0.2.1 - - [15/Jan/2024:10:30:45 +0000] "GET /index.html?x=1 HTTP/1.1" 200 2`,
+		},
+		{
+			name:     "combined",
+			format:   AccessLogCombined,
+			expected: `192.0.2.1 - - [15/Jan/2024:10:30:45 +0000] "GET /index.html?x=1 HTTP/1.1" 200 2 "http://example.com/" "TinyClient/1.0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler := AccessLog(AccessLogConfig{Format: tt.format, Output: &buf, Now: now})(okHandler)
+			sendResponse(handler(newAccessLogRequest()))
+
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.expected {
+				t.Errorf("Unexpected log line:\nExpected: %s\nGot:      %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	handler := AccessLog(AccessLogConfig{Format: AccessLogJSON, Output: &buf})(okHandler)
+
+	req := newAccessLogRequest()
+	req.SetHeader(pkghttp.HeaderAuthorization, basicHeader("alice", "secret"))
+	sendResponse(handler(req))
+
+	var entry AccessLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON log line %q: %v", buf.String(), err)
+	}
+
+	if entry.Method != "GET" || entry.Status != 200 || entry.Bytes != 2 {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.RemoteAddr != "192.0.2.1" || entry.User != "alice" {
+		t.Errorf("Unexpected client fields: %+v", entry)
+	}
+	if entry.UserAgent != "TinyClient/1.0" || entry.Referer != "http://example.com/" {
+		t.Errorf("Unexpected header fields: %+v", entry)
+	}
+}
+
+func TestAccessLogWaitsForBody(t *testing.T) {
+	var buf bytes.Buffer
+	handler := AccessLog(AccessLogConfig{Output: &buf})(func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, strings.NewReader("stream"))
+	})
+	resp := handler(newAccessLogRequest())
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no entry before the body is sent, got %s", buf.String())
+	}
+	sendResponse(resp)
+	if !strings.HasSuffix(strings.TrimSpace(buf.String()), " 200 6") {
+		t.Errorf("Expected the bytes read from the body, got %s", buf.String())
+	}
+}
+
+func TestAccessLogCountsSentBytes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("file body"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		request  string
+		handler  pkghttp.RequestHandler
+		expected string
+	}{
+		{
+			name:    "chunked",
+			request: "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			handler: func(req pkghttp.Request) pkghttp.Response {
+				return pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, strings.NewReader("streamed body"))
+			},
+			expected: " 200 13",
+		},
+		{
+			name:    "file",
+			request: "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			handler: func(req pkghttp.Request) pkghttp.Response {
+				return ServeFile(req, filepath.Join(dir, "file.txt"))
+			},
+			expected: " 200 9",
+		},
+		{
+			name:     "head",
+			request:  "HEAD / HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			handler:  okHandler,
+			expected: " 200 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make(chan string, 1)
+			output := writerFunc(func(p []byte) (int, error) {
+				lines <- strings.TrimSpace(string(p))
+				return len(p), nil
+			})
+			server := startTestServer(t, tt.handler, AccessLog(AccessLogConfig{Output: output}))
+			conn, _ := dialTestServer(t, server)
+			if _, err := io.WriteString(conn, tt.request); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+
+			select {
+			case line := <-lines:
+				if !strings.HasSuffix(line, tt.expected) {
+					t.Errorf("Expected entry ending %q, got %s", tt.expected, line)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Expected an access log entry")
+			}
+		})
+	}
+}
+
+// writerFunc adapts a function to io.Writer
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
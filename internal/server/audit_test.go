@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestAuditLog(t *testing.T) {
+	fixed := time.Date(2024, time.January, 15, 10, 30, 45, 0, time.UTC)
+	body := `{"role": "admin"}`
+	bodySum := sha256.Sum256([]byte(body))
+	secret := []byte("secret")
+	token, err := SignJWT(Claims{ClaimSubject: "alice"}, "HS256", secret)
+	if err != nil {
+		t.Fatalf("SignJWT failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		config     AuditLogConfig
+		method     pkghttp.Method
+		auth       string
+		middleware pkghttp.MiddlewareFunc
+		expected   *AuditEntry
+	}{
+		{
+			name:     "basic user",
+			method:   pkghttp.MethodPost,
+			auth:     "Basic Ym9iOnB3",
+			expected: &AuditEntry{Principal: "bob", Method: "POST", Status: 200, BodySHA256: hex.EncodeToString(bodySum[:])},
+		},
+		{
+			name:       "JWT subject set by inner middleware",
+			method:     pkghttp.MethodPut,
+			auth:       "Bearer " + token,
+			middleware: BearerAuth(JWTConfig{Secret: secret}),
+			expected:   &AuditEntry{Principal: "alice", Method: "PUT", Status: 200, BodySHA256: hex.EncodeToString(bodySum[:])},
+		},
+		{
+			name:       "rejected request",
+			method:     pkghttp.MethodDelete,
+			middleware: BearerAuth(JWTConfig{Secret: secret}),
+			expected:   &AuditEntry{Method: "DELETE", Status: 401, BodySHA256: hex.EncodeToString(bodySum[:])},
+		},
+		{
+			name:     "custom principal",
+			config:   AuditLogConfig{Principal: func(pkghttp.Request) string { return "service" }},
+			method:   pkghttp.MethodPatch,
+			expected: &AuditEntry{Principal: "service", Method: "PATCH", Status: 200, BodySHA256: hex.EncodeToString(bodySum[:])},
+		},
+		{name: "reads are not audited", method: pkghttp.MethodGet},
+		{
+			name:     "configured methods",
+			config:   AuditLogConfig{Methods: []pkghttp.Method{pkghttp.MethodGet}},
+			method:   pkghttp.MethodGet,
+			expected: &AuditEntry{Method: "GET", Status: 200, BodySHA256: hex.EncodeToString(bodySum[:])},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			config := tt.config
+			config.Output = &buf
+			config.Now = func() time.Time { return fixed }
+
+			var received string
+			handler := func(req pkghttp.Request) pkghttp.Response {
+				data, _ := io.ReadAll(req.Body())
+				received = string(data)
+				return okHandler(req)
+			}
+			if tt.middleware != nil {
+				handler = tt.middleware(handler)
+			}
+
+			req := pkghttp.NewRequestWithBody(tt.method, "/users/7", pkghttp.Version11, strings.NewReader(body))
+			req.(*pkghttp.HTTPRequest).SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5555})
+			req.SetHeader(pkghttp.HeaderXRequestID, "req-1")
+			if tt.auth != "" {
+				req.SetHeader(pkghttp.HeaderAuthorization, tt.auth)
+			}
+			AuditLog(config)(handler)(req)
+
+			if tt.expected == nil {
+				if buf.Len() != 0 {
+					t.Errorf("Expected no audit entry, got %s", buf.String())
+				}
+				return
+			}
+
+			var entry AuditEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Invalid audit entry %q: %v", buf.String(), err)
+			}
+			expected := *tt.expected
+			expected.Time, expected.RemoteAddr, expected.RequestID, expected.Path = fixed, "192.0.2.1", "req-1", "/users/7"
+			if entry != expected {
+				t.Errorf("Unexpected entry:\nExpected: %+v\nGot:      %+v", expected, entry)
+			}
+			if tt.expected.Status == 200 && received != body {
+				t.Errorf("Expected the handler to read the body, got %q", received)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// BasicAuthValidator reports whether the supplied credentials are valid
+type BasicAuthValidator func(username, password string) bool
+
+// BasicAuth returns middleware that requires HTTP Basic authentication
+func BasicAuth(realm string, validate BasicAuthValidator) pkghttp.MiddlewareFunc {
+	if realm == "" {
+		realm = DefaultAuthRealm
+	}
+	challenge := fmt.Sprintf("%s realm=%q", AuthSchemeBasic, realm)
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			username, password, ok := ParseBasicAuth(req.GetHeader(pkghttp.HeaderAuthorization))
+			if !ok || validate == nil || !validate(username, password) {
+				return unauthorizedResponse(challenge)
+			}
+			return next(req)
+		}
+	}
+}
+
+// ParseBasicAuth extracts the username and password from a Basic Authorization header value
+func ParseBasicAuth(header string) (string, string, bool) {
+	credentials, ok := cutAuthScheme(header, AuthSchemeBasic)
+	if !ok {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return "", "", false
+	}
+
+	username, password, found := strings.Cut(string(decoded), basicCredentialSeparator)
+	if !found {
+		return "", "", false
+	}
+
+	return username, password, true
+}
+
+// cutAuthScheme returns the credentials following the given scheme, which is matched case-insensitively
+func cutAuthScheme(header, scheme string) (string, bool) {
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return "", false
+	}
+
+	if header[len(scheme)] != ' ' {
+		return "", false
+	}
+
+	credentials := strings.TrimSpace(header[len(scheme)+1:])
+	if credentials == "" {
+		return "", false
+	}
+
+	return credentials, true
+}
+
+// unauthorizedResponse builds a 401 response carrying the given challenge
+func unauthorizedResponse(challenge string) pkghttp.Response {
+	resp := internalhttp.BuildErrorResponse(pkghttp.StatusUnauthorized, "")
+	resp.SetHeader(pkghttp.HeaderWWWAuthenticate, challenge)
+	return resp
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/base64"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func okHandler(req pkghttp.Request) pkghttp.Response {
+	return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "ok")
+}
+
+func basicHeader(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+func TestParseBasicAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		username string
+		password string
+		ok       bool
+	}{
+		{name: "valid credentials", header: basicHeader("alice", "secret"), username: "alice", password: "secret", ok: true},
+		{name: "password with colon", header: basicHeader("bob", "a:b"), username: "bob", password: "a:b", ok: true},
+		{name: "lowercase scheme", header: "basic " + base64.StdEncoding.EncodeToString([]byte("u:p")), username: "u", password: "p", ok: true},
+		{name: "empty header", header: "", ok: false},
+		{name: "wrong scheme", header: "Bearer token", ok: false},
+		{name: "invalid base64", header: "Basic !!!", ok: false},
+		{name: "missing separator", header: "Basic " + base64.StdEncoding.EncodeToString([]byte("nocolon")), ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, password, ok := ParseBasicAuth(tt.header)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%t, got %t", tt.ok, ok)
+			}
+			if username != tt.username || password != tt.password {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.username, tt.password, username, password)
+			}
+		})
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	validate := func(username, password string) bool {
+		return username == "alice" && password == "secret"
+	}
+	handler := BasicAuth("admin", validate)(okHandler)
+
+	t.Run("valid credentials pass through", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+		req.SetHeader(pkghttp.HeaderAuthorization, basicHeader("alice", "secret"))
+
+		resp := handler(req)
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode())
+		}
+	})
+
+	t.Run("missing credentials are challenged", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+
+		resp := handler(req)
+		if resp.StatusCode() != pkghttp.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode())
+		}
+		if got := resp.GetHeader(pkghttp.HeaderWWWAuthenticate); got != `Basic realm="admin"` {
+			t.Errorf("Unexpected challenge: %s", got)
+		}
+	})
+
+	t.Run("invalid credentials are challenged", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+		req.SetHeader(pkghttp.HeaderAuthorization, basicHeader("alice", "wrong"))
+
+		resp := handler(req)
+		if resp.StatusCode() != pkghttp.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode())
+		}
+	})
+
+	t.Run("default realm", func(t *testing.T) {
+		resp := BasicAuth("", validate)(okHandler)(pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11))
+		if got := resp.GetHeader(pkghttp.HeaderWWWAuthenticate); got != `Basic realm="TinyServer"` {
+			t.Errorf("Unexpected challenge: %s", got)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// BindError describes why a request body could not be bound and the status to answer with
+type BindError struct {
+	Status  pkghttp.StatusCode
+	Message string
+	Cause   error
+}
+
+// Error implements the error interface
+func (e *BindError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+// Unwrap implements the errors.Unwrap interface
+func (e *BindError) Unwrap() error {
+	return e.Cause
+}
+
+// Response renders the error as a JSON error response
+func (e *BindError) Response() pkghttp.Response {
+	return internalhttp.BuildJSONErrorResponse(e.Status, e.Error())
+}
+
+// BindErrorResponse converts an error from a Bind helper or a Validator into
+// a response; ValidationErrors become 422 and other errors that are not a
+// *BindError become 400 Bad Request
+func BindErrorResponse(err error) pkghttp.Response {
+	if bindErr, ok := err.(*BindError); ok {
+		return bindErr.Response()
+	}
+	if validationErrs, ok := err.(ValidationErrors); ok {
+		return validationErrs.Response()
+	}
+	return internalhttp.BuildJSONErrorResponse(pkghttp.StatusBadRequest, err.Error())
+}
+
+// BindJSON decodes a JSON request body into v. The body must be declared as JSON
+// and may not exceed MaxRequestBodySize; failures are returned as *BindError.
+func BindJSON(req pkghttp.Request, v interface{}) error {
+	if !isJSONMediaType(req.GetHeader(pkghttp.HeaderContentType)) {
+		return &BindError{Status: pkghttp.StatusUnsupportedMediaType, Message: ErrUnsupportedContentType}
+	}
+
+	return decodeBindBody(req, pkghttp.MimeTypeJSON, v, ErrInvalidJSON)
+}
+
+// Bind decodes the request body into v with the codec registered for its
+// Content-Type, such as JSON, XML or a registered MessagePack codec. Failures
+// are returned as *BindError; a type without a codec gives 415.
+func Bind(req pkghttp.Request, v interface{}) error {
+	contentType := req.GetHeader(pkghttp.HeaderContentType)
+	if _, ok := internalhttp.LookupBodyCodec(contentType); !ok {
+		return &BindError{Status: pkghttp.StatusUnsupportedMediaType, Message: ErrUnsupportedContentType}
+	}
+
+	return decodeBindBody(req, contentType, v, ErrInvalidBody)
+}
+
+// decodeBindBody reads the body and decodes it with the codec for contentType,
+// reporting decode failures with invalidMessage
+func decodeBindBody(req pkghttp.Request, contentType string, v interface{}, invalidMessage string) error {
+	data, err := readBindBody(req)
+	if err != nil {
+		return err
+	}
+
+	if err := internalhttp.DecodeBody(contentType, data, v); err != nil {
+		return &BindError{Status: pkghttp.StatusBadRequest, Message: invalidMessage, Cause: err}
+	}
+
+	return nil
+}
+
+// WriteJSON builds a JSON response from v; values that cannot be encoded yield 500
+func WriteJSON(status pkghttp.StatusCode, v interface{}) pkghttp.Response {
+	data, err := internalhttp.EncodeBody(pkghttp.MimeTypeJSON, v)
+	if err != nil {
+		return internalhttp.BuildJSONErrorResponse(pkghttp.StatusInternalServerError, "")
+	}
+
+	return pkghttp.NewJSONResponse(status, pkghttp.Version11, string(data))
+}
+
+// readBindBody reads the whole request body, enforcing MaxRequestBodySize
+func readBindBody(req pkghttp.Request) ([]byte, error) {
+	if req.Body() == nil {
+		return nil, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrEmptyBody}
+	}
+
+	data, err := readLimitedBody(req, ErrInvalidJSON)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrEmptyBody}
+	}
+
+	return data, nil
+}
+
+// readLimitedBody reads the request body, if any, up to MaxRequestBodySize.
+// Read failures are reported with readErrMessage.
+func readLimitedBody(req pkghttp.Request, readErrMessage string) ([]byte, error) {
+	if req.Body() == nil {
+		return nil, nil
+	}
+
+	if req.ContentLength() > pkghttp.MaxRequestBodySize {
+		return nil, &BindError{Status: pkghttp.StatusRequestEntityTooLarge, Message: ErrBodyTooLarge}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body(), pkghttp.MaxRequestBodySize+1))
+	if err != nil {
+		// A body limit in front of the handler has already chosen the response
+		var bindErr *BindError
+		if errors.As(err, &bindErr) {
+			return nil, bindErr
+		}
+		// A chunked body can only overrun the server limit while being read
+		if badRequestReason(err) == internalhttp.ErrRequestBodyTooLarge {
+			return nil, &BindError{Status: pkghttp.StatusRequestEntityTooLarge, Message: ErrBodyTooLarge}
+		}
+		return nil, &BindError{Status: pkghttp.StatusBadRequest, Message: readErrMessage, Cause: err}
+	}
+	if int64(len(data)) > pkghttp.MaxRequestBodySize {
+		return nil, &BindError{Status: pkghttp.StatusRequestEntityTooLarge, Message: ErrBodyTooLarge}
+	}
+
+	return data, nil
+}
+
+// isJSONMediaType reports whether a Content-Type value declares JSON
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == pkghttp.MimeTypeJSON || strings.HasSuffix(mediaType, jsonMediaTypeSuffix)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+type bindTarget struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// newJSONRequest builds a POST request carrying body with the given Content-Type
+func newJSONRequest(contentType, body string) pkghttp.Request {
+	req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, "/", pkghttp.Version11, strings.NewReader(body))
+	if contentType != "" {
+		req.SetHeader(pkghttp.HeaderContentType, contentType)
+	}
+	req.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(len(body)))
+	return req
+}
+
+func TestBindJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      pkghttp.StatusCode
+	}{
+		{name: "valid body", contentType: pkghttp.MimeTypeJSON, body: `{"name":"gopher","age":3}`},
+		{name: "charset parameter", contentType: "application/json; charset=utf-8", body: `{"name":"gopher"}`},
+		{name: "structured suffix", contentType: "application/problem+json", body: `{"name":"gopher"}`},
+		{name: "wrong content type", contentType: pkghttp.MimeTypeTextPlain, body: `{}`, status: pkghttp.StatusUnsupportedMediaType},
+		{name: "missing content type", body: `{}`, status: pkghttp.StatusUnsupportedMediaType},
+		{name: "empty body", contentType: pkghttp.MimeTypeJSON, body: "", status: pkghttp.StatusBadRequest},
+		{name: "syntax error", contentType: pkghttp.MimeTypeJSON, body: `{"name":`, status: pkghttp.StatusBadRequest},
+		{name: "type mismatch", contentType: pkghttp.MimeTypeJSON, body: `{"age":"old"}`, status: pkghttp.StatusBadRequest},
+		{name: "trailing data", contentType: pkghttp.MimeTypeJSON, body: `{} {}`, status: pkghttp.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target bindTarget
+			err := BindJSON(newJSONRequest(tt.contentType, tt.body), &target)
+
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("BindJSON failed: %v", err)
+				}
+				if target.Name != "gopher" {
+					t.Errorf("Expected decoded name, got %+v", target)
+				}
+				return
+			}
+
+			var bindErr *BindError
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("Expected *BindError, got %v", err)
+			}
+			if bindErr.Status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, bindErr.Status)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      pkghttp.StatusCode
+	}{
+		{name: "json", contentType: pkghttp.MimeTypeJSON, body: `{"name":"gopher"}`},
+		{name: "xml", contentType: pkghttp.MimeTypeXML, body: `<bindTarget><Name>gopher</Name></bindTarget>`},
+		{name: "malformed xml", contentType: pkghttp.MimeTypeXML, body: `<bindTarget>`, status: pkghttp.StatusBadRequest},
+		{name: "no codec", contentType: pkghttp.MimeTypeTextPlain, body: "gopher", status: pkghttp.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target bindTarget
+			err := Bind(newJSONRequest(tt.contentType, tt.body), &target)
+
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("Bind failed: %v", err)
+				}
+				if target.Name != "gopher" {
+					t.Errorf("Expected decoded name, got %+v", target)
+				}
+				return
+			}
+
+			if resp := BindErrorResponse(err); resp.StatusCode() != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode())
+			}
+		})
+	}
+}
+
+func TestBindJSONBodyTooLarge(t *testing.T) {
+	req := newJSONRequest(pkghttp.MimeTypeJSON, `{}`)
+	req.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(pkghttp.MaxRequestBodySize+1))
+
+	err := BindJSON(req, &bindTarget{})
+	if resp := BindErrorResponse(err); resp.StatusCode() != pkghttp.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", resp.StatusCode())
+	}
+}
+
+func TestBindErrorResponse(t *testing.T) {
+	err := BindJSON(newJSONRequest(pkghttp.MimeTypeJSON, `{"unterminated": "`), &bindTarget{})
+	resp := BindErrorResponse(err)
+
+	if resp.StatusCode() != pkghttp.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", resp.StatusCode())
+	}
+
+	body, _ := io.ReadAll(resp.Body())
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Errorf("Error response should be valid JSON: %v\n%s", err, body)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	resp := WriteJSON(pkghttp.StatusCreated, bindTarget{Name: "gopher", Age: 3})
+	if resp.StatusCode() != pkghttp.StatusCreated {
+		t.Errorf("Expected 201, got %d", resp.StatusCode())
+	}
+	if resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeJSON {
+		t.Errorf("Unexpected Content-Type %q", resp.GetHeader(pkghttp.HeaderContentType))
+	}
+
+	body, _ := io.ReadAll(resp.Body())
+	if string(body) != `{"name":"gopher","age":3}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+	if resp.ContentLength() != int64(len(body)) {
+		t.Errorf("Content-Length %d does not match body length %d", resp.ContentLength(), len(body))
+	}
+
+	if resp := WriteJSON(pkghttp.StatusOK, make(chan int)); resp.StatusCode() != pkghttp.StatusInternalServerError {
+		t.Errorf("Expected 500 for unencodable value, got %d", resp.StatusCode())
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"io"
+	"sync/atomic"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// MaxBodySize returns middleware limiting request bodies to limit bytes, for
+// routes that accept less than the parser's MaxRequestBodySize. A declared
+// Content-Length over the limit gets 413 before the handler runs. A body that
+// turns out longer fails the handler's read with a 413 BindError, and the
+// response is then 413 whatever the handler returned.
+func MaxBodySize(limit int64) pkghttp.MiddlewareFunc {
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			if req.Body() == nil {
+				return next(req)
+			}
+			if req.ContentLength() > limit {
+				return internalhttp.BuildErrorResponse(pkghttp.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+			}
+
+			body := &limitedBody{body: req.Body(), remaining: limit}
+			req.SetBody(body)
+
+			resp := next(req)
+			if !body.exceeded.Load() {
+				return resp
+			}
+
+			if resp != nil && resp.Body() != nil {
+				resp.Body().Close()
+			}
+			return internalhttp.BuildErrorResponse(pkghttp.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+		}
+	}
+}
+
+// limitedBody passes through at most remaining bytes of a body and fails the
+// read that finds more
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	exceeded  atomic.Bool
+}
+
+// Read reads from the body within the limit
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if b.remaining <= 0 {
+		// Reading one byte more tells a body that ends at the limit from a longer one
+		var probe [1]byte
+		n, err := b.body.Read(probe[:])
+		if n > 0 {
+			b.exceeded.Store(true)
+			return 0, &BindError{Status: pkghttp.StatusRequestEntityTooLarge, Message: ErrBodyTooLarge}
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// Close closes the underlying body
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
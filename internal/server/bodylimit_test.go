@@ -0,0 +1,80 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	// echoHandler returns the body it read, or the read error as a bind failure
+	echoHandler := func(req pkghttp.Request) pkghttp.Response {
+		data, err := io.ReadAll(req.Body())
+		if err != nil {
+			return BindErrorResponse(err)
+		}
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, string(data))
+	}
+	// ignoringHandler reads the body and answers 200 whatever happened
+	ignoringHandler := func(req pkghttp.Request) pkghttp.Response {
+		io.ReadAll(req.Body())
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "ok")
+	}
+
+	tests := []struct {
+		name           string
+		handler        pkghttp.RequestHandler
+		body           string
+		declareLength  bool
+		expectedStatus pkghttp.StatusCode
+		expectedBody   string
+	}{
+		{name: "under the limit", handler: echoHandler, body: "short", declareLength: true, expectedStatus: pkghttp.StatusOK, expectedBody: "short"},
+		{name: "exactly the limit", handler: echoHandler, body: "0123456789", expectedStatus: pkghttp.StatusOK, expectedBody: "0123456789"},
+		{name: "declared length over", handler: echoHandler, body: "0123456789A", declareLength: true, expectedStatus: pkghttp.StatusRequestEntityTooLarge},
+		{name: "undeclared length over", handler: echoHandler, body: "0123456789A", expectedStatus: pkghttp.StatusRequestEntityTooLarge},
+		{name: "handler ignores the error", handler: ignoringHandler, body: strings.Repeat("x", 100), expectedStatus: pkghttp.StatusRequestEntityTooLarge},
+		{name: "bind sees 413", handler: func(req pkghttp.Request) pkghttp.Response {
+			var v map[string]string
+			if err := Bind(req, &v); err != nil {
+				return BindErrorResponse(err)
+			}
+			return okHandler(req)
+		}, body: `{"name": "a much too long value"}`, expectedStatus: pkghttp.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newJSONRequest(pkghttp.MimeTypeJSON, tt.body)
+			if !tt.declareLength {
+				req.Headers().Del(pkghttp.HeaderContentLength)
+			}
+
+			resp := MaxBodySize(10)(tt.handler)(req)
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if tt.expectedBody != "" {
+				if body := readResponseBody(t, resp); body != tt.expectedBody {
+					t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeChunked(t *testing.T) {
+	server := startTestServer(t, MaxBodySize(8)(func(req pkghttp.Request) pkghttp.Response {
+		io.ReadAll(req.Body())
+		return okHandler(req)
+	}))
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "POST / HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n"+
+		"6\r\nabcdef\r\n6\r\nghijkl\r\n0\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", resp.StatusCode())
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// CachePolicy describes how caches may store a response (RFC 9111)
+type CachePolicy struct {
+	// NoStore forbids storing the response anywhere; it overrides the other fields
+	NoStore bool
+
+	// NoCache lets caches store the response but requires revalidation before reuse
+	NoCache bool
+
+	// Public lets shared caches store responses to authenticated requests,
+	// while Private limits storage to the client's own cache
+	Public  bool
+	Private bool
+
+	// MaxAge is how long the response stays fresh; zero omits max-age.
+	// It is sent in whole seconds.
+	MaxAge time.Duration
+
+	// StaleWhileRevalidate lets caches keep serving the response this long
+	// after it goes stale while they refresh it in the background (RFC 5861)
+	StaleWhileRevalidate time.Duration
+
+	// Immutable tells clients the content never changes while fresh, so
+	// reloads need not revalidate it
+	Immutable bool
+}
+
+// NoStorePolicy forbids caching, for responses carrying private or volatile data
+func NoStorePolicy() CachePolicy {
+	return CachePolicy{NoStore: true}
+}
+
+// MaxAgePolicy lets any cache reuse a response for maxAge
+func MaxAgePolicy(maxAge time.Duration) CachePolicy {
+	return CachePolicy{Public: true, MaxAge: maxAge}
+}
+
+// ImmutablePolicy lets any cache reuse a response for maxAge without ever
+// revalidating it, for assets whose URL changes with their content
+func ImmutablePolicy(maxAge time.Duration) CachePolicy {
+	return CachePolicy{Public: true, MaxAge: maxAge, Immutable: true}
+}
+
+// String returns the Cache-Control header value
+func (p CachePolicy) String() string {
+	if p.NoStore {
+		return cacheDirectiveNoStore
+	}
+
+	var directives []string
+	if p.NoCache {
+		directives = append(directives, cacheDirectiveNoCache)
+	}
+	if p.Private {
+		directives = append(directives, cacheDirectivePrivate)
+	} else if p.Public {
+		directives = append(directives, cacheDirectivePublic)
+	}
+	if p.MaxAge > 0 {
+		seconds := strconv.FormatInt(int64(p.MaxAge/time.Second), 10)
+		directives = append(directives, cacheDirectiveMaxAge+"="+seconds)
+	}
+	if p.StaleWhileRevalidate > 0 {
+		seconds := strconv.FormatInt(int64(p.StaleWhileRevalidate/time.Second), 10)
+		directives = append(directives, cacheDirectiveStaleWhileRevalidate+"="+seconds)
+	}
+	if p.Immutable && p.MaxAge > 0 {
+		directives = append(directives, cacheDirectiveImmutable)
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// SetCachePolicy sets Cache-Control on resp from policy, with the Expires and
+// Pragma headers HTTP/1.0 caches read instead. Responses that must not be
+// reused get an Expires date in the past and Pragma: no-cache; fresh ones
+// expire after MaxAge.
+func SetCachePolicy(resp pkghttp.Response, policy CachePolicy) {
+	if value := policy.String(); value != "" {
+		resp.SetHeader(pkghttp.HeaderCacheControl, value)
+	} else {
+		resp.Headers().Del(pkghttp.HeaderCacheControl)
+	}
+
+	resp.Headers().Del(pkghttp.HeaderPragma)
+	switch {
+	case policy.NoStore || policy.NoCache:
+		resp.SetHeader(pkghttp.HeaderPragma, cacheDirectiveNoCache)
+		resp.SetHeader(pkghttp.HeaderExpires, common.FormatHTTPTime(time.Unix(0, 0)))
+	case policy.MaxAge > 0:
+		resp.SetHeader(pkghttp.HeaderExpires, common.FormatHTTPTime(time.Now().Add(policy.MaxAge)))
+	default:
+		resp.Headers().Del(pkghttp.HeaderExpires)
+	}
+}
+
+// SetNoStore marks resp as never to be cached
+func SetNoStore(resp pkghttp.Response) {
+	SetCachePolicy(resp, NoStorePolicy())
+}
+
+// SetMaxAge lets any cache reuse resp for maxAge
+func SetMaxAge(resp pkghttp.Response, maxAge time.Duration) {
+	SetCachePolicy(resp, MaxAgePolicy(maxAge))
+}
+
+// SetImmutable lets any cache reuse resp for maxAge without revalidating it
+func SetImmutable(resp pkghttp.Response, maxAge time.Duration) {
+	SetCachePolicy(resp, ImmutablePolicy(maxAge))
+}
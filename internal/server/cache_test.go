@@ -0,0 +1,113 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestCachePolicy_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   CachePolicy
+		expected string
+	}{
+		{name: "empty", policy: CachePolicy{}, expected: ""},
+		{name: "no-store", policy: NoStorePolicy(), expected: "no-store"},
+		{name: "no-store overrides", policy: CachePolicy{NoStore: true, MaxAge: time.Hour, Public: true}, expected: "no-store"},
+		{name: "max-age", policy: MaxAgePolicy(time.Hour), expected: "public, max-age=3600"},
+		{name: "immutable", policy: ImmutablePolicy(365 * 24 * time.Hour), expected: "public, max-age=31536000, immutable"},
+		{name: "immutable needs max-age", policy: CachePolicy{Immutable: true}, expected: ""},
+		{name: "private wins over public", policy: CachePolicy{Public: true, Private: true, MaxAge: time.Minute}, expected: "private, max-age=60"},
+		{name: "revalidate", policy: CachePolicy{NoCache: true, Private: true}, expected: "no-cache, private"},
+		{name: "stale-while-revalidate", policy: CachePolicy{MaxAge: time.Minute, StaleWhileRevalidate: time.Hour}, expected: "max-age=60, stale-while-revalidate=3600"},
+		{name: "sub-second max-age truncated", policy: CachePolicy{MaxAge: 1500 * time.Millisecond}, expected: "max-age=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetCachePolicy(t *testing.T) {
+	epoch := common.FormatHTTPTime(time.Unix(0, 0))
+
+	tests := []struct {
+		name            string
+		set             func(pkghttp.Response)
+		expectedControl string
+		expectedPragma  string
+		expectedExpires string
+		expiresIn       time.Duration
+	}{
+		{
+			name:            "no-store",
+			set:             SetNoStore,
+			expectedControl: "no-store",
+			expectedPragma:  "no-cache",
+			expectedExpires: epoch,
+		},
+		{
+			name:            "no-cache",
+			set:             func(resp pkghttp.Response) { SetCachePolicy(resp, CachePolicy{NoCache: true}) },
+			expectedControl: "no-cache",
+			expectedPragma:  "no-cache",
+			expectedExpires: epoch,
+		},
+		{
+			name:            "max-age",
+			set:             func(resp pkghttp.Response) { SetMaxAge(resp, time.Hour) },
+			expectedControl: "public, max-age=3600",
+			expiresIn:       time.Hour,
+		},
+		{
+			name:            "immutable",
+			set:             func(resp pkghttp.Response) { SetImmutable(resp, 24*time.Hour) },
+			expectedControl: "public, max-age=86400, immutable",
+			expiresIn:       24 * time.Hour,
+		},
+		{
+			name: "empty clears earlier headers",
+			set: func(resp pkghttp.Response) {
+				SetNoStore(resp)
+				SetCachePolicy(resp, CachePolicy{})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := pkghttp.NewResponse(pkghttp.StatusOK, pkghttp.Version11)
+			tt.set(resp)
+
+			if got := resp.GetHeader(pkghttp.HeaderCacheControl); got != tt.expectedControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.expectedControl, got)
+			}
+			if got := resp.GetHeader(pkghttp.HeaderPragma); got != tt.expectedPragma {
+				t.Errorf("Expected Pragma %q, got %q", tt.expectedPragma, got)
+			}
+
+			expires := resp.GetHeader(pkghttp.HeaderExpires)
+			if tt.expiresIn == 0 {
+				if expires != tt.expectedExpires {
+					t.Errorf("Expected Expires %q, got %q", tt.expectedExpires, expires)
+				}
+				return
+			}
+
+			date, err := common.ParseHTTPDate(expires)
+			if err != nil {
+				t.Fatalf("Invalid Expires %q: %v", expires, err)
+			}
+			if until := time.Until(date); until < tt.expiresIn-time.Minute || until > tt.expiresIn {
+				t.Errorf("Expected Expires about %v ahead, got %v", tt.expiresIn, until)
+			}
+		})
+	}
+}
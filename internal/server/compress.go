@@ -0,0 +1,143 @@
+package server
+
+import (
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// CompressConfig configures the compression middleware
+type CompressConfig struct {
+	// Encodings lists the codings offered, most preferred first. It defaults
+	// to every registered coding: gzip and deflate are built in, while br is
+	// offered only after a brotli coding is registered with
+	// internalhttp.RegisterContentCoding. Listing an unregistered coding here
+	// does not enable it.
+	Encodings []string
+
+	// MinSize skips bodies whose Content-Length is below it; defaults to
+	// DefaultCompressMinSize. Bodies of unknown length are always compressed.
+	MinSize int64
+}
+
+// Compress returns middleware that compresses text-like responses with the
+// coding the client's Accept-Encoding rates highest
+func Compress(config CompressConfig) pkghttp.MiddlewareFunc {
+	minSize := config.MinSize
+	if minSize <= 0 {
+		minSize = DefaultCompressMinSize
+	}
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			resp := next(req)
+			if resp == nil || !shouldCompress(req, resp, minSize) {
+				return resp
+			}
+
+			// The body now depends on Accept-Encoding, whichever coding is chosen
+			resp.AddHeader(pkghttp.HeaderVary, pkghttp.HeaderAcceptEncoding)
+
+			encodings := config.Encodings
+			if encodings == nil {
+				encodings = internalhttp.ContentCodings()
+			}
+			name := internalhttp.NegotiateContentCoding(req.GetHeader(pkghttp.HeaderAcceptEncoding), encodings)
+			coding, ok := internalhttp.LookupContentCoding(name)
+			if !ok {
+				return resp
+			}
+
+			resp.SetHeader(pkghttp.HeaderContentEncoding, name)
+			resp.Headers().Del(pkghttp.HeaderContentLength)
+			// The compressed bytes differ, so a strong validator no longer holds
+			if etag := resp.GetHeader(pkghttp.HeaderETag); etag != "" && !strings.HasPrefix(etag, weakETagPrefix) {
+				resp.SetHeader(pkghttp.HeaderETag, weakETagPrefix+etag)
+			}
+			resp.SetBody(newCompressedBody(resp.Body(), coding))
+
+			return resp
+		}
+	}
+}
+
+// shouldCompress reports whether resp is a candidate for compression
+func shouldCompress(req pkghttp.Request, resp pkghttp.Response, minSize int64) bool {
+	if req.Method() == pkghttp.MethodHead || resp.Body() == nil ||
+		!internalhttp.BodyAllowedForStatus(resp.StatusCode()) ||
+		resp.StatusCode() == pkghttp.StatusPartialContent ||
+		resp.HasHeader(pkghttp.HeaderContentEncoding) {
+		return false
+	}
+
+	if hasConnectionToken(resp.GetHeader(pkghttp.HeaderCacheControl), cacheDirectiveNoTransform) {
+		return false
+	}
+
+	if value := resp.GetHeader(pkghttp.HeaderContentLength); value != "" {
+		if length, err := strconv.ParseInt(value, 10, 64); err == nil && length < minSize {
+			return false
+		}
+	}
+
+	return isCompressible(resp.GetHeader(pkghttp.HeaderContentType))
+}
+
+// isCompressible reports whether a Content-Type names a text-like media type
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(mediaType, jsonMediaTypeSuffix)
+}
+
+// compressedBody streams body through a coding as the server reads it
+type compressedBody struct {
+	reader *io.PipeReader
+}
+
+// newCompressedBody starts compressing body into a pipe. The source is closed
+// once it has been consumed or the reader is closed.
+func newCompressedBody(body io.ReadCloser, coding internalhttp.ContentCoding) *compressedBody {
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer body.Close()
+
+		writer, err := coding.NewWriter(pw)
+		if err != nil {
+			pw.CloseWithError(common.IOErrorWithCause("failed to start compression", err))
+			return
+		}
+
+		if _, err := io.Copy(writer, body); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
+
+	return &compressedBody{reader: pr}
+}
+
+// Read returns compressed bytes
+func (b *compressedBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+// Close stops compression, releasing the source body
+func (b *compressedBody) Close() error {
+	return b.reader.Close()
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestCompress(t *testing.T) {
+	text := strings.Repeat("compress me ", 200)
+
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		response         func() pkghttp.Response
+		expectedEncoding string
+	}{
+		{
+			name:             "gzip accepted",
+			acceptEncoding:   "gzip, deflate",
+			response:         func() pkghttp.Response { return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, text) },
+			expectedEncoding: common.EncodingGzip,
+		},
+		{
+			name:             "deflate preferred by q-value",
+			acceptEncoding:   "gzip;q=0.1, deflate",
+			response:         func() pkghttp.Response { return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, text) },
+			expectedEncoding: common.EncodingDeflate,
+		},
+		{
+			name:     "no Accept-Encoding",
+			response: func() pkghttp.Response { return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, text) },
+		},
+		{
+			name:           "small body",
+			acceptEncoding: "gzip",
+			response:       func() pkghttp.Response { return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "tiny") },
+		},
+		{
+			name:           "binary content",
+			acceptEncoding: "gzip",
+			response: func() pkghttp.Response {
+				resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, text)
+				resp.SetHeader(pkghttp.HeaderContentType, "image/png")
+				return resp
+			},
+		},
+		{
+			name:           "no-transform",
+			acceptEncoding: "gzip",
+			response: func() pkghttp.Response {
+				resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, text)
+				resp.SetHeader(pkghttp.HeaderCacheControl, "public, no-transform")
+				return resp
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+				return tt.response()
+			}, Compress(CompressConfig{}))
+			conn, reader := dialTestServer(t, server)
+
+			raw := "GET / HTTP/1.1\r\nHost: localhost\r\n"
+			if tt.acceptEncoding != "" {
+				raw += "Accept-Encoding: " + tt.acceptEncoding + "\r\n"
+			}
+			resp, body := roundTrip(t, conn, reader, raw+"\r\n")
+
+			if encoding := resp.GetHeader(pkghttp.HeaderContentEncoding); encoding != tt.expectedEncoding {
+				t.Fatalf("Expected Content-Encoding %q, got %q", tt.expectedEncoding, encoding)
+			}
+			if tt.expectedEncoding == "" {
+				return
+			}
+
+			if resp.GetHeader(pkghttp.HeaderVary) != pkghttp.HeaderAcceptEncoding {
+				t.Errorf("Expected Vary: Accept-Encoding, got %q", resp.GetHeader(pkghttp.HeaderVary))
+			}
+			coding, _ := internalhttp.LookupContentCoding(tt.expectedEncoding)
+			decoder, err := coding.NewReader(strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("NewReader failed: %v", err)
+			}
+			decoded, _ := io.ReadAll(decoder)
+			if string(decoded) != text {
+				t.Errorf("Decoded body does not match, got %d bytes", len(decoded))
+			}
+		})
+	}
+}
+
+func TestCompressWeakensETag(t *testing.T) {
+	handler := Compress(CompressConfig{MinSize: 1})(func(req pkghttp.Request) pkghttp.Response {
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "hello")
+		resp.SetHeader(pkghttp.HeaderETag, `"abc"`)
+		return resp
+	})
+
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderAcceptEncoding, common.EncodingGzip)
+	resp := handler(req)
+
+	if etag := resp.GetHeader(pkghttp.HeaderETag); etag != `W/"abc"` {
+		t.Errorf("Expected a weak ETag, got %q", etag)
+	}
+	if resp.HasHeader(pkghttp.HeaderContentLength) {
+		t.Error("Expected Content-Length to be removed")
+	}
+
+	reader, err := gzip.NewReader(resp.Body())
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != "hello" {
+		t.Errorf("Unexpected body %q", data)
+	}
+}
+
+func TestCompressRegisteredCoding(t *testing.T) {
+	// base64 stands in for a brotli implementation registered by the application
+	internalhttp.RegisterContentCoding(common.EncodingBrotli, internalhttp.ContentCoding{
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return base64.NewEncoder(base64.StdEncoding, w), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+		},
+	})
+
+	handler := Compress(CompressConfig{MinSize: 1})(func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "hello")
+	})
+
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderAcceptEncoding, "gzip, br")
+	resp := handler(req)
+
+	if encoding := resp.GetHeader(pkghttp.HeaderContentEncoding); encoding != common.EncodingBrotli {
+		t.Fatalf("Expected br, got %q", encoding)
+	}
+	var buf bytes.Buffer
+	io.Copy(&buf, resp.Body())
+	if buf.String() != base64.StdEncoding.EncodeToString([]byte("hello")) {
+		t.Errorf("Unexpected body %q", buf.String())
+	}
+}
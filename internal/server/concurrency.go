@@ -0,0 +1,75 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// ConcurrencyConfig configures concurrency limiting
+type ConcurrencyConfig struct {
+	// MaxInFlight caps requests handled at once; zero means DefaultConcurrencyLimit
+	MaxInFlight int
+
+	// MaxWait is how long a request may queue for a slot before it is
+	// rejected; zero means DefaultConcurrencyMaxWait
+	MaxWait time.Duration
+
+	// RetryAfter is advertised to rejected clients, rounded up to whole
+	// seconds; zero means DefaultConcurrencyRetryAfter
+	RetryAfter time.Duration
+}
+
+// LimitConcurrency returns middleware that lets at most MaxInFlight requests
+// reach the handler at once. Others wait up to MaxWait for a slot, then get
+// 503 Service Unavailable with Retry-After. Each call creates its own limit:
+// set it on the server for a global cap, or on a route or group to cap only
+// those handlers.
+func LimitConcurrency(config ConcurrencyConfig) pkghttp.MiddlewareFunc {
+	maxInFlight := config.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultConcurrencyLimit
+	}
+	maxWait := config.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultConcurrencyMaxWait
+	}
+	retryAfter := config.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = DefaultConcurrencyRetryAfter
+	}
+	retryAfterSeconds := strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10)
+	slots := make(chan struct{}, maxInFlight)
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			select {
+			case slots <- struct{}{}:
+			default:
+				// Queue only when every slot is taken
+				timer := time.NewTimer(maxWait)
+				select {
+				case slots <- struct{}{}:
+					timer.Stop()
+				case <-timer.C:
+					return overloadedResponse(retryAfterSeconds)
+				case <-req.Context().Done():
+					timer.Stop()
+					return overloadedResponse(retryAfterSeconds)
+				}
+			}
+			defer func() { <-slots }()
+
+			return next(req)
+		}
+	}
+}
+
+// overloadedResponse tells the client to come back after retryAfter seconds
+func overloadedResponse(retryAfter string) pkghttp.Response {
+	resp := internalhttp.BuildErrorResponse(pkghttp.StatusServiceUnavailable, ErrServerOverloaded)
+	resp.SetHeader(pkghttp.HeaderRetryAfter, retryAfter)
+	return resp
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestLimitConcurrency(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     ConcurrencyConfig
+		hold       time.Duration
+		expected   pkghttp.StatusCode
+		retryAfter string
+	}{
+		{name: "slot frees in time", config: ConcurrencyConfig{MaxInFlight: 1, MaxWait: time.Second}, hold: 20 * time.Millisecond, expected: pkghttp.StatusOK},
+		{name: "wait exceeded", config: ConcurrencyConfig{MaxInFlight: 1, MaxWait: 20 * time.Millisecond}, hold: 200 * time.Millisecond, expected: pkghttp.StatusServiceUnavailable, retryAfter: "1"},
+		{name: "retry after rounded up", config: ConcurrencyConfig{MaxInFlight: 1, MaxWait: 20 * time.Millisecond, RetryAfter: 2500 * time.Millisecond}, hold: 200 * time.Millisecond, expected: pkghttp.StatusServiceUnavailable, retryAfter: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entered := make(chan struct{}, 1)
+			handler := LimitConcurrency(tt.config)(func(req pkghttp.Request) pkghttp.Response {
+				if req.Path() == "/slow" {
+					entered <- struct{}{}
+					time.Sleep(tt.hold)
+				}
+				return okHandler(req)
+			})
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				handler(pkghttp.NewRequest(pkghttp.MethodGet, "/slow", pkghttp.Version11))
+			}()
+			<-entered
+
+			resp := handler(pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11))
+			wg.Wait()
+
+			if resp.StatusCode() != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode())
+			}
+			if got := resp.GetHeader(pkghttp.HeaderRetryAfter); got != tt.retryAfter {
+				t.Errorf("Expected Retry-After %q, got %q", tt.retryAfter, got)
+			}
+		})
+	}
+}
+
+func TestLimitConcurrencySeparateLimits(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	config := ConcurrencyConfig{MaxInFlight: 1, MaxWait: 20 * time.Millisecond}
+
+	slow := LimitConcurrency(config)(func(req pkghttp.Request) pkghttp.Response {
+		entered <- struct{}{}
+		<-release
+		return okHandler(req)
+	})
+	fast := LimitConcurrency(config)(okHandler)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		slow(pkghttp.NewRequest(pkghttp.MethodGet, "/slow", pkghttp.Version11))
+	}()
+	<-entered
+
+	// A busy route leaves routes with their own limit unaffected
+	if resp := fast(pkghttp.NewRequest(pkghttp.MethodGet, "/fast", pkghttp.Version11)); resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode())
+	}
+	if resp := slow(pkghttp.NewRequest(pkghttp.MethodGet, "/slow", pkghttp.Version11)); resp.StatusCode() != pkghttp.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", resp.StatusCode())
+	}
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// StrongETag returns a strong entity tag derived from the content bytes
+func StrongETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:etagHashBytes]) + `"`
+}
+
+// WeakETag returns a weak entity tag derived from the content bytes
+func WeakETag(data []byte) string {
+	return weakETagPrefix + StrongETag(data)
+}
+
+// FileETag returns an entity tag derived from file metadata, avoiding a read of the content
+func FileETag(size int64, modTime time.Time) string {
+	return fmt.Sprintf(`"%x-%x"`, modTime.UnixNano(), size)
+}
+
+// ETagMatches reports whether an If-Match/If-None-Match header value matches etag.
+// Weak comparison ignores the W/ prefix, as required for If-None-Match.
+func ETagMatches(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == etagWildcard {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, weakETagPrefix) == strings.TrimPrefix(etag, weakETagPrefix) {
+				return true
+			}
+			continue
+		}
+
+		if candidate == etag && !strings.HasPrefix(etag, weakETagPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckNotModified reports whether the request's validators show the client copy is current.
+// If-None-Match takes precedence over If-Modified-Since.
+func CheckNotModified(req pkghttp.Request, etag string, lastModified time.Time) bool {
+	if req.Method() != pkghttp.MethodGet && req.Method() != pkghttp.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := req.GetHeader(pkghttp.HeaderIfNoneMatch); ifNoneMatch != "" {
+		return ETagMatches(ifNoneMatch, etag, true)
+	}
+
+	ifModifiedSince := req.GetHeader(pkghttp.HeaderIfModifiedSince)
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := common.ParseHTTPDate(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	// HTTP dates have one-second resolution
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// Conditional returns middleware that turns successful GET/HEAD responses into
+// 304 Not Modified when the request validators match the response's ETag or Last-Modified
+func Conditional() pkghttp.MiddlewareFunc {
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			resp := next(req)
+			if resp == nil || resp.StatusCode() != pkghttp.StatusOK {
+				return resp
+			}
+
+			var lastModified time.Time
+			if value := resp.GetHeader(pkghttp.HeaderLastModified); value != "" {
+				lastModified, _ = common.ParseHTTPDate(value)
+			}
+
+			if !CheckNotModified(req, resp.GetHeader(pkghttp.HeaderETag), lastModified) {
+				return resp
+			}
+
+			return NotModifiedResponse(resp)
+		}
+	}
+}
+
+// NotModifiedResponse converts a full response into a 304 carrying only the
+// headers a cache needs to refresh its stored copy
+func NotModifiedResponse(resp pkghttp.Response) pkghttp.Response {
+	if resp.Body() != nil {
+		resp.Body().Close()
+	}
+
+	notModified := pkghttp.NewResponse(pkghttp.StatusNotModified, resp.Version())
+	for _, name := range notModifiedHeaders {
+		for _, value := range resp.GetHeaders(name) {
+			notModified.AddHeader(name, value)
+		}
+	}
+
+	return notModified
+}
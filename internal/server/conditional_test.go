@@ -0,0 +1,127 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestETagGeneration(t *testing.T) {
+	strong := StrongETag([]byte("hello"))
+	if !strings.HasPrefix(strong, `"`) || !strings.HasSuffix(strong, `"`) {
+		t.Errorf("Strong ETag should be quoted, got %s", strong)
+	}
+	if strong != StrongETag([]byte("hello")) {
+		t.Error("Strong ETag should be deterministic")
+	}
+	if strong == StrongETag([]byte("world")) {
+		t.Error("Different content should produce different ETags")
+	}
+
+	if weak := WeakETag([]byte("hello")); weak != "W/"+strong {
+		t.Errorf("Expected weak ETag W/%s, got %s", strong, weak)
+	}
+
+	modTime := time.Unix(1700000000, 0)
+	if FileETag(10, modTime) == FileETag(11, modTime) {
+		t.Error("File ETag should depend on size")
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		etag     string
+		weak     bool
+		expected bool
+	}{
+		{name: "exact match", header: `"abc"`, etag: `"abc"`, expected: true},
+		{name: "list match", header: `"x", "abc"`, etag: `"abc"`, expected: true},
+		{name: "wildcard", header: `*`, etag: `"abc"`, expected: true},
+		{name: "no match", header: `"x"`, etag: `"abc"`, expected: false},
+		{name: "weak comparison ignores prefix", header: `W/"abc"`, etag: `"abc"`, weak: true, expected: true},
+		{name: "strong comparison rejects weak", header: `W/"abc"`, etag: `W/"abc"`, expected: false},
+		{name: "empty etag", header: `*`, etag: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ETagMatches(tt.header, tt.etag, tt.weak); got != tt.expected {
+				t.Errorf("ETagMatches(%q, %q, %t) = %t, expected %t", tt.header, tt.etag, tt.weak, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConditionalMiddleware(t *testing.T) {
+	lastModified := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	etag := StrongETag([]byte("content"))
+
+	handler := Conditional()(func(req pkghttp.Request) pkghttp.Response {
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "content")
+		resp.SetHeader(pkghttp.HeaderETag, etag)
+		resp.SetHeader(pkghttp.HeaderLastModified, lastModified.Format(common.HTTPDateFormat))
+		resp.SetHeader(pkghttp.HeaderCacheControl, "max-age=60")
+		return resp
+	})
+
+	tests := []struct {
+		name     string
+		method   pkghttp.Method
+		headers  map[string]string
+		expected pkghttp.StatusCode
+	}{
+		{name: "no validators", method: pkghttp.MethodGet, expected: pkghttp.StatusOK},
+		{name: "matching etag", method: pkghttp.MethodGet, headers: map[string]string{pkghttp.HeaderIfNoneMatch: etag}, expected: pkghttp.StatusNotModified},
+		{name: "stale etag", method: pkghttp.MethodGet, headers: map[string]string{pkghttp.HeaderIfNoneMatch: `"old"`}, expected: pkghttp.StatusOK},
+		{
+			name:     "etag takes precedence over date",
+			method:   pkghttp.MethodGet,
+			headers:  map[string]string{pkghttp.HeaderIfNoneMatch: `"old"`, pkghttp.HeaderIfModifiedSince: lastModified.Format(common.HTTPDateFormat)},
+			expected: pkghttp.StatusOK,
+		},
+		{
+			name:     "not modified since",
+			method:   pkghttp.MethodHead,
+			headers:  map[string]string{pkghttp.HeaderIfModifiedSince: lastModified.Add(time.Hour).Format(common.HTTPDateFormat)},
+			expected: pkghttp.StatusNotModified,
+		},
+		{
+			name:     "modified since",
+			method:   pkghttp.MethodGet,
+			headers:  map[string]string{pkghttp.HeaderIfModifiedSince: lastModified.Add(-time.Hour).Format(common.HTTPDateFormat)},
+			expected: pkghttp.StatusOK,
+		},
+		{name: "unsafe method ignored", method: pkghttp.MethodPost, headers: map[string]string{pkghttp.HeaderIfNoneMatch: etag}, expected: pkghttp.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(tt.method, "/", pkghttp.Version11)
+			for name, value := range tt.headers {
+				req.SetHeader(name, value)
+			}
+
+			resp := handler(req)
+			if resp.StatusCode() != tt.expected {
+				t.Fatalf("Expected %d, got %d", tt.expected, resp.StatusCode())
+			}
+
+			if resp.StatusCode() == pkghttp.StatusNotModified {
+				if resp.Body() != nil {
+					t.Error("304 response must not carry a body")
+				}
+				if resp.GetHeader(pkghttp.HeaderETag) != etag || resp.GetHeader(pkghttp.HeaderCacheControl) != "max-age=60" {
+					t.Errorf("304 should keep cache validators, got %v", resp.Headers())
+				}
+				if resp.HasHeader(pkghttp.HeaderContentType) {
+					t.Error("304 should drop entity headers")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,581 @@
+package server
+
+import (
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// Authentication constants
+const (
+	// DefaultAuthRealm is the realm advertised when none is configured
+	DefaultAuthRealm = "TinyServer"
+
+	// AuthSchemeBasic is the HTTP Basic authentication scheme
+	AuthSchemeBasic = "Basic"
+
+	// basicCredentialSeparator separates username and password in Basic credentials
+	basicCredentialSeparator = ":"
+)
+
+// Bearer token constants
+const (
+	// AuthSchemeBearer is the HTTP Bearer authentication scheme
+	AuthSchemeBearer = "Bearer"
+
+	// JWTAlgorithmHS256 is HMAC with SHA-256
+	JWTAlgorithmHS256 = "HS256"
+
+	// JWTAlgorithmHS384 is HMAC with SHA-384
+	JWTAlgorithmHS384 = "HS384"
+
+	// JWTAlgorithmHS512 is HMAC with SHA-512
+	JWTAlgorithmHS512 = "HS512"
+
+	// jwtSegmentCount is the number of dot-separated segments in a compact JWT
+	jwtSegmentCount = 3
+
+	// jwtTokenType is the typ header value for JSON Web Tokens
+	jwtTokenType = "JWT"
+)
+
+// JWT claim names
+const (
+	// ClaimIssuer is the registered "iss" claim
+	ClaimIssuer = "iss"
+
+	// ClaimSubject is the registered "sub" claim
+	ClaimSubject = "sub"
+
+	// ClaimExpiresAt is the registered "exp" claim
+	ClaimExpiresAt = "exp"
+
+	// ClaimNotBefore is the registered "nbf" claim
+	ClaimNotBefore = "nbf"
+
+	// ClaimIssuedAt is the registered "iat" claim
+	ClaimIssuedAt = "iat"
+)
+
+// Authentication error messages
+const (
+	// ErrMissingBearerToken indicates the Authorization header carried no bearer token
+	ErrMissingBearerToken = "missing bearer token"
+	// ErrMalformedToken indicates the token is not a well-formed compact JWT
+	ErrMalformedToken = "malformed token"
+	// ErrUnsupportedAlgorithm indicates the token uses an algorithm we do not accept
+	ErrUnsupportedAlgorithm = "unsupported token algorithm"
+	// ErrInvalidSignature indicates the token signature did not verify
+	ErrInvalidSignature = "invalid token signature"
+	// ErrTokenExpired indicates the exp claim is in the past
+	ErrTokenExpired = "token expired"
+	// ErrTokenNotYetValid indicates the nbf claim is in the future
+	ErrTokenNotYetValid = "token not yet valid"
+	// ErrInvalidTimeClaim indicates an exp or nbf claim that is not a number
+	ErrInvalidTimeClaim = "invalid token time claim"
+	// ErrInvalidIssuer indicates the iss claim did not match
+	ErrInvalidIssuer = "invalid token issuer"
+)
+
+// contextKey namespaces values stored in request contexts by this package
+type contextKey int
+
+const (
+	// claimsContextKey stores verified JWT claims
+	claimsContextKey contextKey = iota
+
+	// interimContextKey stores the writer for 1xx responses
+	interimContextKey
+
+	// hijackContextKey stores the hijacker for the request's connection
+	hijackContextKey
+
+	// tlsContextKey stores the TLS state of the request's connection
+	tlsContextKey
+)
+
+// Server connection settings
+const (
+	// ServerSoftware is the value sent in the Server header
+	ServerSoftware = common.ApplicationName + "/" + common.ApplicationVersion
+
+	// connectionReaderSize is the buffer size used to read requests
+	connectionReaderSize = 4096
+
+	// connectionWriterSize is the buffer size used to write responses
+	connectionWriterSize = 4096
+
+	// bodyCopyBufferSize is the buffer size used to stream response bodies
+	bodyCopyBufferSize = 32 * 1024
+
+	// sendFileSegmentSize is how much of a file body is sent under one write deadline
+	sendFileSegmentSize = 4 << 20
+)
+
+// Request handling error messages
+const (
+	// ErrStreamClosed indicates a write to a streamed body the client no longer reads
+	ErrStreamClosed = "response stream closed"
+	// ErrInvalidStreamItem indicates a value sent on a JSON stream that cannot be encoded
+	ErrInvalidStreamItem = "stream item cannot be encoded as JSON"
+	// ErrUnsupportedExpectation indicates an Expect header other than 100-continue
+	ErrUnsupportedExpectation = "unsupported expectation"
+	// ErrDirectoryListing indicates a file request that named a directory
+	ErrDirectoryListing = "directory listing not allowed"
+)
+
+// HTTPS settings
+const (
+	// DefaultHSTSMaxAge is how long browsers keep an HSTS policy by default
+	DefaultHSTSMaxAge = 365 * 24 * time.Hour
+
+	// hstsDirectiveMaxAge sets the policy lifetime in seconds
+	hstsDirectiveMaxAge = "max-age"
+
+	// hstsDirectiveIncludeSubDomains applies the policy to subdomains
+	hstsDirectiveIncludeSubDomains = "includeSubDomains"
+
+	// hstsDirectivePreload consents to browser preload lists
+	hstsDirectivePreload = "preload"
+
+	// ErrInvalidRedirectHost indicates a request whose Host cannot form an HTTPS URL
+	ErrInvalidRedirectHost = "invalid host for HTTPS redirect"
+)
+
+// Request signature settings
+const (
+	// DefaultSignatureHeader carries the HMAC signature of a request
+	DefaultSignatureHeader = "X-Signature"
+
+	// DefaultSignatureTolerance is how far a signed request's Date may be from now
+	DefaultSignatureTolerance = 5 * time.Minute
+
+	// signatureScheme prefixes signature values with the algorithm used
+	signatureScheme = "sha256="
+
+	// ErrMissingRequestSignature indicates a request without the signature header
+	ErrMissingRequestSignature = "request signature required"
+	// ErrInvalidRequestSignature indicates a signature no configured secret produces
+	ErrInvalidRequestSignature = "invalid request signature"
+	// ErrRequestSignatureExpired indicates a Date missing or outside the tolerance
+	ErrRequestSignatureExpired = "request signature expired"
+)
+
+// Webhook settings
+const (
+	// DefaultWebhookMaxAttempts is how often a delivery is tried
+	DefaultWebhookMaxAttempts = 5
+
+	// DefaultWebhookInitialBackoff is the wait before the first retry
+	DefaultWebhookInitialBackoff = time.Second
+
+	// DefaultWebhookMaxBackoff caps the wait between retries
+	DefaultWebhookMaxBackoff = time.Minute
+
+	// DefaultWebhookTimeout bounds each delivery attempt
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// DefaultWebhookQueueSize is how many deliveries may wait to be sent
+	DefaultWebhookQueueSize = 1000
+
+	// DefaultWebhookWorkers is how many deliveries are sent at once
+	DefaultWebhookWorkers = 4
+
+	// webhookBackoffMultiplier grows the wait after each retry
+	webhookBackoffMultiplier = 2
+
+	// webhookIDBytes is the number of random bytes in an event ID
+	webhookIDBytes = 16
+
+	// headerWebhookID carries the event ID, the same on every retry
+	headerWebhookID = "X-Webhook-ID"
+
+	// headerWebhookEvent carries the event type
+	headerWebhookEvent = "X-Webhook-Event"
+
+	// ErrInvalidWebhookPayload indicates an event payload that cannot be encoded as JSON
+	ErrInvalidWebhookPayload = "invalid webhook payload"
+	// ErrInvalidWebhookURL indicates an endpoint URL that cannot be parsed
+	ErrInvalidWebhookURL = "invalid webhook URL"
+	// ErrWebhookQueueFull indicates an event published while the delivery queue is full
+	ErrWebhookQueueFull = "webhook queue full"
+	// ErrWebhookDispatcherClosed indicates an event published after Close
+	ErrWebhookDispatcherClosed = "webhook dispatcher closed"
+	// ErrWebhookRejected indicates a delivery the endpoint answered with a failure status
+	ErrWebhookRejected = "webhook rejected"
+)
+
+// Traffic mirroring settings
+const (
+	// DefaultMirrorTimeout bounds each mirrored exchange
+	DefaultMirrorTimeout = 10 * time.Second
+
+	// DefaultMirrorMaxInFlight caps mirrored requests still running
+	DefaultMirrorMaxInFlight = 100
+)
+
+// Concurrency limiting settings
+const (
+	// DefaultConcurrencyLimit caps requests handled at once
+	DefaultConcurrencyLimit = 100
+
+	// DefaultConcurrencyMaxWait is how long a request queues for a slot
+	DefaultConcurrencyMaxWait = time.Second
+
+	// DefaultConcurrencyRetryAfter is advertised to rejected clients
+	DefaultConcurrencyRetryAfter = time.Second
+
+	// ErrServerOverloaded is the message of requests rejected for lack of capacity
+	ErrServerOverloaded = "server overloaded"
+)
+
+// Byte range settings
+const (
+	// rangeUnitBytes is the only range unit the server supports
+	rangeUnitBytes = "bytes"
+
+	// maxByteRanges is the most ranges served from one request; longer
+	// Range headers are ignored and the full file is sent
+	maxByteRanges = 32
+)
+
+// Fault injection error messages
+const (
+	// ErrFaultInjected is the message of responses failed on purpose
+	ErrFaultInjected = "fault injected"
+)
+
+// Access log settings
+const (
+	// commonLogTimeFormat is the timestamp layout of the Common Log Format
+	commonLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+	// accessLogEmptyField is written for unknown CLF fields
+	accessLogEmptyField = "-"
+)
+
+// Entity tag settings
+const (
+	// etagHashBytes is how many bytes of the content hash appear in generated ETags
+	etagHashBytes = 16
+
+	// weakETagPrefix marks a weak entity tag
+	weakETagPrefix = "W/"
+
+	// etagWildcard matches any current representation
+	etagWildcard = "*"
+)
+
+// precompressedVariants are the codings FileServer looks for beside a file,
+// with the extension of each pre-compressed copy, most preferred first
+var precompressedVariants = []struct{ coding, extension string }{
+	{coding: common.EncodingBrotli, extension: ".br"},
+	{coding: common.EncodingGzip, extension: ".gz"},
+}
+
+// notModifiedHeaders are copied from the full response onto a 304 (RFC 7232 section 4.1)
+var notModifiedHeaders = []string{
+	pkghttp.HeaderCacheControl,
+	pkghttp.HeaderContentLocation,
+	pkghttp.HeaderDate,
+	pkghttp.HeaderETag,
+	pkghttp.HeaderExpires,
+	pkghttp.HeaderLastModified,
+	pkghttp.HeaderVary,
+}
+
+// hopByHopHeaders apply to a single connection and are never forwarded by a proxy
+var hopByHopHeaders = []string{
+	pkghttp.HeaderConnection,
+	pkghttp.HeaderKeepAlive,
+	pkghttp.HeaderProxyAuthenticate,
+	pkghttp.HeaderProxyAuthorization,
+	pkghttp.HeaderProxyConnection,
+	pkghttp.HeaderTE,
+	pkghttp.HeaderTrailer,
+	pkghttp.HeaderTransferEncoding,
+	pkghttp.HeaderUpgrade,
+}
+
+// standardMethods lists the methods the server understands, in Allow header order
+var standardMethods = []pkghttp.Method{
+	pkghttp.MethodGet,
+	pkghttp.MethodHead,
+	pkghttp.MethodPost,
+	pkghttp.MethodPut,
+	pkghttp.MethodPatch,
+	pkghttp.MethodDelete,
+	pkghttp.MethodOptions,
+}
+
+// Server-sent event fields
+const (
+	sseFieldID    = "id"
+	sseFieldEvent = "event"
+	sseFieldData  = "data"
+	sseFieldRetry = "retry"
+
+	// sseCommentPrefix starts a line clients ignore, used for keep-alives
+	sseCommentPrefix = ":"
+
+	// sseCacheControl stops intermediaries from buffering the stream
+	sseCacheControl = cacheDirectiveNoCache
+)
+
+// Health check settings
+const (
+	// LivenessPath is where HealthRegistry.Register serves liveness
+	LivenessPath = "/healthz"
+
+	// ReadinessPath is where HealthRegistry.Register serves readiness
+	ReadinessPath = "/readyz"
+
+	// DefaultHealthCheckTimeout bounds each health check
+	DefaultHealthCheckTimeout = 5 * time.Second
+
+	// HealthStatusOK reports that every check passed
+	HealthStatusOK = "ok"
+
+	// HealthStatusFail reports that at least one check failed
+	HealthStatusFail = "fail"
+
+	// HealthStatusDraining reports that the server is shutting down
+	HealthStatusDraining = "draining"
+
+	// healthProbePattern names the temporary files DirWritableCheck creates
+	healthProbePattern = ".healthcheck-*"
+
+	// ErrHealthCheckTimeout indicates a check that did not finish in time
+	ErrHealthCheckTimeout = "health check timed out"
+	// ErrHealthCheckPanicked indicates a check that panicked
+	ErrHealthCheckPanicked = "health check panicked"
+	// ErrHealthDialFailed indicates a dependency that refused connections
+	ErrHealthDialFailed = "dial failed"
+	// ErrHealthDirNotWritable indicates a directory files cannot be written to
+	ErrHealthDirNotWritable = "directory not writable"
+)
+
+// OpenAPI generation settings
+const (
+	// openAPIVersion is the OpenAPI specification version of generated documents
+	openAPIVersion = "3.0.3"
+
+	// openAPISchemaRefPrefix locates named schemas within a document
+	openAPISchemaRefPrefix = "#/components/schemas/"
+
+	// openAPIPathParameter is the location of route parameters
+	openAPIPathParameter = "path"
+
+	// openAPIDefaultResponse documents routes that list no responses
+	openAPIDefaultResponse = "default"
+
+	// openAPIDefaultDescription describes the default response
+	openAPIDefaultDescription = "Response"
+)
+
+// JSON-RPC 2.0 error codes (https://www.jsonrpc.org/specification#error_object)
+const (
+	// RPCParseError means the request was not valid JSON
+	RPCParseError = -32700
+	// RPCInvalidRequest means the JSON was not a valid request object
+	RPCInvalidRequest = -32600
+	// RPCMethodNotFound means no method is registered under the name
+	RPCMethodNotFound = -32601
+	// RPCInvalidParams means the params could not be decoded for the method
+	RPCInvalidParams = -32602
+	// RPCInternalError means the method failed
+	RPCInternalError = -32603
+)
+
+// JSON-RPC settings
+const (
+	// rpcVersion is the protocol version every request and response names
+	rpcVersion = "2.0"
+
+	// rpcNullID is the ID of responses to requests whose ID could not be read
+	rpcNullID = "null"
+
+	// ErrInvalidRPCMethod indicates a function that cannot be registered as a method
+	ErrInvalidRPCMethod = "invalid RPC method"
+	// ErrRPCParse is the message of RPCParseError
+	ErrRPCParse = "Parse error"
+	// ErrRPCInvalidRequest is the message of RPCInvalidRequest
+	ErrRPCInvalidRequest = "Invalid Request"
+	// ErrRPCMethodNotFound is the message of RPCMethodNotFound
+	ErrRPCMethodNotFound = "Method not found"
+	// ErrRPCInvalidParams is the message of RPCInvalidParams
+	ErrRPCInvalidParams = "Invalid params"
+	// ErrRPCInternal is the message of RPCInternalError
+	ErrRPCInternal = "Internal error"
+)
+
+// Body binding error messages
+const (
+	// ErrUnsupportedContentType indicates the body is not in the expected media type
+	ErrUnsupportedContentType = "unsupported content type"
+	// ErrEmptyBody indicates the request carried no body to bind
+	ErrEmptyBody = "request body is empty"
+	// ErrBodyTooLarge indicates the body exceeds the configured limit
+	ErrBodyTooLarge = "request body too large"
+	// ErrInvalidJSON indicates the body is not valid JSON for the target value
+	ErrInvalidJSON = "invalid JSON body"
+	// ErrInvalidBody indicates a body its declared media type's codec cannot decode
+	ErrInvalidBody = "invalid request body"
+	// ErrInvalidForm indicates the body is not a valid URL-encoded form
+	ErrInvalidForm = "invalid form body"
+	// ErrInvalidParam indicates a query, path or form value of the wrong type
+	ErrInvalidParam = "invalid parameter"
+	// ErrValidationFailed indicates bound values that failed validation
+	ErrValidationFailed = "validation failed"
+	// ErrInvalidUpload indicates a multipart body that could not be read
+	ErrInvalidUpload = "invalid multipart body"
+	// ErrUploadTooLarge indicates an uploaded file over the size limit
+	ErrUploadTooLarge = "uploaded file too large"
+	// ErrSaveUpload indicates an uploaded file that could not be written to disk
+	ErrSaveUpload = "failed to save uploaded file"
+)
+
+// File upload settings
+const (
+	// defaultUploadMemoryLimit is how much of an upload is buffered before spooling to disk
+	defaultUploadMemoryLimit = 1 << 20
+
+	// uploadTempPattern names the temporary files uploads are spooled to
+	uploadTempPattern = ".upload-*"
+
+	// multipartBoundaryParam is the Content-Type parameter carrying the part delimiter
+	multipartBoundaryParam = "boundary"
+)
+
+// Resumable upload settings
+const (
+	// headerUploadOffset carries how many bytes of an upload are stored
+	headerUploadOffset = "Upload-Offset"
+
+	// headerUploadLength carries the total size of an upload
+	headerUploadLength = "Upload-Length"
+
+	// headerTusResumable names the version of the resumable upload protocol
+	headerTusResumable = "Tus-Resumable"
+
+	// tusVersion is the tus protocol version the upload endpoints follow
+	tusVersion = "1.0.0"
+
+	// uploadIDBytes is the number of random bytes in an upload ID
+	uploadIDBytes = 16
+
+	// uploadLengthSuffix names the file recording the length of a stored upload
+	uploadLengthSuffix = ".length"
+
+	// ErrInvalidUploadLength indicates a missing or malformed Upload-Length
+	ErrInvalidUploadLength = "invalid upload length"
+	// ErrInvalidUploadOffset indicates a chunk without a usable Upload-Offset or Content-Range
+	ErrInvalidUploadOffset = "invalid upload offset"
+	// ErrUploadOffsetMismatch indicates a chunk that does not start where the stored data ends
+	ErrUploadOffsetMismatch = "upload offset does not match stored data"
+	// ErrInvalidUploadID indicates an unknown upload
+	ErrInvalidUploadID = "unknown upload"
+)
+
+// Response compression settings
+const (
+	// DefaultCompressMinSize is the smallest known body length worth compressing
+	DefaultCompressMinSize = 1024
+
+	// cacheDirectiveNoTransform forbids intermediaries from changing the body
+	cacheDirectiveNoTransform = "no-transform"
+)
+
+// Cache-Control directives
+const (
+	// cacheDirectiveNoStore forbids storing the response
+	cacheDirectiveNoStore = "no-store"
+
+	// cacheDirectiveNoCache requires revalidation before every reuse
+	cacheDirectiveNoCache = "no-cache"
+
+	// cacheDirectivePublic lets shared caches store the response
+	cacheDirectivePublic = "public"
+
+	// cacheDirectivePrivate limits storage to the client's own cache
+	cacheDirectivePrivate = "private"
+
+	// cacheDirectiveMaxAge limits how long the response stays fresh, in seconds
+	cacheDirectiveMaxAge = "max-age"
+
+	// cacheDirectiveStaleWhileRevalidate allows serving a stale response while it is refreshed
+	cacheDirectiveStaleWhileRevalidate = "stale-while-revalidate"
+
+	// cacheDirectiveImmutable promises the body never changes while fresh
+	cacheDirectiveImmutable = "immutable"
+)
+
+// compressibleTypes are media type prefixes whose bodies usually shrink when compressed
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"image/svg+xml",
+}
+
+// Content digest error messages
+const (
+	// ErrMissingDigest indicates an upload without the Content-MD5 or Digest header required
+	ErrMissingDigest = "request body digest required"
+)
+
+// Interim response error messages
+const (
+	// ErrInvalidInterimStatus indicates a status outside 1xx, or 101 which only an upgrade may send
+	ErrInvalidInterimStatus = "interim response status must be 1xx other than 101"
+	// ErrInterimUnavailable indicates the request cannot receive 1xx responses
+	ErrInterimUnavailable = "interim responses are unavailable for this request"
+)
+
+// Hijack error messages
+const (
+	// ErrHijackUnavailable indicates a request whose connection cannot be taken over
+	ErrHijackUnavailable = "connection cannot be hijacked"
+	// ErrAlreadyHijacked indicates a second attempt to take over a connection
+	ErrAlreadyHijacked = "connection already hijacked"
+)
+
+// Set-Cookie attribute names (RFC 6265 section 4.1)
+const (
+	cookieAttrExpires  = "Expires"
+	cookieAttrMaxAge   = "Max-Age"
+	cookieAttrDomain   = "Domain"
+	cookieAttrPath     = "Path"
+	cookieAttrSecure   = "Secure"
+	cookieAttrHTTPOnly = "HttpOnly"
+	cookieAttrSameSite = "SameSite"
+)
+
+// Cookie error messages
+const (
+	// ErrInvalidCookieName indicates a cookie name that is not an RFC 6265 token
+	ErrInvalidCookieName = "invalid cookie name"
+	// ErrInvalidCookieValue indicates a cookie value with characters outside cookie-octet
+	ErrInvalidCookieValue = "invalid cookie value"
+	// ErrInvalidCookiePath indicates a Path attribute with control characters or ';'
+	ErrInvalidCookiePath = "invalid cookie path"
+	// ErrInvalidCookieDomain indicates a Domain attribute that is not a host name
+	ErrInvalidCookieDomain = "invalid cookie domain"
+	// ErrInsecureSameSiteNone indicates SameSite=None without Secure, which browsers reject
+	ErrInsecureSameSiteNone = "SameSite=None cookie must be Secure"
+)
+
+// jsonMediaTypeSuffix marks structured syntax media types based on JSON (RFC 6839)
+const jsonMediaTypeSuffix = "+json"
+
+// Route pattern syntax
+const (
+	// routeParamPrefix marks a segment that captures one path segment
+	routeParamPrefix = ":"
+
+	// routeWildcardPrefix marks a final segment that captures the rest of the path
+	routeWildcardPrefix = "*"
+)
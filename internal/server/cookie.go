@@ -0,0 +1,168 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// SameSite controls whether a browser sends a cookie on cross-site requests
+type SameSite int
+
+const (
+	// SameSiteDefault omits the attribute, leaving the browser default
+	SameSiteDefault SameSite = iota
+	// SameSiteLax sends the cookie on top-level cross-site navigations only
+	SameSiteLax
+	// SameSiteStrict never sends the cookie on cross-site requests
+	SameSiteStrict
+	// SameSiteNone sends the cookie on every request; it requires Secure
+	SameSiteNone
+)
+
+// String returns the attribute value, or "" for SameSiteDefault
+func (s SameSite) String() string {
+	switch s {
+	case SameSiteLax:
+		return "Lax"
+	case SameSiteStrict:
+		return "Strict"
+	case SameSiteNone:
+		return "None"
+	default:
+		return ""
+	}
+}
+
+// Cookie describes a cookie set with a Set-Cookie header (RFC 6265)
+type Cookie struct {
+	Name  string
+	Value string
+
+	// Path and Domain scope the cookie; empty values are omitted
+	Path   string
+	Domain string
+
+	// Expires is omitted when zero
+	Expires time.Time
+
+	// MaxAge is the lifetime in seconds. Zero omits the attribute and a
+	// negative value deletes the cookie at once (Max-Age=0).
+	MaxAge int
+
+	Secure   bool
+	HttpOnly bool
+	SameSite SameSite
+}
+
+// Validate checks the name, value and attributes against RFC 6265
+func (c *Cookie) Validate() error {
+	if !isCookieName(c.Name) {
+		return common.InvalidInputError(ErrInvalidCookieName + ": " + c.Name)
+	}
+	if !isCookieValue(c.Value) {
+		return common.InvalidInputError(ErrInvalidCookieValue)
+	}
+	if strings.ContainsFunc(c.Path, func(r rune) bool { return r < 0x20 || r == 0x7f || r == ';' }) {
+		return common.InvalidInputError(ErrInvalidCookiePath)
+	}
+	if c.Domain != "" && !isCookieDomain(c.Domain) {
+		return common.InvalidInputError(ErrInvalidCookieDomain + ": " + c.Domain)
+	}
+	if c.SameSite == SameSiteNone && !c.Secure {
+		return common.InvalidInputError(ErrInsecureSameSiteNone)
+	}
+	return nil
+}
+
+// String serializes the cookie as a Set-Cookie header value. It does not
+// validate; use Validate or SetCookie for untrusted input.
+func (c *Cookie) String() string {
+	var b strings.Builder
+	b.WriteString(c.Name + "=" + c.Value)
+
+	if c.Path != "" {
+		b.WriteString("; " + cookieAttrPath + "=" + c.Path)
+	}
+	if c.Domain != "" {
+		// A leading dot is ignored by browsers (RFC 6265 section 5.2.3)
+		b.WriteString("; " + cookieAttrDomain + "=" + strings.TrimPrefix(c.Domain, "."))
+	}
+	if !c.Expires.IsZero() {
+		b.WriteString("; " + cookieAttrExpires + "=" + common.FormatHTTPTime(c.Expires))
+	}
+	switch {
+	case c.MaxAge > 0:
+		b.WriteString("; " + cookieAttrMaxAge + "=" + strconv.Itoa(c.MaxAge))
+	case c.MaxAge < 0:
+		b.WriteString("; " + cookieAttrMaxAge + "=0")
+	}
+	if c.HttpOnly {
+		b.WriteString("; " + cookieAttrHTTPOnly)
+	}
+	if c.Secure {
+		b.WriteString("; " + cookieAttrSecure)
+	}
+	if sameSite := c.SameSite.String(); sameSite != "" {
+		b.WriteString("; " + cookieAttrSameSite + "=" + sameSite)
+	}
+
+	return b.String()
+}
+
+// SetCookie validates cookie and adds it to resp as a Set-Cookie header
+func SetCookie(resp pkghttp.Response, cookie *Cookie) error {
+	if err := cookie.Validate(); err != nil {
+		return err
+	}
+	resp.AddHeader(pkghttp.HeaderSetCookie, cookie.String())
+	return nil
+}
+
+// isCookieName reports whether name is a non-empty token
+func isCookieName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= 0x20 || r >= 0x7f || strings.ContainsRune(`()<>@,;:\"/[]?={}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isCookieValue reports whether value is cookie-octets, optionally in double quotes
+func isCookieValue(value string) bool {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+	for _, r := range value {
+		if r <= 0x20 || r >= 0x7f || r == '"' || r == ',' || r == ';' || r == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// isCookieDomain reports whether domain is a host name, allowing a leading dot
+func isCookieDomain(domain string) bool {
+	domain = strings.TrimPrefix(domain, ".")
+	if domain == "" {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
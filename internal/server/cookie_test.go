@@ -0,0 +1,110 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestCookieString(t *testing.T) {
+	tests := []struct {
+		name     string
+		cookie   Cookie
+		expected string
+	}{
+		{
+			name:     "name and value only",
+			cookie:   Cookie{Name: "session", Value: "abc123"},
+			expected: "session=abc123",
+		},
+		{
+			name: "all attributes",
+			cookie: Cookie{
+				Name:     "session",
+				Value:    "abc123",
+				Path:     "/app",
+				Domain:   ".example.com",
+				Expires:  time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC),
+				MaxAge:   3600,
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: SameSiteStrict,
+			},
+			expected: "session=abc123; Path=/app; Domain=example.com; Expires=Wed, 02 Jan 2030 03:04:05 GMT; " +
+				"Max-Age=3600; HttpOnly; Secure; SameSite=Strict",
+		},
+		{
+			name:     "deletion",
+			cookie:   Cookie{Name: "session", MaxAge: -1},
+			expected: "session=; Max-Age=0",
+		},
+		{
+			name:     "lax",
+			cookie:   Cookie{Name: "pref", Value: `"dark"`, SameSite: SameSiteLax},
+			expected: `pref="dark"; SameSite=Lax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cookie.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCookieValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  Cookie
+		wantErr string
+	}{
+		{name: "valid", cookie: Cookie{Name: "id", Value: "a1-b2_c3", Domain: "example.com", Path: "/"}},
+		{name: "empty name", cookie: Cookie{Value: "x"}, wantErr: ErrInvalidCookieName},
+		{name: "separator in name", cookie: Cookie{Name: "a=b"}, wantErr: ErrInvalidCookieName},
+		{name: "space in value", cookie: Cookie{Name: "id", Value: "a b"}, wantErr: ErrInvalidCookieValue},
+		{name: "semicolon in value", cookie: Cookie{Name: "id", Value: "a;b"}, wantErr: ErrInvalidCookieValue},
+		{name: "semicolon in path", cookie: Cookie{Name: "id", Path: "/a;b"}, wantErr: ErrInvalidCookiePath},
+		{name: "bad domain", cookie: Cookie{Name: "id", Domain: "exa mple.com"}, wantErr: ErrInvalidCookieDomain},
+		{name: "empty label", cookie: Cookie{Name: "id", Domain: "example..com"}, wantErr: ErrInvalidCookieDomain},
+		{name: "SameSite=None without Secure", cookie: Cookie{Name: "id", SameSite: SameSiteNone}, wantErr: ErrInsecureSameSiteNone},
+		{name: "SameSite=None with Secure", cookie: Cookie{Name: "id", SameSite: SameSiteNone, Secure: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cookie.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected %q error, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSetCookie(t *testing.T) {
+	resp := pkghttp.NewResponse(pkghttp.StatusOK, pkghttp.Version11)
+
+	if err := SetCookie(resp, &Cookie{Name: "a", Value: "1"}); err != nil {
+		t.Fatalf("SetCookie failed: %v", err)
+	}
+	if err := SetCookie(resp, &Cookie{Name: "b", Value: "2", HttpOnly: true}); err != nil {
+		t.Fatalf("SetCookie failed: %v", err)
+	}
+	if err := SetCookie(resp, &Cookie{Name: "bad name"}); err == nil {
+		t.Error("Expected an invalid cookie to be rejected")
+	}
+
+	cookies := resp.GetHeaders(pkghttp.HeaderSetCookie)
+	if len(cookies) != 2 || cookies[0] != "a=1" || cookies[1] != "b=2; HttpOnly" {
+		t.Errorf("Unexpected Set-Cookie headers: %q", cookies)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"bytes"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// DigestConfig configures the digest verification middleware
+type DigestConfig struct {
+	// Required rejects request bodies that carry neither Content-MD5 nor Digest
+	Required bool
+}
+
+// VerifyDigest returns middleware that reads each request body and rejects it
+// with 400 Bad Request when it does not match its Content-MD5 or Digest header.
+// Handlers see the buffered body, so bodies are limited to MaxRequestBodySize.
+func VerifyDigest(config DigestConfig) pkghttp.MiddlewareFunc {
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			if req.Body() == nil {
+				return next(req)
+			}
+
+			if !internalhttp.HasDigest(req.Headers()) {
+				if config.Required {
+					resp := internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, ErrMissingDigest)
+					resp.SetHeader(pkghttp.HeaderWantDigest, internalhttp.DigestAlgorithmSHA256)
+					return resp
+				}
+				return next(req)
+			}
+
+			data, err := readLimitedBody(req, internalhttp.ErrUnexpectedEOF)
+			if err != nil {
+				return BindErrorResponse(err)
+			}
+			if err := internalhttp.VerifyDigest(req.Headers(), data); err != nil {
+				return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, badRequestReason(err))
+			}
+
+			req.SetBody(bytes.NewReader(data))
+			return next(req)
+		}
+	}
+}
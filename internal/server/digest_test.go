@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestVerifyDigest(t *testing.T) {
+	body := "uploaded data"
+
+	tests := []struct {
+		name           string
+		config         DigestConfig
+		headers        string
+		expectedStatus pkghttp.StatusCode
+	}{
+		{
+			name:           "matching Digest",
+			headers:        "Digest: " + internalhttp.ContentDigest([]byte(body)) + "\r\n",
+			expectedStatus: pkghttp.StatusOK,
+		},
+		{
+			name:           "matching Content-MD5",
+			headers:        "Content-MD5: " + internalhttp.ContentMD5([]byte(body)) + "\r\n",
+			expectedStatus: pkghttp.StatusOK,
+		},
+		{
+			name:           "mismatched Digest",
+			headers:        "Digest: " + internalhttp.ContentDigest([]byte("something else")) + "\r\n",
+			expectedStatus: pkghttp.StatusBadRequest,
+		},
+		{
+			name:           "no digest allowed",
+			expectedStatus: pkghttp.StatusOK,
+		},
+		{
+			name:           "no digest when required",
+			config:         DigestConfig{Required: true},
+			expectedStatus: pkghttp.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+				data, _ := io.ReadAll(req.Body())
+				received = string(data)
+				return okHandler(req)
+			}, VerifyDigest(tt.config))
+			conn, reader := dialTestServer(t, server)
+
+			raw := fmt.Sprintf("PUT /upload HTTP/1.1\r\nHost: localhost\r\nContent-Length: %d\r\n%s\r\n%s", len(body), tt.headers, body)
+			resp, _ := roundTrip(t, conn, reader, raw)
+
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if tt.expectedStatus == pkghttp.StatusOK && received != body {
+				t.Errorf("Expected handler to read %q, got %q", body, received)
+			}
+			if tt.config.Required && resp.GetHeader(pkghttp.HeaderWantDigest) != internalhttp.DigestAlgorithmSHA256 {
+				t.Errorf("Expected Want-Digest, got %q", resp.GetHeader(pkghttp.HeaderWantDigest))
+			}
+		})
+	}
+}
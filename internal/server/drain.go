@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// Shutdown stops the server gracefully. With a health registry set, readiness
+// fails first and the server keeps serving for its shutdown delay. Then no new
+// connections are accepted, idle keep-alive connections are closed, and
+// requests in flight finish with "Connection: close" until ctx is done, when
+// remaining connections are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.announceDraining(ctx)
+	return s.tcpServer.Shutdown(ctx)
+}
+
+// announceDraining fails readiness and waits out the shutdown delay, unless
+// ctx ends first
+func (s *Server) announceDraining(ctx context.Context) {
+	s.mu.RLock()
+	health := s.health
+	s.mu.RUnlock()
+
+	if health == nil || !s.IsRunning() {
+		return
+	}
+
+	health.SetDraining(true)
+
+	timer := time.NewTimer(health.config.ShutdownDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// drainWatcher interrupts a connection waiting for its next request once the
+// server starts draining, and tells the serve loop to stop keeping it alive
+type drainWatcher struct {
+	conn     pkgtcp.Connection
+	stop     func() bool
+	mu       sync.Mutex
+	idle     bool
+	draining bool
+}
+
+// watchDrain follows the draining signal of the server that accepted conn
+func watchDrain(conn pkgtcp.Connection) *drainWatcher {
+	w := &drainWatcher{conn: conn}
+	if c, ok := conn.(pkgtcp.ContextConnection); ok {
+		w.stop = context.AfterFunc(c.Context(), w.drain)
+	}
+	return w
+}
+
+// drain marks the connection draining, failing a pending read for the next request
+func (w *drainWatcher) drain() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.draining = true
+	if w.idle {
+		w.conn.SetReadDeadline(time.Now())
+	}
+}
+
+// waitIdle marks the connection as waiting for a request, or reports false
+// when it should be closed instead
+func (w *drainWatcher) waitIdle() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.draining {
+		return false
+	}
+	w.idle = true
+	return true
+}
+
+// busy marks the connection as serving a request
+func (w *drainWatcher) busy() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.idle = false
+}
+
+// isDraining reports whether the server has started draining
+func (w *drainWatcher) isDraining() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.draining
+}
+
+// close stops watching
+func (w *drainWatcher) close() {
+	if w.stop != nil {
+		w.stop()
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestShutdownClosesIdleConnections(t *testing.T) {
+	server := startTestServer(t, okHandler)
+
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+	if resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"); resp.StatusCode() != pkghttp.StatusOK {
+		t.Fatalf("Expected status %d, got %d", pkghttp.StatusOK, resp.StatusCode())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected idle connection to be closed promptly, Shutdown took %v", elapsed)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("Expected idle connection to be closed, got %v", err)
+	}
+}
+
+func TestShutdownFinishesInFlightRequests(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		close(entered)
+		<-release
+		return okHandler(req)
+	})
+
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	<-entered
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- server.Shutdown(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for server.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	resp, body := roundTrip(t, conn, reader, "")
+	if resp.StatusCode() != pkghttp.StatusOK || body != "ok" {
+		t.Errorf("Expected in-flight request to complete, got %d %q", resp.StatusCode(), body)
+	}
+	if got := resp.GetHeader(pkghttp.HeaderConnection); got != pkghttp.ConnectionClose {
+		t.Errorf("Expected Connection: close while draining, got %q", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+}
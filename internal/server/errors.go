@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bytes"
+	"html/template"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// ErrorRenderer builds the response for an error status. An empty message means
+// the standard status text. req is nil when the request could not be parsed.
+type ErrorRenderer func(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response
+
+// HTMLErrorRenderer renders errors as the built-in HTML error page
+func HTMLErrorRenderer(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+	return internalhttp.BuildErrorResponse(status, message)
+}
+
+// JSONErrorRenderer renders errors as a JSON error object
+func JSONErrorRenderer(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+	return internalhttp.BuildJSONErrorResponse(status, message)
+}
+
+// orDefaultRenderer substitutes the HTML renderer for a nil renderer
+func orDefaultRenderer(renderer ErrorRenderer) ErrorRenderer {
+	if renderer == nil {
+		return HTMLErrorRenderer
+	}
+	return renderer
+}
+
+// ErrorPageData is passed to error page templates
+type ErrorPageData struct {
+	Status     pkghttp.StatusCode
+	StatusText string
+	Message    string
+	Path       string
+}
+
+// ErrorPages renders error responses from pages registered per status code.
+// Clients that prefer JSON to HTML in their Accept header get the JSON variant.
+// Use Render as the ErrorRenderer of a server or router, and Middleware to
+// replace the built-in pages that handlers return.
+type ErrorPages struct {
+	templates map[pkghttp.StatusCode]*template.Template
+	handlers  map[pkghttp.StatusCode]ErrorRenderer
+	json      ErrorRenderer
+	fallback  ErrorRenderer
+	mu        sync.RWMutex
+}
+
+// NewErrorPages creates a registry that renders every status with the
+// built-in pages until others are registered
+func NewErrorPages() *ErrorPages {
+	return &ErrorPages{
+		templates: make(map[pkghttp.StatusCode]*template.Template),
+		handlers:  make(map[pkghttp.StatusCode]ErrorRenderer),
+	}
+}
+
+// SetTemplate renders status with tmpl, executed with an ErrorPageData
+func (p *ErrorPages) SetTemplate(status pkghttp.StatusCode, tmpl *template.Template) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.templates[status] = tmpl
+}
+
+// SetHandler renders status with renderer, whatever the client accepts
+func (p *ErrorPages) SetHandler(status pkghttp.StatusCode, renderer ErrorRenderer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[status] = renderer
+}
+
+// SetJSONRenderer sets the renderer for clients preferring JSON; nil means JSONErrorRenderer
+func (p *ErrorPages) SetJSONRenderer(renderer ErrorRenderer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.json = renderer
+}
+
+// SetFallback sets the renderer for statuses without a page; nil means HTMLErrorRenderer
+func (p *ErrorPages) SetFallback(renderer ErrorRenderer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fallback = renderer
+}
+
+// Render builds the error response for status. A handler registered for the
+// status wins; otherwise a client preferring JSON gets the JSON variant, and
+// others the status template or the fallback.
+func (p *ErrorPages) Render(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+	p.mu.RLock()
+	handler := p.handlers[status]
+	tmpl := p.templates[status]
+	jsonRenderer := p.json
+	fallback := orDefaultRenderer(p.fallback)
+	p.mu.RUnlock()
+
+	if handler != nil {
+		return handler(req, status, message)
+	}
+	if req != nil && prefersJSON(req.GetHeader(pkghttp.HeaderAccept)) {
+		if jsonRenderer == nil {
+			jsonRenderer = JSONErrorRenderer
+		}
+		return jsonRenderer(req, status, message)
+	}
+	if tmpl == nil {
+		return fallback(req, status, message)
+	}
+
+	if message == "" {
+		message = pkghttp.StatusText(status)
+	}
+	data := ErrorPageData{Status: status, StatusText: pkghttp.StatusText(status), Message: message}
+	if req != nil {
+		data.Path = req.Path()
+	}
+
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		return fallback(req, status, message)
+	}
+	return pkghttp.NewHTMLResponse(status, pkghttp.Version11, page.String())
+}
+
+// Middleware returns middleware that re-renders HTML error responses from
+// handlers, such as the built-in pages of FileServer, when the status has a
+// registered page or the client prefers JSON. Headers other than those
+// describing the body, such as Allow or Retry-After, are kept.
+func (p *ErrorPages) Middleware() pkghttp.MiddlewareFunc {
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			resp := next(req)
+			if resp == nil || !p.replaces(req, resp) {
+				return resp
+			}
+
+			page := p.Render(req, resp.StatusCode(), "")
+			for _, name := range resp.HeaderNames() {
+				if page.HasHeader(name) || isBodyHeader(name) {
+					continue
+				}
+				for _, value := range resp.Headers()[name] {
+					page.AddHeader(name, value)
+				}
+			}
+			if body := resp.Body(); body != nil {
+				body.Close()
+			}
+			return page
+		}
+	}
+}
+
+// replaces reports whether the middleware should re-render resp
+func (p *ErrorPages) replaces(req pkghttp.Request, resp pkghttp.Response) bool {
+	if resp.StatusCode() < pkghttp.StatusBadRequest {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.GetHeader(pkghttp.HeaderContentType))
+	if mediaType != pkghttp.MimeTypeTextHTML {
+		return false
+	}
+
+	p.mu.RLock()
+	_, hasHandler := p.handlers[resp.StatusCode()]
+	_, hasTemplate := p.templates[resp.StatusCode()]
+	p.mu.RUnlock()
+
+	return hasHandler || hasTemplate || prefersJSON(req.GetHeader(pkghttp.HeaderAccept))
+}
+
+// isBodyHeader reports whether a header describes the body of a response
+func isBodyHeader(name string) bool {
+	for _, header := range []string{pkghttp.HeaderContentType, pkghttp.HeaderContentLength, pkghttp.HeaderContentEncoding, pkghttp.HeaderETag} {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefersJSON reports whether an Accept header rates JSON above HTML
+func prefersJSON(accept string) bool {
+	if accept == "" {
+		return false
+	}
+	return acceptWeight(accept, pkghttp.MimeTypeJSON) > acceptWeight(accept, pkghttp.MimeTypeTextHTML)
+}
+
+// acceptWeight returns the q-value an Accept header gives mediaType, taken
+// from its most specific matching range (RFC 7231 section 5.3.2)
+func acceptWeight(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	weight, specificity := 0.0, -1
+
+	for _, item := range strings.Split(accept, ",") {
+		rangeType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+
+		var rangeSpecificity int
+		switch {
+		case rangeType == mediaType:
+			rangeSpecificity = 2
+		case rangeType == mainType+"/*":
+			rangeSpecificity = 1
+		case rangeType == "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		specificity = rangeSpecificity
+		weight = 1
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+	}
+
+	return weight
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestErrorPagesRender(t *testing.T) {
+	pages := NewErrorPages()
+	pages.SetTemplate(pkghttp.StatusNotFound, template.Must(template.New("404").Parse(`<h1>{{.Status}} {{.StatusText}}</h1><p>{{.Path}}: {{.Message}}</p>`)))
+	pages.SetHandler(pkghttp.StatusServiceUnavailable, func(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+		return internalhttp.BuildTextResponse(status, "down for maintenance")
+	})
+
+	tests := []struct {
+		name        string
+		status      pkghttp.StatusCode
+		accept      string
+		contentType string
+		contains    string
+	}{
+		{name: "template", status: pkghttp.StatusNotFound, contentType: pkghttp.MimeTypeTextHTML, contains: "<h1>404 Not Found</h1><p>/missing: gone &lt;now&gt;</p>"},
+		{name: "handler", status: pkghttp.StatusServiceUnavailable, accept: pkghttp.MimeTypeJSON, contains: "down for maintenance"},
+		{name: "fallback", status: pkghttp.StatusInternalServerError, contentType: pkghttp.MimeTypeTextHTML, contains: "TinyServer"},
+		{name: "json preferred", status: pkghttp.StatusNotFound, accept: "application/json", contentType: pkghttp.MimeTypeJSON, contains: `"code": 404`},
+		{name: "json weighted higher", status: pkghttp.StatusNotFound, accept: "text/html;q=0.5, application/json", contentType: pkghttp.MimeTypeJSON, contains: `"code": 404`},
+		{name: "browser", status: pkghttp.StatusNotFound, accept: "text/html,application/xhtml+xml,*/*;q=0.8", contentType: pkghttp.MimeTypeTextHTML, contains: "<h1>404 Not Found</h1>"},
+		{name: "any type", status: pkghttp.StatusNotFound, accept: "*/*", contentType: pkghttp.MimeTypeTextHTML, contains: "<h1>404 Not Found</h1>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, "/missing", pkghttp.Version11)
+			if tt.accept != "" {
+				req.SetHeader(pkghttp.HeaderAccept, tt.accept)
+			}
+
+			resp := pages.Render(req, tt.status, "gone <now>")
+			body := readResponseBody(t, resp)
+
+			if resp.StatusCode() != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode())
+			}
+			if tt.contentType != "" && !strings.HasPrefix(resp.GetHeader(pkghttp.HeaderContentType), tt.contentType) {
+				t.Errorf("Expected Content-Type %q, got %q", tt.contentType, resp.GetHeader(pkghttp.HeaderContentType))
+			}
+			if !strings.Contains(body, tt.contains) {
+				t.Errorf("Expected body to contain %q, got %q", tt.contains, body)
+			}
+		})
+	}
+}
+
+func TestErrorPagesMiddleware(t *testing.T) {
+	pages := NewErrorPages()
+	pages.SetTemplate(pkghttp.StatusMethodNotAllowed, template.Must(template.New("405").Parse(`custom {{.Status}}`)))
+
+	handler := pages.Middleware()(func(req pkghttp.Request) pkghttp.Response {
+		switch req.Path() {
+		case "/405":
+			resp := internalhttp.BuildErrorResponse(pkghttp.StatusMethodNotAllowed, "")
+			resp.SetHeader(pkghttp.HeaderAllow, "GET")
+			return resp
+		case "/404":
+			return internalhttp.BuildErrorResponse(pkghttp.StatusNotFound, "")
+		case "/bind":
+			return internalhttp.BuildJSONErrorResponse(pkghttp.StatusBadRequest, "bad field")
+		}
+		return okHandler(req)
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		accept   string
+		expected string
+		allow    string
+	}{
+		{name: "registered page", path: "/405", expected: "custom 405", allow: "GET"},
+		{name: "built-in page kept", path: "/404", expected: "TinyServer"},
+		{name: "json preferred", path: "/404", accept: pkghttp.MimeTypeJSON, expected: `"code": 404`},
+		{name: "json left alone", path: "/bind", expected: "bad field"},
+		{name: "success left alone", path: "/", expected: "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11)
+			if tt.accept != "" {
+				req.SetHeader(pkghttp.HeaderAccept, tt.accept)
+			}
+
+			resp := handler(req)
+			if body := readResponseBody(t, resp); !strings.Contains(body, tt.expected) {
+				t.Errorf("Expected body to contain %q, got %q", tt.expected, body)
+			}
+			if got := resp.GetHeader(pkghttp.HeaderAllow); got != tt.allow {
+				t.Errorf("Expected Allow %q, got %q", tt.allow, got)
+			}
+		})
+	}
+}
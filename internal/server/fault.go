@@ -0,0 +1,109 @@
+package server
+
+import (
+	"math/rand"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// FaultConfig configures fault injection. Each fault is sampled on its own,
+// so a request may be delayed and then fail.
+type FaultConfig struct {
+	// Delay is the latency added to affected requests
+	Delay time.Duration
+
+	// DelayPercentage of requests delayed, from 0 to 100
+	DelayPercentage float64
+
+	// ErrorStatus answers affected requests instead of the handler;
+	// zero means 503 Service Unavailable
+	ErrorStatus pkghttp.StatusCode
+
+	// ErrorPercentage of requests failed with ErrorStatus, from 0 to 100
+	ErrorPercentage float64
+
+	// DropPercentage of requests whose connection is closed without a response, from 0 to 100
+	DropPercentage float64
+
+	// TargetHeader limits faults to requests carrying this header; empty targets every request
+	TargetHeader string
+
+	// TargetValues are the TargetHeader values that select a request; empty accepts any value
+	TargetValues []string
+
+	// Random returns a number in [0, 1) to sample requests; defaults to rand.Float64
+	Random func() float64
+}
+
+// targets reports whether faults apply to req
+func (c FaultConfig) targets(req pkghttp.Request) bool {
+	if c.TargetHeader == "" {
+		return true
+	}
+
+	value := req.GetHeader(c.TargetHeader)
+	if value == "" {
+		return false
+	}
+	if len(c.TargetValues) == 0 {
+		return true
+	}
+	for _, target := range c.TargetValues {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// InjectFaults returns middleware that adds latency, error responses and
+// dropped connections to test how clients cope with a misbehaving server.
+// A delay ends early when the request context is done. Where the connection
+// cannot be taken over, a dropped request is answered with 503 instead.
+func InjectFaults(config FaultConfig) pkghttp.MiddlewareFunc {
+	random := config.Random
+	if random == nil {
+		random = rand.Float64
+	}
+	errorStatus := config.ErrorStatus
+	if errorStatus == 0 {
+		errorStatus = pkghttp.StatusServiceUnavailable
+	}
+	sampled := func(percentage float64) bool {
+		return percentage > 0 && random()*100 < percentage
+	}
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			if !config.targets(req) {
+				return next(req)
+			}
+
+			if config.Delay > 0 && sampled(config.DelayPercentage) {
+				timer := time.NewTimer(config.Delay)
+				select {
+				case <-timer.C:
+				case <-req.Context().Done():
+					timer.Stop()
+				}
+			}
+
+			if sampled(config.DropPercentage) {
+				conn, _, err := Hijack(req)
+				if err != nil {
+					return internalhttp.BuildErrorResponse(pkghttp.StatusServiceUnavailable, ErrFaultInjected)
+				}
+				conn.Close()
+				return nil
+			}
+
+			if sampled(config.ErrorPercentage) {
+				return internalhttp.BuildErrorResponse(errorStatus, ErrFaultInjected)
+			}
+
+			return next(req)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestInjectFaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FaultConfig
+		header   string
+		sample   float64
+		expected pkghttp.StatusCode
+		delayed  bool
+	}{
+		{name: "no faults", config: FaultConfig{}, expected: pkghttp.StatusOK},
+		{name: "error sampled", config: FaultConfig{ErrorPercentage: 50}, sample: 0.3, expected: pkghttp.StatusServiceUnavailable},
+		{name: "error not sampled", config: FaultConfig{ErrorPercentage: 50}, sample: 0.6, expected: pkghttp.StatusOK},
+		{name: "custom status", config: FaultConfig{ErrorStatus: pkghttp.StatusBadGateway, ErrorPercentage: 100}, expected: pkghttp.StatusBadGateway},
+		{name: "delay", config: FaultConfig{Delay: 50 * time.Millisecond, DelayPercentage: 100}, expected: pkghttp.StatusOK, delayed: true},
+		{name: "delay then error", config: FaultConfig{Delay: 50 * time.Millisecond, DelayPercentage: 100, ErrorPercentage: 100}, expected: pkghttp.StatusServiceUnavailable, delayed: true},
+		{name: "targeted", config: FaultConfig{ErrorPercentage: 100, TargetHeader: "X-Chaos"}, header: "on", expected: pkghttp.StatusServiceUnavailable},
+		{name: "untargeted", config: FaultConfig{ErrorPercentage: 100, TargetHeader: "X-Chaos"}, expected: pkghttp.StatusOK},
+		{name: "target value", config: FaultConfig{ErrorPercentage: 100, TargetHeader: "X-Chaos", TargetValues: []string{"errors"}}, header: "errors", expected: pkghttp.StatusServiceUnavailable},
+		{name: "other target value", config: FaultConfig{ErrorPercentage: 100, TargetHeader: "X-Chaos", TargetValues: []string{"errors"}}, header: "latency", expected: pkghttp.StatusOK},
+		{name: "drop without connection", config: FaultConfig{DropPercentage: 100}, expected: pkghttp.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Random = func() float64 { return tt.sample }
+			handler := InjectFaults(config)(okHandler)
+
+			req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+			if tt.header != "" {
+				req.SetHeader("X-Chaos", tt.header)
+			}
+
+			start := time.Now()
+			resp := handler(req)
+			elapsed := time.Since(start)
+
+			if resp.StatusCode() != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode())
+			}
+			if tt.delayed && elapsed < tt.config.Delay {
+				t.Errorf("Expected a delay of at least %v, got %v", tt.config.Delay, elapsed)
+			}
+		})
+	}
+}
+
+func TestInjectFaultsDelayCanceled(t *testing.T) {
+	handler := InjectFaults(FaultConfig{Delay: time.Minute, DelayPercentage: 100})(okHandler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+	req.SetContext(ctx)
+
+	start := time.Now()
+	handler(req)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the delay to end with the context, took %v", elapsed)
+	}
+}
+
+func TestInjectFaultsDrop(t *testing.T) {
+	server := startTestServer(t, okHandler, InjectFaults(FaultConfig{DropPercentage: 100}))
+	conn, reader := dialTestServer(t, server)
+
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, err := io.ReadAll(reader); err != nil || len(data) != 0 {
+		t.Errorf("Expected the connection to close without a response, got %q (%v)", data, err)
+	}
+}
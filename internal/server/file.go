@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	"github.com/ganyariya/tinyserver/pkg/http/mime"
+)
+
+// fileBody is a response body read from a file of known size. The server
+// writes it through the connection's ReadFrom, which on TCP connections uses
+// sendfile so the bytes never pass through userspace buffers.
+type fileBody struct {
+	*os.File
+	size int64
+}
+
+// ServeFile responds with the file at path. The response carries the file's
+// size, modification time and an ETag derived from both, and becomes a 304
+// when the request validators show the client copy is current. A Range
+// header gives a 206 with the requested bytes unless If-Range shows the
+// client copy is stale. A missing file gives 404 and a directory 403.
+func ServeFile(req pkghttp.Request, path string) pkghttp.Response {
+	file, err := os.Open(path)
+	if err != nil {
+		return fileErrorResponse(err)
+	}
+	return serveFile(req, file, "")
+}
+
+// ServeFS responds with the file name from fsys as ServeFile does. A file
+// without a modification time, as in an embed.FS, gets an ETag hashed from
+// its content and no Last-Modified.
+func ServeFS(req pkghttp.Request, fsys fs.FS, name string) pkghttp.Response {
+	return serveFSFile(req, fsys, name, "")
+}
+
+// serveFSFile is ServeFS with a fixed Content-Type, for files such as
+// pre-compressed copies whose own name does not describe their content. An
+// empty contentType is derived from the file as usual.
+func serveFSFile(req pkghttp.Request, fsys fs.FS, name, contentType string) pkghttp.Response {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return fileErrorResponse(err)
+	}
+	return serveFile(req, file, contentType)
+}
+
+// serveFile responds with an open file, which it closes unless the response
+// body takes it over
+func serveFile(req pkghttp.Request, file fs.File, contentType string) pkghttp.Response {
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fileErrorResponse(err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return internalhttp.BuildErrorResponse(pkghttp.StatusForbidden, ErrDirectoryListing)
+	}
+
+	etag, err := fileValidator(file, info)
+	if err != nil {
+		file.Close()
+		return fileErrorResponse(err)
+	}
+
+	resp := pkghttp.NewResponse(pkghttp.StatusOK, req.Version())
+	if etag != "" {
+		resp.SetHeader(pkghttp.HeaderETag, etag)
+	}
+	if !info.ModTime().IsZero() {
+		resp.SetHeader(pkghttp.HeaderLastModified, common.FormatHTTPTime(info.ModTime()))
+	}
+
+	if CheckNotModified(req, etag, info.ModTime()) {
+		file.Close()
+		return NotModifiedResponse(resp)
+	}
+
+	var content io.Reader = file
+	if contentType == "" {
+		contentType, content, err = fileContentType(file, info.Name())
+		if err != nil {
+			file.Close()
+			return fileErrorResponse(err)
+		}
+	}
+
+	// Ranges need to seek, which a file replaying sniffed bytes cannot do
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		resp.SetHeader(pkghttp.HeaderAcceptRanges, rangeUnitBytes)
+		if ranges, ok := rangeRequested(req, etag, info.ModTime(), info.Size()); ok {
+			return serveRanges(resp, file, seeker, contentType, ranges, info.Size())
+		}
+	}
+
+	resp.SetHeader(pkghttp.HeaderContentType, contentType)
+	resp.SetHeader(pkghttp.HeaderContentLength, strconv.FormatInt(info.Size(), 10))
+	if osFile, ok := content.(*os.File); ok {
+		resp.SetBody(&fileBody{File: osFile, size: info.Size()})
+	} else {
+		resp.SetBody(&replayBody{Reader: content, Closer: file})
+	}
+
+	return resp
+}
+
+// fileValidator returns the ETag for a file: from its size and modification
+// time, or when it has none from a hash of its content, read through and
+// rewound. A file that can neither be dated nor rewound gets no ETag.
+func fileValidator(file fs.File, info fs.FileInfo) (string, error) {
+	if !info.ModTime().IsZero() {
+		return FileETag(info.Size(), info.ModTime()), nil
+	}
+
+	seeker, ok := file.(io.Seeker)
+	if !ok {
+		return "", nil
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return StrongETag(content), nil
+}
+
+// fileContentType picks a media type from the extension of name, sniffing the
+// start of the file when the extension is unknown. It returns the reader the
+// body continues from, which replays the sniffed bytes if the file cannot seek.
+func fileContentType(file fs.File, name string) (string, io.Reader, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType, file, nil
+	}
+
+	prefix := make([]byte, mime.SniffLength)
+	n, err := io.ReadFull(file, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	prefix = prefix[:n]
+
+	seeker, ok := file.(io.Seeker)
+	if !ok {
+		return mime.DetectContentType(prefix), io.MultiReader(bytes.NewReader(prefix), file), nil
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+
+	return mime.DetectContentType(prefix), file, nil
+}
+
+// replayBody is a file body read through another reader, such as one
+// replaying bytes consumed while sniffing its type
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// fileErrorResponse maps a failure to open or stat a file to a response
+func fileErrorResponse(err error) pkghttp.Response {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return internalhttp.BuildErrorResponse(pkghttp.StatusNotFound, "")
+	case errors.Is(err, fs.ErrPermission):
+		return internalhttp.BuildErrorResponse(pkghttp.StatusForbidden, "")
+	default:
+		return internalhttp.BuildErrorResponse(pkghttp.StatusInternalServerError, "")
+	}
+}
+
+// sendFile writes a file body in segments through w's ReadFrom and returns
+// how many bytes it sent. w is flushed first so ReadFrom reaches the
+// connection, and every segment gets a fresh write deadline, so a large file
+// only times out when the client stalls.
+func sendFile(w *bufio.Writer, body *fileBody) (int64, error) {
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	var sent int64
+	for sent < body.size {
+		n, err := w.ReadFrom(&io.LimitedReader{R: body.File, N: min(body.size-sent, sendFileSegmentSize)})
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+		if n == 0 {
+			// The file shrank after its length was announced
+			return sent, io.ErrUnexpectedEOF
+		}
+	}
+
+	return sent, nil
+}
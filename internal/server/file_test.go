@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestServeFile(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Repeat("tinyserver ", 1000)
+	if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return ServeFile(req, filepath.Join(dir, req.Path()))
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET /page.html HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	etag := resp.GetHeader(pkghttp.HeaderETag)
+
+	tests := []struct {
+		name           string
+		request        string
+		expectedStatus pkghttp.StatusCode
+		expectedBody   string
+	}{
+		{
+			name:           "file",
+			request:        "GET /page.html HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			expectedStatus: pkghttp.StatusOK,
+			expectedBody:   content,
+		},
+		{
+			name:           "current copy",
+			request:        "GET /page.html HTTP/1.1\r\nHost: localhost\r\nIf-None-Match: " + etag + "\r\n\r\n",
+			expectedStatus: pkghttp.StatusNotModified,
+		},
+		{
+			name:           "missing file",
+			request:        "GET /missing.html HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			expectedStatus: pkghttp.StatusNotFound,
+		},
+		{
+			name:           "directory",
+			request:        "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			expectedStatus: pkghttp.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectedStatus == pkghttp.StatusNotModified && etag == "" {
+				t.Fatal("Expected the file response to carry an ETag")
+			}
+
+			resp, body := roundTrip(t, conn, reader, tt.request)
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if tt.expectedBody != "" && body != tt.expectedBody {
+				t.Errorf("Unexpected body of %d bytes", len(body))
+			}
+		})
+	}
+
+	if body != content {
+		t.Errorf("Unexpected body of %d bytes", len(body))
+	}
+	if resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeTextHTML {
+		t.Errorf("Unexpected Content-Type %q", resp.GetHeader(pkghttp.HeaderContentType))
+	}
+	if resp.GetHeader(pkghttp.HeaderContentLength) != strconv.Itoa(len(content)) {
+		t.Errorf("Unexpected Content-Length %q", resp.GetHeader(pkghttp.HeaderContentLength))
+	}
+}
+
+// BenchmarkServeFile compares sending a file with sendfile against copying it
+// through userspace buffers
+func BenchmarkServeFile(b *testing.B) {
+	const size = 8 << 20
+	path := filepath.Join(b.TempDir(), "large.bin")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{'x'}, size), 0o644); err != nil {
+		b.Fatalf("WriteFile failed: %v", err)
+	}
+
+	handlers := []struct {
+		name    string
+		handler pkghttp.RequestHandler
+	}{
+		{
+			name: "sendfile",
+			handler: func(req pkghttp.Request) pkghttp.Response {
+				return ServeFile(req, path)
+			},
+		},
+		{
+			name: "userspace copy",
+			handler: func(req pkghttp.Request) pkghttp.Response {
+				resp := ServeFile(req, path)
+				// Hiding the file type sends the body through copyBody's buffer
+				resp.SetBody(struct{ io.ReadCloser }{resp.Body()})
+				return resp
+			},
+		},
+	}
+
+	for _, h := range handlers {
+		b.Run(h.name, func(b *testing.B) {
+			server := startTestServer(b, h.handler)
+			conn, reader := dialTestServer(b, server)
+
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				io.WriteString(conn, "GET /large.bin HTTP/1.1\r\nHost: localhost\r\n\r\n")
+				resp, err := internalhttp.ReadResponse(reader)
+				if err != nil {
+					b.Fatalf("ReadResponse failed: %v", err)
+				}
+				// Discarding keeps the client's cost out of the comparison
+				io.Copy(io.Discard, resp.Body())
+			}
+		})
+	}
+}
+
+func TestServeFileSniffsUnknownExtensions(t *testing.T) {
+	dir := t.TempDir()
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+	if err := os.WriteFile(filepath.Join(dir, "logo"), []byte(png), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return ServeFile(req, filepath.Join(dir, req.Path()))
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET /logo HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeImagePNG {
+		t.Errorf("Expected sniffed %s, got %q", pkghttp.MimeTypeImagePNG, resp.GetHeader(pkghttp.HeaderContentType))
+	}
+	// Sniffing must not consume the start of the body
+	if body != png {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"strings"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// RouteGroup registers routes under a shared path prefix. Middleware added to a
+// group wraps only its routes and those of its nested groups; router-wide
+// middleware still applies to everything.
+type RouteGroup struct {
+	router     *Router
+	parent     *RouteGroup
+	prefix     string
+	middleware []pkghttp.MiddlewareFunc
+}
+
+// Group creates a route group whose paths are prefixed with prefix
+func (r *Router) Group(prefix string) *RouteGroup {
+	return &RouteGroup{router: r, prefix: joinRoutePath("", prefix)}
+}
+
+// Group creates a nested group inheriting this group's prefix and middleware
+func (g *RouteGroup) Group(prefix string) *RouteGroup {
+	return &RouteGroup{router: g.router, parent: g, prefix: joinRoutePath(g.prefix, prefix)}
+}
+
+// Prefix returns the full path prefix of the group
+func (g *RouteGroup) Prefix() string {
+	return g.prefix
+}
+
+// Use adds middleware to the group, applied in the order given
+func (g *RouteGroup) Use(middleware pkghttp.MiddlewareFunc) {
+	g.router.mu.Lock()
+	defer g.router.mu.Unlock()
+	g.middleware = append(g.middleware, middleware)
+}
+
+// Handle registers a handler for a method and a path relative to the group prefix
+func (g *RouteGroup) Handle(method pkghttp.Method, path string, handler pkghttp.RequestHandler) {
+	g.router.Handle(method, joinRoutePath(g.prefix, path), func(req pkghttp.Request) pkghttp.Response {
+		return g.wrap(handler)(req)
+	})
+}
+
+// Describe attaches documentation to a route registered through the group
+func (g *RouteGroup) Describe(method pkghttp.Method, path string, doc RouteDoc) {
+	g.router.Describe(method, joinRoutePath(g.prefix, path), doc)
+}
+
+// HandleFunc registers a handler function relative to the group prefix
+func (g *RouteGroup) HandleFunc(method pkghttp.Method, path string, handler func(pkghttp.Request) pkghttp.Response) {
+	g.Handle(method, path, handler)
+}
+
+// wrap applies the middleware of this group and its ancestors, outermost first.
+// It runs per request so middleware added after registration still applies.
+func (g *RouteGroup) wrap(handler pkghttp.RequestHandler) pkghttp.RequestHandler {
+	g.router.mu.RLock()
+	defer g.router.mu.RUnlock()
+
+	for group := g; group != nil; group = group.parent {
+		for i := len(group.middleware) - 1; i >= 0; i-- {
+			handler = group.middleware[i](handler)
+		}
+	}
+	return handler
+}
+
+// joinRoutePath appends path to prefix with exactly one slash between them
+func joinRoutePath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if path == "" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return prefix + path
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestJoinRoutePath(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		path     string
+		expected string
+	}{
+		{prefix: "/api", path: "/users", expected: "/api/users"},
+		{prefix: "/api/", path: "/users", expected: "/api/users"},
+		{prefix: "/api", path: "users", expected: "/api/users"},
+		{prefix: "/api", path: "/", expected: "/api/"},
+		{prefix: "/api", path: "", expected: "/api"},
+		{prefix: "", path: "/v1", expected: "/v1"},
+		{prefix: "", path: "", expected: "/"},
+	}
+
+	for _, tt := range tests {
+		if got := joinRoutePath(tt.prefix, tt.path); got != tt.expected {
+			t.Errorf("joinRoutePath(%q, %q) = %q, expected %q", tt.prefix, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestRouteGroups(t *testing.T) {
+	var trail []string
+	tag := func(name string) pkghttp.MiddlewareFunc {
+		return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+			return func(req pkghttp.Request) pkghttp.Response {
+				trail = append(trail, name)
+				return next(req)
+			}
+		}
+	}
+	echoPath := func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.Path()+" "+req.PathParam("id"))
+	}
+
+	router := NewRouter()
+	router.Use(tag("router"))
+
+	api := router.Group("/api")
+	api.Use(tag("api"))
+	v1 := api.Group("/v1")
+	v1.HandleFunc(pkghttp.MethodGet, "/users/:id", echoPath)
+	v1.Use(tag("v1"))
+
+	router.HandleFunc(pkghttp.MethodGet, "/health", echoPath)
+
+	tests := []struct {
+		path     string
+		expected string
+		trail    string
+	}{
+		{path: "/api/v1/users/5", expected: "/api/v1/users/5 5", trail: "router,api,v1"},
+		{path: "/health", expected: "/health ", trail: "router"},
+	}
+
+	for _, tt := range tests {
+		trail = nil
+		resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11))
+		body, _ := io.ReadAll(resp.Body())
+		if string(body) != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.expected, body)
+		}
+		if strings.Join(trail, ",") != tt.trail {
+			t.Errorf("%s: expected middleware %s, got %v", tt.path, tt.trail, trail)
+		}
+	}
+
+	if v1.Prefix() != "/api/v1" {
+		t.Errorf("Unexpected group prefix %q", v1.Prefix())
+	}
+}
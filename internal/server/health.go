@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// HealthCheck reports a problem with a dependency, or nil when it is healthy.
+// It should return promptly once ctx is done.
+type HealthCheck func(ctx context.Context) error
+
+// HealthConfig configures a HealthRegistry
+type HealthConfig struct {
+	// Timeout bounds each check; zero means DefaultHealthCheckTimeout
+	Timeout time.Duration
+
+	// ShutdownDelay is how long Server.Stop keeps serving after readiness
+	// fails, so load balancers stop sending traffic before connections close
+	ShutdownDelay time.Duration
+}
+
+// CheckResult is the outcome of one check in a health report
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// HealthReport is the JSON body of the health endpoints
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// HealthRegistry holds named liveness and readiness checks and serves their
+// aggregated status. Liveness tells whether the process should be restarted;
+// readiness whether it should receive traffic, and fails once shutdown starts.
+type HealthRegistry struct {
+	config    HealthConfig
+	liveness  map[string]HealthCheck
+	readiness map[string]HealthCheck
+	draining  atomic.Bool
+	mu        sync.RWMutex
+}
+
+// NewHealthRegistry creates a registry without checks
+func NewHealthRegistry(config HealthConfig) *HealthRegistry {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultHealthCheckTimeout
+	}
+	return &HealthRegistry{
+		config:    config,
+		liveness:  make(map[string]HealthCheck),
+		readiness: make(map[string]HealthCheck),
+	}
+}
+
+// AddLivenessCheck registers a check run by the liveness endpoint
+func (h *HealthRegistry) AddLivenessCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness[name] = check
+}
+
+// AddReadinessCheck registers a check run by the readiness endpoint
+func (h *HealthRegistry) AddReadinessCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness[name] = check
+}
+
+// SetDraining marks the server as shutting down, failing readiness while
+// liveness still passes
+func (h *HealthRegistry) SetDraining(draining bool) {
+	h.draining.Store(draining)
+}
+
+// Draining reports whether shutdown has started
+func (h *HealthRegistry) Draining() bool {
+	return h.draining.Load()
+}
+
+// Register serves liveness at /healthz and readiness at /readyz on router
+func (h *HealthRegistry) Register(router *Router) {
+	router.Handle(pkghttp.MethodGet, LivenessPath, h.LivenessHandler())
+	router.Handle(pkghttp.MethodGet, ReadinessPath, h.ReadinessHandler())
+}
+
+// LivenessHandler runs the liveness checks, answering 200 when all pass and 503 otherwise
+func (h *HealthRegistry) LivenessHandler() pkghttp.RequestHandler {
+	return func(req pkghttp.Request) pkghttp.Response {
+		return healthResponse(h.run(req.Context(), h.liveness))
+	}
+}
+
+// ReadinessHandler runs the readiness checks, answering 200 when all pass and
+// 503 otherwise or while draining
+func (h *HealthRegistry) ReadinessHandler() pkghttp.RequestHandler {
+	return func(req pkghttp.Request) pkghttp.Response {
+		if h.Draining() {
+			return healthResponse(HealthReport{Status: HealthStatusDraining})
+		}
+		return healthResponse(h.run(req.Context(), h.readiness))
+	}
+}
+
+// run executes checks concurrently in name order, each under the configured timeout
+func (h *HealthRegistry) run(ctx context.Context, checks map[string]HealthCheck) HealthReport {
+	h.mu.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	funcs := make([]HealthCheck, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		funcs[i] = checks[name]
+	}
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(names))
+	var wg sync.WaitGroup
+	for i := range funcs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, funcs[i], h.config.Timeout)
+		}(i)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: HealthStatusOK}
+	if len(names) > 0 {
+		report.Checks = make(map[string]CheckResult, len(names))
+	}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != HealthStatusOK {
+			report.Status = HealthStatusFail
+		}
+	}
+	return report
+}
+
+// runCheck runs one check, treating a panic or a timeout as a failure
+func runCheck(ctx context.Context, check HealthCheck, timeout time.Duration) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errs <- common.ServerError(ErrHealthCheckPanicked)
+			}
+		}()
+		errs <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = common.TimeoutError(ErrHealthCheckTimeout)
+	}
+
+	result = CheckResult{Status: HealthStatusOK, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// healthResponse renders a report, with 503 unless everything is healthy
+func healthResponse(report HealthReport) pkghttp.Response {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return internalhttp.BuildJSONErrorResponse(pkghttp.StatusInternalServerError, "")
+	}
+
+	status := pkghttp.StatusOK
+	if report.Status != HealthStatusOK {
+		status = pkghttp.StatusServiceUnavailable
+	}
+	resp := pkghttp.NewJSONResponse(status, pkghttp.Version11, string(data))
+	SetNoStore(resp)
+	return resp
+}
+
+// TCPDialCheck passes when a TCP connection to address can be opened
+func TCPDialCheck(address string) HealthCheck {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return common.NetworkErrorWithCause(ErrHealthDialFailed+": "+address, err)
+		}
+		return conn.Close()
+	}
+}
+
+// DirWritableCheck passes when a file can be created in dir, catching full
+// or read-only disks
+func DirWritableCheck(dir string) HealthCheck {
+	return func(ctx context.Context) error {
+		file, err := os.CreateTemp(dir, healthProbePattern)
+		if err != nil {
+			return common.IOErrorWithCause(ErrHealthDirNotWritable+": "+dir, err)
+		}
+		defer os.Remove(file.Name())
+
+		if _, err := file.Write([]byte{0}); err != nil {
+			file.Close()
+			return common.IOErrorWithCause(ErrHealthDirNotWritable+": "+dir, err)
+		}
+		return file.Close()
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestHealthRegistryReadiness(t *testing.T) {
+	pass := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("database unreachable") }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	panics := func(ctx context.Context) error { panic("boom") }
+
+	tests := []struct {
+		name     string
+		checks   map[string]HealthCheck
+		draining bool
+		expected pkghttp.StatusCode
+		status   string
+		failed   []string
+	}{
+		{name: "no checks", expected: pkghttp.StatusOK, status: HealthStatusOK},
+		{name: "all pass", checks: map[string]HealthCheck{"db": pass, "cache": pass}, expected: pkghttp.StatusOK, status: HealthStatusOK},
+		{name: "one fails", checks: map[string]HealthCheck{"db": fail, "cache": pass}, expected: pkghttp.StatusServiceUnavailable, status: HealthStatusFail, failed: []string{"db"}},
+		{name: "timeout", checks: map[string]HealthCheck{"slow": hang}, expected: pkghttp.StatusServiceUnavailable, status: HealthStatusFail, failed: []string{"slow"}},
+		{name: "panic", checks: map[string]HealthCheck{"broken": panics}, expected: pkghttp.StatusServiceUnavailable, status: HealthStatusFail, failed: []string{"broken"}},
+		{name: "draining", checks: map[string]HealthCheck{"db": pass}, draining: true, expected: pkghttp.StatusServiceUnavailable, status: HealthStatusDraining},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealthRegistry(HealthConfig{Timeout: 20 * time.Millisecond})
+			for name, check := range tt.checks {
+				health.AddReadinessCheck(name, check)
+			}
+			health.SetDraining(tt.draining)
+
+			resp := health.ReadinessHandler()(pkghttp.NewRequest(pkghttp.MethodGet, ReadinessPath, pkghttp.Version11))
+			if resp.StatusCode() != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode())
+			}
+
+			var report HealthReport
+			if err := json.Unmarshal([]byte(readResponseBody(t, resp)), &report); err != nil {
+				t.Fatalf("Invalid report: %v", err)
+			}
+			if report.Status != tt.status {
+				t.Errorf("Expected status %q, got %q", tt.status, report.Status)
+			}
+			for _, name := range tt.failed {
+				if result := report.Checks[name]; result.Status != HealthStatusFail || result.Error == "" {
+					t.Errorf("Expected %s to fail with an error, got %+v", name, result)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthRegistryLivenessIgnoresDraining(t *testing.T) {
+	health := NewHealthRegistry(HealthConfig{})
+	health.AddReadinessCheck("db", func(ctx context.Context) error { return errors.New("down") })
+	health.SetDraining(true)
+
+	resp := health.LivenessHandler()(pkghttp.NewRequest(pkghttp.MethodGet, LivenessPath, pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected status %d, got %d", pkghttp.StatusOK, resp.StatusCode())
+	}
+}
+
+func TestServerStopFailsReadinessFirst(t *testing.T) {
+	health := NewHealthRegistry(HealthConfig{ShutdownDelay: 100 * time.Millisecond})
+	router := NewRouter()
+	health.Register(router)
+
+	server := startTestServer(t, router.ServeRequest)
+	server.SetHealthRegistry(health)
+
+	stopped := make(chan struct{})
+	go func() {
+		server.Stop()
+		close(stopped)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !health.Draining() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	conn, reader := dialTestServer(t, server)
+	resp, _ := roundTrip(t, conn, reader, "GET /readyz HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusServiceUnavailable {
+		t.Errorf("Expected status %d while draining, got %d", pkghttp.StatusServiceUnavailable, resp.StatusCode())
+	}
+	conn.Close()
+	<-stopped
+}
+
+func TestTCPDialCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	address := listener.Addr().String()
+
+	if err := TCPDialCheck(address)(context.Background()); err != nil {
+		t.Errorf("Expected open port to pass, got %v", err)
+	}
+
+	listener.Close()
+	if err := TCPDialCheck(address)(context.Background()); err == nil {
+		t.Error("Expected closed port to fail")
+	}
+}
+
+func TestDirWritableCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := DirWritableCheck(dir)(context.Background()); err != nil {
+		t.Errorf("Expected writable directory to pass, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected probe file to be removed, found %d entries", len(entries))
+	}
+	if err := DirWritableCheck(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Error("Expected missing directory to fail")
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// hijacker hands a request's connection over to its handler
+type hijacker struct {
+	conn     pkgtcp.Connection
+	rw       *bufio.ReadWriter
+	interim  *interimWriter
+	closed   chan struct{}
+	hijacked bool
+	done     bool
+	mu       sync.Mutex
+}
+
+// withHijacker lets handlers of req take over conn
+func withHijacker(req pkghttp.Request, conn pkgtcp.Connection, reader *bufio.Reader, writer *bufio.Writer, interim *interimWriter) *hijacker {
+	h := &hijacker{
+		conn:    conn,
+		rw:      bufio.NewReadWriter(reader, writer),
+		interim: interim,
+		closed:  make(chan struct{}),
+	}
+	req.SetContext(context.WithValue(req.Context(), hijackContextKey, h))
+	return h
+}
+
+// hijack transfers the connection to the caller
+func (h *hijacker) hijack() (pkgtcp.Connection, *bufio.ReadWriter, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.hijacked {
+		return nil, nil, common.HTTPError(ErrAlreadyHijacked)
+	}
+	if h.done {
+		return nil, nil, common.HTTPError(ErrHijackUnavailable)
+	}
+	h.hijacked = true
+
+	// Nothing else may write to the connection once the handler owns it
+	if h.interim != nil {
+		h.interim.finish()
+	}
+	// The handler's protocol manages its own timeouts
+	if err := h.conn.SetDeadline(time.Time{}); err != nil {
+		return nil, nil, common.IOErrorWithCause(ErrHijackUnavailable, err)
+	}
+
+	return &hijackedConn{Connection: h.conn, closed: h.closed}, h.rw, nil
+}
+
+// finish ends the window for hijacking, reporting whether the connection was taken
+func (h *hijacker) finish() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.done = true
+	return h.hijacked
+}
+
+// wait blocks until the handler closes the hijacked connection
+func (h *hijacker) wait() {
+	<-h.closed
+}
+
+// hijackedConn signals the server when the handler closes the connection
+type hijackedConn struct {
+	pkgtcp.Connection
+	closed chan struct{}
+	once   sync.Once
+}
+
+// Close closes the connection and releases the server's hold on it
+func (c *hijackedConn) Close() error {
+	err := c.Connection.Close()
+	c.once.Do(func() { close(c.closed) })
+	return err
+}
+
+// Hijack lets the handler of req take over its connection, for WebSockets or
+// other protocols spoken without an Upgrade response. The server sends no
+// response and reads no further requests; the returned reader may already hold
+// bytes the client sent. The caller must close the connection when done.
+func Hijack(req pkghttp.Request) (pkgtcp.Connection, *bufio.ReadWriter, error) {
+	h, ok := req.Context().Value(hijackContextKey).(*hijacker)
+	if !ok {
+		return nil, nil, common.HTTPError(ErrHijackUnavailable)
+	}
+	return h.hijack()
+}
+
+// isHijacked reports whether the handler of req took over its connection
+func isHijacked(req pkghttp.Request) bool {
+	h, ok := req.Context().Value(hijackContextKey).(*hijacker)
+	if !ok {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hijacked
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestServerHijack(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		conn, rw, err := Hijack(req)
+		if err != nil {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusInternalServerError, err.Error())
+		}
+
+		// Speak a custom protocol from a goroutine that outlives the handler
+		go func() {
+			defer conn.Close()
+			rw.WriteString("HELLO " + req.Path() + "\n")
+			rw.Flush()
+
+			line, err := rw.ReadString('\n')
+			if err != nil {
+				return
+			}
+			rw.WriteString(strings.ToUpper(line))
+			rw.Flush()
+		}()
+		return nil
+	})
+	conn, reader := dialTestServer(t, server)
+
+	// The protocol's first line is sent along with the request head
+	if _, err := io.WriteString(conn, "GET /custom HTTP/1.1\r\nHost: localhost\r\n\r\nping\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for _, expected := range []string{"HELLO /custom\n", "PING\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString failed: %v", err)
+		}
+		if line != expected {
+			t.Errorf("Expected %q, got %q", expected, line)
+		}
+	}
+
+	// Closing the hijacked connection ends it; no HTTP response follows
+	if extra, err := reader.ReadString('\n'); err != io.EOF {
+		t.Errorf("Expected the connection to close, got %q (%v)", extra, err)
+	}
+}
+
+func TestHijackErrors(t *testing.T) {
+	t.Run("twice", func(t *testing.T) {
+		errs := make(chan error, 1)
+		server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+			conn, _, _ := Hijack(req)
+			_, _, err := Hijack(req)
+			errs <- err
+			conn.Close()
+			return nil
+		})
+		conn, _ := dialTestServer(t, server)
+		io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), ErrAlreadyHijacked) {
+			t.Errorf("Expected %q, got %v", ErrAlreadyHijacked, err)
+		}
+	})
+
+	t.Run("after the handler returns", func(t *testing.T) {
+		requests := make(chan pkghttp.Request, 1)
+		server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+			requests <- req
+			return okHandler(req)
+		})
+		conn, reader := dialTestServer(t, server)
+
+		roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		if _, _, err := Hijack(<-requests); err == nil {
+			t.Error("Expected hijacking to fail once the response was written")
+		}
+	})
+
+	t.Run("outside the server", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+		if _, _, err := Hijack(req); err == nil || !strings.Contains(err.Error(), ErrHijackUnavailable) {
+			t.Errorf("Expected %q, got %v", ErrHijackUnavailable, err)
+		}
+	})
+}
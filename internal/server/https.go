@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// withTLSState records the TLS state of conn in the context of req, when
+// conn runs over TLS
+func withTLSState(req pkghttp.Request, conn pkgtcp.Connection) {
+	tlsConn, ok := conn.(pkgtcp.TLSConnection)
+	if !ok {
+		return
+	}
+	if state, ok := tlsConn.TLSState(); ok {
+		req.SetContext(context.WithValue(req.Context(), tlsContextKey, &state))
+	}
+}
+
+// TLSState returns the TLS state of the connection req arrived on, or nil
+// for a plain connection
+func TLSState(req pkghttp.Request) *tls.ConnectionState {
+	state, _ := req.Context().Value(tlsContextKey).(*tls.ConnectionState)
+	return state
+}
+
+// IsTLS reports whether req arrived on a TLS connection
+func IsTLS(req pkghttp.Request) bool {
+	return TLSState(req) != nil
+}
+
+// isSecureRequest reports whether req reached the server over TLS, directly
+// or, when trustForwardedProto is set, through a proxy that says so in
+// X-Forwarded-Proto
+func isSecureRequest(req pkghttp.Request, trustForwardedProto bool) bool {
+	if IsTLS(req) {
+		return true
+	}
+	if !trustForwardedProto {
+		return false
+	}
+
+	// The proxy nearest the client appends first
+	proto, _, _ := strings.Cut(req.GetHeader(pkghttp.HeaderXForwardedProto), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), pkghttp.SchemeHTTPS)
+}
+
+// HSTSConfig configures the Strict-Transport-Security header (RFC 6797)
+type HSTSConfig struct {
+	// MaxAge is how long browsers remember to use HTTPS only; zero means
+	// DefaultHSTSMaxAge. It is sent in whole seconds.
+	MaxAge time.Duration
+
+	// IncludeSubDomains extends the policy to every subdomain
+	IncludeSubDomains bool
+
+	// Preload asks for inclusion in browser preload lists, which requires
+	// IncludeSubDomains and a MaxAge of at least a year
+	Preload bool
+
+	// TrustForwardedProto treats requests with X-Forwarded-Proto: https as
+	// secure, for servers behind a proxy that terminates TLS
+	TrustForwardedProto bool
+}
+
+// String returns the Strict-Transport-Security header value
+func (c HSTSConfig) String() string {
+	maxAge := c.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultHSTSMaxAge
+	}
+
+	value := hstsDirectiveMaxAge + "=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if c.IncludeSubDomains {
+		value += "; " + hstsDirectiveIncludeSubDomains
+	}
+	if c.Preload {
+		value += "; " + hstsDirectivePreload
+	}
+	return value
+}
+
+// HSTS returns middleware that sets Strict-Transport-Security on responses
+// to secure requests. Plain HTTP responses never carry it, since an attacker
+// could forge them (RFC 6797 section 7.2).
+func HSTS(config HSTSConfig) pkghttp.MiddlewareFunc {
+	value := config.String()
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			resp := next(req)
+			if resp != nil && isSecureRequest(req, config.TrustForwardedProto) {
+				resp.SetHeader(pkghttp.HeaderStrictTransportSecurity, value)
+			}
+			return resp
+		}
+	}
+}
+
+// HTTPSRedirectConfig configures the redirect from plain HTTP to HTTPS
+type HTTPSRedirectConfig struct {
+	// Host replaces the request's Host in redirect targets. Without it the
+	// Host header is used, so set it when clients can reach the server by
+	// names it should not redirect to.
+	Host string
+
+	// Port is the HTTPS port of the target; zero means the default port 443
+	Port int
+
+	// TrustForwardedProto treats requests with X-Forwarded-Proto: https as
+	// secure, for servers behind a proxy that terminates TLS
+	TrustForwardedProto bool
+}
+
+// HTTPSRedirectHandler returns a handler answering every request with a 301
+// to the https:// URL of the same path and query, for a plain HTTP listener
+// that only sends clients on
+func HTTPSRedirectHandler(config HTTPSRedirectConfig) pkghttp.RequestHandler {
+	return func(req pkghttp.Request) pkghttp.Response {
+		location, ok := config.location(req)
+		if !ok {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, ErrInvalidRedirectHost)
+		}
+		return internalhttp.BuildRedirectResponse(pkghttp.StatusMovedPermanently, location)
+	}
+}
+
+// RedirectToHTTPS returns middleware that passes secure requests on and
+// redirects the rest as HTTPSRedirectHandler does
+func RedirectToHTTPS(config HTTPSRedirectConfig) pkghttp.MiddlewareFunc {
+	redirect := HTTPSRedirectHandler(config)
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			if isSecureRequest(req, config.TrustForwardedProto) {
+				return next(req)
+			}
+			return redirect(req)
+		}
+	}
+}
+
+// location returns the https:// equivalent of the request URL
+func (c HTTPSRedirectConfig) location(req pkghttp.Request) (string, bool) {
+	host := c.Host
+	if host == "" {
+		host = req.GetHeader(pkghttp.HeaderHost)
+	}
+	if host == "" || strings.ContainsAny(host, "/\\@?# \t") {
+		return "", false
+	}
+
+	// The HTTPS port replaces whatever port plain HTTP used
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	} else {
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+	if c.Port != 0 && c.Port != pkghttp.DefaultHTTPSPort {
+		host = net.JoinHostPort(host, strconv.Itoa(c.Port))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	target := req.Path()
+	if !strings.HasPrefix(target, "/") {
+		return "", false
+	}
+
+	return pkghttp.SchemeHTTPS + "://" + host + target, true
+}
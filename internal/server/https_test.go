@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// fakeTLSConnection reports a TLS state without performing a handshake
+type fakeTLSConnection struct {
+	pkgtcp.Connection
+}
+
+// TLSState returns an empty completed state
+func (fakeTLSConnection) TLSState() (tls.ConnectionState, bool) {
+	return tls.ConnectionState{HandshakeComplete: true}, true
+}
+
+// newHTTPSTestRequest builds a GET for path, marked as arriving over TLS when secure
+func newHTTPSTestRequest(path, host string, secure bool) pkghttp.Request {
+	req := pkghttp.NewRequest(pkghttp.MethodGet, path, pkghttp.Version11)
+	if host != "" {
+		req.SetHeader(pkghttp.HeaderHost, host)
+	}
+	if secure {
+		withTLSState(req, fakeTLSConnection{})
+	}
+	return req
+}
+
+func TestHSTS(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         HSTSConfig
+		secure         bool
+		forwardedProto string
+		expected       string
+	}{
+		{name: "default max-age", secure: true, expected: "max-age=31536000"},
+		{
+			name:     "all directives",
+			config:   HSTSConfig{MaxAge: 2 * 365 * 24 * time.Hour, IncludeSubDomains: true, Preload: true},
+			secure:   true,
+			expected: "max-age=63072000; includeSubDomains; preload",
+		},
+		{name: "plain HTTP", expected: ""},
+		{name: "untrusted forwarded proto", forwardedProto: "https", expected: ""},
+		{name: "trusted forwarded proto", config: HSTSConfig{TrustForwardedProto: true}, forwardedProto: "HTTPS, http", expected: "max-age=31536000"},
+		{name: "trusted forwarded plain proto", config: HSTSConfig{TrustForwardedProto: true}, forwardedProto: "http", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newHTTPSTestRequest("/", "example.com", tt.secure)
+			if tt.forwardedProto != "" {
+				req.SetHeader(pkghttp.HeaderXForwardedProto, tt.forwardedProto)
+			}
+
+			resp := HSTS(tt.config)(okHandler)(req)
+			if got := resp.GetHeader(pkghttp.HeaderStrictTransportSecurity); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name             string
+		config           HTTPSRedirectConfig
+		path             string
+		host             string
+		secure           bool
+		expectedStatus   pkghttp.StatusCode
+		expectedLocation string
+	}{
+		{name: "secure passes", path: "/a", host: "example.com", secure: true, expectedStatus: pkghttp.StatusOK},
+		{name: "path and query kept", path: "/a/b?x=1&y=2", host: "example.com", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "https://example.com/a/b?x=1&y=2"},
+		{name: "plain port dropped", path: "/", host: "example.com:8080", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "https://example.com/"},
+		{name: "configured port", config: HTTPSRedirectConfig{Port: 8443}, path: "/", host: "example.com:8080", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "https://example.com:8443/"},
+		{name: "IPv6 host", path: "/", host: "[::1]:8080", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "https://[::1]/"},
+		{name: "configured host", config: HTTPSRedirectConfig{Host: "www.example.com"}, path: "/", host: "evil.example", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "https://www.example.com/"},
+		{name: "missing host", path: "/", expectedStatus: pkghttp.StatusBadRequest},
+		{name: "host with path", path: "/", host: "evil.example/x", expectedStatus: pkghttp.StatusBadRequest},
+		{name: "asterisk target", path: "*", host: "example.com", expectedStatus: pkghttp.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := RedirectToHTTPS(tt.config)(okHandler)(newHTTPSTestRequest(tt.path, tt.host, tt.secure))
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if got := resp.GetHeader(pkghttp.HeaderLocation); got != tt.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
+
+func TestIsTLS(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		if IsTLS(req) {
+			return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "tls")
+		}
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "plain")
+	})
+	conn, reader := dialTestServer(t, server)
+
+	_, body := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if body != "plain" {
+		t.Errorf("Expected a plain connection, got %q", body)
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// interimWriter sends 1xx responses on a connection while its handler runs
+type interimWriter struct {
+	w    *bufio.Writer
+	done bool
+	mu   sync.Mutex
+}
+
+// write sends one interim response and flushes it to the client
+func (iw *interimWriter) write(status pkghttp.StatusCode, header pkghttp.Header) error {
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+
+	if iw.done {
+		return common.HTTPError(ErrInterimUnavailable)
+	}
+
+	resp := pkghttp.NewResponse(status, pkghttp.Version11)
+	for _, name := range header.OrderedNames(nil) {
+		for _, value := range header[name] {
+			resp.AddHeader(name, value)
+		}
+	}
+	// A 1xx response ends at its blank line, so it must not announce a body
+	resp.Headers().Del(pkghttp.HeaderContentLength)
+	resp.Headers().Del(pkghttp.HeaderTransferEncoding)
+
+	if err := internalhttp.WriteResponseHead(iw.w, resp); err != nil {
+		return err
+	}
+	return iw.w.Flush()
+}
+
+// finish stops further interim responses once the final response is due
+func (iw *interimWriter) finish() {
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	iw.done = true
+}
+
+// withInterimWriter lets handlers of req send interim responses on w. HTTP/1.0
+// clients do not understand 1xx responses, so they get none.
+func withInterimWriter(req pkghttp.Request, w *bufio.Writer) *interimWriter {
+	if req.Version() == pkghttp.Version10 {
+		return nil
+	}
+
+	iw := &interimWriter{w: w}
+	req.SetContext(context.WithValue(req.Context(), interimContextKey, iw))
+	return iw
+}
+
+// continueReader sends 100 Continue before the first read of a body whose
+// client waits for it (Expect: 100-continue), so a handler that rejects the
+// request without reading the body spares the client from sending it
+type continueReader struct {
+	io.ReadCloser
+	iw   *interimWriter
+	sent atomic.Bool
+}
+
+// Read asks the client for the body on first use, then reads it
+func (cr *continueReader) Read(p []byte) (int, error) {
+	if cr.sent.CompareAndSwap(false, true) {
+		if err := cr.iw.write(pkghttp.StatusContinue, nil); err != nil {
+			return 0, err
+		}
+	}
+	return cr.ReadCloser.Read(p)
+}
+
+// withContinue replaces the body of a request expecting 100-continue with a
+// continueReader. It returns nil when the client is not waiting: the request
+// has no body, no such expectation or cannot receive interim responses.
+func withContinue(req pkghttp.Request, iw *interimWriter) *continueReader {
+	if iw == nil || req.Body() == nil || !expectsContinue(req) {
+		return nil
+	}
+
+	cr := &continueReader{ReadCloser: req.Body(), iw: iw}
+	req.SetBody(cr)
+	return cr
+}
+
+// asked reports whether the client was sent 100 Continue. A nil reader
+// means the client sends its body unasked.
+func (cr *continueReader) asked() bool {
+	return cr == nil || cr.sent.Load()
+}
+
+// expectsContinue reports whether req carries Expect: 100-continue
+func expectsContinue(req pkghttp.Request) bool {
+	for _, line := range req.GetHeaders(pkghttp.HeaderExpect) {
+		for _, expectation := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(expectation), pkghttp.ExpectContinue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteInterimResponse sends a 1xx informational response, such as 102 Processing,
+// ahead of the final response to req. It fails for statuses outside 1xx, for
+// 101 (see Upgrade), for HTTP/1.0 requests and once the handler has returned.
+func WriteInterimResponse(req pkghttp.Request, status pkghttp.StatusCode, header pkghttp.Header) error {
+	if !internalhttp.IsInterimStatus(status) {
+		return common.InvalidInputError(ErrInvalidInterimStatus)
+	}
+
+	iw, ok := req.Context().Value(interimContextKey).(*interimWriter)
+	if !ok {
+		return common.HTTPError(ErrInterimUnavailable)
+	}
+	return iw.write(status, header)
+}
+
+// WriteEarlyHints sends 103 Early Hints with a Link header per link, letting the
+// client preload resources while the final response is prepared
+func WriteEarlyHints(req pkghttp.Request, links ...string) error {
+	header := pkghttp.Header{}
+	for _, link := range links {
+		header.Add(pkghttp.HeaderLink, link)
+	}
+	return WriteInterimResponse(req, pkghttp.StatusEarlyHints, header)
+}
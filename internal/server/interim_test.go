@@ -0,0 +1,158 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestWriteInterimResponse(t *testing.T) {
+	errs := make(chan error, 4)
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		errs <- WriteInterimResponse(req, pkghttp.StatusProcessing, nil)
+		errs <- WriteEarlyHints(req, "</style.css>; rel=preload; as=style", "</app.js>; rel=preload; as=script")
+		errs <- WriteInterimResponse(req, pkghttp.StatusOK, nil)
+		errs <- WriteInterimResponse(req, pkghttp.StatusSwitchingProtocols, nil)
+		return okHandler(req)
+	})
+	conn, reader := dialTestServer(t, server)
+
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var head []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString failed: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "HTTP/1.1 200") {
+			break
+		}
+		head = append(head, line)
+	}
+
+	expected := []string{
+		"HTTP/1.1 102 Processing", "",
+		"HTTP/1.1 103 Early Hints",
+		"Link: </style.css>; rel=preload; as=style",
+		"Link: </app.js>; rel=preload; as=script", "",
+	}
+	if strings.Join(head, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected interim responses:\n%s", strings.Join(head, "\n"))
+	}
+
+	for i, wantErr := range []bool{false, false, true, true} {
+		if err := <-errs; (err != nil) != wantErr {
+			t.Errorf("Call %d: expected error %v, got %v", i, wantErr, err)
+		}
+	}
+}
+
+func TestWriteInterimResponseUnavailable(t *testing.T) {
+	t.Run("HTTP/1.0", func(t *testing.T) {
+		var hintErr error
+		server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+			hintErr = WriteEarlyHints(req, "</style.css>; rel=preload")
+			return okHandler(req)
+		})
+		conn, reader := dialTestServer(t, server)
+
+		resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.0\r\n\r\n")
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode())
+		}
+		if hintErr == nil {
+			t.Error("Expected interim responses to be refused for HTTP/1.0")
+		}
+	})
+
+	t.Run("after the handler returns", func(t *testing.T) {
+		requests := make(chan pkghttp.Request, 1)
+		server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+			requests <- req
+			return okHandler(req)
+		})
+		conn, reader := dialTestServer(t, server)
+
+		roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		if err := WriteInterimResponse(<-requests, pkghttp.StatusProcessing, nil); err == nil {
+			t.Error("Expected an error once the final response was written")
+		}
+	})
+
+	t.Run("outside the server", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+		err := WriteInterimResponse(req, pkghttp.StatusProcessing, nil)
+		if err == nil || !strings.Contains(err.Error(), ErrInterimUnavailable) {
+			t.Errorf("Expected %q, got %v", ErrInterimUnavailable, err)
+		}
+	})
+}
+
+func TestServerInterimResponsesReachClient(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		WriteEarlyHints(req, "</style.css>; rel=preload")
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "final")
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusOK || body != "final" {
+		t.Errorf("Expected the final response, got %d %q", resp.StatusCode(), body)
+	}
+	if internalhttp.IsInterimStatus(resp.StatusCode()) {
+		t.Error("ReadResponse returned an interim response")
+	}
+}
+
+func TestServerSendsContinue(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		if req.Path() == "/reject" {
+			return pkghttp.NewTextResponse(pkghttp.StatusForbidden, pkghttp.Version11, "no")
+		}
+		body, _ := io.ReadAll(req.Body())
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, string(body))
+	})
+
+	t.Run("continue before the body is read", func(t *testing.T) {
+		conn, reader := dialTestServer(t, server)
+
+		head := "POST /echo HTTP/1.1\r\nHost: localhost\r\nExpect: 100-continue\r\nContent-Length: 2\r\n\r\n"
+		if _, err := io.WriteString(conn, head); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+
+		// The body is held back until the server asks for it
+		status, err := reader.ReadString('\n')
+		if err != nil || !strings.HasPrefix(status, "HTTP/1.1 100 ") {
+			t.Fatalf("Expected 100 Continue first, got %q (%v)", status, err)
+		}
+		if blank, err := reader.ReadString('\n'); err != nil || blank != "\r\n" {
+			t.Fatalf("Expected the interim response to end, got %q (%v)", blank, err)
+		}
+
+		resp, body := roundTrip(t, conn, reader, "hi")
+		if resp.StatusCode() != pkghttp.StatusOK || body != "hi" {
+			t.Errorf("Expected the echoed body, got %d %q", resp.StatusCode(), body)
+		}
+	})
+
+	t.Run("no continue when the body is not read", func(t *testing.T) {
+		conn, reader := dialTestServer(t, server)
+
+		head := "POST /reject HTTP/1.1\r\nHost: localhost\r\nExpect: 100-continue\r\nContent-Length: 2\r\n\r\n"
+		resp, _ := roundTrip(t, conn, reader, head)
+		if resp.StatusCode() != pkghttp.StatusForbidden {
+			t.Errorf("Expected 403 without 100 Continue, got %d", resp.StatusCode())
+		}
+		if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionClose {
+			t.Errorf("Expected the connection to close, got %q", resp.GetHeader(pkghttp.HeaderConnection))
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// RPCError is a JSON-RPC 2.0 error object. Methods return one to choose the
+// code their callers see; other errors become RPCInternalError.
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// rpcRequest is a request object as sent by the client. An absent ID marks
+// a notification, which gets no response; an explicit null is kept as "null".
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcResponse is a response object
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcMethod is a registered Go function
+type rpcMethod struct {
+	fn     reflect.Value
+	params reflect.Type
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RPCServer maps JSON-RPC 2.0 requests, single or batched, to registered Go
+// functions (https://www.jsonrpc.org/specification)
+type RPCServer struct {
+	methods map[string]rpcMethod
+	mu      sync.RWMutex
+}
+
+// NewRPCServer creates a server without methods
+func NewRPCServer() *RPCServer {
+	return &RPCServer{methods: make(map[string]rpcMethod)}
+}
+
+// Register exposes fn as the method name. fn must have the form
+// func(context.Context, P) (R, error) or func(context.Context) (R, error);
+// params are decoded from JSON into P, and R is encoded as the result. The
+// context is that of the HTTP request.
+func (s *RPCServer) Register(name string, fn interface{}) error {
+	value := reflect.ValueOf(fn)
+	fnType := value.Type()
+	if fnType.Kind() != reflect.Func || name == "" ||
+		fnType.NumIn() < 1 || fnType.NumIn() > 2 || fnType.In(0) != contextType ||
+		fnType.NumOut() != 2 || fnType.Out(1) != errorType {
+		return common.InvalidInputError(ErrInvalidRPCMethod + ": " + name)
+	}
+
+	method := rpcMethod{fn: value}
+	if fnType.NumIn() == 2 {
+		method.params = fnType.In(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = method
+	return nil
+}
+
+// ServeRequest answers a JSON-RPC POST. Responses use status 200 whatever
+// their errors; a request made only of notifications gets 204 No Content.
+func (s *RPCServer) ServeRequest(req pkghttp.Request) pkghttp.Response {
+	if req.Method() != pkghttp.MethodPost {
+		resp := internalhttp.BuildErrorResponse(pkghttp.StatusMethodNotAllowed, "")
+		resp.SetHeader(pkghttp.HeaderAllow, string(pkghttp.MethodPost))
+		return resp
+	}
+
+	body, err := readLimitedBody(req, ErrInvalidJSON)
+	if err != nil {
+		return BindErrorResponse(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	if len(body) == 0 || body[0] != '[' {
+		resp, ok := s.call(req.Context(), body)
+		if !ok {
+			return pkghttp.NewResponse(pkghttp.StatusNoContent, pkghttp.Version11)
+		}
+		return rpcJSONResponse(resp)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return rpcJSONResponse(rpcErrorResponse(nil, RPCParseError, ErrRPCParse))
+	}
+	if len(batch) == 0 {
+		return rpcJSONResponse(rpcErrorResponse(nil, RPCInvalidRequest, ErrRPCInvalidRequest))
+	}
+
+	var responses []rpcResponse
+	for _, raw := range batch {
+		if resp, ok := s.call(req.Context(), raw); ok {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return pkghttp.NewResponse(pkghttp.StatusNoContent, pkghttp.Version11)
+	}
+	return rpcJSONResponse(responses)
+}
+
+// call runs one request object, reporting false for a notification, whose
+// outcome is not sent back
+func (s *RPCServer) call(ctx context.Context, raw json.RawMessage) (rpcResponse, bool) {
+	if !json.Valid(raw) {
+		return rpcErrorResponse(nil, RPCParseError, ErrRPCParse), true
+	}
+	// Valid JSON that is not a request object, such as a number in a batch
+	var request rpcRequest
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return rpcErrorResponse(nil, RPCInvalidRequest, ErrRPCInvalidRequest), true
+	}
+
+	if !validRPCID(request.ID) {
+		return rpcErrorResponse(nil, RPCInvalidRequest, ErrRPCInvalidRequest), true
+	}
+	notification := len(request.ID) == 0
+	if request.JSONRPC != rpcVersion || request.Method == "" {
+		return rpcErrorResponse(request.ID, RPCInvalidRequest, ErrRPCInvalidRequest), true
+	}
+
+	s.mu.RLock()
+	method, ok := s.methods[request.Method]
+	s.mu.RUnlock()
+	if !ok {
+		return rpcErrorResponse(request.ID, RPCMethodNotFound, ErrRPCMethodNotFound), !notification
+	}
+
+	result, rpcErr := method.invoke(ctx, request.Params)
+	if notification {
+		return rpcResponse{}, false
+	}
+	if rpcErr != nil {
+		return rpcResponse{JSONRPC: rpcVersion, Error: rpcErr, ID: request.ID}, true
+	}
+	return rpcResponse{JSONRPC: rpcVersion, Result: result, ID: request.ID}, true
+}
+
+// invoke decodes params, calls the method and encodes its result
+func (m rpcMethod) invoke(ctx context.Context, params json.RawMessage) (result json.RawMessage, rpcErr *RPCError) {
+	args := []reflect.Value{reflect.ValueOf(ctx)}
+	if m.params != nil {
+		arg := reflect.New(m.params)
+		if len(params) > 0 {
+			if params[0] != '{' && params[0] != '[' {
+				return nil, &RPCError{Code: RPCInvalidParams, Message: ErrRPCInvalidParams}
+			}
+			if err := json.Unmarshal(params, arg.Interface()); err != nil {
+				return nil, &RPCError{Code: RPCInvalidParams, Message: ErrRPCInvalidParams}
+			}
+		}
+		args = append(args, arg.Elem())
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, rpcErr = nil, &RPCError{Code: RPCInternalError, Message: ErrRPCInternal}
+		}
+	}()
+
+	out := m.fn.Call(args)
+	if err, _ := out[1].Interface().(error); err != nil {
+		if methodErr, ok := err.(*RPCError); ok {
+			return nil, methodErr
+		}
+		return nil, &RPCError{Code: RPCInternalError, Message: err.Error()}
+	}
+
+	data, err := json.Marshal(out[0].Interface())
+	if err != nil {
+		return nil, &RPCError{Code: RPCInternalError, Message: ErrRPCInternal}
+	}
+	return data, nil
+}
+
+// validRPCID reports whether id is absent, or a string, number or null
+func validRPCID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+// rpcErrorResponse builds an error response object. A nil id becomes null,
+// for requests whose ID could not be read.
+func rpcErrorResponse(id json.RawMessage, code int, message string) rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage(rpcNullID)
+	}
+	return rpcResponse{JSONRPC: rpcVersion, Error: &RPCError{Code: code, Message: message}, ID: id}
+}
+
+// rpcJSONResponse encodes response objects as the HTTP response
+func rpcJSONResponse(v interface{}) pkghttp.Response {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return internalhttp.BuildJSONErrorResponse(pkghttp.StatusInternalServerError, "")
+	}
+	return pkghttp.NewJSONResponse(pkghttp.StatusOK, pkghttp.Version11, string(data))
+}
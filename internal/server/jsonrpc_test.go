@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestRPCServer(t *testing.T) {
+	type addParams struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+
+	notified := make(chan string, 4)
+	rpc := NewRPCServer()
+	for name, fn := range map[string]interface{}{
+		"add": func(ctx context.Context, p addParams) (int, error) { return p.A + p.B, nil },
+		"sum": func(ctx context.Context, values []int) (int, error) {
+			total := 0
+			for _, v := range values {
+				total += v
+			}
+			return total, nil
+		},
+		"ping":   func(ctx context.Context) (string, error) { return "pong", nil },
+		"notify": func(ctx context.Context, msg []string) (interface{}, error) { notified <- msg[0]; return nil, nil },
+		"fail": func(ctx context.Context) (interface{}, error) {
+			return nil, &RPCError{Code: 42, Message: "custom", Data: "detail"}
+		},
+		"broken": func(ctx context.Context) (interface{}, error) { return nil, errors.New("disk full") },
+		"panics": func(ctx context.Context) (interface{}, error) { panic("boom") },
+	} {
+		if err := rpc.Register(name, fn); err != nil {
+			t.Fatalf("Register %s failed: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		status   pkghttp.StatusCode
+		expected string
+	}{
+		{name: "named params", body: `{"jsonrpc":"2.0","method":"add","params":{"a":2,"b":3},"id":1}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","result":5,"id":1}`},
+		{name: "positional params", body: `{"jsonrpc":"2.0","method":"sum","params":[1,2,3],"id":"a"}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","result":6,"id":"a"}`},
+		{name: "no params", body: `{"jsonrpc":"2.0","method":"ping","id":null}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","result":"pong","id":null}`},
+		{name: "nil result", body: `{"jsonrpc":"2.0","method":"notify","params":["hi"],"id":2}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","result":null,"id":2}`},
+		{name: "notification", body: `{"jsonrpc":"2.0","method":"notify","params":["quiet"]}`, status: pkghttp.StatusNoContent},
+		{name: "parse error", body: `{"jsonrpc":"2.0","method"`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{name: "wrong version", body: `{"jsonrpc":"1.0","method":"ping","id":3}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":3}`},
+		{name: "object id", body: `{"jsonrpc":"2.0","method":"ping","id":{}}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{name: "unknown method", body: `{"jsonrpc":"2.0","method":"nope","id":4}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":4}`},
+		{name: "unstructured params", body: `{"jsonrpc":"2.0","method":"notify","params":"hi","id":5}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":5}`},
+		{name: "invalid params", body: `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":5}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":5}`},
+		{name: "method error", body: `{"jsonrpc":"2.0","method":"fail","id":6}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":42,"message":"custom","data":"detail"},"id":6}`},
+		{name: "plain error", body: `{"jsonrpc":"2.0","method":"broken","id":7}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"disk full"},"id":7}`},
+		{name: "panic", body: `{"jsonrpc":"2.0","method":"panics","id":8}`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":8}`},
+		{
+			name:     "batch",
+			body:     `[{"jsonrpc":"2.0","method":"ping","id":1},{"jsonrpc":"2.0","method":"notify","params":["batched"]},1,{"jsonrpc":"2.0","method":"nope","id":2}]`,
+			status:   pkghttp.StatusOK,
+			expected: `[{"jsonrpc":"2.0","result":"pong","id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2}]`,
+		},
+		{name: "empty batch", body: `[]`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{name: "invalid batch", body: `[{"jsonrpc":"2.0"`, status: pkghttp.StatusOK, expected: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{name: "batch of notifications", body: `[{"jsonrpc":"2.0","method":"notify","params":["n1"]}]`, status: pkghttp.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := rpc.ServeRequest(newJSONRequest(pkghttp.MimeTypeJSON, tt.body))
+			if resp.StatusCode() != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode())
+			}
+			if tt.expected == "" {
+				return
+			}
+
+			body := readResponseBody(t, resp)
+			if !jsonEqual(t, body, tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, body)
+			}
+		})
+	}
+
+	if got := len(notified); got != 4 {
+		t.Errorf("Expected 4 notify calls, got %d", got)
+	}
+}
+
+func TestRPCServerRegister(t *testing.T) {
+	tests := []struct {
+		name  string
+		fn    interface{}
+		valid bool
+	}{
+		{name: "with params", fn: func(context.Context, int) (int, error) { return 0, nil }, valid: true},
+		{name: "without params", fn: func(context.Context) (int, error) { return 0, nil }, valid: true},
+		{name: "not a function", fn: 5},
+		{name: "no context", fn: func(int) (int, error) { return 0, nil }},
+		{name: "no error", fn: func(context.Context) int { return 0 }},
+		{name: "too many params", fn: func(context.Context, int, int) (int, error) { return 0, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRPCServer().Register("method", tt.fn)
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestRPCServerMethodNotAllowed(t *testing.T) {
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/rpc", pkghttp.Version11)
+	resp := NewRPCServer().ServeRequest(req)
+	if resp.StatusCode() != pkghttp.StatusMethodNotAllowed || resp.GetHeader(pkghttp.HeaderAllow) != "POST" {
+		t.Errorf("Expected 405 allowing POST, got %d %q", resp.StatusCode(), resp.GetHeader(pkghttp.HeaderAllow))
+	}
+}
+
+// jsonEqual reports whether two JSON documents hold the same values
+func jsonEqual(t *testing.T, got, expected string) bool {
+	t.Helper()
+
+	var gotValue, expectedValue interface{}
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", expected, err)
+	}
+	return reflect.DeepEqual(gotValue, expectedValue)
+}
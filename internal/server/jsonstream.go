@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// JSONStream sends newline-delimited JSON values to one client
+type JSONStream struct {
+	stream *StreamWriter
+	mu     sync.Mutex
+}
+
+// Send encodes v on its own line and flushes it to the client. A value that
+// cannot be encoded is reported without writing anything.
+func (s *JSONStream) Send(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return common.InvalidInputErrorWithCause(ErrInvalidStreamItem, err)
+	}
+
+	if _, err := s.stream.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.stream.Flush()
+}
+
+// Done is closed when the client goes away
+func (s *JSONStream) Done() <-chan struct{} {
+	return s.stream.Done()
+}
+
+// StreamJSON creates an application/x-ndjson response whose values produce
+// sends in its own goroutine, each flushed as soon as it is written, for long
+// result sets or log tailing. The stream ends when produce returns; produce
+// should return once Done is closed or Send fails.
+func StreamJSON(produce func(*JSONStream)) pkghttp.Response {
+	resp := NewStreamResponse(pkghttp.StatusOK, func(w *StreamWriter) {
+		produce(&JSONStream{stream: w})
+	})
+	resp.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeNDJSON)
+	return resp
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestStreamJSON(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	release := make(chan struct{})
+	sendErr := make(chan error, 1)
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return StreamJSON(func(s *JSONStream) {
+			s.Send(item{ID: 1})
+			// The first line must reach the client before the producer continues
+			<-release
+			sendErr <- s.Send(func() {})
+			s.Send(item{ID: 2})
+		})
+	})
+	conn, reader := dialTestServer(t, server)
+
+	if _, err := io.WriteString(conn, "GET /items HTTP/1.1\r\nHost: localhost\r\n\r\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	resp, err := internalhttp.ReadResponse(reader)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+	if resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeNDJSON {
+		t.Errorf("Expected Content-Type %s, got %s", pkghttp.MimeTypeNDJSON, resp.GetHeader(pkghttp.HeaderContentType))
+	}
+
+	lines := bufio.NewScanner(resp.Body())
+	for _, expected := range []int{1, 2} {
+		if !lines.Scan() {
+			t.Fatalf("Expected item %d, stream ended: %v", expected, lines.Err())
+		}
+
+		var got item
+		if err := json.Unmarshal(lines.Bytes(), &got); err != nil {
+			t.Fatalf("Line %q is not JSON: %v", lines.Text(), err)
+		}
+		if got.ID != expected {
+			t.Errorf("Expected item %d, got %d", expected, got.ID)
+		}
+
+		if expected == 1 {
+			close(release)
+			if err := <-sendErr; err == nil {
+				t.Error("Expected an error sending a value that cannot be encoded")
+			}
+		}
+	}
+
+	if lines.Scan() {
+		t.Errorf("Unexpected extra line %q", lines.Text())
+	}
+}
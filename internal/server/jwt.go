@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// Claims holds the decoded payload of a JSON Web Token
+type Claims map[string]interface{}
+
+// Subject returns the "sub" claim
+func (c Claims) Subject() string {
+	subject, _ := c[ClaimSubject].(string)
+	return subject
+}
+
+// Issuer returns the "iss" claim
+func (c Claims) Issuer() string {
+	issuer, _ := c[ClaimIssuer].(string)
+	return issuer
+}
+
+// timeClaim returns a NumericDate claim as a time. It reports false when the
+// claim is absent and an error when it is present but not a number.
+func (c Claims) timeClaim(name string) (time.Time, bool, error) {
+	raw, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		return time.Time{}, false, common.InvalidInputError(ErrInvalidTimeClaim + ": " + name)
+	}
+	return time.Unix(int64(value), 0), true, nil
+}
+
+// JWTConfig configures bearer token verification
+type JWTConfig struct {
+	// Secret is the shared HMAC key
+	Secret []byte
+
+	// Issuer, when set, must match the "iss" claim
+	Issuer string
+
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+
+	// Realm is advertised in WWW-Authenticate challenges
+	Realm string
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// jwtHeader is the JOSE header of a compact JWT
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// BearerAuth returns middleware that verifies HMAC-signed JWT bearer tokens
+// and stores the verified claims in the request context
+func BearerAuth(config JWTConfig) pkghttp.MiddlewareFunc {
+	realm := config.Realm
+	if realm == "" {
+		realm = DefaultAuthRealm
+	}
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			token, ok := cutAuthScheme(req.GetHeader(pkghttp.HeaderAuthorization), AuthSchemeBearer)
+			if !ok {
+				return unauthorizedResponse(fmt.Sprintf("%s realm=%q", AuthSchemeBearer, realm))
+			}
+
+			claims, err := VerifyJWT(token, config)
+			if err != nil {
+				return unauthorizedResponse(fmt.Sprintf("%s realm=%q, error=\"invalid_token\"", AuthSchemeBearer, realm))
+			}
+
+			req.SetContext(context.WithValue(req.Context(), claimsContextKey, claims))
+			return next(req)
+		}
+	}
+}
+
+// ClaimsFromRequest returns the claims stored by BearerAuth
+func ClaimsFromRequest(req pkghttp.Request) (Claims, bool) {
+	claims, ok := req.Context().Value(claimsContextKey).(Claims)
+	return claims, ok
+}
+
+// VerifyJWT checks the signature and time-based claims of a compact JWT
+func VerifyJWT(token string, config JWTConfig) (Claims, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != jwtSegmentCount {
+		return nil, common.InvalidInputError(ErrMalformedToken)
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(segments[0], &header); err != nil {
+		return nil, err
+	}
+
+	newHash, ok := jwtHashFunc(header.Algorithm)
+	if !ok {
+		return nil, common.InvalidInputError(ErrUnsupportedAlgorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, common.InvalidInputErrorWithCause(ErrMalformedToken, err)
+	}
+
+	expected := signJWTInput(newHash, config.Secret, segments[0]+"."+segments[1])
+	if !hmac.Equal(signature, expected) {
+		return nil, common.InvalidInputError(ErrInvalidSignature)
+	}
+
+	var claims Claims
+	if err := decodeJWTSegment(segments[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := validateJWTClaims(claims, config); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// SignJWT produces a compact JWT for the given claims using the named HMAC algorithm
+func SignJWT(claims Claims, algorithm string, secret []byte) (string, error) {
+	newHash, ok := jwtHashFunc(algorithm)
+	if !ok {
+		return "", common.InvalidInputError(ErrUnsupportedAlgorithm)
+	}
+
+	headerJSON, err := json.Marshal(jwtHeader{Algorithm: algorithm, Type: jwtTokenType})
+	if err != nil {
+		return "", common.InvalidInputErrorWithCause(ErrMalformedToken, err)
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", common.InvalidInputErrorWithCause(ErrMalformedToken, err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+	signature := signJWTInput(newHash, secret, signingInput)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// validateJWTClaims enforces exp, nbf and iss
+func validateJWTClaims(claims Claims, config JWTConfig) error {
+	now := time.Now
+	if config.Now != nil {
+		now = config.Now
+	}
+	current := now()
+
+	expiresAt, ok, err := claims.timeClaim(ClaimExpiresAt)
+	if err != nil {
+		return err
+	}
+	if ok && !current.Before(expiresAt.Add(config.Leeway)) {
+		return common.InvalidInputError(ErrTokenExpired)
+	}
+
+	notBefore, ok, err := claims.timeClaim(ClaimNotBefore)
+	if err != nil {
+		return err
+	}
+	if ok && current.Add(config.Leeway).Before(notBefore) {
+		return common.InvalidInputError(ErrTokenNotYetValid)
+	}
+
+	if config.Issuer != "" && claims.Issuer() != config.Issuer {
+		return common.InvalidInputError(ErrInvalidIssuer)
+	}
+
+	return nil
+}
+
+// decodeJWTSegment decodes a base64url JSON segment into v
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return common.InvalidInputErrorWithCause(ErrMalformedToken, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return common.InvalidInputErrorWithCause(ErrMalformedToken, err)
+	}
+
+	return nil
+}
+
+// jwtHashFunc maps a JWS algorithm name to its hash constructor
+func jwtHashFunc(algorithm string) (func() hash.Hash, bool) {
+	switch algorithm {
+	case JWTAlgorithmHS256:
+		return sha256.New, true
+	case JWTAlgorithmHS384:
+		return sha512.New384, true
+	case JWTAlgorithmHS512:
+		return sha512.New, true
+	default:
+		return nil, false
+	}
+}
+
+// signJWTInput computes the HMAC of the signing input
+func signJWTInput(newHash func() hash.Hash, secret []byte, signingInput string) []byte {
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+var testJWTSecret = []byte("test-secret")
+
+func mustSignJWT(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := SignJWT(claims, JWTAlgorithmHS256, testJWTSecret)
+	if err != nil {
+		t.Fatalf("SignJWT failed: %v", err)
+	}
+	return token
+}
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	config := JWTConfig{
+		Secret: testJWTSecret,
+		Issuer: "tinyserver",
+		Now:    func() time.Time { return now },
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		wantErr string
+	}{
+		{
+			name: "valid token",
+			token: func(t *testing.T) string {
+				return mustSignJWT(t, Claims{ClaimIssuer: "tinyserver", ClaimSubject: "alice", ClaimExpiresAt: now.Add(time.Hour).Unix()})
+			},
+		},
+		{
+			name: "expired token",
+			token: func(t *testing.T) string {
+				return mustSignJWT(t, Claims{ClaimIssuer: "tinyserver", ClaimExpiresAt: now.Add(-time.Minute).Unix()})
+			},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "not yet valid",
+			token: func(t *testing.T) string {
+				return mustSignJWT(t, Claims{ClaimIssuer: "tinyserver", ClaimNotBefore: now.Add(time.Minute).Unix()})
+			},
+			wantErr: ErrTokenNotYetValid,
+		},
+		{
+			name: "expiry not a number",
+			token: func(t *testing.T) string {
+				return mustSignJWT(t, Claims{ClaimIssuer: "tinyserver", ClaimSubject: "alice", ClaimExpiresAt: "0"})
+			},
+			wantErr: ErrInvalidTimeClaim,
+		},
+		{
+			name: "null not before",
+			token: func(t *testing.T) string {
+				return mustSignJWT(t, Claims{ClaimIssuer: "tinyserver", ClaimSubject: "alice", ClaimNotBefore: nil})
+			},
+			wantErr: ErrInvalidTimeClaim,
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return mustSignJWT(t, Claims{ClaimIssuer: "someone-else"})
+			},
+			wantErr: ErrInvalidIssuer,
+		},
+		{
+			name: "tampered payload",
+			token: func(t *testing.T) string {
+				token := mustSignJWT(t, Claims{ClaimIssuer: "tinyserver"})
+				other := mustSignJWT(t, Claims{ClaimIssuer: "tinyserver", ClaimSubject: "root"})
+				parts := strings.Split(token, ".")
+				otherParts := strings.Split(other, ".")
+				return parts[0] + "." + otherParts[1] + "." + parts[2]
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "wrong secret",
+			token: func(t *testing.T) string {
+				token, _ := SignJWT(Claims{ClaimIssuer: "tinyserver"}, JWTAlgorithmHS256, []byte("other"))
+				return token
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "malformed token",
+			token:   func(t *testing.T) string { return "not-a-jwt" },
+			wantErr: ErrMalformedToken,
+		},
+		{
+			name: "unsupported algorithm",
+			token: func(t *testing.T) string {
+				// {"alg":"none"}
+				return "eyJhbGciOiJub25lIn0.e30."
+			},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := VerifyJWT(tt.token(t), config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if claims.Subject() != "alice" {
+					t.Errorf("Expected subject alice, got %s", claims.Subject())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTLeeway(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token := mustSignJWT(t, Claims{ClaimExpiresAt: now.Add(-10 * time.Second).Unix()})
+
+	config := JWTConfig{Secret: testJWTSecret, Leeway: 30 * time.Second, Now: func() time.Time { return now }}
+	if _, err := VerifyJWT(token, config); err != nil {
+		t.Errorf("Expected token within leeway to verify, got %v", err)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	config := JWTConfig{Secret: testJWTSecret}
+
+	var seen Claims
+	handler := BearerAuth(config)(func(req pkghttp.Request) pkghttp.Response {
+		seen, _ = ClaimsFromRequest(req)
+		return okHandler(req)
+	})
+
+	t.Run("valid token injects claims", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+		req.SetHeader(pkghttp.HeaderAuthorization, "Bearer "+mustSignJWT(t, Claims{ClaimSubject: "alice"}))
+
+		resp := handler(req)
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode())
+		}
+		if seen.Subject() != "alice" {
+			t.Errorf("Expected claims for alice, got %v", seen)
+		}
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		resp := handler(pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11))
+		if resp.StatusCode() != pkghttp.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode())
+		}
+		if got := resp.GetHeader(pkghttp.HeaderWWWAuthenticate); got != `Bearer realm="TinyServer"` {
+			t.Errorf("Unexpected challenge: %s", got)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+		req.SetHeader(pkghttp.HeaderAuthorization, "Bearer garbage")
+
+		resp := handler(req)
+		if resp.StatusCode() != pkghttp.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", resp.StatusCode())
+		}
+		if got := resp.GetHeader(pkghttp.HeaderWWWAuthenticate); !strings.Contains(got, `error="invalid_token"`) {
+			t.Errorf("Expected invalid_token challenge, got %s", got)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// MirrorConfig configures traffic mirroring
+type MirrorConfig struct {
+	// Upstream is the base URL of the shadow backend, such as "http://shadow:8080";
+	// the request path and query are appended to it
+	Upstream string
+
+	// Client sends the mirrored requests
+	Client pkghttp.Client
+
+	// Percentage of requests mirrored, from 0 to 100
+	Percentage float64
+
+	// Timeout bounds each mirrored exchange; zero means DefaultMirrorTimeout
+	Timeout time.Duration
+
+	// MaxInFlight caps mirrored requests still running, beyond which requests
+	// are not mirrored; zero means DefaultMirrorMaxInFlight
+	MaxInFlight int
+
+	// Random returns a number in [0, 1) to sample requests; defaults to rand.Float64
+	Random func() float64
+}
+
+// Mirror returns middleware that copies a share of requests, headers and
+// body, to a shadow upstream in the background. Mirror responses and errors
+// are discarded, so the shadow never affects the client. Bodies of mirrored
+// requests are buffered up to MaxRequestBodySize; longer ones are not mirrored.
+func Mirror(config MirrorConfig) pkghttp.MiddlewareFunc {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultMirrorTimeout
+	}
+	maxInFlight := config.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMirrorMaxInFlight
+	}
+	random := config.Random
+	if random == nil {
+		random = rand.Float64
+	}
+	upstream := strings.TrimSuffix(config.Upstream, "/")
+	inFlight := make(chan struct{}, maxInFlight)
+
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			if config.Client == nil || random()*100 >= config.Percentage || !strings.HasPrefix(req.Path(), "/") {
+				return next(req)
+			}
+
+			body, ok := bufferMirroredBody(req)
+			if !ok {
+				return next(req)
+			}
+
+			select {
+			case inFlight <- struct{}{}:
+				mirrored := mirroredRequest(req, upstream+req.Path(), body)
+				go func() {
+					defer func() { <-inFlight }()
+					sendMirrored(config.Client, mirrored, timeout)
+				}()
+			default:
+				// The shadow is falling behind; skip rather than queue
+			}
+
+			return next(req)
+		}
+	}
+}
+
+// bufferMirroredBody reads the request body so it can be sent twice, leaving
+// req with an equivalent body. It reports false for a body it could not
+// buffer, which the handler then reads as it would have.
+func bufferMirroredBody(req pkghttp.Request) ([]byte, bool) {
+	if req.Body() == nil {
+		return nil, true
+	}
+
+	original := req.Body()
+	data, err := io.ReadAll(io.LimitReader(original, pkghttp.MaxRequestBodySize+1))
+	if err != nil || int64(len(data)) > pkghttp.MaxRequestBodySize {
+		req.SetBody(&prefixedBody{Reader: io.MultiReader(bytes.NewReader(data), original), body: original})
+		return nil, false
+	}
+
+	req.SetBody(bytes.NewReader(data))
+	return data, true
+}
+
+// mirroredRequest copies req for target with its end-to-end headers and body
+func mirroredRequest(req pkghttp.Request, target string, body []byte) pkghttp.Request {
+	mirrored := pkghttp.NewRequest(req.Method(), target, req.Version())
+	for _, name := range req.HeaderNames() {
+		for _, value := range req.Headers()[name] {
+			mirrored.AddHeader(name, value)
+		}
+	}
+	removeHopByHopHeaders(mirrored.Headers())
+	deleteHeader(mirrored.Headers(), pkghttp.HeaderHost)
+
+	if req.Body() != nil {
+		deleteHeader(mirrored.Headers(), pkghttp.HeaderContentLength)
+		mirrored.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(len(body)))
+		mirrored.SetBody(bytes.NewReader(body))
+	}
+	return mirrored
+}
+
+// sendMirrored sends a mirrored request and discards the response
+func sendMirrored(client pkghttp.Client, req pkghttp.Request, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resp, err := client.DoWithContext(ctx, req)
+	if err != nil {
+		return
+	}
+	if body := resp.Body(); body != nil {
+		io.Copy(io.Discard, body)
+		body.Close()
+	}
+}
+
+// prefixedBody replays bytes already read ahead of the rest of a body
+type prefixedBody struct {
+	io.Reader
+	body io.Closer
+}
+
+// Close closes the underlying body
+func (b *prefixedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/client"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// mirroredCopy is what the shadow upstream received
+type mirroredCopy struct {
+	method pkghttp.Method
+	path   string
+	header string
+	body   string
+}
+
+func TestMirror(t *testing.T) {
+	received := make(chan mirroredCopy, 4)
+	shadow := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		data, _ := io.ReadAll(req.Body())
+		received <- mirroredCopy{method: req.Method(), path: req.Path(), header: req.GetHeader("X-Trace"), body: string(data)}
+		return internalErrorHandler(req)
+	})
+	mirrorClient := client.NewClient()
+	t.Cleanup(mirrorClient.CloseIdleConnections)
+
+	tests := []struct {
+		name       string
+		percentage float64
+		sample     float64
+		mirrored   bool
+	}{
+		{name: "sampled", percentage: 50, sample: 0.2, mirrored: true},
+		{name: "not sampled", percentage: 50, sample: 0.7},
+		{name: "everything", percentage: 100, sample: 0.99, mirrored: true},
+		{name: "disabled", percentage: 0, sample: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primaryBody string
+			handler := Mirror(MirrorConfig{
+				Upstream:   "http://" + shadow.Addr().String() + "/",
+				Client:     mirrorClient,
+				Percentage: tt.percentage,
+				Random:     func() float64 { return tt.sample },
+			})(func(req pkghttp.Request) pkghttp.Response {
+				data, _ := io.ReadAll(req.Body())
+				primaryBody = string(data)
+				return okHandler(req)
+			})
+
+			req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, "/orders?dry=1", pkghttp.Version11, strings.NewReader("order data"))
+			req.SetHeader(pkghttp.HeaderHost, "primary.example")
+			req.SetHeader("X-Trace", "abc")
+			resp := handler(req)
+
+			// The shadow's 500 never reaches the client
+			if resp.StatusCode() != pkghttp.StatusOK || primaryBody != "order data" {
+				t.Fatalf("Expected the primary handler to answer with the full body, got %d %q", resp.StatusCode(), primaryBody)
+			}
+
+			select {
+			case got := <-received:
+				if !tt.mirrored {
+					t.Fatalf("Expected no mirrored request, got %+v", got)
+				}
+				expected := mirroredCopy{method: pkghttp.MethodPost, path: "/orders?dry=1", header: "abc", body: "order data"}
+				if got != expected {
+					t.Errorf("Expected mirrored %+v, got %+v", expected, got)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.mirrored {
+					t.Fatal("Expected a mirrored request")
+				}
+			}
+		})
+	}
+}
+
+// internalErrorHandler answers every request with 500
+func internalErrorHandler(pkghttp.Request) pkghttp.Response {
+	return pkghttp.NewTextResponse(pkghttp.StatusInternalServerError, pkghttp.Version11, "shadow failure")
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// RouteDoc documents a route for OpenAPI generation. Bodies are described by
+// example Go values, whose types are turned into JSON schemas following their
+// json tags; fields without omitempty are listed as required.
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+
+	// Request is a value of the JSON request body type; nil means no body
+	Request interface{}
+
+	// Responses maps statuses to values of their JSON body types; a nil value
+	// documents a response without a body
+	Responses map[pkghttp.StatusCode]interface{}
+}
+
+// RouteInfo is a registered route and its documentation
+type RouteInfo struct {
+	Method pkghttp.Method
+	Path   string
+	Doc    RouteDoc
+}
+
+// OpenAPIInfo is the info object of a generated OpenAPI document
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// jsonSchema is the subset of the OpenAPI schema object generated from Go types
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+}
+
+// openAPIDocument is the root of an OpenAPI 3 document
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components *openAPIComponents                      `json:"components,omitempty"`
+}
+
+// openAPIComponents holds the schemas of named types, referenced by $ref
+type openAPIComponents struct {
+	Schemas map[string]*jsonSchema `json:"schemas"`
+}
+
+// openAPIOperation describes one method on a path
+type openAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+// openAPIParameter describes a path parameter
+type openAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required"`
+	Schema   *jsonSchema `json:"schema"`
+}
+
+// openAPIBody describes a request body
+type openAPIBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+// openAPIResponse describes a response
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+// openAPIMediaType carries the schema of a body
+type openAPIMediaType struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+// GenerateOpenAPI returns an OpenAPI 3 JSON document describing the routes of router
+func GenerateOpenAPI(router *Router, info OpenAPIInfo) ([]byte, error) {
+	schemas := &schemaBuilder{components: make(map[string]*jsonSchema)}
+	doc := openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+
+	for _, route := range router.Routes() {
+		path, params := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+
+		operation := &openAPIOperation{
+			Summary:     route.Doc.Summary,
+			Description: route.Doc.Description,
+			Tags:        route.Doc.Tags,
+			Responses:   make(map[string]openAPIResponse),
+		}
+		for _, name := range params {
+			operation.Parameters = append(operation.Parameters, openAPIParameter{
+				Name: name, In: openAPIPathParameter, Required: true, Schema: &jsonSchema{Type: "string"},
+			})
+		}
+		if route.Doc.Request != nil {
+			operation.RequestBody = &openAPIBody{Required: true, Content: schemas.jsonContent(route.Doc.Request)}
+		}
+		for status, body := range route.Doc.Responses {
+			response := openAPIResponse{Description: pkghttp.StatusText(status)}
+			if body != nil {
+				response.Content = schemas.jsonContent(body)
+			}
+			operation.Responses[strconv.Itoa(int(status))] = response
+		}
+		if len(operation.Responses) == 0 {
+			operation.Responses[openAPIDefaultResponse] = openAPIResponse{Description: openAPIDefaultDescription}
+		}
+
+		doc.Paths[path][strings.ToLower(string(route.Method))] = operation
+	}
+
+	if len(schemas.components) > 0 {
+		doc.Components = &openAPIComponents{Schemas: schemas.components}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// OpenAPIHandler returns a handler serving the OpenAPI document of router,
+// generated on each request so it reflects routes added later
+func OpenAPIHandler(router *Router, info OpenAPIInfo) pkghttp.RequestHandler {
+	return func(req pkghttp.Request) pkghttp.Response {
+		data, err := GenerateOpenAPI(router, info)
+		if err != nil {
+			return internalhttp.BuildJSONErrorResponse(pkghttp.StatusInternalServerError, "")
+		}
+		return pkghttp.NewJSONResponse(pkghttp.StatusOK, pkghttp.Version11, string(data))
+	}
+}
+
+// openAPIPath converts a route pattern to an OpenAPI path template, turning
+// ":id" and "*path" into "{id}" and "{path}", and returns the parameter names
+func openAPIPath(pattern string) (string, []string) {
+	segments := strings.Split(pattern, "/")
+	var params []string
+	for i, segment := range segments {
+		name, isParam := strings.CutPrefix(segment, routeParamPrefix)
+		if !isParam {
+			name, isParam = strings.CutPrefix(segment, routeWildcardPrefix)
+		}
+		if isParam {
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// schemaBuilder turns Go types into schemas, collecting named structs as components
+type schemaBuilder struct {
+	components map[string]*jsonSchema
+}
+
+// jsonContent describes a JSON body of the type of value
+func (b *schemaBuilder) jsonContent(value interface{}) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{pkghttp.MimeTypeJSON: {Schema: b.schemaFor(reflect.TypeOf(value))}}
+}
+
+// timeType is encoded as an RFC 3339 string
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of values of t as encoding/json writes them
+func (b *schemaBuilder) schemaFor(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &jsonSchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &jsonSchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &jsonSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &jsonSchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		return &jsonSchema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, seen := b.components[t.Name()]; !seen {
+			// Reserve the name first so recursive types refer to themselves
+			b.components[t.Name()] = &jsonSchema{}
+			*b.components[t.Name()] = *b.structSchema(t)
+		}
+		return &jsonSchema{Ref: openAPISchemaRefPrefix + t.Name()}
+	default:
+		// Interfaces and other kinds may hold any value
+		return &jsonSchema{}
+	}
+}
+
+// structSchema describes the exported fields of a struct by their json names
+func (b *schemaBuilder) structSchema(t reflect.Type) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs contribute their fields directly
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(field.Type)
+			for property, propertySchema := range embedded.Properties {
+				schema.Properties[property] = propertySchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = b.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
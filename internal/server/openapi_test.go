@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// openAPIUser is a documented body type
+type openAPIUser struct {
+	ID      int64         `json:"id"`
+	Name    string        `json:"name"`
+	Email   string        `json:"email,omitempty"`
+	Created time.Time     `json:"created"`
+	Friends []openAPIUser `json:"friends,omitempty"`
+	secret  string
+	Ignored string `json:"-"`
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	router := NewRouter()
+	router.Handle(pkghttp.MethodGet, "/users/:id", okHandler)
+	router.Handle(pkghttp.MethodPost, "/users", okHandler)
+	router.Handle(pkghttp.MethodGet, "/files/*path", okHandler)
+	api := router.Group("/api")
+	api.Handle(pkghttp.MethodDelete, "/users/:id", okHandler)
+
+	router.Describe(pkghttp.MethodGet, "/users/:id", RouteDoc{
+		Summary:   "Get a user",
+		Tags:      []string{"users"},
+		Responses: map[pkghttp.StatusCode]interface{}{pkghttp.StatusOK: openAPIUser{}, pkghttp.StatusNotFound: nil},
+	})
+	router.Describe(pkghttp.MethodPost, "/users", RouteDoc{
+		Request:   struct{ Name string }{},
+		Responses: map[pkghttp.StatusCode]interface{}{pkghttp.StatusCreated: &openAPIUser{}},
+	})
+	api.Describe(pkghttp.MethodDelete, "/users/:id", RouteDoc{Summary: "Delete a user"})
+
+	data, err := GenerateOpenAPI(router, OpenAPIInfo{Title: "Users", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPI failed: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Invalid document: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     []string
+		expected interface{}
+	}{
+		{name: "version", path: []string{"openapi"}, expected: openAPIVersion},
+		{name: "title", path: []string{"info", "title"}, expected: "Users"},
+		{name: "summary", path: []string{"paths", "/users/{id}", "get", "summary"}, expected: "Get a user"},
+		{name: "tags", path: []string{"paths", "/users/{id}", "get", "tags"}, expected: []interface{}{"users"}},
+		{name: "path parameter", path: []string{"paths", "/users/{id}", "get", "parameters"}, expected: []interface{}{
+			map[string]interface{}{"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}},
+		}},
+		{name: "response ref", path: []string{"paths", "/users/{id}", "get", "responses", "200", "content", "application/json", "schema", "$ref"}, expected: "#/components/schemas/openAPIUser"},
+		{name: "bodiless response", path: []string{"paths", "/users/{id}", "get", "responses", "404"}, expected: map[string]interface{}{"description": "Not Found"}},
+		{name: "inline request", path: []string{"paths", "/users", "post", "requestBody", "content", "application/json", "schema"}, expected: map[string]interface{}{
+			"type": "object", "properties": map[string]interface{}{"Name": map[string]interface{}{"type": "string"}}, "required": []interface{}{"Name"},
+		}},
+		{name: "pointer response", path: []string{"paths", "/users", "post", "responses", "201", "content", "application/json", "schema", "$ref"}, expected: "#/components/schemas/openAPIUser"},
+		{name: "wildcard", path: []string{"paths", "/files/{path}", "get", "responses", "default", "description"}, expected: openAPIDefaultDescription},
+		{name: "group route", path: []string{"paths", "/api/users/{id}", "delete", "summary"}, expected: "Delete a user"},
+		{name: "component", path: []string{"components", "schemas", "openAPIUser"}, expected: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id":      map[string]interface{}{"type": "integer", "format": "int64"},
+				"name":    map[string]interface{}{"type": "string"},
+				"email":   map[string]interface{}{"type": "string"},
+				"created": map[string]interface{}{"type": "string", "format": "date-time"},
+				"friends": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/components/schemas/openAPIUser"}},
+			},
+			"required": []interface{}{"id", "name", "created"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{} = doc
+			for _, key := range tt.path {
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					t.Fatalf("Expected an object at %q", key)
+				}
+				value = object[key]
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestRouterDescribeUnregistered(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected describing an unregistered route to panic")
+		}
+	}()
+	NewRouter().Describe(pkghttp.MethodGet, "/missing", RouteDoc{})
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	router := NewRouter()
+	router.Handle(pkghttp.MethodGet, "/openapi.json", OpenAPIHandler(router, OpenAPIInfo{Title: "API", Version: "1"}))
+
+	resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, "/openapi.json", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode())
+	}
+
+	var doc struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(readResponseBody(t, resp)), &doc); err != nil {
+		t.Fatalf("Invalid document: %v", err)
+	}
+	if _, ok := doc.Paths["/openapi.json"]; !ok {
+		t.Errorf("Expected the document to list its own route, got %v", doc.Paths)
+	}
+}
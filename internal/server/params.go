@@ -0,0 +1,180 @@
+package server
+
+import (
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// Params gives typed access to request values such as query parameters, path
+// parameters or form fields. Missing or empty values yield the default given;
+// values that do not parse are reported as a 400 *BindError naming the parameter.
+type Params map[string][]string
+
+// QueryParams returns the query parameters of req
+func QueryParams(req pkghttp.Request) Params {
+	return Params(req.QueryValues())
+}
+
+// PathParams returns the route parameters captured for req
+func PathParams(req pkghttp.Request) Params {
+	params := make(Params)
+	for name, value := range req.PathParams() {
+		params[name] = []string{value}
+	}
+	return params
+}
+
+// FormParams reads and parses a URL-encoded form body, consuming the body.
+// A request without a body yields no values. The body must be declared as a
+// form and may not exceed MaxRequestBodySize; failures are returned as *BindError.
+func FormParams(req pkghttp.Request) (Params, error) {
+	if req.Body() == nil {
+		return Params{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.GetHeader(pkghttp.HeaderContentType))
+	if err != nil || mediaType != pkghttp.MimeTypeForm {
+		return nil, &BindError{Status: pkghttp.StatusUnsupportedMediaType, Message: ErrUnsupportedContentType}
+	}
+
+	data, err := readLimitedBody(req, ErrInvalidForm)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := url.ParseQuery(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrInvalidForm, Cause: err}
+	}
+
+	return Params(values), nil
+}
+
+// QueryInt returns the named query parameter as an int
+func QueryInt(req pkghttp.Request, name string, def int) (int, error) {
+	return QueryParams(req).Int(name, def)
+}
+
+// QueryBool returns the named query parameter as a bool
+func QueryBool(req pkghttp.Request, name string, def bool) (bool, error) {
+	return QueryParams(req).Bool(name, def)
+}
+
+// QueryTime returns the named query parameter as a time in the given layout
+func QueryTime(req pkghttp.Request, name, layout string, def time.Time) (time.Time, error) {
+	return QueryParams(req).Time(name, layout, def)
+}
+
+// Get returns the first value of the named parameter, or "" if it is absent
+func (p Params) Get(name string) string {
+	if values := p[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// String returns the named parameter, or def if it is missing or empty
+func (p Params) String(name, def string) string {
+	if value := p.Get(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// Int returns the named parameter as an int
+func (p Params) Int(name string, def int) (int, error) {
+	value := p.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, invalidParam(name, err)
+	}
+	return n, nil
+}
+
+// Int64 returns the named parameter as an int64
+func (p Params) Int64(name string, def int64) (int64, error) {
+	value := p.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return def, invalidParam(name, err)
+	}
+	return n, nil
+}
+
+// Float64 returns the named parameter as a float64
+func (p Params) Float64(name string, def float64) (float64, error) {
+	value := p.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return def, invalidParam(name, err)
+	}
+	return f, nil
+}
+
+// Bool returns the named parameter as a bool, accepting the forms strconv.ParseBool does
+func (p Params) Bool(name string, def bool) (bool, error) {
+	value := p.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, invalidParam(name, err)
+	}
+	return b, nil
+}
+
+// Time returns the named parameter parsed with layout, such as time.RFC3339
+func (p Params) Time(name, layout string, def time.Time) (time.Time, error) {
+	value := p.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return def, invalidParam(name, err)
+	}
+	return t, nil
+}
+
+// Duration returns the named parameter as a duration such as "1m30s"
+func (p Params) Duration(name string, def time.Duration) (time.Duration, error) {
+	value := p.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def, invalidParam(name, err)
+	}
+	return d, nil
+}
+
+// invalidParam reports a parameter value that failed to parse
+func invalidParam(name string, cause error) error {
+	return &BindError{
+		Status:  pkghttp.StatusBadRequest,
+		Message: ErrInvalidParam + " " + strconv.Quote(name),
+		Cause:   cause,
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestQueryTypedAccessors(t *testing.T) {
+	req := pkghttp.NewRequest(pkghttp.MethodGet,
+		"/items?page=3&verbose=true&since=2024-05-01T10:00:00Z&limit=ten&empty=", pkghttp.Version11)
+
+	page, err := QueryInt(req, "page", 1)
+	if err != nil || page != 3 {
+		t.Errorf("QueryInt(page) = %d, %v; want 3, nil", page, err)
+	}
+
+	size, err := QueryInt(req, "size", 20)
+	if err != nil || size != 20 {
+		t.Errorf("QueryInt(size) = %d, %v; want default 20, nil", size, err)
+	}
+
+	empty, err := QueryInt(req, "empty", 5)
+	if err != nil || empty != 5 {
+		t.Errorf("QueryInt(empty) = %d, %v; want default 5, nil", empty, err)
+	}
+
+	verbose, err := QueryBool(req, "verbose", false)
+	if err != nil || !verbose {
+		t.Errorf("QueryBool(verbose) = %v, %v; want true, nil", verbose, err)
+	}
+
+	since, err := QueryTime(req, "since", time.RFC3339, time.Time{})
+	expected := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err != nil || !since.Equal(expected) {
+		t.Errorf("QueryTime(since) = %v, %v; want %v, nil", since, err, expected)
+	}
+
+	limit, err := QueryInt(req, "limit", 10)
+	if limit != 10 {
+		t.Errorf("Expected the default on a parse failure, got %d", limit)
+	}
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || bindErr.Status != pkghttp.StatusBadRequest {
+		t.Fatalf("Expected a 400 *BindError, got %v", err)
+	}
+	if bindErr.Message != `invalid parameter "limit"` {
+		t.Errorf("Expected the parameter to be named, got %q", bindErr.Message)
+	}
+}
+
+func TestParamsTypedAccessors(t *testing.T) {
+	params := Params{
+		"id":      {"42"},
+		"big":     {"9000000000"},
+		"ratio":   {"0.75"},
+		"ttl":     {"1m30s"},
+		"name":    {"gopher"},
+		"bad":     {"x"},
+		"several": {"first", "second"},
+	}
+
+	if n, err := params.Int64("big", 0); err != nil || n != 9000000000 {
+		t.Errorf("Int64(big) = %d, %v", n, err)
+	}
+	if f, err := params.Float64("ratio", 0); err != nil || f != 0.75 {
+		t.Errorf("Float64(ratio) = %v, %v", f, err)
+	}
+	if d, err := params.Duration("ttl", 0); err != nil || d != 90*time.Second {
+		t.Errorf("Duration(ttl) = %v, %v", d, err)
+	}
+	if s := params.String("missing", "anonymous"); s != "anonymous" {
+		t.Errorf("String(missing) = %q, want default", s)
+	}
+	if s := params.Get("several"); s != "first" {
+		t.Errorf("Get(several) = %q, want first value", s)
+	}
+
+	checks := map[string]func() error{
+		"Int":      func() error { _, err := params.Int("bad", 0); return err },
+		"Int64":    func() error { _, err := params.Int64("bad", 0); return err },
+		"Float64":  func() error { _, err := params.Float64("bad", 0); return err },
+		"Bool":     func() error { _, err := params.Bool("bad", false); return err },
+		"Duration": func() error { _, err := params.Duration("bad", 0); return err },
+		"Time":     func() error { _, err := params.Time("bad", time.RFC3339, time.Time{}); return err },
+	}
+	for name, check := range checks {
+		if err := check(); err == nil {
+			t.Errorf("%s(bad): expected error, got nil", name)
+		}
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	router := NewRouter()
+	router.Handle(pkghttp.MethodGet, "/users/:id", func(req pkghttp.Request) pkghttp.Response {
+		id, err := PathParams(req).Int("id", 0)
+		if err != nil {
+			return BindErrorResponse(err)
+		}
+		return WriteJSON(pkghttp.StatusOK, map[string]int{"id": id})
+	})
+
+	tests := []struct {
+		path   string
+		status pkghttp.StatusCode
+	}{
+		{"/users/7", pkghttp.StatusOK},
+		{"/users/seven", pkghttp.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11))
+			if resp.StatusCode() != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode())
+			}
+		})
+	}
+}
+
+func TestFormParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      pkghttp.StatusCode
+		age         int
+	}{
+		{name: "valid form", contentType: pkghttp.MimeTypeForm, body: "name=gopher&age=3", age: 3},
+		{name: "charset parameter", contentType: pkghttp.MimeTypeForm + "; charset=utf-8", body: "age=5", age: 5},
+		{name: "empty form", contentType: pkghttp.MimeTypeForm, body: "", age: 1},
+		{name: "wrong content type", contentType: pkghttp.MimeTypeJSON, body: `{"age":3}`, status: pkghttp.StatusUnsupportedMediaType},
+		{name: "malformed form", contentType: pkghttp.MimeTypeForm, body: "age=%zz", status: pkghttp.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form, err := FormParams(newJSONRequest(tt.contentType, tt.body))
+			if tt.status != 0 {
+				var bindErr *BindError
+				if !errors.As(err, &bindErr) || bindErr.Status != tt.status {
+					t.Fatalf("Expected BindError with status %d, got %v", tt.status, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FormParams failed: %v", err)
+			}
+
+			age, err := form.Int("age", 1)
+			if err != nil || age != tt.age {
+				t.Errorf("Int(age) = %d, %v; want %d, nil", age, err, tt.age)
+			}
+		})
+	}
+}
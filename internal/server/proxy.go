@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// ForwardProxyHandler returns a handler that relays absolute-form requests to the
+// origin server named in the request target. A nil dial uses a plain TCP dialer.
+// HTTPS origins are reached through CONNECT instead; see ConnectHandler.
+func ForwardProxyHandler(dial TunnelDialer) pkghttp.RequestHandler {
+	dial = orDefaultDialer(dial)
+
+	return func(req pkghttp.Request) pkghttp.Response {
+		target, err := url.Parse(req.Path())
+		if err != nil || target.Scheme != pkghttp.SchemeHTTP || target.Host == "" {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, "")
+		}
+
+		upstream, err := dial(originAddress(target))
+		if err != nil {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadGateway, "")
+		}
+
+		writer := bufio.NewWriterSize(upstream, connectionWriterSize)
+		if err := internalhttp.WriteRequest(writer, outboundRequest(req, target)); err != nil {
+			upstream.Close()
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadGateway, "")
+		}
+		if err := writer.Flush(); err != nil {
+			upstream.Close()
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadGateway, "")
+		}
+
+		resp, err := internalhttp.ReadResponseForMethod(bufio.NewReaderSize(upstream, connectionReaderSize), req.Method())
+		if err != nil {
+			upstream.Close()
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadGateway, "")
+		}
+
+		// The server reframes the body for its own connection
+		removeHopByHopHeaders(resp.Headers())
+		resp.AddHeader(pkghttp.HeaderVia, viaValue(resp.Version()))
+		resp.SetVersion(pkghttp.Version11)
+
+		if resp.Body() == nil {
+			upstream.Close()
+		} else {
+			resp.SetBody(&proxiedBody{Reader: resp.Body(), upstream: upstream})
+		}
+
+		return resp
+	}
+}
+
+// proxiedBody releases the origin connection once the relayed body is closed
+type proxiedBody struct {
+	io.Reader
+	upstream io.Closer
+}
+
+// Close closes the origin connection
+func (b *proxiedBody) Close() error {
+	return b.upstream.Close()
+}
+
+// outboundRequest rewrites a proxied request into origin form for the origin server
+func outboundRequest(req pkghttp.Request, target *url.URL) pkghttp.Request {
+	outbound := pkghttp.NewRequestWithBody(req.Method(), target.RequestURI(), pkghttp.Version11, req.Body())
+
+	for _, name := range req.HeaderNames() {
+		for _, value := range req.Headers()[name] {
+			outbound.AddHeader(name, value)
+		}
+	}
+	removeHopByHopHeaders(outbound.Headers())
+
+	deleteHeader(outbound.Headers(), pkghttp.HeaderHost)
+	outbound.SetHeader(pkghttp.HeaderHost, target.Host)
+	outbound.AddHeader(pkghttp.HeaderVia, viaValue(req.Version()))
+
+	// One origin connection per request keeps framing simple
+	outbound.SetHeader(pkghttp.HeaderConnection, pkghttp.ConnectionClose)
+
+	return outbound
+}
+
+// originAddress returns the host:port to dial for target
+func originAddress(target *url.URL) string {
+	if target.Port() != "" {
+		return target.Host
+	}
+	return net.JoinHostPort(target.Hostname(), strconv.Itoa(pkghttp.DefaultHTTPPort))
+}
+
+// removeHopByHopHeaders deletes connection-scoped headers, including any named in Connection
+func removeHopByHopHeaders(headers pkghttp.Header) {
+	for name, values := range headers {
+		if !strings.EqualFold(name, pkghttp.HeaderConnection) {
+			continue
+		}
+		for _, value := range values {
+			for _, token := range strings.Split(value, ",") {
+				if token = strings.TrimSpace(token); token != "" {
+					deleteHeader(headers, token)
+				}
+			}
+		}
+	}
+
+	for _, name := range hopByHopHeaders {
+		deleteHeader(headers, name)
+	}
+}
+
+// deleteHeader removes every spelling of name from headers
+func deleteHeader(headers pkghttp.Header, name string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+}
+
+// viaValue renders this hop's Via entry for a message received with version
+func viaValue(version pkghttp.Version) string {
+	return strings.TrimPrefix(string(version), pkghttp.HTTPVersionPrefix) + " " + ServerSoftware
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestForwardProxy(t *testing.T) {
+	origin := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		resp := pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.Path()+" "+req.GetHeader(pkghttp.HeaderHost))
+		resp.SetHeader("X-Seen-Proxy-Connection", req.GetHeader(pkghttp.HeaderProxyConnection))
+		resp.SetHeader("X-Seen-Via", req.GetHeader(pkghttp.HeaderVia))
+		return resp
+	})
+	originAddr := origin.Addr().String()
+
+	proxy := startTestServer(t, okHandler)
+	proxy.EnableForwardProxy(nil)
+	conn, reader := dialTestServer(t, proxy)
+
+	// Two requests on one client connection: each is relayed independently
+	for i := 0; i < 2; i++ {
+		resp, body := roundTrip(t, conn, reader,
+			"GET http://"+originAddr+"/path?x=1 HTTP/1.1\r\nHost: "+originAddr+"\r\nProxy-Connection: keep-alive\r\n\r\n")
+
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode())
+		}
+		if body != "/path?x=1 "+originAddr {
+			t.Errorf("Origin saw unexpected request: %q", body)
+		}
+		if resp.GetHeader("X-Seen-Proxy-Connection") != "" {
+			t.Error("Hop-by-hop headers should not be forwarded")
+		}
+		if resp.GetHeader("X-Seen-Via") != "1.1 "+ServerSoftware {
+			t.Errorf("Expected Via on the forwarded request, got %q", resp.GetHeader("X-Seen-Via"))
+		}
+		if resp.GetHeader(pkghttp.HeaderVia) == "" {
+			t.Error("Expected Via on the relayed response")
+		}
+	}
+}
+
+func TestForwardProxyOriginUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	unreachable := listener.Addr().String()
+	listener.Close()
+
+	proxy := startTestServer(t, okHandler)
+	proxy.EnableForwardProxy(nil)
+	conn, reader := dialTestServer(t, proxy)
+
+	resp, _ := roundTrip(t, conn, reader, "GET http://"+unreachable+"/ HTTP/1.1\r\nHost: "+unreachable+"\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", resp.StatusCode())
+	}
+}
+
+func TestRemoveHopByHopHeaders(t *testing.T) {
+	headers := pkghttp.Header{
+		"Connection":        {"close, X-Private"},
+		"X-Private":         {"secret"},
+		"Keep-Alive":        {"timeout=5"},
+		"Transfer-Encoding": {"chunked"},
+		"Content-Type":      {"text/plain"},
+	}
+
+	removeHopByHopHeaders(headers)
+
+	if len(headers) != 1 || headers["Content-Type"] == nil {
+		t.Errorf("Expected only end-to-end headers to remain, got %v", headers)
+	}
+}
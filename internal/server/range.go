@@ -0,0 +1,219 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// byteRange is a satisfiable range of a representation
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+// contentRange formats the range as a Content-Range value
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("%s %d-%d/%d", rangeUnitBytes, r.start, r.start+r.length-1, size)
+}
+
+// parseRange parses a Range header for a representation of size bytes
+// (RFC 7233 section 2.1). It reports false when the header should be
+// ignored: a syntax error, another unit, or too many or overlapping ranges.
+// Specs beyond the end are dropped, so no ranges means none is satisfiable.
+func parseRange(value string, size int64) ([]byteRange, bool) {
+	unit, specs, found := strings.Cut(value, "=")
+	if !found || !strings.EqualFold(strings.TrimSpace(unit), rangeUnitBytes) {
+		return nil, false
+	}
+
+	var ranges []byteRange
+	var total int64
+	count := 0
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		if count++; count > maxByteRanges {
+			return nil, false
+		}
+
+		first, last, found := strings.Cut(spec, "-")
+		if !found {
+			return nil, false
+		}
+
+		var r byteRange
+		if first == "" {
+			// A suffix range asks for the last bytes
+			suffix, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || suffix < 0 {
+				return nil, false
+			}
+			if suffix == 0 || size == 0 {
+				continue
+			}
+			r = byteRange{start: max(size-suffix, 0), length: min(suffix, size)}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, false
+			}
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, false
+				}
+				end = min(end, size-1)
+			}
+			if start >= size {
+				continue
+			}
+			r = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, r)
+		total += r.length
+	}
+
+	if count == 0 {
+		return nil, false
+	}
+	// Overlapping ranges would send more than the whole file
+	if total > size {
+		return nil, false
+	}
+
+	return ranges, true
+}
+
+// CheckIfRange reports whether the request's If-Range validator still matches
+// the representation, so its Range header applies (RFC 7233 section 3.2). An
+// entity tag must match strongly; a date must equal Last-Modified exactly.
+// Without If-Range the Range header always applies.
+func CheckIfRange(req pkghttp.Request, etag string, lastModified time.Time) bool {
+	ifRange := strings.TrimSpace(req.GetHeader(pkghttp.HeaderIfRange))
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, weakETagPrefix) {
+		return etag != "" && !strings.HasPrefix(ifRange, weakETagPrefix) && ETagMatches(ifRange, etag, false)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	date, err := common.ParseHTTPDate(ifRange)
+	if err != nil {
+		return false
+	}
+	return lastModified.Truncate(time.Second).Equal(date)
+}
+
+// rangeRequested returns the ranges of a size-byte file that req asks for, or
+// false when the full file should be sent. Range only applies to GET.
+func rangeRequested(req pkghttp.Request, etag string, lastModified time.Time, size int64) ([]byteRange, bool) {
+	if req.Method() != pkghttp.MethodGet || !req.HasHeader(pkghttp.HeaderRange) {
+		return nil, false
+	}
+	// The client's partial copy is stale, so it needs the whole file
+	if !CheckIfRange(req, etag, lastModified) {
+		return nil, false
+	}
+	return parseRange(req.GetHeader(pkghttp.HeaderRange), size)
+}
+
+// serveRanges turns resp into a 206 carrying ranges of file, or a 416 when
+// none is satisfiable. file is closed once the body is done with it.
+func serveRanges(resp pkghttp.Response, file fs.File, content io.ReadSeeker, contentType string, ranges []byteRange, size int64) pkghttp.Response {
+	if len(ranges) == 0 {
+		file.Close()
+		unsatisfiable := internalhttp.BuildErrorResponse(pkghttp.StatusRequestedRangeNotSatisfiable, "")
+		unsatisfiable.SetHeader(pkghttp.HeaderContentRange, fmt.Sprintf("%s */%d", rangeUnitBytes, size))
+		return unsatisfiable
+	}
+
+	resp.SetStatusCode(pkghttp.StatusPartialContent)
+
+	if len(ranges) == 1 {
+		r := ranges[0]
+		resp.SetHeader(pkghttp.HeaderContentType, contentType)
+		resp.SetHeader(pkghttp.HeaderContentRange, r.contentRange(size))
+		resp.SetHeader(pkghttp.HeaderContentLength, strconv.FormatInt(r.length, 10))
+
+		if osFile, ok := content.(*os.File); ok {
+			if _, err := osFile.Seek(r.start, io.SeekStart); err != nil {
+				file.Close()
+				return fileErrorResponse(err)
+			}
+			resp.SetBody(&fileBody{File: osFile, size: r.length})
+			return resp
+		}
+		resp.SetBody(&replayBody{Reader: &sectionReader{file: content, start: r.start, remaining: r.length}, Closer: file})
+		return resp
+	}
+
+	// A fresh writer picks a random boundary
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	var parts []io.Reader
+	var length int64
+	for _, r := range ranges {
+		head := fmt.Sprintf("\r\n--%s\r\n%s: %s\r\n%s: %s\r\n\r\n", boundary,
+			pkghttp.HeaderContentType, contentType, pkghttp.HeaderContentRange, r.contentRange(size))
+		parts = append(parts, strings.NewReader(head), &sectionReader{file: content, start: r.start, remaining: r.length})
+		length += int64(len(head)) + r.length
+	}
+	tail := "\r\n--" + boundary + "--\r\n"
+	parts = append(parts, strings.NewReader(tail))
+	length += int64(len(tail))
+
+	resp.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeMultipartByteRanges+"; "+multipartBoundaryParam+"="+boundary)
+	resp.SetHeader(pkghttp.HeaderContentLength, strconv.FormatInt(length, 10))
+	resp.SetBody(&replayBody{Reader: io.MultiReader(parts...), Closer: file})
+	return resp
+}
+
+// sectionReader reads length bytes from start, seeking when first read so
+// several sections of one file can be read in turn
+type sectionReader struct {
+	file      io.ReadSeeker
+	start     int64
+	remaining int64
+	seeked    bool
+}
+
+// Read reads from the section
+func (r *sectionReader) Read(p []byte) (int, error) {
+	if !r.seeked {
+		if _, err := r.file.Seek(r.start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.seeked = true
+	}
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.file.Read(p)
+	r.remaining -= int64(n)
+	if err == io.EOF && r.remaining > 0 {
+		// The file shrank after its length was announced
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
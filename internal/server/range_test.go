@@ -0,0 +1,198 @@
+package server
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []byteRange
+		valid    bool
+	}{
+		{name: "bounded", value: "bytes=0-9", expected: []byteRange{{start: 0, length: 10}}, valid: true},
+		{name: "open ended", value: "bytes=90-", expected: []byteRange{{start: 90, length: 10}}, valid: true},
+		{name: "suffix", value: "bytes=-5", expected: []byteRange{{start: 95, length: 5}}, valid: true},
+		{name: "suffix longer than file", value: "bytes=-500", expected: []byteRange{{start: 0, length: 100}}, valid: true},
+		{name: "end clamped", value: "bytes=95-200", expected: []byteRange{{start: 95, length: 5}}, valid: true},
+		{name: "several", value: "bytes=0-1, 10-11", expected: []byteRange{{start: 0, length: 2}, {start: 10, length: 2}}, valid: true},
+		{name: "unit case", value: "Bytes=0-0", expected: []byteRange{{start: 0, length: 1}}, valid: true},
+		{name: "beyond the end", value: "bytes=100-", valid: true},
+		{name: "empty suffix", value: "bytes=-0", valid: true},
+		{name: "one satisfiable", value: "bytes=200-300,0-0", expected: []byteRange{{start: 0, length: 1}}, valid: true},
+		{name: "other unit", value: "items=0-1"},
+		{name: "reversed", value: "bytes=9-0"},
+		{name: "garbage", value: "bytes=a-b"},
+		{name: "no dash", value: "bytes=5"},
+		{name: "no specs", value: "bytes="},
+		{name: "overlapping", value: "bytes=0-99,0-99"},
+		{name: "too many", value: "bytes=" + strings.Repeat("0-0,", maxByteRanges) + "0-0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, valid := parseRange(tt.value, 100)
+			if valid != tt.valid {
+				t.Fatalf("Expected valid %v, got %v", tt.valid, valid)
+			}
+			if !reflect.DeepEqual(ranges, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, ranges)
+			}
+		})
+	}
+}
+
+func TestCheckIfRange(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	etag := `"abc"`
+
+	tests := []struct {
+		name     string
+		ifRange  string
+		etag     string
+		expected bool
+	}{
+		{name: "absent", etag: etag, expected: true},
+		{name: "matching etag", ifRange: etag, etag: etag, expected: true},
+		{name: "changed etag", ifRange: `"old"`, etag: etag},
+		{name: "weak etag", ifRange: `W/"abc"`, etag: etag},
+		{name: "weak current etag", ifRange: etag, etag: `W/"abc"`},
+		{name: "matching date", ifRange: common.FormatHTTPTime(modTime), etag: etag, expected: true},
+		{name: "earlier date", ifRange: common.FormatHTTPTime(modTime.Add(-time.Hour)), etag: etag},
+		{name: "later date", ifRange: common.FormatHTTPTime(modTime.Add(time.Hour)), etag: etag},
+		{name: "invalid date", ifRange: "yesterday", etag: etag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+			if tt.ifRange != "" {
+				req.SetHeader(pkghttp.HeaderIfRange, tt.ifRange)
+			}
+			if got := CheckIfRange(req, tt.etag, modTime); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServeFileRanges(t *testing.T) {
+	dir := t.TempDir()
+	content := "0123456789abcdefghij"
+	path := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	etag := FileETag(info.Size(), info.ModTime())
+	lastModified := common.FormatHTTPTime(info.ModTime())
+
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return ServeFile(req, path)
+	})
+	conn, reader := dialTestServer(t, server)
+
+	tests := []struct {
+		name         string
+		headers      string
+		status       pkghttp.StatusCode
+		contentRange string
+		body         string
+	}{
+		{name: "single range", headers: "Range: bytes=2-5\r\n", status: pkghttp.StatusPartialContent, contentRange: "bytes 2-5/20", body: "2345"},
+		{name: "suffix range", headers: "Range: bytes=-3\r\n", status: pkghttp.StatusPartialContent, contentRange: "bytes 17-19/20", body: "hij"},
+		{name: "unsatisfiable", headers: "Range: bytes=50-\r\n", status: pkghttp.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */20"},
+		{name: "invalid range ignored", headers: "Range: bytes=x-y\r\n", status: pkghttp.StatusOK, body: content},
+		{name: "if-range etag current", headers: "Range: bytes=0-1\r\nIf-Range: " + etag + "\r\n", status: pkghttp.StatusPartialContent, contentRange: "bytes 0-1/20", body: "01"},
+		{name: "if-range etag changed", headers: "Range: bytes=0-1\r\nIf-Range: \"stale\"\r\n", status: pkghttp.StatusOK, body: content},
+		{name: "if-range date current", headers: "Range: bytes=0-1\r\nIf-Range: " + lastModified + "\r\n", status: pkghttp.StatusPartialContent, contentRange: "bytes 0-1/20", body: "01"},
+		{name: "if-range date changed", headers: "Range: bytes=0-1\r\nIf-Range: Mon, 01 Jan 2001 00:00:00 GMT\r\n", status: pkghttp.StatusOK, body: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := roundTrip(t, conn, reader, "GET /data.txt HTTP/1.1\r\nHost: localhost\r\n"+tt.headers+"\r\n")
+
+			if resp.StatusCode() != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode())
+			}
+			if got := resp.GetHeader(pkghttp.HeaderContentRange); got != tt.contentRange {
+				t.Errorf("Expected Content-Range %q, got %q", tt.contentRange, got)
+			}
+			if tt.body != "" && body != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, body)
+			}
+		})
+	}
+
+	// Range only applies to GET
+	head := pkghttp.NewRequest(pkghttp.MethodHead, "/data.txt", pkghttp.Version11)
+	head.SetHeader(pkghttp.HeaderRange, "bytes=0-1")
+	resp := ServeFile(head, path)
+	resp.Body().Close()
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected HEAD to ignore Range, got %d", resp.StatusCode())
+	}
+}
+
+func TestServeFSMultipleRanges(t *testing.T) {
+	fsys := fstest.MapFS{"data.txt": {Data: []byte("0123456789abcdefghij")}}
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/data.txt", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderRange, "bytes=0-2,-4")
+
+	resp := ServeFS(req, fsys, "data.txt")
+	if resp.StatusCode() != pkghttp.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", resp.StatusCode())
+	}
+	if got := resp.GetHeader(pkghttp.HeaderAcceptRanges); got != rangeUnitBytes {
+		t.Errorf("Expected Accept-Ranges %q, got %q", rangeUnitBytes, got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.GetHeader(pkghttp.HeaderContentType))
+	if err != nil || mediaType != pkghttp.MimeTypeMultipartByteRanges {
+		t.Fatalf("Unexpected Content-Type %q", resp.GetHeader(pkghttp.HeaderContentType))
+	}
+	body := readResponseBody(t, resp)
+	if got := resp.GetHeader(pkghttp.HeaderContentLength); got != strconv.Itoa(len(body)) {
+		t.Errorf("Expected Content-Length %d, got %s", len(body), got)
+	}
+
+	expected := []struct{ contentRange, data string }{
+		{contentRange: "bytes 0-2/20", data: "012"},
+		{contentRange: "bytes 16-19/20", data: "ghij"},
+	}
+	parts := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	for _, want := range expected {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("NextPart failed: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		if part.Header.Get(pkghttp.HeaderContentRange) != want.contentRange || string(data) != want.data {
+			t.Errorf("Expected part %q %q, got %q %q", want.contentRange, want.data, part.Header.Get(pkghttp.HeaderContentRange), data)
+		}
+		if part.Header.Get(pkghttp.HeaderContentType) != pkghttp.MimeTypeTextPlain {
+			t.Errorf("Unexpected part Content-Type %q", part.Header.Get(pkghttp.HeaderContentType))
+		}
+	}
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("Expected two parts, got another (%v)", err)
+	}
+}
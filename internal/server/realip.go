@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// RealIPConfig configures the RealIP middleware
+type RealIPConfig struct {
+	// TrustedProxies are the address ranges of proxies whose forwarding
+	// headers are believed, such as netip.MustParsePrefix("10.0.0.0/8")
+	TrustedProxies []netip.Prefix
+}
+
+// remoteAddrSetter is implemented by requests whose remote address can be replaced
+type remoteAddrSetter interface {
+	SetRemoteAddr(net.Addr)
+}
+
+// RealIP returns middleware that replaces the remote address of requests
+// relayed by a trusted proxy with the client address the proxy reports, so
+// logging and per-client limits see the client rather than the proxy.
+// X-Forwarded-For is read from the right, skipping trusted proxies, and
+// X-Real-IP is used when it is absent. A request whose X-Forwarded-For holds
+// an entry that is not an address keeps its peer address, as do requests from
+// any other peer, since their headers could be forged.
+func RealIP(config RealIPConfig) pkghttp.MiddlewareFunc {
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			setter, ok := req.(remoteAddrSetter)
+			if !ok {
+				return next(req)
+			}
+
+			peer, ok := addrIP(req.RemoteAddr())
+			if !ok || !config.isTrusted(peer) {
+				return next(req)
+			}
+
+			if client, ok := config.clientIP(req); ok {
+				setter.SetRemoteAddr(&net.TCPAddr{IP: client.AsSlice(), Zone: client.Zone()})
+			}
+			return next(req)
+		}
+	}
+}
+
+// clientIP returns the client address reported by the forwarding headers
+func (c RealIPConfig) clientIP(req pkghttp.Request) (netip.Addr, bool) {
+	var hops []string
+	for _, value := range req.GetHeaders(pkghttp.HeaderXForwardedFor) {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	// Each proxy appends the peer it saw, so the client is the rightmost
+	// address not added by a trusted proxy. A hop that is not an address
+	// hides who the client is, so the peer is kept.
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		client = ip.Unmap()
+		if !c.isTrusted(client) {
+			break
+		}
+	}
+	if client.IsValid() {
+		return client, true
+	}
+
+	ip, err := netip.ParseAddr(strings.TrimSpace(req.GetHeader(pkghttp.HeaderXRealIP)))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// isTrusted reports whether ip belongs to a trusted proxy
+func (c RealIPConfig) isTrusted(ip netip.Addr) bool {
+	for _, prefix := range c.TrustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrIP extracts the IP address of a network address
+func addrIP(addr net.Addr) (netip.Addr, bool) {
+	if addr == nil {
+		return netip.Addr{}, false
+	}
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		ip, ok := netip.AddrFromSlice(tcpAddr.IP)
+		return ip.Unmap(), ok
+	}
+
+	ip, err := netip.ParseAddr(remoteHost(addr))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
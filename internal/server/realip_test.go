@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestRealIP(t *testing.T) {
+	config := RealIPConfig{TrustedProxies: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}}
+
+	tests := []struct {
+		name         string
+		peer         string
+		forwardedFor []string
+		realIP       string
+		expectedHost string
+	}{
+		{name: "trusted proxy", peer: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.7"}, expectedHost: "203.0.113.7"},
+		{name: "untrusted peer", peer: "198.51.100.2:4000", forwardedFor: []string{"203.0.113.7"}, expectedHost: "198.51.100.2"},
+		{name: "spoofed leftmost entry", peer: "10.0.0.1:4000", forwardedFor: []string{"1.2.3.4, 203.0.113.7"}, expectedHost: "203.0.113.7"},
+		{name: "proxy chain", peer: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.7, 10.0.0.5", "10.0.0.9"}, expectedHost: "203.0.113.7"},
+		{name: "only proxies", peer: "10.0.0.1:4000", forwardedFor: []string{"10.0.0.5, 10.0.0.9"}, expectedHost: "10.0.0.5"},
+		{name: "invalid entry keeps the peer", peer: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.7, unknown, 10.0.0.9"}, expectedHost: "10.0.0.1"},
+		{name: "invalid entry left of proxies", peer: "10.0.0.1:4000", forwardedFor: []string{"garbage, 10.0.0.5"}, expectedHost: "10.0.0.1"},
+		{name: "X-Real-IP", peer: "10.0.0.1:4000", realIP: "203.0.113.8", expectedHost: "203.0.113.8"},
+		{name: "X-Forwarded-For preferred", peer: "10.0.0.1:4000", forwardedFor: []string{"203.0.113.7"}, realIP: "203.0.113.8", expectedHost: "203.0.113.7"},
+		{name: "invalid X-Real-IP", peer: "10.0.0.1:4000", realIP: "localhost", expectedHost: "10.0.0.1"},
+		{name: "IPv6", peer: "[fd00::1]:4000", forwardedFor: []string{"2001:db8::7"}, expectedHost: "2001:db8::7"},
+		{name: "IPv4-mapped peer", peer: "[::ffff:10.0.0.1]:4000", realIP: "203.0.113.8", expectedHost: "203.0.113.8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peer, err := net.ResolveTCPAddr("tcp", tt.peer)
+			if err != nil {
+				t.Fatalf("Invalid peer %q: %v", tt.peer, err)
+			}
+			req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+			req.(remoteAddrSetter).SetRemoteAddr(peer)
+			for _, value := range tt.forwardedFor {
+				req.AddHeader(pkghttp.HeaderXForwardedFor, value)
+			}
+			if tt.realIP != "" {
+				req.SetHeader(pkghttp.HeaderXRealIP, tt.realIP)
+			}
+
+			var seen string
+			RealIP(config)(func(req pkghttp.Request) pkghttp.Response {
+				seen = remoteHost(req.RemoteAddr())
+				return okHandler(req)
+			})(req)
+
+			if seen != tt.expectedHost {
+				t.Errorf("Expected remote host %q, got %q", tt.expectedHost, seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// UploadInfo describes the progress of a resumable upload
+type UploadInfo struct {
+	ID     string
+	Offset int64
+	Length int64
+}
+
+// Complete reports whether every byte of the upload has arrived
+func (i UploadInfo) Complete() bool {
+	return i.Offset == i.Length
+}
+
+// UploadStore keeps the data of resumable uploads. A missing upload is
+// reported with an error wrapping fs.ErrNotExist.
+type UploadStore interface {
+	// Create starts an upload of length bytes and returns its ID
+	Create(length int64) (string, error)
+
+	// Info returns the progress of an upload
+	Info(id string) (UploadInfo, error)
+
+	// Append adds data to the end of an upload, returning how much was stored
+	// even when reading data fails part way
+	Append(id string, data io.Reader) (int64, error)
+
+	// Delete discards an upload
+	Delete(id string) error
+}
+
+// ResumableUploadConfig configures ResumableUploads
+type ResumableUploadConfig struct {
+	// Prefix is the path uploads are created at; each upload lives at Prefix/ID
+	Prefix string
+
+	// Store keeps upload data
+	Store UploadStore
+
+	// MaxSize is the largest upload accepted; zero means no limit
+	MaxSize int64
+
+	// OnComplete is called once the last byte of an upload is stored. The
+	// response it returns replaces the usual 204; nil keeps it.
+	OnComplete func(req pkghttp.Request, info UploadInfo) pkghttp.Response
+}
+
+// resumableMethods are the methods ResumableUploads answers
+var resumableMethods = []pkghttp.Method{pkghttp.MethodPost, pkghttp.MethodHead, pkghttp.MethodPatch, pkghttp.MethodDelete}
+
+// ResumableUploads returns a handler for uploads that survive dropped
+// connections, after the tus protocol: POST to Prefix with Upload-Length
+// creates an upload, HEAD on its location reports the Upload-Offset stored so
+// far, and PATCH sends data from that offset, given in Upload-Offset or as the
+// start of a Content-Range. DELETE discards an upload. A PATCH whose offset is
+// not the stored one gets 409 Conflict, so the client asks again and resumes.
+func ResumableUploads(config ResumableUploadConfig) pkghttp.RequestHandler {
+	prefix := strings.TrimSuffix(config.Prefix, "/")
+	locks := &uploadLocks{held: make(map[string]*sync.Mutex), refs: make(map[string]int)}
+
+	return func(req pkghttp.Request) pkghttp.Response {
+		resp := serveResumable(req, config, prefix, locks)
+		resp.SetHeader(headerTusResumable, tusVersion)
+		return resp
+	}
+}
+
+// serveResumable dispatches an upload request by path and method
+func serveResumable(req pkghttp.Request, config ResumableUploadConfig, prefix string, locks *uploadLocks) pkghttp.Response {
+	rest, ok := strings.CutPrefix(requestPath(req), prefix)
+	if !ok {
+		return internalhttp.BuildErrorResponse(pkghttp.StatusNotFound, "")
+	}
+	id := strings.Trim(rest, "/")
+
+	switch {
+	case id == "" && req.Method() == pkghttp.MethodPost:
+		return createUpload(req, config, prefix)
+	case id == "" || strings.Contains(id, "/"):
+		return internalhttp.BuildErrorResponse(pkghttp.StatusNotFound, "")
+	case req.Method() == pkghttp.MethodHead:
+		info, err := config.Store.Info(id)
+		if err != nil {
+			return uploadErrorResponse(err)
+		}
+		resp := uploadProgressResponse(pkghttp.StatusOK, info)
+		SetNoStore(resp)
+		return resp
+	case req.Method() == pkghttp.MethodPatch:
+		unlock := locks.lock(id)
+		defer unlock()
+		return appendUpload(req, config, id)
+	case req.Method() == pkghttp.MethodDelete:
+		if err := config.Store.Delete(id); err != nil {
+			return uploadErrorResponse(err)
+		}
+		return pkghttp.NewResponse(pkghttp.StatusNoContent, pkghttp.Version11)
+	default:
+		resp := internalhttp.BuildErrorResponse(pkghttp.StatusMethodNotAllowed, "")
+		resp.SetHeader(pkghttp.HeaderAllow, FormatAllow(resumableMethods))
+		return resp
+	}
+}
+
+// createUpload starts an upload of the announced Upload-Length
+func createUpload(req pkghttp.Request, config ResumableUploadConfig, prefix string) pkghttp.Response {
+	length, err := strconv.ParseInt(req.GetHeader(headerUploadLength), 10, 64)
+	if err != nil || length < 0 {
+		return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, ErrInvalidUploadLength)
+	}
+	if config.MaxSize > 0 && length > config.MaxSize {
+		return internalhttp.BuildErrorResponse(pkghttp.StatusRequestEntityTooLarge, ErrUploadTooLarge)
+	}
+
+	id, err := config.Store.Create(length)
+	if err != nil {
+		return uploadErrorResponse(err)
+	}
+
+	resp := uploadProgressResponse(pkghttp.StatusCreated, UploadInfo{ID: id, Length: length})
+	resp.SetHeader(pkghttp.HeaderLocation, prefix+"/"+id)
+	return resp
+}
+
+// appendUpload stores the request body at the offset the client claims
+func appendUpload(req pkghttp.Request, config ResumableUploadConfig, id string) pkghttp.Response {
+	offset, ok := chunkOffset(req)
+	if !ok {
+		return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, ErrInvalidUploadOffset)
+	}
+
+	info, err := config.Store.Info(id)
+	if err != nil {
+		return uploadErrorResponse(err)
+	}
+	if offset != info.Offset {
+		resp := internalhttp.BuildErrorResponse(pkghttp.StatusConflict, ErrUploadOffsetMismatch)
+		setUploadProgress(resp, info)
+		return resp
+	}
+
+	if req.Body() != nil {
+		// A chunk declared to run past the upload is refused before anything is stored
+		remaining := info.Length - info.Offset
+		if req.HasHeader(pkghttp.HeaderContentLength) && req.ContentLength() > remaining {
+			resp := internalhttp.BuildErrorResponse(pkghttp.StatusRequestEntityTooLarge, ErrUploadTooLarge)
+			setUploadProgress(resp, info)
+			return resp
+		}
+
+		// A chunked body cannot declare its size, so it is checked as it arrives
+		body := &uploadChunkReader{reader: req.Body(), remaining: remaining}
+		n, err := config.Store.Append(id, body)
+		info.Offset += n
+		if body.overshot {
+			resp := internalhttp.BuildErrorResponse(pkghttp.StatusRequestEntityTooLarge, ErrUploadTooLarge)
+			setUploadProgress(resp, info)
+			return resp
+		}
+		if err != nil {
+			return uploadErrorResponse(err)
+		}
+	}
+
+	if info.Complete() && config.OnComplete != nil {
+		if resp := config.OnComplete(req, info); resp != nil {
+			return resp
+		}
+	}
+	return uploadProgressResponse(pkghttp.StatusNoContent, info)
+}
+
+// chunkOffset returns where the client says its data starts: Upload-Offset,
+// or the first byte of a Content-Range such as "bytes 100-199/1000"
+func chunkOffset(req pkghttp.Request) (int64, bool) {
+	if value := req.GetHeader(headerUploadOffset); value != "" {
+		offset, err := strconv.ParseInt(value, 10, 64)
+		return offset, err == nil && offset >= 0
+	}
+
+	unit, rest, found := strings.Cut(req.GetHeader(pkghttp.HeaderContentRange), " ")
+	if !found || !strings.EqualFold(unit, rangeUnitBytes) {
+		return 0, false
+	}
+	first, _, found := strings.Cut(rest, "-")
+	if !found {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(first, 10, 64)
+	return offset, err == nil && offset >= 0
+}
+
+// uploadChunkReader reads a chunk of at most remaining bytes. The read that
+// would fill the upload is held back until the body is known to end there,
+// so a chunk running past the upload never leaves it looking complete.
+type uploadChunkReader struct {
+	reader    io.Reader
+	remaining int64
+	overshot  bool
+}
+
+// Read reads from the chunk, failing once it proves longer than the upload
+func (r *uploadChunkReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, r.checkEnd()
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining > 0 || err != nil {
+		return n, err
+	}
+	if err := r.checkEnd(); err != io.EOF {
+		return 0, err
+	}
+	return n, nil
+}
+
+// checkEnd returns io.EOF when the chunk has no data left after the upload length
+func (r *uploadChunkReader) checkEnd() error {
+	var probe [1]byte
+	n, err := io.ReadFull(r.reader, probe[:])
+	if n > 0 {
+		r.overshot = true
+		return common.InvalidInputError(ErrUploadTooLarge)
+	}
+	return err
+}
+
+// uploadProgressResponse reports the stored offset and total length of an upload
+func uploadProgressResponse(status pkghttp.StatusCode, info UploadInfo) pkghttp.Response {
+	resp := pkghttp.NewResponse(status, pkghttp.Version11)
+	setUploadProgress(resp, info)
+	return resp
+}
+
+// setUploadProgress adds the stored offset and total length of an upload to resp
+func setUploadProgress(resp pkghttp.Response, info UploadInfo) {
+	resp.SetHeader(headerUploadOffset, strconv.FormatInt(info.Offset, 10))
+	resp.SetHeader(headerUploadLength, strconv.FormatInt(info.Length, 10))
+}
+
+// uploadErrorResponse maps a store failure to a response
+func uploadErrorResponse(err error) pkghttp.Response {
+	if errors.Is(err, fs.ErrNotExist) {
+		return internalhttp.BuildErrorResponse(pkghttp.StatusNotFound, "")
+	}
+	return internalhttp.BuildErrorResponse(pkghttp.StatusInternalServerError, "")
+}
+
+// uploadLocks serializes appends to each upload
+type uploadLocks struct {
+	held map[string]*sync.Mutex
+	refs map[string]int
+	mu   sync.Mutex
+}
+
+// lock waits for exclusive access to the upload id and returns its release
+func (l *uploadLocks) lock(id string) func() {
+	l.mu.Lock()
+	m, ok := l.held[id]
+	if !ok {
+		m = &sync.Mutex{}
+		l.held[id] = m
+	}
+	l.refs[id]++
+	l.mu.Unlock()
+
+	m.Lock()
+	return func() {
+		m.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.refs[id]--; l.refs[id] == 0 {
+			delete(l.held, id)
+			delete(l.refs, id)
+		}
+	}
+}
+
+// FileUploadStore keeps resumable uploads as files in a directory: the data
+// in a file named by the upload ID and its length beside it
+type FileUploadStore struct {
+	dir string
+}
+
+// NewFileUploadStore creates a store in dir, which must exist
+func NewFileUploadStore(dir string) *FileUploadStore {
+	return &FileUploadStore{dir: dir}
+}
+
+// Path returns the file holding the data of upload id, for moving it once complete
+func (s *FileUploadStore) Path(id string) (string, error) {
+	if !isUploadID(id) {
+		return "", common.InvalidInputErrorWithCause(ErrInvalidUploadID, fs.ErrNotExist)
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// Create starts an empty upload of length bytes
+func (s *FileUploadStore) Create(length int64) (string, error) {
+	var buf [uploadIDBytes]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", common.IOErrorWithCause(ErrSaveUpload, err)
+	}
+	id := hex.EncodeToString(buf[:])
+	path := filepath.Join(s.dir, id)
+
+	if err := os.WriteFile(path+uploadLengthSuffix, []byte(strconv.FormatInt(length, 10)), 0o600); err != nil {
+		return "", common.IOErrorWithCause(ErrSaveUpload, err)
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		os.Remove(path + uploadLengthSuffix)
+		return "", common.IOErrorWithCause(ErrSaveUpload, err)
+	}
+	return id, nil
+}
+
+// Info reads the length of upload id and how much of it is stored
+func (s *FileUploadStore) Info(id string) (UploadInfo, error) {
+	path, err := s.Path(id)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	data, err := os.ReadFile(path + uploadLengthSuffix)
+	if err != nil {
+		return UploadInfo{}, common.IOErrorWithCause(ErrInvalidUploadID, err)
+	}
+	length, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return UploadInfo{}, common.IOErrorWithCause(ErrInvalidUploadLength, err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return UploadInfo{}, common.IOErrorWithCause(ErrInvalidUploadID, err)
+	}
+	return UploadInfo{ID: id, Offset: stat.Size(), Length: length}, nil
+}
+
+// Append writes data to the end of upload id
+func (s *FileUploadStore) Append(id string, data io.Reader) (int64, error) {
+	path, err := s.Path(id)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return 0, common.IOErrorWithCause(ErrInvalidUploadID, err)
+	}
+	n, err := io.Copy(file, data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, common.IOErrorWithCause(ErrSaveUpload, err)
+	}
+	return n, nil
+}
+
+// Delete removes the files of upload id
+func (s *FileUploadStore) Delete(id string) error {
+	path, err := s.Path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path + uploadLengthSuffix); err != nil {
+		return common.IOErrorWithCause(ErrInvalidUploadID, err)
+	}
+	os.Remove(path)
+	return nil
+}
+
+// isUploadID reports whether id has the form Create gives, so it cannot name
+// a file outside the store
+func isUploadID(id string) bool {
+	if len(id) != hex.EncodedLen(uploadIDBytes) {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestResumableUploads(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileUploadStore(dir)
+	completed := make(chan UploadInfo, 1)
+	server := startTestServer(t, ResumableUploads(ResumableUploadConfig{
+		Prefix:  "/uploads",
+		Store:   store,
+		MaxSize: 100,
+		OnComplete: func(req pkghttp.Request, info UploadInfo) pkghttp.Response {
+			completed <- info
+			return nil
+		},
+	}))
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "POST /uploads HTTP/1.1\r\nHost: localhost\r\nUpload-Length: 10\r\nContent-Length: 0\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode())
+	}
+	location := resp.GetHeader(pkghttp.HeaderLocation)
+	if !strings.HasPrefix(location, "/uploads/") {
+		t.Fatalf("Unexpected Location %q", location)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		body   string
+		status pkghttp.StatusCode
+		offset string
+	}{
+		{name: "first chunk", method: "PATCH", path: location, header: "Upload-Offset: 0", body: "01234", status: pkghttp.StatusNoContent, offset: "5"},
+		{name: "progress", method: "HEAD", path: location, status: pkghttp.StatusOK, offset: "5"},
+		{name: "stale offset", method: "PATCH", path: location, header: "Upload-Offset: 2", body: "23456", status: pkghttp.StatusConflict, offset: "5"},
+		{name: "missing offset", method: "PATCH", path: location, body: "56789", status: pkghttp.StatusBadRequest},
+		{name: "content range chunk", method: "PATCH", path: location, header: "Content-Range: bytes 5-9/10", body: "56789", status: pkghttp.StatusNoContent, offset: "10"},
+		{name: "beyond the length", method: "PATCH", path: location, header: "Upload-Offset: 10", body: "x", status: pkghttp.StatusRequestEntityTooLarge},
+		{name: "unknown upload", method: "HEAD", path: "/uploads/" + strings.Repeat("0", 32), status: pkghttp.StatusNotFound},
+		{name: "invalid id", method: "HEAD", path: "/uploads/..%2Fsecret", status: pkghttp.StatusNotFound},
+		{name: "too large", method: "POST", path: "/uploads", header: "Upload-Length: 1000", status: pkghttp.StatusRequestEntityTooLarge},
+		{name: "no length", method: "POST", path: "/uploads", status: pkghttp.StatusBadRequest},
+		{name: "wrong method", method: "PUT", path: location, status: pkghttp.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := tt.method + " " + tt.path + " HTTP/1.1\r\nHost: localhost\r\n"
+			if tt.header != "" {
+				raw += tt.header + "\r\n"
+			}
+			raw += "Content-Length: " + strconv.Itoa(len(tt.body)) + "\r\n\r\n" + tt.body
+
+			if _, err := io.WriteString(conn, raw); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			resp, err := internalhttp.ReadResponseForMethod(reader, pkghttp.Method(tt.method))
+			if err != nil {
+				t.Fatalf("ReadResponse failed: %v", err)
+			}
+			if resp.Body() != nil {
+				io.Copy(io.Discard, resp.Body())
+			}
+
+			if resp.StatusCode() != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode())
+			}
+			if tt.offset != "" && resp.GetHeader(headerUploadOffset) != tt.offset {
+				t.Errorf("Expected Upload-Offset %s, got %q", tt.offset, resp.GetHeader(headerUploadOffset))
+			}
+			if resp.GetHeader(headerTusResumable) != tusVersion {
+				t.Errorf("Expected Tus-Resumable %s, got %q", tusVersion, resp.GetHeader(headerTusResumable))
+			}
+		})
+	}
+
+	select {
+	case info := <-completed:
+		path, _ := store.Path(info.ID)
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "0123456789" {
+			t.Errorf("Expected the assembled upload, got %q (%v)", data, err)
+		}
+	default:
+		t.Fatal("Expected OnComplete to be called")
+	}
+
+	resp, _ = roundTrip(t, conn, reader, "DELETE "+location+" HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", resp.StatusCode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the upload files to be removed, found %d", len(entries))
+	}
+}
+
+func TestResumableUploadOvershoot(t *testing.T) {
+	store := NewFileUploadStore(t.TempDir())
+	completed := make(chan UploadInfo, 1)
+	server := startTestServer(t, ResumableUploads(ResumableUploadConfig{
+		Prefix: "/uploads",
+		Store:  store,
+		OnComplete: func(req pkghttp.Request, info UploadInfo) pkghttp.Response {
+			completed <- info
+			return nil
+		},
+	}))
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "POST /uploads HTTP/1.1\r\nHost: localhost\r\nUpload-Length: 10\r\nContent-Length: 0\r\n\r\n")
+	location := resp.GetHeader(pkghttp.HeaderLocation)
+
+	// A declared length past the upload is refused without storing anything
+	resp, _ = roundTrip(t, conn, reader, "PATCH "+location+" HTTP/1.1\r\nHost: localhost\r\n"+
+		"Upload-Offset: 0\r\nContent-Length: 12\r\n\r\n0123456789ab")
+	if resp.StatusCode() != pkghttp.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status 413, got %d", resp.StatusCode())
+	}
+	if offset := resp.GetHeader(headerUploadOffset); offset != "0" {
+		t.Errorf("Expected nothing stored, got Upload-Offset %q", offset)
+	}
+
+	// A chunked body running past the length is refused and leaves the upload incomplete
+	resp, _ = roundTrip(t, conn, reader, "PATCH "+location+" HTTP/1.1\r\nHost: localhost\r\n"+
+		"Upload-Offset: 0\r\nTransfer-Encoding: chunked\r\n\r\nc\r\n0123456789ab\r\n0\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status 413, got %d", resp.StatusCode())
+	}
+	if offset := resp.GetHeader(headerUploadOffset); offset != "0" {
+		t.Errorf("Expected nothing stored, got Upload-Offset %q", offset)
+	}
+	if info, err := store.Info(strings.TrimPrefix(location, "/uploads/")); err != nil || info.Complete() {
+		t.Errorf("Expected the stored upload to stay incomplete, got %+v (%v)", info, err)
+	}
+
+	// The exact remainder then completes it
+	resp, _ = roundTrip(t, conn, reader, "PATCH "+location+" HTTP/1.1\r\nHost: localhost\r\n"+
+		"Upload-Offset: 0\r\nTransfer-Encoding: chunked\r\n\r\na\r\n0123456789\r\n0\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", resp.StatusCode())
+	}
+
+	select {
+	case info := <-completed:
+		path, _ := store.Path(info.ID)
+		if data, err := os.ReadFile(path); err != nil || string(data) != "0123456789" {
+			t.Errorf("Expected only the exact chunk stored, got %q (%v)", data, err)
+		}
+	default:
+		t.Fatal("Expected OnComplete to be called")
+	}
+}
@@ -0,0 +1,501 @@
+package server
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// Router implements the http.Router interface. Paths are matched segment by
+// segment: ":name" captures one segment and "*name" captures the rest of the path.
+// Static segments take precedence over parameters, and parameters over wildcards.
+type Router struct {
+	root             *routeNode
+	middleware       []pkghttp.MiddlewareFunc
+	notFound         pkghttp.RequestHandler
+	methodNotAllowed pkghttp.RequestHandler
+	errorRenderer    ErrorRenderer
+	routes           []RouteInfo
+	trailingSlash    TrailingSlashPolicy
+	caseInsensitive  bool
+	mu               sync.RWMutex
+}
+
+// TrailingSlashPolicy controls how /foo and /foo/ relate when only one is registered
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashStrict treats /foo and /foo/ as different paths
+	TrailingSlashStrict TrailingSlashPolicy = iota
+	// TrailingSlashRedirect redirects to the registered form of the path
+	TrailingSlashRedirect
+	// TrailingSlashIgnore serves either form with the registered handler
+	TrailingSlashIgnore
+)
+
+// RouterOption configures a Router
+type RouterOption func(*Router)
+
+// WithTrailingSlash sets the trailing slash policy
+func WithTrailingSlash(policy TrailingSlashPolicy) RouterOption {
+	return func(r *Router) {
+		r.trailingSlash = policy
+	}
+}
+
+// WithCaseInsensitivePaths makes static path segments match regardless of case.
+// Captured parameters keep the case the client sent.
+func WithCaseInsensitivePaths() RouterOption {
+	return func(r *Router) {
+		r.caseInsensitive = true
+	}
+}
+
+// routeNode is one path segment in the routing tree
+type routeNode struct {
+	static   map[string]*routeNode
+	param    *routeNode
+	wildcard *routeNode
+	name     string
+	handlers map[pkghttp.Method]pkghttp.RequestHandler
+}
+
+// NewRouter creates an empty router
+func NewRouter(options ...RouterOption) *Router {
+	r := &Router{
+		root: newRouteNode(""),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// newRouteNode creates a tree node capturing into name, if it is a parameter
+func newRouteNode(name string) *routeNode {
+	return &routeNode{
+		static: make(map[string]*routeNode),
+		name:   name,
+	}
+}
+
+// Handle registers a handler for a method and path pattern.
+// It panics if the pattern conflicts with an existing route, or if method is
+// neither standard nor registered with pkghttp.RegisterMethod.
+func (r *Router) Handle(method pkghttp.Method, path string, handler pkghttp.RequestHandler) {
+	if !pkghttp.IsKnownMethod(method) {
+		panic("router: unknown method " + string(method) + " for " + path)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	node := r.root
+	segments := splitPath(path)
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, routeParamPrefix):
+			node = node.paramChild(path, segment[len(routeParamPrefix):])
+		case strings.HasPrefix(segment, routeWildcardPrefix):
+			if i != len(segments)-1 {
+				panic("router: wildcard must be the last segment in " + path)
+			}
+			node = node.wildcardChild(path, segment[len(routeWildcardPrefix):])
+		default:
+			key := r.staticKey(segment)
+			child, exists := node.static[key]
+			if !exists {
+				child = newRouteNode("")
+				node.static[key] = child
+			}
+			node = child
+		}
+	}
+
+	if node.handlers == nil {
+		node.handlers = make(map[pkghttp.Method]pkghttp.RequestHandler)
+	}
+	if _, exists := node.handlers[method]; !exists {
+		r.routes = append(r.routes, RouteInfo{Method: method, Path: path})
+	}
+	node.handlers[method] = handler
+}
+
+// Describe attaches documentation to the route registered for method and
+// path, for OpenAPI generation. It panics if no such route exists.
+func (r *Router) Describe(method pkghttp.Method, path string, doc RouteDoc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.routes {
+		if r.routes[i].Method == method && r.routes[i].Path == path {
+			r.routes[i].Doc = doc
+			return
+		}
+	}
+	panic("router: cannot describe unregistered route " + string(method) + " " + path)
+}
+
+// Routes returns the registered routes in registration order
+func (r *Router) Routes() []RouteInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]RouteInfo(nil), r.routes...)
+}
+
+// paramChild returns the single-segment parameter child, creating it if needed
+func (n *routeNode) paramChild(path, name string) *routeNode {
+	if name == "" {
+		panic("router: unnamed parameter in " + path)
+	}
+	if n.param == nil {
+		n.param = newRouteNode(name)
+	} else if n.param.name != name {
+		panic("router: parameter :" + name + " in " + path + " conflicts with :" + n.param.name)
+	}
+	return n.param
+}
+
+// wildcardChild returns the catch-all child, creating it if needed
+func (n *routeNode) wildcardChild(path, name string) *routeNode {
+	if name == "" {
+		panic("router: unnamed wildcard in " + path)
+	}
+	if n.wildcard == nil {
+		n.wildcard = newRouteNode(name)
+	} else if n.wildcard.name != name {
+		panic("router: wildcard *" + name + " in " + path + " conflicts with *" + n.wildcard.name)
+	}
+	return n.wildcard
+}
+
+// HandleFunc registers a handler function
+func (r *Router) HandleFunc(method pkghttp.Method, path string, handler func(pkghttp.Request) pkghttp.Response) {
+	r.Handle(method, path, handler)
+}
+
+// Use adds middleware, applied in the order given to every routed request
+func (r *Router) Use(middleware pkghttp.MiddlewareFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, middleware)
+}
+
+// SetNotFoundHandler sets the handler for requests whose path matches no route
+func (r *Router) SetNotFoundHandler(handler pkghttp.RequestHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notFound = handler
+}
+
+// SetMethodNotAllowedHandler sets the handler for requests whose path matches
+// but whose method does not. The router adds the Allow header if it is missing.
+func (r *Router) SetMethodNotAllowedHandler(handler pkghttp.RequestHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methodNotAllowed = handler
+}
+
+// SetErrorRenderer sets how the router renders its own error responses
+func (r *Router) SetErrorRenderer(renderer ErrorRenderer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errorRenderer = renderer
+}
+
+// Error renders an error response with the router's error renderer, so
+// handlers can produce error pages consistent with the router's own
+func (r *Router) Error(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+	r.mu.RLock()
+	renderer := orDefaultRenderer(r.errorRenderer)
+	r.mu.RUnlock()
+
+	return renderer(req, status, message)
+}
+
+// Route finds the handler registered for the request's method and path and
+// returns the captured path parameters. HEAD requests fall back to the GET
+// handler; the server strips the body.
+func (r *Router) Route(req pkghttp.Request) (pkghttp.RequestHandler, map[string]string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	node, params := r.find(requestPath(req))
+	if node == nil {
+		return nil, nil
+	}
+
+	if handler, exists := node.handlers[req.Method()]; exists {
+		return handler, params
+	}
+
+	if req.Method() == pkghttp.MethodHead {
+		if handler, exists := node.handlers[pkghttp.MethodGet]; exists {
+			return handler, params
+		}
+	}
+
+	return nil, nil
+}
+
+// find looks up the route node for path, applying the router's matching options.
+// The caller must hold r.mu.
+func (r *Router) find(path string) (*routeNode, map[string]string) {
+	if path == "" {
+		return nil, nil
+	}
+
+	params := make(map[string]string)
+	if node := r.root.match(splitPath(path), params, r.staticKey); node != nil {
+		return node, params
+	}
+
+	if r.trailingSlash == TrailingSlashIgnore {
+		if alternate := toggleTrailingSlash(path); alternate != "" {
+			params = make(map[string]string)
+			if node := r.root.match(splitPath(alternate), params, r.staticKey); node != nil {
+				return node, params
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// staticKey returns the tree key for a static segment
+func (r *Router) staticKey(segment string) string {
+	if r.caseInsensitive {
+		return strings.ToLower(segment)
+	}
+	return segment
+}
+
+// match finds the node with handlers for segments, recording captured parameters
+func (n *routeNode) match(segments []string, params map[string]string, staticKey func(string) string) *routeNode {
+	if len(segments) == 0 {
+		if n.handlers != nil {
+			return n
+		}
+		return nil
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	if child, exists := n.static[staticKey(segment)]; exists {
+		if found := child.match(rest, params, staticKey); found != nil {
+			return found
+		}
+	}
+
+	if n.param != nil && segment != "" {
+		if found := n.param.match(rest, params, staticKey); found != nil {
+			params[n.param.name] = segment
+			return found
+		}
+	}
+
+	if n.wildcard != nil && n.wildcard.handlers != nil {
+		params[n.wildcard.name] = strings.Join(segments, "/")
+		return n.wildcard
+	}
+
+	return nil
+}
+
+// AllowedMethods returns the methods that can be served for path, or nil when
+// nothing is registered there. HEAD and OPTIONS are implied by the router.
+func (r *Router) AllowedMethods(path string) []pkghttp.Method {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	node, _ := r.find(cleanPath(stripQuery(path)))
+	if node == nil {
+		return nil
+	}
+	methods := node.handlers
+
+	allowed := []pkghttp.Method{pkghttp.MethodOptions}
+	for method := range methods {
+		if method != pkghttp.MethodOptions {
+			allowed = append(allowed, method)
+		}
+	}
+	if _, hasGet := methods[pkghttp.MethodGet]; hasGet {
+		if _, hasHead := methods[pkghttp.MethodHead]; !hasHead {
+			allowed = append(allowed, pkghttp.MethodHead)
+		}
+	}
+
+	sortMethods(allowed)
+	return allowed
+}
+
+// ServeRequest routes the request and runs it through the router middleware
+func (r *Router) ServeRequest(req pkghttp.Request) pkghttp.Response {
+	handler, params := r.Route(req)
+	if handler == nil {
+		handler = r.fallbackHandler(req)
+	} else {
+		req.SetPathParams(params)
+	}
+
+	r.mu.RLock()
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	r.mu.RUnlock()
+
+	return handler(req)
+}
+
+// splitPath splits a path into segments; "/" is one empty segment and a
+// trailing slash yields a final empty segment, so /a and /a/ stay distinct
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// toggleTrailingSlash adds or removes a trailing slash; the root has no alternate
+func toggleTrailingSlash(path string) string {
+	switch {
+	case path == "/" || path == "":
+		return ""
+	case strings.HasSuffix(path, "/"):
+		return strings.TrimSuffix(path, "/")
+	default:
+		return path + "/"
+	}
+}
+
+// requestPath returns the request path without its query string, decoded and
+// normalized for matching
+func requestPath(req pkghttp.Request) string {
+	return cleanPath(stripQuery(req.Path()))
+}
+
+// cleanPath decodes path and removes its dot segments. A path that cannot be
+// decoded becomes "", which matches no route.
+func cleanPath(path string) string {
+	cleaned, _ := pkghttp.CleanPath(path)
+	return cleaned
+}
+
+// stripQuery removes a query string from path
+func stripQuery(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	return path
+}
+
+// fallbackHandler answers requests no registered handler matched: OPTIONS
+// and other methods on a known path get the Allow list, anything else 404
+func (r *Router) fallbackHandler(req pkghttp.Request) pkghttp.RequestHandler {
+	if redirect := r.trailingSlashRedirect(req); redirect != nil {
+		return redirect
+	}
+
+	allowed := r.AllowedMethods(req.Path())
+
+	r.mu.RLock()
+	notFound, methodNotAllowed := r.notFound, r.methodNotAllowed
+	r.mu.RUnlock()
+
+	switch {
+	case allowed == nil:
+		if notFound != nil {
+			return notFound
+		}
+		return func(req pkghttp.Request) pkghttp.Response {
+			return r.Error(req, pkghttp.StatusNotFound, "")
+		}
+	case req.Method() == pkghttp.MethodOptions:
+		return func(req pkghttp.Request) pkghttp.Response {
+			return OptionsResponse(allowed)
+		}
+	}
+
+	if methodNotAllowed == nil {
+		methodNotAllowed = func(req pkghttp.Request) pkghttp.Response {
+			return r.Error(req, pkghttp.StatusMethodNotAllowed, "")
+		}
+	}
+
+	return func(req pkghttp.Request) pkghttp.Response {
+		resp := methodNotAllowed(req)
+		if resp != nil && !resp.HasHeader(pkghttp.HeaderAllow) {
+			resp.SetHeader(pkghttp.HeaderAllow, FormatAllow(allowed))
+		}
+		return resp
+	}
+}
+
+// trailingSlashRedirect returns a handler redirecting to the registered form of
+// the request path, or nil when the policy or the routes do not call for one
+func (r *Router) trailingSlashRedirect(req pkghttp.Request) pkghttp.RequestHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.trailingSlash != TrailingSlashRedirect {
+		return nil
+	}
+
+	_, query, _ := strings.Cut(req.Path(), "?")
+	alternate := toggleTrailingSlash(requestPath(req))
+	if alternate == "" {
+		return nil
+	}
+	if node, _ := r.find(alternate); node == nil {
+		return nil
+	}
+
+	// The matched path is decoded, so escape it again for the Location header
+	location := (&url.URL{Path: alternate, RawQuery: query}).String()
+
+	// 301 lets clients turn other methods into GET; 308 preserves them
+	status := pkghttp.StatusPermanentRedirect
+	if req.Method() == pkghttp.MethodGet || req.Method() == pkghttp.MethodHead {
+		status = pkghttp.StatusMovedPermanently
+	}
+
+	return func(req pkghttp.Request) pkghttp.Response {
+		return internalhttp.BuildRedirectResponse(status, location)
+	}
+}
+
+// OptionsResponse builds an empty response advertising the allowed methods
+func OptionsResponse(allowed []pkghttp.Method) pkghttp.Response {
+	resp := pkghttp.NewResponse(pkghttp.StatusNoContent, pkghttp.Version11)
+	resp.SetHeader(pkghttp.HeaderAllow, FormatAllow(allowed))
+	return resp
+}
+
+// FormatAllow renders methods as an Allow header value
+func FormatAllow(methods []pkghttp.Method) string {
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = string(method)
+	}
+	return strings.Join(names, ", ")
+}
+
+// sortMethods orders methods as listed in standardMethods, then alphabetically
+func sortMethods(methods []pkghttp.Method) {
+	rank := func(method pkghttp.Method) int {
+		for i, standard := range standardMethods {
+			if method == standard {
+				return i
+			}
+		}
+		return len(standardMethods)
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		ri, rj := rank(methods[i]), rank(methods[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return methods[i] < methods[j]
+	})
+}
@@ -0,0 +1,472 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestRouterRoute(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/users", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "list")
+	})
+	router.HandleFunc(pkghttp.MethodPost, "/users", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusCreated, pkghttp.Version11, "created")
+	})
+
+	tests := []struct {
+		name     string
+		method   pkghttp.Method
+		path     string
+		expected pkghttp.StatusCode
+	}{
+		{name: "GET route", method: pkghttp.MethodGet, path: "/users", expected: pkghttp.StatusOK},
+		{name: "POST route", method: pkghttp.MethodPost, path: "/users", expected: pkghttp.StatusCreated},
+		{name: "HEAD falls back to GET", method: pkghttp.MethodHead, path: "/users", expected: pkghttp.StatusOK},
+		{name: "unknown path", method: pkghttp.MethodGet, path: "/missing", expected: pkghttp.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := router.ServeRequest(pkghttp.NewRequest(tt.method, tt.path, pkghttp.Version11))
+			if resp.StatusCode() != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, resp.StatusCode())
+			}
+		})
+	}
+}
+
+func TestRouterPrefersExplicitHeadHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/", okHandler)
+	router.HandleFunc(pkghttp.MethodHead, "/", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewResponse(pkghttp.StatusNoContent, pkghttp.Version11)
+	})
+
+	resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodHead, "/", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusNoContent {
+		t.Errorf("Expected explicit HEAD handler, got %d", resp.StatusCode())
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/", okHandler)
+	router.Use(func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			resp := next(req)
+			resp.SetHeader("X-Routed", "yes")
+			return resp
+		}
+	})
+
+	for _, path := range []string{"/", "/missing"} {
+		resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, path, pkghttp.Version11))
+		if resp.GetHeader("X-Routed") != "yes" {
+			t.Errorf("Middleware did not run for %s", path)
+		}
+	}
+}
+
+func TestServerHeadSuppressesBody(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "hello")
+	})
+
+	server := startTestServer(t, nil)
+	server.SetRouter(router)
+	conn, reader := dialTestServer(t, server)
+
+	if _, err := io.WriteString(conn, "HEAD / HTTP/1.1\r\nHost: localhost\r\n\r\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	resp, err := internalhttp.ReadResponseForMethod(reader, pkghttp.MethodHead)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode())
+	}
+	if resp.GetHeader(pkghttp.HeaderContentLength) != "5" {
+		t.Errorf("Expected GET Content-Length to be preserved, got %q", resp.GetHeader(pkghttp.HeaderContentLength))
+	}
+	if resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeTextPlain {
+		t.Errorf("Expected Content-Type to be preserved, got %q", resp.GetHeader(pkghttp.HeaderContentType))
+	}
+
+	// Any body bytes would corrupt the next response on the connection
+	_, body := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if body != "hello" {
+		t.Errorf("Unexpected body after HEAD: %q", body)
+	}
+}
+
+func TestRouterOptions(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/users", okHandler)
+	router.HandleFunc(pkghttp.MethodPost, "/users", okHandler)
+	router.HandleFunc(pkghttp.MethodDelete, "/users", okHandler)
+
+	resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodOptions, "/users", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode())
+	}
+	if allow := resp.GetHeader(pkghttp.HeaderAllow); allow != "GET, HEAD, POST, DELETE, OPTIONS" {
+		t.Errorf("Unexpected Allow header: %q", allow)
+	}
+
+	resp = router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodOptions, "/missing", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusNotFound {
+		t.Errorf("Expected 404 for unknown path, got %d", resp.StatusCode())
+	}
+}
+
+func TestRouterExplicitOptionsHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodOptions, "/", okHandler)
+
+	resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodOptions, "/", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected registered OPTIONS handler, got %d", resp.StatusCode())
+	}
+}
+
+func TestServerOptionsAsterisk(t *testing.T) {
+	server := startTestServer(t, okHandler)
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "OPTIONS * HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode())
+	}
+	// Extension methods registered by other tests follow the standard ones
+	if allow := resp.GetHeader(pkghttp.HeaderAllow); !strings.HasPrefix(allow, FormatAllow(standardMethods)) {
+		t.Errorf("Unexpected Allow header: %q", allow)
+	}
+
+	server.SetOptionsHandler(func(req pkghttp.Request) pkghttp.Response {
+		resp := pkghttp.NewResponse(pkghttp.StatusOK, pkghttp.Version11)
+		resp.SetHeader(pkghttp.HeaderAllow, "GET")
+		return resp
+	})
+
+	resp, _ = roundTrip(t, conn, reader, "OPTIONS * HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.GetHeader(pkghttp.HeaderAllow) != "GET" {
+		t.Errorf("Expected custom OPTIONS handler, got Allow %q", resp.GetHeader(pkghttp.HeaderAllow))
+	}
+}
+
+func TestRouterExtensionMethods(t *testing.T) {
+	t.Run("unregistered method panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected a panic for an unregistered method")
+			}
+		}()
+		NewRouter().HandleFunc("UNREGISTERED", "/", okHandler)
+	})
+
+	pkghttp.RegisterMethod("PROPFIND")
+	pkghttp.RegisterMethod("MKCOL")
+
+	router := NewRouter()
+	router.HandleFunc("PROPFIND", "/files", okHandler)
+	router.HandleFunc(pkghttp.MethodGet, "/files", okHandler)
+	server := startTestServer(t, router.ServeRequest)
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "PROPFIND /files HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode())
+	}
+
+	resp, _ = roundTrip(t, conn, reader, "MKCOL /files HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", resp.StatusCode())
+	}
+	if allow := resp.GetHeader(pkghttp.HeaderAllow); allow != "GET, HEAD, OPTIONS, PROPFIND" {
+		t.Errorf("Unexpected Allow header: %q", allow)
+	}
+
+	resp, _ = roundTrip(t, conn, reader, "OPTIONS * HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if allow := resp.GetHeader(pkghttp.HeaderAllow); !strings.HasSuffix(allow, "MKCOL, PROPFIND") {
+		t.Errorf("Expected extension methods in Allow, got %q", allow)
+	}
+}
+
+func TestRouterPathParams(t *testing.T) {
+	router := NewRouter()
+	echoParams := func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			req.PathParam("id")+"|"+req.PathParam("post")+"|"+req.PathParam("filepath"))
+	}
+	router.HandleFunc(pkghttp.MethodGet, "/users/:id", echoParams)
+	router.HandleFunc(pkghttp.MethodGet, "/users/me", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "static")
+	})
+	router.HandleFunc(pkghttp.MethodGet, "/users/:id/posts/:post", echoParams)
+	router.HandleFunc(pkghttp.MethodGet, "/static/*filepath", echoParams)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		status   pkghttp.StatusCode
+	}{
+		{name: "single parameter", path: "/users/42", expected: "42||", status: pkghttp.StatusOK},
+		{name: "query string ignored", path: "/users/42?verbose=1", expected: "42||", status: pkghttp.StatusOK},
+		{name: "static beats parameter", path: "/users/me", expected: "static", status: pkghttp.StatusOK},
+		{name: "nested parameters", path: "/users/7/posts/99", expected: "7|99|", status: pkghttp.StatusOK},
+		{name: "wildcard", path: "/static/css/site.css", expected: "||css/site.css", status: pkghttp.StatusOK},
+		{name: "empty segment does not match parameter", path: "/users/", status: pkghttp.StatusNotFound},
+		{name: "extra segment", path: "/users/42/extra", status: pkghttp.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11))
+			if resp.StatusCode() != tt.status {
+				t.Fatalf("Expected %d, got %d", tt.status, resp.StatusCode())
+			}
+			if tt.status != pkghttp.StatusOK {
+				return
+			}
+
+			body, _ := io.ReadAll(resp.Body())
+			if string(body) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestRouterRouteReturnsParams(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/items/:sku", okHandler)
+
+	handler, params := router.Route(pkghttp.NewRequest(pkghttp.MethodGet, "/items/abc-1", pkghttp.Version11))
+	if handler == nil {
+		t.Fatal("Expected a handler")
+	}
+	if params["sku"] != "abc-1" {
+		t.Errorf("Expected sku=abc-1, got %v", params)
+	}
+}
+
+func TestRouterConflictingParams(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/users/:id", okHandler)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for conflicting parameter names")
+		}
+	}()
+	router.HandleFunc(pkghttp.MethodGet, "/users/:name/profile", okHandler)
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/users/:id", okHandler)
+	router.HandleFunc(pkghttp.MethodPut, "/users/:id", okHandler)
+
+	resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodDelete, "/users/1", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusMethodNotAllowed {
+		t.Fatalf("Expected 405, got %d", resp.StatusCode())
+	}
+	if allow := resp.GetHeader(pkghttp.HeaderAllow); allow != "GET, HEAD, PUT, OPTIONS" {
+		t.Errorf("Unexpected Allow header: %q", allow)
+	}
+
+	resp = router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodDelete, "/posts/1", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusNotFound {
+		t.Errorf("Expected 404 for unknown path, got %d", resp.StatusCode())
+	}
+}
+
+func TestRouterCustomErrorHandlers(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/items", okHandler)
+	router.SetErrorRenderer(JSONErrorRenderer)
+
+	resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, "/missing", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusNotFound || resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeJSON {
+		t.Errorf("Expected JSON 404, got %d %s", resp.StatusCode(), resp.GetHeader(pkghttp.HeaderContentType))
+	}
+
+	resp = router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodPost, "/items", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusMethodNotAllowed || resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeJSON {
+		t.Errorf("Expected JSON 405, got %d %s", resp.StatusCode(), resp.GetHeader(pkghttp.HeaderContentType))
+	}
+
+	router.SetNotFoundHandler(func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusNotFound, pkghttp.Version11, "nothing at "+req.Path())
+	})
+	router.SetMethodNotAllowedHandler(func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusMethodNotAllowed, pkghttp.Version11, "try another method")
+	})
+
+	resp = router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, "/missing", pkghttp.Version11))
+	if body, _ := io.ReadAll(resp.Body()); string(body) != "nothing at /missing" {
+		t.Errorf("Expected custom 404 body, got %q", body)
+	}
+
+	resp = router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodPost, "/items", pkghttp.Version11))
+	if body, _ := io.ReadAll(resp.Body()); string(body) != "try another method" {
+		t.Errorf("Expected custom 405 body, got %q", body)
+	}
+	if resp.GetHeader(pkghttp.HeaderAllow) != "GET, HEAD, OPTIONS" {
+		t.Errorf("Expected Allow to be added to custom 405, got %q", resp.GetHeader(pkghttp.HeaderAllow))
+	}
+
+	if resp := router.Error(nil, pkghttp.StatusConflict, "taken"); resp.StatusCode() != pkghttp.StatusConflict {
+		t.Errorf("Expected Error to render the given status, got %d", resp.StatusCode())
+	}
+}
+
+func TestServerErrorRenderer(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		panic("boom")
+	})
+	server.SetErrorRenderer(func(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+		return pkghttp.NewTextResponse(status, pkghttp.Version11, "branded "+pkghttp.StatusText(status))
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusInternalServerError || body != "branded Internal Server Error" {
+		t.Errorf("Expected branded 500, got %d %q", resp.StatusCode(), body)
+	}
+
+	resp, body = roundTrip(t, conn, reader, "BROKEN\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusBadRequest || body != "branded Bad Request" {
+		t.Errorf("Expected branded 400, got %d %q", resp.StatusCode(), body)
+	}
+}
+
+func TestRouterTrailingSlashPolicies(t *testing.T) {
+	register := func(router *Router) *Router {
+		router.HandleFunc(pkghttp.MethodGet, "/foo", okHandler)
+		router.HandleFunc(pkghttp.MethodPost, "/bar/", okHandler)
+		return router
+	}
+
+	tests := []struct {
+		name     string
+		router   *Router
+		method   pkghttp.Method
+		path     string
+		status   pkghttp.StatusCode
+		location string
+	}{
+		{name: "strict", router: register(NewRouter()), method: pkghttp.MethodGet, path: "/foo/", status: pkghttp.StatusNotFound},
+		{
+			name:     "redirect GET",
+			router:   register(NewRouter(WithTrailingSlash(TrailingSlashRedirect))),
+			method:   pkghttp.MethodGet,
+			path:     "/foo/?q=1",
+			status:   pkghttp.StatusMovedPermanently,
+			location: "/foo?q=1",
+		},
+		{
+			name:     "redirect POST keeps method",
+			router:   register(NewRouter(WithTrailingSlash(TrailingSlashRedirect))),
+			method:   pkghttp.MethodPost,
+			path:     "/bar",
+			status:   pkghttp.StatusPermanentRedirect,
+			location: "/bar/",
+		},
+		{
+			name:   "redirect unknown path",
+			router: register(NewRouter(WithTrailingSlash(TrailingSlashRedirect))),
+			method: pkghttp.MethodGet,
+			path:   "/baz/",
+			status: pkghttp.StatusNotFound,
+		},
+		{name: "ignore", router: register(NewRouter(WithTrailingSlash(TrailingSlashIgnore))), method: pkghttp.MethodGet, path: "/foo/", status: pkghttp.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.router.ServeRequest(pkghttp.NewRequest(tt.method, tt.path, pkghttp.Version11))
+			if resp.StatusCode() != tt.status {
+				t.Fatalf("Expected %d, got %d", tt.status, resp.StatusCode())
+			}
+			if resp.GetHeader(pkghttp.HeaderLocation) != tt.location {
+				t.Errorf("Expected Location %q, got %q", tt.location, resp.GetHeader(pkghttp.HeaderLocation))
+			}
+		})
+	}
+}
+
+func TestRouterCaseInsensitivePaths(t *testing.T) {
+	echoID := func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.PathParam("id"))
+	}
+
+	sensitive := NewRouter()
+	sensitive.HandleFunc(pkghttp.MethodGet, "/Users/:id", echoID)
+	if resp := sensitive.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, "/users/1", pkghttp.Version11)); resp.StatusCode() != pkghttp.StatusNotFound {
+		t.Errorf("Expected case-sensitive router to 404, got %d", resp.StatusCode())
+	}
+
+	insensitive := NewRouter(WithCaseInsensitivePaths())
+	insensitive.HandleFunc(pkghttp.MethodGet, "/Users/:id", echoID)
+	resp := insensitive.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, "/USERS/AbC", pkghttp.Version11))
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Fatalf("Expected case-insensitive match, got %d", resp.StatusCode())
+	}
+	if body, _ := io.ReadAll(resp.Body()); string(body) != "AbC" {
+		t.Errorf("Parameter case should be preserved, got %q", body)
+	}
+}
+
+func TestRouterMatchesNormalizedPaths(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc(pkghttp.MethodGet, "/files/:name", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, req.PathParam("name"))
+	})
+	router.HandleFunc(pkghttp.MethodGet, "/admin", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "admin")
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected pkghttp.StatusCode
+		body     string
+	}{
+		{name: "percent-encoded parameter", path: "/files/my%20notes.txt", expected: pkghttp.StatusOK, body: "my notes.txt"},
+		{name: "encoded letters", path: "/%61dmin", expected: pkghttp.StatusOK, body: "admin"},
+		{name: "dot segments", path: "/files/../admin", expected: pkghttp.StatusOK, body: "admin"},
+		{name: "encoded dot segments", path: "/files/%2e%2e/admin?x=1", expected: pkghttp.StatusOK, body: "admin"},
+		{name: "current directory", path: "/./files/./a.txt", expected: pkghttp.StatusOK, body: "a.txt"},
+		{name: "no escape above root", path: "/../../admin", expected: pkghttp.StatusOK, body: "admin"},
+		{name: "encoded NUL", path: "/files/a%00.txt", expected: pkghttp.StatusNotFound},
+		{name: "encoded slash is not a separator", path: "/files%2Fsecret", expected: pkghttp.StatusNotFound},
+		{name: "encoded slash stays in a parameter", path: "/files/a%2fb", expected: pkghttp.StatusOK, body: "a%2Fb"},
+		{name: "encoded backslash", path: "/files%5Csecret", expected: pkghttp.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := router.ServeRequest(pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11))
+			if resp.StatusCode() != tt.expected {
+				t.Fatalf("Expected %d, got %d", tt.expected, resp.StatusCode())
+			}
+			if tt.body == "" {
+				return
+			}
+			body, _ := io.ReadAll(resp.Body())
+			if string(body) != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, body)
+			}
+		})
+	}
+}
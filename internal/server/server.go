@@ -0,0 +1,615 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	"github.com/ganyariya/tinyserver/internal/tcp"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// Server implements the http.Server interface on top of the TCP server
+type Server struct {
+	tcpServer      pkgtcp.Server
+	handler        pkghttp.RequestHandler
+	optionsHandler pkghttp.RequestHandler
+	connectHandler pkghttp.RequestHandler
+	proxyHandler   pkghttp.RequestHandler
+	errorRenderer  ErrorRenderer
+	validator      pkghttp.RequestValidator
+	middleware     []pkghttp.MiddlewareFunc
+	parsing        internalhttp.ParserOptions
+	headerTimeout  time.Duration
+	health         *HealthRegistry
+	logger         *common.Logger
+	mu             sync.RWMutex
+}
+
+// NewServer creates a new HTTP server listening on the given address.
+// A "unix:///path/to.sock" address listens on a Unix domain socket instead.
+func NewServer(network, address string) (*Server, error) {
+	if path, ok := internalhttp.UnixSocketPath(address); ok {
+		network, address = pkgtcp.NetworkUnix, path
+	}
+
+	tcpServer, err := tcp.NewServer(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		tcpServer:     tcpServer,
+		headerTimeout: pkghttp.DefaultServerReadHeaderTimeout,
+		logger:        common.NewDefaultLogger(),
+	}
+	tcpServer.SetHandler(s.serveConnection)
+
+	return s, nil
+}
+
+// Start starts the HTTP server
+func (s *Server) Start() error {
+	return s.tcpServer.Start()
+}
+
+// Stop stops the HTTP server like Shutdown, draining connections for the TCP
+// server's drain timeout
+func (s *Server) Stop() error {
+	s.announceDraining(context.Background())
+	return s.tcpServer.Stop()
+}
+
+// IsRunning returns true if the server is running
+func (s *Server) IsRunning() bool {
+	return s.tcpServer.IsRunning()
+}
+
+// Addr returns the server's listening address
+func (s *Server) Addr() net.Addr {
+	return s.tcpServer.Addr()
+}
+
+// SetRouter sets the request router
+func (s *Server) SetRouter(router pkghttp.Router) {
+	s.SetHandler(router.ServeRequest)
+}
+
+// SetHandler sets a single request handler
+func (s *Server) SetHandler(handler pkghttp.RequestHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+}
+
+// SetOptionsHandler sets the handler for server-wide "OPTIONS *" requests.
+// Without one, the server answers with the methods it understands.
+func (s *Server) SetOptionsHandler(handler pkghttp.RequestHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.optionsHandler = handler
+}
+
+// SetConnectHandler sets the handler for CONNECT requests. Returning a
+// TunnelResponse turns the client connection into a tunnel; see ConnectHandler.
+func (s *Server) SetConnectHandler(handler pkghttp.RequestHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connectHandler = handler
+}
+
+// SetProxyHandler sets the handler for absolute-form requests such as
+// "GET http://example.com/ HTTP/1.1", which clients send to a forward proxy
+func (s *Server) SetProxyHandler(handler pkghttp.RequestHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.proxyHandler = handler
+}
+
+// EnableForwardProxy makes the server act as a forward proxy: plain HTTP
+// requests are relayed to their origin and CONNECT requests are tunneled
+func (s *Server) EnableForwardProxy(dial TunnelDialer) {
+	s.SetProxyHandler(ForwardProxyHandler(dial))
+	s.SetConnectHandler(ConnectHandler(dial))
+}
+
+// SetErrorRenderer sets how the server renders the errors it generates itself:
+// unparseable requests, handler panics and requests with no handler
+func (s *Server) SetErrorRenderer(renderer ErrorRenderer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errorRenderer = renderer
+}
+
+// SetRequestValidator sets extra rules requests must pass before reaching the
+// handler, such as required headers or a shorter target limit. Requests that
+// fail are answered with 400 Bad Request, or 414 for an overlong target.
+func (s *Server) SetRequestValidator(validator pkghttp.RequestValidator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validator = validator
+}
+
+// SetMaxRequestBodySize limits request bodies to size bytes. A request declaring
+// a larger body is answered with 413 Request Entity Too Large and its connection
+// closed. Zero restores the default, pkghttp.MaxRequestBodySize.
+func (s *Server) SetMaxRequestBodySize(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parsing.MaxBodySize = size
+}
+
+// SetParserOptions sets the rules requests are parsed under, replacing any
+// limit set with SetMaxRequestBodySize. The zero value parses strictly with
+// the default limits.
+func (s *Server) SetParserOptions(options internalhttp.ParserOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parsing = options
+}
+
+// SetReadHeaderTimeout limits how long a client may take to send a request
+// line and headers once it starts a request, so clients trickling header bytes
+// (slowloris) lose their connection. Zero leaves only the read timeout.
+func (s *Server) SetReadHeaderTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headerTimeout = timeout
+}
+
+// SetHealthRegistry links health to the server lifecycle so readiness fails
+// as soon as Stop is called
+func (s *Server) SetHealthRegistry(health *HealthRegistry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health = health
+}
+
+// readHeaderTimeout returns the configured header read timeout
+func (s *Server) readHeaderTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.headerTimeout
+}
+
+// parserOptions returns the rules requests are read under
+func (s *Server) parserOptions() internalhttp.ParserOptions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.parsing
+}
+
+// SetMiddleware adds middleware, applied in the order given
+func (s *Server) SetMiddleware(middleware ...pkghttp.MiddlewareFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// serveConnection reads requests from a connection until it should be closed
+func (s *Server) serveConnection(conn pkgtcp.Connection) {
+	reader := bufio.NewReaderSize(conn, connectionReaderSize)
+	writer := bufio.NewWriterSize(deadlineWriter{conn: conn, timeout: pkghttp.DefaultServerWriteTimeout}, connectionWriterSize)
+	drain := watchDrain(conn)
+	defer drain.close()
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(pkghttp.DefaultServerReadTimeout)); err != nil {
+			s.logger.Warn("Failed to set read deadline: %v", err)
+		}
+
+		// Wait for the next request under the read timeout, then give its head
+		// the shorter header timeout. A draining server closes idle connections.
+		if !drain.waitIdle() {
+			return
+		}
+		if _, err := reader.Peek(1); err != nil {
+			return
+		}
+		drain.busy()
+		if timeout := s.readHeaderTimeout(); timeout > 0 {
+			if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+				s.logger.Warn("Failed to set read deadline: %v", err)
+			}
+		}
+
+		req, err := internalhttp.ReadRequestWithOptions(reader, conn.RemoteAddr(), s.parserOptions())
+		if err != nil {
+			if !isConnectionGone(err) {
+				s.writeParseError(conn, writer, err)
+			}
+			return
+		}
+
+		// The body as parsed is what must be consumed before the next request,
+		// even if a handler replaces it
+		body := req.Body()
+
+		// The body may take the full read timeout
+		if err := conn.SetReadDeadline(time.Now().Add(pkghttp.DefaultServerReadTimeout)); err != nil {
+			s.logger.Warn("Failed to set read deadline: %v", err)
+		}
+
+		withTLSState(req, conn)
+		interim := withInterimWriter(req, writer)
+		expect := withContinue(req, interim)
+		hijack := withHijacker(req, conn, reader, writer, interim)
+		resp := s.handle(req)
+		if interim != nil {
+			interim.finish()
+		}
+		if hijack.finish() {
+			// The handler owns the connection now, so any response is discarded
+			if resp != nil && resp.Body() != nil {
+				resp.Body().Close()
+			}
+			hijack.wait()
+			return
+		}
+
+		if upgrade, ok := resp.(*UpgradeResponse); ok {
+			s.serveUpgrade(conn, reader, writer, req, upgrade)
+			return
+		}
+
+		if tunnel, ok := resp.(*TunnelResponse); ok {
+			if req.Method() == pkghttp.MethodConnect && pkghttp.IsSuccess(tunnel.StatusCode()) {
+				s.serveTunnel(conn, reader, writer, tunnel)
+				return
+			}
+			tunnel.Upstream.Close()
+		}
+
+		// A client never asked for its body may still be about to send it,
+		// leaving no reliable start for the next request
+		keepAlive := wantsKeepAlive(req, resp) && !drain.isDraining() && expect.asked()
+
+		keepAlive, err = writeResponse(writer, req, resp, keepAlive)
+		if err != nil {
+			s.logger.Debug("Failed to write response to %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+
+		// A body the client was never asked for is not waited for
+		if !expect.asked() || !closeBody(body) || !keepAlive {
+			return
+		}
+	}
+}
+
+// handle runs the request through the middleware chain and handler
+func (s *Server) handle(req pkghttp.Request) (resp pkghttp.Response) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logger.Error("Panic serving %s %s: %v", req.Method(), req.Path(), recovered)
+			resp = s.renderError(req, pkghttp.StatusInternalServerError, "")
+		}
+	}()
+
+	if !expectationsMet(req) {
+		return s.renderError(req, pkghttp.StatusExpectationFailed, ErrUnsupportedExpectation)
+	}
+
+	if err := s.validateRequest(req); err != nil {
+		reason := badRequestReason(err)
+		if reason == internalhttp.ErrTargetTooLong {
+			return s.renderError(req, pkghttp.StatusRequestURITooLong, reason)
+		}
+		return s.renderError(req, pkghttp.StatusBadRequest, reason)
+	}
+
+	resp = s.buildHandler(req)(req)
+	if resp == nil && isHijacked(req) {
+		return nil
+	}
+	if resp == nil {
+		s.logger.Error("Handler returned no response for %s %s", req.Method(), req.Path())
+		resp = s.renderError(req, pkghttp.StatusInternalServerError, "")
+	}
+
+	return resp
+}
+
+// validateRequest checks req against the configured validator, if any
+func (s *Server) validateRequest(req pkghttp.Request) error {
+	s.mu.RLock()
+	validator := s.validator
+	s.mu.RUnlock()
+
+	if validator == nil {
+		return nil
+	}
+	return validator.ValidateRequest(req)
+}
+
+// buildHandler wraps the handler for req with the middleware chain. An
+// absolute-form target is brought into origin form unless a proxy handler takes it.
+func (s *Server) buildHandler(req pkghttp.Request) pkghttp.RequestHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	handler := s.handler
+	switch {
+	case req.Method() == pkghttp.MethodOptions && req.Path() == internalhttp.AsteriskTarget:
+		handler = s.optionsHandler
+		if handler == nil {
+			handler = serverOptionsHandler
+		}
+	case req.Method() == pkghttp.MethodConnect && s.connectHandler != nil:
+		handler = s.connectHandler
+	case s.proxyHandler != nil && internalhttp.IsAbsoluteTarget(req.Path()):
+		handler = s.proxyHandler
+	case internalhttp.IsAbsoluteTarget(req.Path()):
+		// The target names this server, so route on its path alone
+		req.SetPath(internalhttp.OriginForm(req.Path()))
+	}
+	if handler == nil {
+		handler = func(req pkghttp.Request) pkghttp.Response {
+			return s.renderError(req, pkghttp.StatusNotFound, "")
+		}
+	}
+
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
+
+	return handler
+}
+
+// writeParseError reports a request that could not be read before closing the connection
+func (s *Server) writeParseError(conn pkgtcp.Connection, writer *bufio.Writer, cause error) {
+	s.logger.Debug("Bad request from %s: %v", conn.RemoteAddr(), cause)
+
+	reason := badRequestReason(cause)
+	status := pkghttp.StatusBadRequest
+	if reason == internalhttp.ErrRequestBodyTooLarge {
+		status = pkghttp.StatusRequestEntityTooLarge
+	}
+
+	resp := s.renderError(nil, status, reason)
+	prepareHeaders(resp, false)
+	if err := internalhttp.WriteResponse(writer, resp); err != nil {
+		return
+	}
+	writer.Flush()
+}
+
+// renderError builds an error response with the configured renderer
+func (s *Server) renderError(req pkghttp.Request, status pkghttp.StatusCode, message string) pkghttp.Response {
+	s.mu.RLock()
+	renderer := orDefaultRenderer(s.errorRenderer)
+	s.mu.RUnlock()
+
+	return renderer(req, status, message)
+}
+
+// badRequestReason names the protocol rule a request broke, such as
+// conflicting framing headers, or "" when the cause is not a parse error
+func badRequestReason(cause error) string {
+	var tinyErr *common.TinyServerError
+	if errors.As(cause, &tinyErr) && tinyErr.Type == common.ErrorTypeProtocol {
+		return tinyErr.Message
+	}
+	return ""
+}
+
+// serverOptionsHandler answers "OPTIONS *" with every method the server
+// understands, including registered extension methods
+func serverOptionsHandler(req pkghttp.Request) pkghttp.Response {
+	methods := append([]pkghttp.Method{}, standardMethods...)
+	return OptionsResponse(append(methods, pkghttp.ExtensionMethods()...))
+}
+
+// writeResponse frames and writes resp, returning whether the connection may be reused
+func writeResponse(w *bufio.Writer, req pkghttp.Request, resp pkghttp.Response, keepAlive bool) (bool, error) {
+	body := resp.Body()
+	if body != nil {
+		defer body.Close()
+	}
+
+	if !internalhttp.BodyAllowedForStatus(resp.StatusCode()) {
+		body = nil
+	}
+
+	// HTTP/1.0 clients cannot decode chunks, so such a body ends at close instead
+	if req.Version() == pkghttp.Version10 {
+		resp.Headers().Del(pkghttp.HeaderTransferEncoding)
+	}
+
+	// A HEAD response carries the headers a GET would, but never a body
+	head := req.Method() == pkghttp.MethodHead
+
+	chunked := false
+	switch {
+	case head && body != nil && !resp.HasHeader(pkghttp.HeaderContentLength):
+		// The length is unknown without reading the body, so no framing header is sent
+	case body != nil && !resp.HasHeader(pkghttp.HeaderContentLength):
+		if req.Version() != pkghttp.Version10 {
+			chunked = true
+			resp.SetHeader(pkghttp.HeaderTransferEncoding, pkghttp.TransferEncodingChunked)
+		} else {
+			// Without chunking, an HTTP/1.0 body of unknown length ends at close
+			keepAlive = false
+		}
+	case body == nil && internalhttp.BodyAllowedForStatus(resp.StatusCode()) && !resp.HasHeader(pkghttp.HeaderContentLength):
+		resp.SetHeader(pkghttp.HeaderContentLength, "0")
+	}
+
+	prepareHeaders(resp, keepAlive)
+
+	if err := internalhttp.WriteResponseHead(w, resp); err != nil {
+		return false, err
+	}
+
+	if body != nil && !head {
+		if err := copyBody(w, body, chunked); err != nil {
+			return false, common.IOErrorWithCause("failed to write response body", err)
+		}
+	}
+
+	return keepAlive, w.Flush()
+}
+
+// copyBody streams body, as chunks when chunked is set, flushing after every
+// read so data a streamed body produces reaches the client promptly
+func copyBody(w *bufio.Writer, body io.Reader, chunked bool) error {
+	if file, ok := body.(*fileBody); ok && !chunked {
+		_, err := sendFile(w, file)
+		return err
+	}
+	// A file body counted by the access log is still sent with sendfile
+	if counted, ok := body.(*countingBody); ok && !chunked {
+		if file, ok := counted.ReadCloser.(*fileBody); ok {
+			n, err := sendFile(w, file)
+			counted.n += n
+			return err
+		}
+	}
+
+	var dst io.Writer = w
+	var chunkedWriter *internalhttp.ChunkedWriter
+	if chunked {
+		chunkedWriter = internalhttp.NewChunkedWriter(w)
+		dst = chunkedWriter
+	}
+	buf := make([]byte, bodyCopyBufferSize)
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, writeErr := dst.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			if flushErr := w.Flush(); flushErr != nil {
+				return flushErr
+			}
+		}
+
+		if err == io.EOF {
+			if chunkedWriter != nil {
+				return chunkedWriter.Close()
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// prepareHeaders fills in the headers the server is responsible for
+func prepareHeaders(resp pkghttp.Response, keepAlive bool) {
+	if !resp.HasHeader(pkghttp.HeaderDate) {
+		resp.SetHeader(pkghttp.HeaderDate, common.FormatHTTPDate())
+	}
+
+	if !resp.HasHeader(pkghttp.HeaderServer) {
+		resp.SetHeader(pkghttp.HeaderServer, ServerSoftware)
+	}
+
+	if keepAlive {
+		resp.SetHeader(pkghttp.HeaderConnection, pkghttp.ConnectionKeepAlive)
+	} else {
+		resp.SetHeader(pkghttp.HeaderConnection, pkghttp.ConnectionClose)
+	}
+}
+
+// wantsKeepAlive decides whether the connection may serve another request.
+// HTTP/1.0 connections close after each response unless the client sends
+// "Connection: keep-alive".
+func wantsKeepAlive(req pkghttp.Request, resp pkghttp.Response) bool {
+	if hasConnectionToken(req.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionClose) ||
+		hasConnectionToken(resp.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionClose) {
+		return false
+	}
+
+	if req.Version() == pkghttp.Version10 {
+		return hasConnectionToken(req.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionKeepAlive)
+	}
+
+	return true
+}
+
+// hasConnectionToken reports whether a comma-separated Connection header contains token
+func hasConnectionToken(header, token string) bool {
+	for _, value := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(value), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// expectationsMet reports whether the server can meet every expectation in
+// req's Expect header. Only 100-continue is defined, and it is met by sending
+// 100 Continue when the handler first reads the body. HTTP/1.0 requests have
+// no expectations (RFC 7231 section 5.1.1), so their Expect is ignored.
+func expectationsMet(req pkghttp.Request) bool {
+	if req.Version() == pkghttp.Version10 {
+		return true
+	}
+
+	for _, line := range req.GetHeaders(pkghttp.HeaderExpect) {
+		for _, expectation := range strings.Split(line, ",") {
+			expectation = strings.TrimSpace(expectation)
+			if expectation != "" && !strings.EqualFold(expectation, pkghttp.ExpectContinue) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// closeBody closes a request body as read from the connection, reporting
+// whether the connection is positioned at the next request
+func closeBody(body io.ReadCloser) bool {
+	if body == nil {
+		return true
+	}
+	return body.Close() == nil
+}
+
+// deadlineWriter extends the write deadline before every write, so a streamed
+// response only times out when the client stops accepting data
+type deadlineWriter struct {
+	conn    pkgtcp.Connection
+	timeout time.Duration
+}
+
+// Write sets a fresh deadline and writes p
+func (w deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+		return 0, err
+	}
+	return w.conn.Write(p)
+}
+
+// ReadFrom sets a fresh deadline and copies r, letting a connection that
+// implements io.ReaderFrom send files without copying them through userspace
+func (w deadlineWriter) ReadFrom(r io.Reader) (int64, error) {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+		return 0, err
+	}
+	if readerFrom, ok := w.conn.(io.ReaderFrom); ok {
+		return readerFrom.ReadFrom(r)
+	}
+	return io.Copy(w.conn, r)
+}
+
+// isConnectionGone reports whether a read error means the peer went away or idled out
+func isConnectionGone(err error) bool {
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
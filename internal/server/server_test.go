@@ -0,0 +1,598 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// startTestServer starts a server on a random local port with the given handler
+func startTestServer(t testing.TB, handler pkghttp.RequestHandler, middleware ...pkghttp.MiddlewareFunc) *Server {
+	t.Helper()
+
+	server, err := NewServer("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	server.SetHandler(handler)
+	server.SetMiddleware(middleware...)
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	return server
+}
+
+// dialTestServer opens a raw connection to the server
+func dialTestServer(t testing.TB, server *Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, bufio.NewReader(conn)
+}
+
+// roundTrip writes a raw request and reads one response with its body
+func roundTrip(t testing.TB, conn net.Conn, reader *bufio.Reader, rawRequest string) (pkghttp.Response, string) {
+	t.Helper()
+
+	if _, err := io.WriteString(conn, rawRequest); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	resp, err := internalhttp.ReadResponse(reader)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+
+	var body []byte
+	if resp.Body() != nil {
+		body, err = io.ReadAll(resp.Body())
+		if err != nil {
+			t.Fatalf("Reading body failed: %v", err)
+		}
+	}
+
+	return resp, string(body)
+}
+
+func TestServerServesRequests(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "hello "+req.Path())
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET /world HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode())
+	}
+	if body != "hello /world" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+	if resp.GetHeader(pkghttp.HeaderServer) != ServerSoftware {
+		t.Errorf("Expected Server header %s, got %s", ServerSoftware, resp.GetHeader(pkghttp.HeaderServer))
+	}
+	if !resp.HasHeader(pkghttp.HeaderDate) {
+		t.Error("Expected Date header")
+	}
+}
+
+func TestServerKeepAlive(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		body, _ := io.ReadAll(req.Body())
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, string(body))
+	})
+	conn, reader := dialTestServer(t, server)
+
+	for _, payload := range []string{"first", "second"} {
+		raw := "POST /echo HTTP/1.1\r\nHost: localhost\r\nContent-Length: " +
+			strconv.Itoa(len(payload)) + "\r\n\r\n" + payload
+		resp, body := roundTrip(t, conn, reader, raw)
+		if body != payload {
+			t.Errorf("Expected %q, got %q", payload, body)
+		}
+		if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionKeepAlive {
+			t.Errorf("Expected keep-alive, got %s", resp.GetHeader(pkghttp.HeaderConnection))
+		}
+	}
+}
+
+func TestServerDiscardsUnreadBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler pkghttp.RequestHandler
+	}{
+		{name: "body left unread", handler: okHandler},
+		{
+			name: "body partly read and replaced",
+			handler: func(req pkghttp.Request) pkghttp.Response {
+				io.ReadFull(req.Body(), make([]byte, 2))
+				req.SetBody(strings.NewReader("replacement"))
+				return okHandler(req)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startTestServer(t, tt.handler)
+			conn, reader := dialTestServer(t, server)
+
+			for i := 0; i < 2; i++ {
+				raw := "POST /upload HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\n0123456789"
+				resp, _ := roundTrip(t, conn, reader, raw)
+				if resp.StatusCode() != pkghttp.StatusOK {
+					t.Fatalf("Request %d: expected 200, got %d", i, resp.StatusCode())
+				}
+			}
+		})
+	}
+}
+
+func TestServerDecodesChunkedRequestBodies(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		body, err := io.ReadAll(req.Body())
+		if err != nil {
+			return pkghttp.NewTextResponse(pkghttp.StatusBadRequest, pkghttp.Version11, err.Error())
+		}
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, string(body))
+	})
+	conn, reader := dialTestServer(t, server)
+
+	for _, payload := range []string{"first", "second"} {
+		raw := "POST /echo HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n" +
+			strconv.FormatInt(int64(len(payload)), 16) + "\r\n" + payload + "\r\n0\r\n\r\n"
+		resp, body := roundTrip(t, conn, reader, raw)
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", resp.StatusCode(), body)
+		}
+		if body != payload {
+			t.Errorf("Expected %q, got %q", payload, body)
+		}
+	}
+}
+
+func TestServerRejectsOverflowingChunkSize(t *testing.T) {
+	// The handler leaves the body for the server to drain after responding
+	server := startTestServer(t, okHandler)
+	conn, reader := dialTestServer(t, server)
+
+	raw := "POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"8000000000000001\r\nabc\r\n0\r\n\r\n"
+	if _, err := io.WriteString(conn, raw); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	internalhttp.ReadResponse(reader)
+
+	// The server is still up and serving new connections
+	conn, reader = dialTestServer(t, server)
+	resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Errorf("Expected 200 after the malformed request, got %d", resp.StatusCode())
+	}
+}
+
+func TestServerConnectionClose(t *testing.T) {
+	server := startTestServer(t, okHandler)
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionClose {
+		t.Errorf("Expected Connection: close, got %s", resp.GetHeader(pkghttp.HeaderConnection))
+	}
+
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("Expected server to close the connection, got %v", err)
+	}
+}
+
+func TestServerChunksUnknownLengthBodies(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, strings.NewReader("streamed"))
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.GetHeader(pkghttp.HeaderTransferEncoding) != pkghttp.TransferEncodingChunked {
+		t.Errorf("Expected chunked encoding, got %q", resp.GetHeader(pkghttp.HeaderTransferEncoding))
+	}
+	if body != "streamed" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+}
+
+func TestServerHTTP10(t *testing.T) {
+	t.Run("closes by default", func(t *testing.T) {
+		server := startTestServer(t, okHandler)
+		conn, reader := dialTestServer(t, server)
+
+		resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.0\r\n\r\n")
+		if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionClose {
+			t.Errorf("Expected Connection: close, got %q", resp.GetHeader(pkghttp.HeaderConnection))
+		}
+		if _, err := reader.ReadByte(); err != io.EOF {
+			t.Errorf("Expected server to close the connection, got %v", err)
+		}
+	})
+
+	t.Run("keep-alive opt-in", func(t *testing.T) {
+		server := startTestServer(t, okHandler)
+		conn, reader := dialTestServer(t, server)
+
+		for i := 0; i < 2; i++ {
+			resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.0\r\nConnection: keep-alive\r\n\r\n")
+			if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionKeepAlive {
+				t.Fatalf("Expected Connection: keep-alive, got %q", resp.GetHeader(pkghttp.HeaderConnection))
+			}
+		}
+	})
+
+	t.Run("no chunked responses", func(t *testing.T) {
+		server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+			resp := pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, strings.NewReader("streamed"))
+			resp.SetHeader(pkghttp.HeaderTransferEncoding, pkghttp.TransferEncodingChunked)
+			return resp
+		})
+		conn, reader := dialTestServer(t, server)
+
+		resp, body := roundTrip(t, conn, reader, "GET / HTTP/1.0\r\nConnection: keep-alive\r\n\r\n")
+		if resp.HasHeader(pkghttp.HeaderTransferEncoding) {
+			t.Errorf("Expected no Transfer-Encoding, got %q", resp.GetHeader(pkghttp.HeaderTransferEncoding))
+		}
+		if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionClose {
+			t.Errorf("Expected the body to end at close, got Connection %q", resp.GetHeader(pkghttp.HeaderConnection))
+		}
+		if body != "streamed" {
+			t.Errorf("Unexpected body: %q", body)
+		}
+	})
+}
+
+func TestServerBadRequest(t *testing.T) {
+	server := startTestServer(t, okHandler)
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "NOT A REQUEST\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", resp.StatusCode())
+	}
+}
+
+func TestServerRecoversFromPanics(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		panic("boom")
+	})
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", resp.StatusCode())
+	}
+}
+
+func TestServerMiddlewareOrder(t *testing.T) {
+	var order []string
+	tag := func(name string) pkghttp.MiddlewareFunc {
+		return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+			return func(req pkghttp.Request) pkghttp.Response {
+				order = append(order, name)
+				return next(req)
+			}
+		}
+	}
+
+	server := startTestServer(t, okHandler, tag("outer"), tag("inner"))
+	conn, reader := dialTestServer(t, server)
+	roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("Unexpected middleware order: %v", order)
+	}
+}
+
+func TestDeadlineWriterExtendsDeadlinePerWrite(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	const timeout = 200 * time.Millisecond
+	writer := deadlineWriter{conn: local, timeout: timeout}
+
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			time.Sleep(timeout / 2)
+			if _, err := remote.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The whole stream outlasts the timeout, but no single write does
+	for i := 0; i < 4; i++ {
+		if _, err := writer.Write([]byte("x")); err != nil {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestDeadlineWriterTimesOutStalledPeer(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	writer := deadlineWriter{conn: local, timeout: 50 * time.Millisecond}
+	_, err := writer.Write([]byte("x"))
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestServerRoutesAbsoluteFormWithoutProxy(t *testing.T) {
+	router := NewRouter()
+	router.Handle(pkghttp.MethodGet, "/items", func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11,
+			req.GetHeader(pkghttp.HeaderHost)+" "+req.Path())
+	})
+	server := startTestServer(t, router.ServeRequest)
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET http://example.com/items?page=2 HTTP/1.1\r\nHost: ignored\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode())
+	}
+	if body != "example.com /items?page=2" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+}
+
+func TestServerRejectsConflictingFraming(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "smuggled")
+	})
+	conn, reader := dialTestServer(t, server)
+
+	raw := "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"0\r\n\r\nGET /admin HTTP/1.1\r\nHost: localhost\r\n\r\n"
+	resp, body := roundTrip(t, conn, reader, raw)
+	if resp.StatusCode() != pkghttp.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", resp.StatusCode())
+	}
+	if !strings.Contains(body, internalhttp.ErrConflictingFraming) {
+		t.Errorf("Expected the reason in the body, got %q", body)
+	}
+	if resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionClose {
+		t.Errorf("Expected the connection to close, got %q", resp.GetHeader(pkghttp.HeaderConnection))
+	}
+}
+
+func TestServerRequiresHost(t *testing.T) {
+	server := startTestServer(t, okHandler)
+	conn, reader := dialTestServer(t, server)
+
+	resp, body := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", resp.StatusCode())
+	}
+	if !strings.Contains(body, internalhttp.ErrMissingHost) {
+		t.Errorf("Expected the reason in the body, got %q", body)
+	}
+}
+
+func TestServerRejectsOversizedBody(t *testing.T) {
+	var called int32
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		atomic.AddInt32(&called, 1)
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "ok")
+	})
+	server.SetMaxRequestBodySize(8)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected pkghttp.StatusCode
+	}{
+		{name: "within limit", body: "12345678", expected: pkghttp.StatusOK},
+		{name: "over limit", body: "123456789", expected: pkghttp.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, reader := dialTestServer(t, server)
+			raw := "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: " +
+				strconv.Itoa(len(tt.body)) + "\r\n\r\n" + tt.body
+			resp, _ := roundTrip(t, conn, reader, raw)
+			if resp.StatusCode() != tt.expected {
+				t.Fatalf("Expected %d, got %d", tt.expected, resp.StatusCode())
+			}
+			if tt.expected == pkghttp.StatusRequestEntityTooLarge &&
+				resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionClose {
+				t.Errorf("Expected the connection to close, got %q", resp.GetHeader(pkghttp.HeaderConnection))
+			}
+		})
+	}
+
+	if atomic.LoadInt32(&called) != 1 {
+		t.Errorf("Expected the handler to run only for the body within the limit, ran %d times", called)
+	}
+}
+
+func TestServerParserOptions(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, string(req.Method()))
+	})
+	server.SetParserOptions(internalhttp.ParserOptions{
+		AllowedMethods: []pkghttp.Method{pkghttp.MethodGet, "PURGE"},
+		MaxHeaderLines: 2,
+	})
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected pkghttp.StatusCode
+	}{
+		{name: "extension method", raw: "PURGE / HTTP/1.1\r\nHost: localhost\r\n\r\n", expected: pkghttp.StatusOK},
+		{name: "method not allowed by parser", raw: "DELETE / HTTP/1.1\r\nHost: localhost\r\n\r\n", expected: pkghttp.StatusBadRequest},
+		{
+			name:     "too many headers",
+			raw:      "GET / HTTP/1.1\r\nHost: localhost\r\nA: 1\r\nB: 2\r\n\r\n",
+			expected: pkghttp.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, reader := dialTestServer(t, server)
+			resp, _ := roundTrip(t, conn, reader, tt.raw)
+			if resp.StatusCode() != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, resp.StatusCode())
+			}
+		})
+	}
+}
+
+func TestServerReadHeaderTimeout(t *testing.T) {
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		return pkghttp.NewTextResponse(pkghttp.StatusOK, pkghttp.Version11, "ok")
+	})
+	server.SetReadHeaderTimeout(100 * time.Millisecond)
+
+	t.Run("prompt request is served", func(t *testing.T) {
+		conn, reader := dialTestServer(t, server)
+
+		// Idle time before the request starts does not count against the header timeout
+		time.Sleep(200 * time.Millisecond)
+		resp, _ := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		if resp.StatusCode() != pkghttp.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode())
+		}
+	})
+
+	t.Run("trickled headers are cut off", func(t *testing.T) {
+		conn, reader := dialTestServer(t, server)
+
+		if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n"); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+
+		start := time.Now()
+		if _, err := reader.ReadByte(); err != io.EOF {
+			t.Fatalf("Expected the server to close the connection, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Expected the connection to close near the header timeout, took %v", elapsed)
+		}
+	})
+}
+
+func TestServerExpectationFailed(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            string
+		expectedStatus pkghttp.StatusCode
+	}{
+		{
+			name:           "100-continue",
+			raw:            "POST / HTTP/1.1\r\nHost: localhost\r\nExpect: 100-Continue\r\nContent-Length: 2\r\n\r\nhi",
+			expectedStatus: pkghttp.StatusOK,
+		},
+		{
+			name:           "100-continue ignored for HTTP/1.0",
+			raw:            "POST / HTTP/1.0\r\nExpect: 100-continue\r\nContent-Length: 2\r\n\r\nhi",
+			expectedStatus: pkghttp.StatusOK,
+		},
+		{
+			name:           "unknown expectation",
+			raw:            "GET / HTTP/1.1\r\nHost: localhost\r\nExpect: teapot\r\n\r\n",
+			expectedStatus: pkghttp.StatusExpectationFailed,
+		},
+		{
+			name:           "unknown expectation among known ones",
+			raw:            "POST / HTTP/1.1\r\nHost: localhost\r\nExpect: 100-continue, x-custom=1\r\nContent-Length: 2\r\n\r\nhi",
+			expectedStatus: pkghttp.StatusExpectationFailed,
+		},
+		{
+			name:           "unknown expectation ignored for HTTP/1.0",
+			raw:            "GET / HTTP/1.0\r\nExpect: teapot\r\n\r\n",
+			expectedStatus: pkghttp.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+			server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+				handled = true
+				return okHandler(req)
+			})
+			conn, reader := dialTestServer(t, server)
+
+			resp, _ := roundTrip(t, conn, reader, tt.raw)
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if handled != (tt.expectedStatus == pkghttp.StatusOK) {
+				t.Errorf("Expected handler to run: %v", tt.expectedStatus == pkghttp.StatusOK)
+			}
+		})
+	}
+}
+
+func TestServerRequestValidator(t *testing.T) {
+	server := startTestServer(t, okHandler)
+	server.SetRequestValidator(internalhttp.NewRequestValidator(internalhttp.ValidatorOptions{
+		MaxTargetLength: 16,
+		RequiredHeaders: []string{"X-Client"},
+	}))
+
+	tests := []struct {
+		name           string
+		raw            string
+		expectedStatus pkghttp.StatusCode
+	}{
+		{
+			name:           "valid",
+			raw:            "GET / HTTP/1.1\r\nHost: localhost\r\nX-Client: test\r\n\r\n",
+			expectedStatus: pkghttp.StatusOK,
+		},
+		{
+			name:           "missing required header",
+			raw:            "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n",
+			expectedStatus: pkghttp.StatusBadRequest,
+		},
+		{
+			name:           "target too long",
+			raw:            "GET /this/target/is/too/long HTTP/1.1\r\nHost: localhost\r\nX-Client: test\r\n\r\n",
+			expectedStatus: pkghttp.StatusRequestURITooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, reader := dialTestServer(t, server)
+			resp, _ := roundTrip(t, conn, reader, tt.raw)
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+		})
+	}
+}
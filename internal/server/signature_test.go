@@ -0,0 +1,97 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	current, previous := []byte("current-secret"), []byte("previous-secret")
+	config := SignatureConfig{
+		Secrets: [][]byte{current, previous},
+		Now:     func() time.Time { return now },
+	}
+
+	tests := []struct {
+		name           string
+		secret         []byte
+		signedAt       time.Time
+		tamper         func(pkghttp.Request)
+		expectedStatus pkghttp.StatusCode
+		expectedError  string
+	}{
+		{name: "valid", secret: current, signedAt: now, expectedStatus: pkghttp.StatusOK},
+		{name: "rotated secret", secret: previous, signedAt: now.Add(-time.Minute), expectedStatus: pkghttp.StatusOK},
+		{name: "clock skew ahead", secret: current, signedAt: now.Add(4 * time.Minute), expectedStatus: pkghttp.StatusOK},
+		{name: "unknown secret", secret: []byte("other"), signedAt: now, expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrInvalidRequestSignature},
+		{name: "replayed", secret: current, signedAt: now.Add(-10 * time.Minute), expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrRequestSignatureExpired},
+		{name: "from the future", secret: current, signedAt: now.Add(10 * time.Minute), expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrRequestSignatureExpired},
+		{
+			name: "missing signature", secret: current, signedAt: now,
+			tamper:         func(req pkghttp.Request) { req.Headers().Del(DefaultSignatureHeader) },
+			expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrMissingRequestSignature,
+		},
+		{
+			name: "tampered body", secret: current, signedAt: now,
+			tamper:         func(req pkghttp.Request) { req.SetBody(strings.NewReader(`{"amount": 1000}`)) },
+			expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrInvalidRequestSignature,
+		},
+		{
+			name: "tampered path", secret: current, signedAt: now,
+			tamper:         func(req pkghttp.Request) { req.SetPath("/hooks/other") },
+			expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrInvalidRequestSignature,
+		},
+		{
+			name: "tampered date", secret: current, signedAt: now,
+			tamper: func(req pkghttp.Request) {
+				req.SetHeader(pkghttp.HeaderDate, common.FormatHTTPTime(now.Add(time.Second)))
+			},
+			expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrInvalidRequestSignature,
+		},
+		{
+			name: "unsupported scheme", secret: current, signedAt: now,
+			tamper: func(req pkghttp.Request) {
+				req.SetHeader(DefaultSignatureHeader, strings.Replace(req.GetHeader(DefaultSignatureHeader), "sha256=", "md5=", 1))
+			},
+			expectedStatus: pkghttp.StatusUnauthorized, expectedError: ErrInvalidRequestSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, "/hooks/payment?id=7", pkghttp.Version11, strings.NewReader(`{"amount": 10}`))
+			if err := SignRequest(req, tt.secret, "", tt.signedAt); err != nil {
+				t.Fatalf("SignRequest failed: %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(req)
+			}
+
+			var received string
+			resp := VerifySignature(config)(func(req pkghttp.Request) pkghttp.Response {
+				data, _ := io.ReadAll(req.Body())
+				received = string(data)
+				return okHandler(req)
+			})(req)
+
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if tt.expectedError != "" {
+				if body := readResponseBody(t, resp); !strings.Contains(body, tt.expectedError) {
+					t.Errorf("Expected error %q in %q", tt.expectedError, body)
+				}
+				return
+			}
+			if received != `{"amount": 10}` {
+				t.Errorf("Expected the handler to read the signed body, got %q", received)
+			}
+		})
+	}
+}
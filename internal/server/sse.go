@@ -0,0 +1,137 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// Event is a single server-sent event
+type Event struct {
+	// ID is stored by the client and echoed back in Last-Event-ID on reconnect
+	ID string
+
+	// Event names the event type; empty means "message"
+	Event string
+
+	// Data is the payload; multi-line data is split across data fields
+	Data string
+
+	// Retry tells the client how long to wait before reconnecting
+	Retry time.Duration
+}
+
+// Format renders the event in text/event-stream framing
+func (e Event) Format() string {
+	var b strings.Builder
+
+	if e.ID != "" {
+		writeEventField(&b, sseFieldID, e.ID)
+	}
+	if e.Event != "" {
+		writeEventField(&b, sseFieldEvent, e.Event)
+	}
+	if e.Retry > 0 {
+		writeEventField(&b, sseFieldRetry, strconv.FormatInt(e.Retry.Milliseconds(), 10))
+	}
+
+	data := strings.ReplaceAll(e.Data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		writeEventField(&b, sseFieldData, line)
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writeEventField writes one "name: value" line, dropping line breaks from value
+func writeEventField(b *strings.Builder, name, value string) {
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	fmt.Fprintf(b, "%s: %s\n", name, value)
+}
+
+// EventStream sends events to one connected client
+type EventStream struct {
+	writer *io.PipeWriter
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+}
+
+// Send writes an event; the server flushes it to the client immediately
+func (s *EventStream) Send(event Event) error {
+	return s.write(event.Format())
+}
+
+// Comment writes a comment line, useful as a keep-alive through idle proxies
+func (s *EventStream) Comment(text string) error {
+	return s.write(sseCommentPrefix + " " + strings.ReplaceAll(text, "\n", " ") + "\n\n")
+}
+
+// Done is closed when the client goes away
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// write sends one complete frame in a single pipe write so it is flushed as one chunk
+func (s *EventStream) write(frame string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := io.WriteString(s.writer, frame); err != nil {
+		return common.IOErrorWithCause("event stream closed", err)
+	}
+	return nil
+}
+
+// finish marks the stream as finished
+func (s *EventStream) finish() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// eventStreamBody is the response body fed by an EventStream
+type eventStreamBody struct {
+	*io.PipeReader
+	stream *EventStream
+}
+
+// Close stops the producer once the server is done with the response
+func (b *eventStreamBody) Close() error {
+	b.stream.finish()
+	return b.PipeReader.Close()
+}
+
+// NewSSEResponse creates a text/event-stream response whose events are produced by
+// produce in its own goroutine. The stream ends when produce returns; produce
+// should return once Done is closed or Send fails.
+func NewSSEResponse(produce func(*EventStream)) pkghttp.Response {
+	reader, writer := io.Pipe()
+	stream := &EventStream{writer: writer, done: make(chan struct{})}
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				writer.CloseWithError(common.ServerError(fmt.Sprintf("event producer panicked: %v", recovered)))
+				return
+			}
+			writer.Close()
+		}()
+		produce(stream)
+	}()
+
+	resp := pkghttp.NewResponseWithBody(pkghttp.StatusOK, pkghttp.Version11, &eventStreamBody{PipeReader: reader, stream: stream})
+	resp.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeEventStream)
+	resp.SetHeader(pkghttp.HeaderCacheControl, sseCacheControl)
+	return resp
+}
+
+// LastEventID returns the ID of the last event a reconnecting client received
+func LastEventID(req pkghttp.Request) string {
+	return req.GetHeader(pkghttp.HeaderLastEventID)
+}
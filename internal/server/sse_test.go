@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestEventFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    Event
+		expected string
+	}{
+		{name: "data only", event: Event{Data: "hello"}, expected: "data: hello\n\n"},
+		{
+			name:     "all fields",
+			event:    Event{ID: "7", Event: "update", Data: "x", Retry: 3 * time.Second},
+			expected: "id: 7\nevent: update\nretry: 3000\ndata: x\n\n",
+		},
+		{name: "multi-line data", event: Event{Data: "a\nb\r\nc"}, expected: "data: a\ndata: b\ndata: c\n\n"},
+		{name: "line breaks stripped from id", event: Event{ID: "1\n2", Data: "x"}, expected: "id: 12\ndata: x\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.Format(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServerStreamsEvents(t *testing.T) {
+	release := make(chan struct{})
+	server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		lastID := LastEventID(req)
+		return NewSSEResponse(func(stream *EventStream) {
+			stream.Send(Event{ID: "1", Data: "resumed after " + lastID})
+			// The first event must reach the client before the handler finishes
+			<-release
+			stream.Send(Event{ID: "2", Data: "done"})
+		})
+	})
+	conn, reader := dialTestServer(t, server)
+
+	if _, err := io.WriteString(conn, "GET /events HTTP/1.1\r\nHost: localhost\r\nLast-Event-ID: 0\r\n\r\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	resp, err := internalhttp.ReadResponse(reader)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+	if resp.GetHeader(pkghttp.HeaderContentType) != pkghttp.MimeTypeEventStream {
+		t.Errorf("Unexpected Content-Type %q", resp.GetHeader(pkghttp.HeaderContentType))
+	}
+	if resp.GetHeader(pkghttp.HeaderCacheControl) != "no-cache" {
+		t.Errorf("Unexpected Cache-Control %q", resp.GetHeader(pkghttp.HeaderCacheControl))
+	}
+
+	events := bufio.NewReader(resp.Body())
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Reading event failed: %v", err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if first := readEvent(); first != "id: 1\ndata: resumed after 0\n" {
+		t.Errorf("Unexpected first event %q", first)
+	}
+
+	close(release)
+	if second := readEvent(); second != "id: 2\ndata: done\n" {
+		t.Errorf("Unexpected second event %q", second)
+	}
+
+	if rest, _ := io.ReadAll(events); len(rest) != 0 {
+		t.Errorf("Expected the stream to end, got %q", rest)
+	}
+}
+
+func TestEventStreamDoneWhenBodyClosed(t *testing.T) {
+	sendErr := make(chan error, 1)
+	resp := NewSSEResponse(func(stream *EventStream) {
+		<-stream.Done()
+		sendErr <- stream.Send(Event{Data: "too late"})
+	})
+
+	resp.Body().(io.Closer).Close()
+
+	select {
+	case err := <-sendErr:
+		if err == nil {
+			t.Error("Send should fail after the client is gone")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Producer was not notified that the stream closed")
+	}
+}
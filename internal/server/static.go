@@ -0,0 +1,224 @@
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	"github.com/ganyariya/tinyserver/pkg/http/mime"
+)
+
+// StaticConfig configures FileServer
+type StaticConfig struct {
+	// Root is the directory files are served from; empty means common.DefaultStaticDir
+	Root string
+
+	// FS serves files from a file system such as an embed.FS instead of Root
+	FS fs.FS
+
+	// Prefix is removed from request paths before they are mapped into Root,
+	// for a server mounted under a route such as "/assets/*path". A trailing
+	// slash is ignored, so "/assets/" acts as "/assets".
+	Prefix string
+
+	// IndexFiles are tried in order when a directory is requested;
+	// nil means common.DefaultIndexFile
+	IndexFiles []string
+
+	// SPA serves the index file of Root for paths that match no file, so an
+	// app with client-side routing loads on any of its URLs
+	SPA bool
+
+	// RestrictSymlinks refuses files reached through symbolic links that
+	// point outside Root. It does not apply to FS.
+	RestrictSymlinks bool
+
+	// Precompressed serves a copy such as app.js.br or app.js.gz found next
+	// to a requested file when the client accepts its coding. Files whose
+	// type is unknown from their extension are always served as stored.
+	Precompressed bool
+
+	// CachePolicies sets the caching headers of files by extension, such as
+	// ".js" or ".html"; files whose extension is not listed get none
+	CachePolicies map[string]CachePolicy
+}
+
+// root returns the configured root or its default
+func (c StaticConfig) root() string {
+	if c.Root != "" {
+		return c.Root
+	}
+	return common.DefaultStaticDir
+}
+
+// fileSystem returns FS, or the directory tree under Root
+func (c StaticConfig) fileSystem() fs.FS {
+	if c.FS != nil {
+		return c.FS
+	}
+	return os.DirFS(c.root())
+}
+
+// indexFiles returns the configured index file names or the default
+func (c StaticConfig) indexFiles() []string {
+	if c.IndexFiles != nil {
+		return c.IndexFiles
+	}
+	return []string{common.DefaultIndexFile}
+}
+
+// staticMethods are the methods FileServer answers
+var staticMethods = []pkghttp.Method{pkghttp.MethodGet, pkghttp.MethodHead}
+
+// FileServer returns a handler serving the files under config.Root, or in
+// config.FS, by request path. A directory is served through its first
+// existing index file, after a redirect that adds the trailing slash relative
+// links need.
+func FileServer(config StaticConfig) pkghttp.RequestHandler {
+	config.Prefix = strings.TrimSuffix(config.Prefix, "/")
+	fsys := config.fileSystem()
+
+	return func(req pkghttp.Request) pkghttp.Response {
+		if req.Method() != pkghttp.MethodGet && req.Method() != pkghttp.MethodHead {
+			resp := internalhttp.BuildErrorResponse(pkghttp.StatusMethodNotAllowed, "")
+			resp.SetHeader(pkghttp.HeaderAllow, FormatAllow(staticMethods))
+			return resp
+		}
+
+		urlPath := requestPath(req)
+		if urlPath == "" {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, "")
+		}
+
+		relative, ok := strings.CutPrefix(urlPath, config.Prefix)
+		if !ok || (relative != "" && !strings.HasPrefix(relative, "/")) {
+			return config.notFound(req, fsys)
+		}
+		name, err := common.SafePath(relative)
+		if err == nil {
+			// CleanPath keeps "%25" encoded, but the file name has a literal '%'
+			name, err = url.PathUnescape(name)
+		}
+		if err != nil || !config.contains(name) {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadRequest, "")
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return config.notFound(req, fsys)
+			}
+			return fileErrorResponse(err)
+		}
+
+		if !info.IsDir() {
+			return config.serveFile(req, fsys, name)
+		}
+
+		if !strings.HasSuffix(urlPath, "/") {
+			_, query, _ := strings.Cut(req.Path(), "?")
+			unescaped, _ := url.PathUnescape(urlPath)
+			location := (&url.URL{Path: unescaped + "/", RawQuery: query}).String()
+			return internalhttp.BuildRedirectResponse(pkghttp.StatusMovedPermanently, location)
+		}
+
+		if index := config.findIndex(fsys, name); index != "" {
+			return config.serveFile(req, fsys, index)
+		}
+		if config.SPA {
+			return config.notFound(req, fsys)
+		}
+		return internalhttp.BuildErrorResponse(pkghttp.StatusForbidden, ErrDirectoryListing)
+	}
+}
+
+// contains reports whether the file name may be served, which fails only for
+// a symbolic link out of Root when RestrictSymlinks is set
+func (c StaticConfig) contains(name string) bool {
+	if !c.RestrictSymlinks || c.FS != nil {
+		return true
+	}
+
+	_, err := common.SafeJoinNoSymlinkEscape(c.root(), name)
+	return err == nil
+}
+
+// isFile reports whether name is a regular file in fsys that may be served
+func (c StaticConfig) isFile(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir() && c.contains(name)
+}
+
+// findIndex returns the first index file present in dir, or ""
+func (c StaticConfig) findIndex(fsys fs.FS, dir string) string {
+	for _, name := range c.indexFiles() {
+		if index := path.Join(dir, name); c.isFile(fsys, index) {
+			return index
+		}
+	}
+	return ""
+}
+
+// serveFile serves the file name with the cache policy for its extension
+func (c StaticConfig) serveFile(req pkghttp.Request, fsys fs.FS, name string) pkghttp.Response {
+	resp := c.serveEncoded(req, fsys, name)
+
+	status := resp.StatusCode()
+	if policy, ok := c.CachePolicies[strings.ToLower(path.Ext(name))]; ok &&
+		(status == pkghttp.StatusOK || status == pkghttp.StatusPartialContent || status == pkghttp.StatusNotModified) {
+		SetCachePolicy(resp, policy)
+	}
+	return resp
+}
+
+// serveEncoded serves the file name, or its pre-compressed copy in the coding
+// the client rates highest when Precompressed is set
+func (c StaticConfig) serveEncoded(req pkghttp.Request, fsys fs.FS, name string) pkghttp.Response {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if !c.Precompressed || contentType == "" {
+		return ServeFS(req, fsys, name)
+	}
+
+	variants := make(map[string]string)
+	var codings []string
+	for _, variant := range precompressedVariants {
+		if candidate := name + variant.extension; c.isFile(fsys, candidate) {
+			variants[variant.coding] = candidate
+			codings = append(codings, variant.coding)
+		}
+	}
+	if len(codings) == 0 {
+		return ServeFS(req, fsys, name)
+	}
+
+	coding := internalhttp.NegotiateContentCoding(req.GetHeader(pkghttp.HeaderAcceptEncoding), codings)
+	var resp pkghttp.Response
+	if coding == "" {
+		resp = ServeFS(req, fsys, name)
+	} else {
+		resp = serveFSFile(req, fsys, variants[coding], contentType)
+		if resp.StatusCode() == pkghttp.StatusOK || resp.StatusCode() == pkghttp.StatusPartialContent {
+			resp.SetHeader(pkghttp.HeaderContentEncoding, coding)
+		}
+	}
+
+	// Which file is served now depends on Accept-Encoding
+	resp.AddHeader(pkghttp.HeaderVary, pkghttp.HeaderAcceptEncoding)
+	return resp
+}
+
+// notFound answers a path with no file: 404, or the root index in SPA mode
+func (c StaticConfig) notFound(req pkghttp.Request, fsys fs.FS) pkghttp.Response {
+	if c.SPA {
+		if index := c.findIndex(fsys, "."); index != "" {
+			return c.serveFile(req, fsys, index)
+		}
+	}
+	return internalhttp.BuildErrorResponse(pkghttp.StatusNotFound, "")
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// writeStaticTree creates files under a temporary root from relative path to content
+func writeStaticTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	return root
+}
+
+// readResponseBody reads and closes the body of resp
+func readResponseBody(t *testing.T, resp pkghttp.Response) string {
+	t.Helper()
+
+	if resp.Body() == nil {
+		return ""
+	}
+	defer resp.Body().Close()
+
+	body, err := io.ReadAll(resp.Body())
+	if err != nil {
+		t.Fatalf("Reading body failed: %v", err)
+	}
+	return string(body)
+}
+
+func TestFileServer(t *testing.T) {
+	root := writeStaticTree(t, map[string]string{
+		"index.html":         "home",
+		"app.js":             "code",
+		"docs/index.htm":     "docs",
+		"empty/readme.txt":   "readme",
+		"assets/logo.svg":    "<svg/>",
+		"assets/nested/a.js": "nested",
+		"100%/sale.txt":      "sale",
+	})
+
+	tests := []struct {
+		name             string
+		config           StaticConfig
+		method           pkghttp.Method
+		path             string
+		expectedStatus   pkghttp.StatusCode
+		expectedBody     string
+		expectedLocation string
+	}{
+		{name: "file", path: "/app.js", expectedStatus: pkghttp.StatusOK, expectedBody: "code"},
+		{name: "root index", path: "/", expectedStatus: pkghttp.StatusOK, expectedBody: "home"},
+		{name: "query ignored", path: "/app.js?v=2", expectedStatus: pkghttp.StatusOK, expectedBody: "code"},
+		{
+			name:           "configured index names",
+			config:         StaticConfig{IndexFiles: []string{"index.html", "index.htm"}},
+			path:           "/docs/",
+			expectedStatus: pkghttp.StatusOK,
+			expectedBody:   "docs",
+		},
+		{name: "default index name only", path: "/docs/", expectedStatus: pkghttp.StatusForbidden},
+		{name: "directory redirect", path: "/docs?x=1", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "/docs/?x=1"},
+		{name: "encoded percent sign", path: "/100%25/sale.txt", expectedStatus: pkghttp.StatusOK, expectedBody: "sale"},
+		{name: "directory redirect keeps escapes", path: "/100%25", expectedStatus: pkghttp.StatusMovedPermanently, expectedLocation: "/100%25/"},
+		{name: "missing file", path: "/missing.js", expectedStatus: pkghttp.StatusNotFound},
+		{name: "spa fallback", config: StaticConfig{SPA: true}, path: "/users/42", expectedStatus: pkghttp.StatusOK, expectedBody: "home"},
+		{name: "spa directory without index", config: StaticConfig{SPA: true}, path: "/empty/", expectedStatus: pkghttp.StatusOK, expectedBody: "home"},
+		{name: "spa serves real files", config: StaticConfig{SPA: true}, path: "/app.js", expectedStatus: pkghttp.StatusOK, expectedBody: "code"},
+		{name: "prefix", config: StaticConfig{Prefix: "/static"}, path: "/static/assets/nested/a.js", expectedStatus: pkghttp.StatusOK, expectedBody: "nested"},
+		{name: "prefix boundary", config: StaticConfig{Prefix: "/static"}, path: "/staticassets/nested/a.js", expectedStatus: pkghttp.StatusNotFound},
+		{name: "prefix with trailing slash", config: StaticConfig{Prefix: "/static/"}, path: "/static/assets/nested/a.js", expectedStatus: pkghttp.StatusOK, expectedBody: "nested"},
+		{name: "method not allowed", method: pkghttp.MethodPost, path: "/app.js", expectedStatus: pkghttp.StatusMethodNotAllowed},
+		{name: "traversal stays in root", path: "/../../app.js", expectedStatus: pkghttp.StatusOK, expectedBody: "code"},
+		{name: "double encoded traversal", path: "/%252e%252e/app.js", expectedStatus: pkghttp.StatusBadRequest},
+		{name: "encoded NUL byte", path: "/app.js%00.png", expectedStatus: pkghttp.StatusBadRequest},
+		{name: "symlink outside root", path: "/leak.txt", expectedStatus: pkghttp.StatusOK, expectedBody: "secret"},
+		{name: "restricted symlink outside root", config: StaticConfig{RestrictSymlinks: true}, path: "/leak.txt", expectedStatus: pkghttp.StatusBadRequest},
+		{name: "restricted symlink inside root", config: StaticConfig{RestrictSymlinks: true}, path: "/main.js", expectedStatus: pkghttp.StatusOK, expectedBody: "code"},
+	}
+
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", secret, err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "leak.txt")); err != nil {
+		t.Skipf("Symbolic links unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "app.js"), filepath.Join(root, "main.js")); err != nil {
+		t.Skipf("Symbolic links unavailable: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Root = root
+			method := tt.method
+			if method == "" {
+				method = pkghttp.MethodGet
+			}
+
+			resp := FileServer(config)(pkghttp.NewRequest(method, tt.path, pkghttp.Version11))
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if tt.expectedBody != "" {
+				if body := readResponseBody(t, resp); body != tt.expectedBody {
+					t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+				}
+			}
+			if tt.expectedLocation != "" && resp.GetHeader(pkghttp.HeaderLocation) != tt.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tt.expectedLocation, resp.GetHeader(pkghttp.HeaderLocation))
+			}
+		})
+	}
+}
+
+func TestFileServerPrecompressed(t *testing.T) {
+	root := writeStaticTree(t, map[string]string{
+		"app.js":          "code",
+		"app.js.gz":       "gzip code",
+		"app.js.br":       "brotli code",
+		"site.css":        "style",
+		"site.css.gz":     "gzip style",
+		"plain.txt":       "plain",
+		"data.unknown":    "data",
+		"data.unknown.gz": "gzip data",
+	})
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		expectedBody     string
+		expectedEncoding string
+		expectedVary     bool
+	}{
+		{name: "brotli preferred", path: "/app.js", acceptEncoding: "gzip, br", expectedBody: "brotli code", expectedEncoding: "br", expectedVary: true},
+		{name: "gzip by weight", path: "/app.js", acceptEncoding: "gzip, br;q=0.5", expectedBody: "gzip code", expectedEncoding: "gzip", expectedVary: true},
+		{name: "only available variant", path: "/site.css", acceptEncoding: "br, gzip", expectedBody: "gzip style", expectedEncoding: "gzip", expectedVary: true},
+		{name: "no accepted variant", path: "/site.css", acceptEncoding: "br", expectedBody: "style", expectedVary: true},
+		{name: "no Accept-Encoding", path: "/app.js", expectedBody: "code", expectedVary: true},
+		{name: "no variants", path: "/plain.txt", acceptEncoding: "gzip", expectedBody: "plain"},
+		{name: "unknown type", path: "/data.unknown", acceptEncoding: "gzip", expectedBody: "data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11)
+			if tt.acceptEncoding != "" {
+				req.SetHeader(pkghttp.HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+
+			resp := FileServer(StaticConfig{Root: root, Precompressed: true})(req)
+			if resp.StatusCode() != pkghttp.StatusOK {
+				t.Fatalf("Expected 200, got %d", resp.StatusCode())
+			}
+			if body := readResponseBody(t, resp); body != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+			}
+			if got := resp.GetHeader(pkghttp.HeaderContentEncoding); got != tt.expectedEncoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.expectedEncoding, got)
+			}
+			if got := resp.GetHeader(pkghttp.HeaderVary) == pkghttp.HeaderAcceptEncoding; got != tt.expectedVary {
+				t.Errorf("Expected Vary set %v, got %q", tt.expectedVary, resp.GetHeader(pkghttp.HeaderVary))
+			}
+			if tt.expectedEncoding != "" && resp.GetHeader(pkghttp.HeaderContentType) == pkghttp.MimeTypeOctetStream {
+				t.Errorf("Expected Content-Type of the original file, got %q", resp.GetHeader(pkghttp.HeaderContentType))
+			}
+		})
+	}
+}
+
+func TestFileServerFS(t *testing.T) {
+	modTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":       {Data: []byte("home"), ModTime: modTime},
+		"assets/app.js":    {Data: []byte("code")},
+		"assets/app.js.gz": {Data: []byte("gzip code")},
+		"data":             {Data: []byte("<html>page</html>")},
+	}
+	handler := FileServer(StaticConfig{FS: fsys, Precompressed: true})
+
+	tests := []struct {
+		name                 string
+		path                 string
+		acceptEncoding       string
+		expectedStatus       pkghttp.StatusCode
+		expectedBody         string
+		expectedContentType  string
+		expectedLastModified bool
+	}{
+		{name: "root index", path: "/", expectedStatus: pkghttp.StatusOK, expectedBody: "home", expectedContentType: pkghttp.MimeTypeTextHTML, expectedLastModified: true},
+		{name: "file without modification time", path: "/assets/app.js", expectedStatus: pkghttp.StatusOK, expectedBody: "code", expectedContentType: pkghttp.MimeTypeTextJavaScript},
+		{name: "pre-compressed", path: "/assets/app.js", acceptEncoding: "gzip", expectedStatus: pkghttp.StatusOK, expectedBody: "gzip code", expectedContentType: pkghttp.MimeTypeTextJavaScript},
+		{name: "sniffed type", path: "/data", expectedStatus: pkghttp.StatusOK, expectedBody: "<html>page</html>", expectedContentType: pkghttp.MimeTypeTextHTML},
+		{name: "missing file", path: "/missing.js", expectedStatus: pkghttp.StatusNotFound},
+		{name: "traversal", path: "/%252e%252e/index.html", expectedStatus: pkghttp.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11)
+			if tt.acceptEncoding != "" {
+				req.SetHeader(pkghttp.HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+
+			resp := handler(req)
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if tt.expectedStatus != pkghttp.StatusOK {
+				return
+			}
+
+			if body := readResponseBody(t, resp); body != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+			}
+			if got := resp.GetHeader(pkghttp.HeaderContentType); got != tt.expectedContentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.expectedContentType, got)
+			}
+			if got := resp.HasHeader(pkghttp.HeaderLastModified); got != tt.expectedLastModified {
+				t.Errorf("Expected Last-Modified present %v, got %v", tt.expectedLastModified, got)
+			}
+			if resp.GetHeader(pkghttp.HeaderETag) == "" {
+				t.Error("Expected an ETag")
+			}
+		})
+	}
+}
+
+func TestServeFSContentETag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("first")},
+		"b.txt": {Data: []byte("other")},
+	}
+
+	first := ServeFS(pkghttp.NewRequest(pkghttp.MethodGet, "/a.txt", pkghttp.Version11), fsys, "a.txt")
+	other := ServeFS(pkghttp.NewRequest(pkghttp.MethodGet, "/b.txt", pkghttp.Version11), fsys, "b.txt")
+	etag := first.GetHeader(pkghttp.HeaderETag)
+	if etag == other.GetHeader(pkghttp.HeaderETag) {
+		t.Fatalf("Expected distinct ETags for equal-sized files, both %q", etag)
+	}
+	if body := readResponseBody(t, first); body != "first" {
+		t.Errorf("Expected body %q after hashing, got %q", "first", body)
+	}
+	readResponseBody(t, other)
+
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/a.txt", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderIfNoneMatch, etag)
+	if resp := ServeFS(req, fsys, "a.txt"); resp.StatusCode() != pkghttp.StatusNotModified {
+		t.Errorf("Expected 304 for a matching ETag, got %d", resp.StatusCode())
+	}
+}
+
+func TestFileServerCachePolicies(t *testing.T) {
+	root := writeStaticTree(t, map[string]string{
+		"index.html":      "home",
+		"app.3f2a.js":     "code",
+		"robots.txt":      "rules",
+		"docs/index.HTML": "docs",
+	})
+	config := StaticConfig{
+		Root:       root,
+		IndexFiles: []string{"index.html", "index.HTML"},
+		CachePolicies: map[string]CachePolicy{
+			".html": {NoCache: true},
+			".js":   ImmutablePolicy(365 * 24 * time.Hour),
+		},
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		ifNoneMatch     bool
+		expectedStatus  pkghttp.StatusCode
+		expectedControl string
+	}{
+		{name: "immutable asset", path: "/app.3f2a.js", expectedStatus: pkghttp.StatusOK, expectedControl: "public, max-age=31536000, immutable"},
+		{name: "index file", path: "/", expectedStatus: pkghttp.StatusOK, expectedControl: "no-cache"},
+		{name: "extension case ignored", path: "/docs/", expectedStatus: pkghttp.StatusOK, expectedControl: "no-cache"},
+		{name: "not modified", path: "/app.3f2a.js", ifNoneMatch: true, expectedStatus: pkghttp.StatusNotModified, expectedControl: "public, max-age=31536000, immutable"},
+		{name: "unlisted extension", path: "/robots.txt", expectedStatus: pkghttp.StatusOK},
+		{name: "missing file", path: "/missing.js", expectedStatus: pkghttp.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := pkghttp.NewRequest(pkghttp.MethodGet, tt.path, pkghttp.Version11)
+			if tt.ifNoneMatch {
+				req.SetHeader(pkghttp.HeaderIfNoneMatch, "*")
+			}
+
+			resp := FileServer(config)(req)
+			readResponseBody(t, resp)
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+			if got := resp.GetHeader(pkghttp.HeaderCacheControl); got != tt.expectedControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.expectedControl, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// StreamWriter writes a response body while the response is being sent.
+// Writes are buffered; Flush pushes them to the client straight away.
+type StreamWriter struct {
+	pipe *io.PipeWriter
+	buf  *bufio.Writer
+	done chan struct{}
+	once sync.Once
+	mu   sync.Mutex
+}
+
+// Write buffers p, sending it once the buffer fills or Flush is called
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n, err := w.buf.Write(p)
+	if err != nil {
+		return n, common.IOErrorWithCause(ErrStreamClosed, err)
+	}
+	return n, nil
+}
+
+// Flush sends everything written so far to the client
+func (w *StreamWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.buf.Flush(); err != nil {
+		return common.IOErrorWithCause(ErrStreamClosed, err)
+	}
+	return nil
+}
+
+// Done is closed when the client goes away or the server stops reading the body
+func (w *StreamWriter) Done() <-chan struct{} {
+	return w.done
+}
+
+// finish marks the stream as finished
+func (w *StreamWriter) finish() {
+	w.once.Do(func() { close(w.done) })
+}
+
+// streamBody is the response body fed by a StreamWriter
+type streamBody struct {
+	*io.PipeReader
+	stream *StreamWriter
+}
+
+// Close stops the producer once the server is done with the response
+func (b *streamBody) Close() error {
+	b.stream.finish()
+	return b.PipeReader.Close()
+}
+
+// NewStreamResponse creates a response whose body produce writes in its own
+// goroutine, for long polling or progress output. The body ends when produce
+// returns, after a final flush. Without a Content-Length the server sends it
+// chunked, or until close for HTTP/1.0 clients.
+func NewStreamResponse(status pkghttp.StatusCode, produce func(*StreamWriter)) pkghttp.Response {
+	reader, writer := io.Pipe()
+	stream := &StreamWriter{
+		pipe: writer,
+		buf:  bufio.NewWriterSize(writer, bodyCopyBufferSize),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				writer.CloseWithError(common.ServerError(fmt.Sprintf("stream producer panicked: %v", recovered)))
+				return
+			}
+			writer.CloseWithError(stream.Flush())
+		}()
+		produce(stream)
+	}()
+
+	return pkghttp.NewResponseWithBody(status, pkghttp.Version11, &streamBody{PipeReader: reader, stream: stream})
+}
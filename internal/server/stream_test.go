@@ -0,0 +1,82 @@
+package server
+
+import (
+	"io"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestServerFlushesStreamedBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		chunked bool
+	}{
+		{name: "chunked", request: "GET /progress HTTP/1.1\r\nHost: localhost\r\n\r\n", chunked: true},
+		{name: "HTTP/1.0 until close", request: "GET /progress HTTP/1.0\r\n\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			server := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+				return NewStreamResponse(pkghttp.StatusOK, func(w *StreamWriter) {
+					io.WriteString(w, "10%")
+					io.WriteString(w, "...")
+					w.Flush()
+					// The flushed output must reach the client before the producer finishes
+					<-release
+					io.WriteString(w, "100%")
+				})
+			})
+			conn, reader := dialTestServer(t, server)
+
+			if _, err := io.WriteString(conn, tt.request); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			resp, err := internalhttp.ReadResponse(reader)
+			if err != nil {
+				t.Fatalf("ReadResponse failed: %v", err)
+			}
+			if chunked := resp.GetHeader(pkghttp.HeaderTransferEncoding) == pkghttp.TransferEncodingChunked; chunked != tt.chunked {
+				t.Errorf("Expected chunked %v, got Transfer-Encoding %q", tt.chunked, resp.GetHeader(pkghttp.HeaderTransferEncoding))
+			}
+
+			first := make([]byte, len("10%..."))
+			if _, err := io.ReadFull(resp.Body(), first); err != nil {
+				t.Fatalf("Reading flushed data failed: %v", err)
+			}
+			if string(first) != "10%..." {
+				t.Errorf("Expected flushed %q, got %q", "10%...", first)
+			}
+
+			close(release)
+			rest, err := io.ReadAll(resp.Body())
+			if err != nil {
+				t.Fatalf("Reading the rest failed: %v", err)
+			}
+			if string(rest) != "100%" {
+				t.Errorf("Expected final write %q, got %q", "100%", rest)
+			}
+		})
+	}
+}
+
+func TestStreamWriterDoneWhenBodyClosed(t *testing.T) {
+	finished := make(chan error, 1)
+	resp := NewStreamResponse(pkghttp.StatusOK, func(w *StreamWriter) {
+		<-w.Done()
+		_, err := w.Write([]byte("late"))
+		if err == nil {
+			err = w.Flush()
+		}
+		finished <- err
+	})
+
+	resp.Body().(io.Closer).Close()
+	if err := <-finished; err == nil {
+		t.Error("Expected writes after the body was closed to fail")
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// RateLimitedWriter paces writes to an underlying writer with a token bucket:
+// it sends up to Burst bytes at once and refills at BytesPerSecond
+type RateLimitedWriter struct {
+	w      io.Writer
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// NewRateLimitedWriter limits writes to w to bytesPerSecond. A burst of zero
+// or less allows one second's worth of data at once.
+func NewRateLimitedWriter(w io.Writer, bytesPerSecond, burst int) *RateLimitedWriter {
+	if burst <= 0 {
+		burst = bytesPerSecond
+	}
+	return &RateLimitedWriter{
+		w:      w,
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Write sends p in pieces no larger than the burst, waiting for each to be allowed
+func (w *RateLimitedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		size := len(p) - written
+		if size > w.burst {
+			size = w.burst
+		}
+		w.wait(size)
+
+		n, err := w.w.Write(p[written : written+size])
+		written += n
+		w.tokens -= float64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// wait blocks until size bytes may be sent
+func (w *RateLimitedWriter) wait(size int) {
+	w.refill()
+	if missing := float64(size) - w.tokens; missing > 0 {
+		time.Sleep(time.Duration(missing / w.rate * float64(time.Second)))
+		w.refill()
+	}
+}
+
+// refill adds the tokens earned since the last refill, up to the burst
+func (w *RateLimitedWriter) refill() {
+	now := time.Now()
+	w.tokens += now.Sub(w.last).Seconds() * w.rate
+	if w.tokens > float64(w.burst) {
+		w.tokens = float64(w.burst)
+	}
+	w.last = now
+}
+
+// ThrottleConfig configures response bandwidth throttling
+type ThrottleConfig struct {
+	// BytesPerSecond is the sustained rate of each response body; zero or
+	// less disables throttling
+	BytesPerSecond int
+
+	// Burst is the most sent at once; zero means one second's worth
+	Burst int
+}
+
+// Throttle returns middleware that sends each response body at no more than
+// the configured rate, to simulate slow networks or cap large downloads.
+// The limit applies per response, not across connections, and throttled
+// files are copied rather than sent with sendfile.
+func Throttle(config ThrottleConfig) pkghttp.MiddlewareFunc {
+	return func(next pkghttp.RequestHandler) pkghttp.RequestHandler {
+		return func(req pkghttp.Request) pkghttp.Response {
+			resp := next(req)
+			if resp == nil || resp.Body() == nil || config.BytesPerSecond <= 0 || req.Method() == pkghttp.MethodHead {
+				return resp
+			}
+
+			resp.SetBody(newThrottledBody(resp.Body(), config.BytesPerSecond, config.Burst))
+			return resp
+		}
+	}
+}
+
+// throttledBody feeds body through a RateLimitedWriter as the server reads it
+type throttledBody struct {
+	reader *io.PipeReader
+}
+
+// newThrottledBody starts copying body into a rate limited pipe. The source is
+// closed once it has been consumed or the reader is closed.
+func newThrottledBody(body io.ReadCloser, bytesPerSecond, burst int) *throttledBody {
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer body.Close()
+
+		_, err := io.Copy(NewRateLimitedWriter(pw, bytesPerSecond, burst), body)
+		pw.CloseWithError(err)
+	}()
+
+	return &throttledBody{reader: pr}
+}
+
+// Read returns bytes as the rate allows
+func (b *throttledBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+// Close stops the copy, releasing the source body
+func (b *throttledBody) Close() error {
+	return b.reader.Close()
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestRateLimitedWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    int
+		burst   int
+		size    int
+		minTime time.Duration
+		maxTime time.Duration
+	}{
+		{name: "within burst", rate: 1000, burst: 500, size: 400, maxTime: 50 * time.Millisecond},
+		{name: "beyond burst", rate: 1000, burst: 100, size: 300, minTime: 150 * time.Millisecond, maxTime: time.Second},
+		{name: "default burst", rate: 2000, size: 2000, maxTime: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewRateLimitedWriter(&buf, tt.rate, tt.burst)
+			data := bytes.Repeat([]byte("x"), tt.size)
+
+			start := time.Now()
+			n, err := w.Write(data)
+			elapsed := time.Since(start)
+
+			if err != nil || n != tt.size || !bytes.Equal(buf.Bytes(), data) {
+				t.Fatalf("Expected %d bytes written, got %d (%v)", tt.size, n, err)
+			}
+			if elapsed < tt.minTime || elapsed > tt.maxTime {
+				t.Errorf("Expected the write to take between %v and %v, took %v", tt.minTime, tt.maxTime, elapsed)
+			}
+		})
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	body := strings.Repeat("y", 300)
+	handler := func(req pkghttp.Request) pkghttp.Response {
+		return internalhttp.BuildTextResponse(pkghttp.StatusOK, body)
+	}
+
+	tests := []struct {
+		name    string
+		config  ThrottleConfig
+		minTime time.Duration
+	}{
+		{name: "throttled", config: ThrottleConfig{BytesPerSecond: 1000, Burst: 100}, minTime: 150 * time.Millisecond},
+		{name: "disabled", config: ThrottleConfig{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startTestServer(t, handler, Throttle(tt.config))
+			conn, reader := dialTestServer(t, server)
+
+			start := time.Now()
+			resp, got := roundTrip(t, conn, reader, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+			elapsed := time.Since(start)
+
+			if resp.StatusCode() != pkghttp.StatusOK || got != body {
+				t.Fatalf("Expected the full body, got %d with %d bytes", resp.StatusCode(), len(got))
+			}
+			if elapsed < tt.minTime {
+				t.Errorf("Expected the response to take at least %v, took %v", tt.minTime, elapsed)
+			}
+		})
+	}
+}
+
+// signalingCloser reports when it is closed
+type signalingCloser struct {
+	io.Reader
+	closed chan struct{}
+}
+
+// Close signals the test
+func (c *signalingCloser) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestThrottledBodyClose(t *testing.T) {
+	source := &signalingCloser{Reader: strings.NewReader(strings.Repeat("z", 1000)), closed: make(chan struct{})}
+	body := newThrottledBody(source, 100, 10)
+
+	if _, err := io.ReadFull(body, make([]byte, 10)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	body.Close()
+
+	select {
+	case <-source.closed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected closing the body to release the source")
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	"github.com/ganyariya/tinyserver/internal/tcp"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// TunnelDialer opens the upstream connection for a CONNECT request
+type TunnelDialer func(address string) (pkgtcp.Connection, error)
+
+// TunnelResponse is a successful CONNECT response that hands the client
+// connection over to a raw byte tunnel with Upstream
+type TunnelResponse struct {
+	pkghttp.Response
+	Upstream io.ReadWriteCloser
+}
+
+// NewTunnelResponse creates a 200 response that tunnels to upstream
+func NewTunnelResponse(upstream io.ReadWriteCloser) *TunnelResponse {
+	return &TunnelResponse{
+		Response: pkghttp.NewResponse(pkghttp.StatusOK, pkghttp.Version11),
+		Upstream: upstream,
+	}
+}
+
+// ConnectHandler returns a handler that dials the CONNECT target and tunnels to it.
+// A nil dial uses a plain TCP dialer.
+func ConnectHandler(dial TunnelDialer) pkghttp.RequestHandler {
+	dial = orDefaultDialer(dial)
+
+	return func(req pkghttp.Request) pkghttp.Response {
+		if req.Method() != pkghttp.MethodConnect {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusMethodNotAllowed, "")
+		}
+
+		upstream, err := dial(req.Path())
+		if err != nil {
+			return internalhttp.BuildErrorResponse(pkghttp.StatusBadGateway, "")
+		}
+
+		return NewTunnelResponse(upstream)
+	}
+}
+
+// orDefaultDialer substitutes a plain TCP dialer for a nil dial
+func orDefaultDialer(dial TunnelDialer) TunnelDialer {
+	if dial != nil {
+		return dial
+	}
+
+	dialer := tcp.NewDialer()
+	return func(address string) (pkgtcp.Connection, error) {
+		return dialer.Dial("tcp", address)
+	}
+}
+
+// serveTunnel answers a CONNECT request and relays bytes until either side closes
+func (s *Server) serveTunnel(conn pkgtcp.Connection, reader *bufio.Reader, writer *bufio.Writer, tunnel *TunnelResponse) {
+	defer tunnel.Upstream.Close()
+
+	// A 2xx reply to CONNECT has no body, so it must not carry framing headers
+	delete(tunnel.Headers(), pkghttp.HeaderContentLength)
+	delete(tunnel.Headers(), pkghttp.HeaderTransferEncoding)
+	if !tunnel.HasHeader(pkghttp.HeaderDate) {
+		tunnel.SetHeader(pkghttp.HeaderDate, common.FormatHTTPDate())
+	}
+	if !tunnel.HasHeader(pkghttp.HeaderServer) {
+		tunnel.SetHeader(pkghttp.HeaderServer, ServerSoftware)
+	}
+
+	if err := internalhttp.WriteResponseHead(writer, tunnel); err != nil {
+		return
+	}
+	if err := writer.Flush(); err != nil {
+		return
+	}
+
+	// Tunnels live as long as their peers want; the request deadlines no longer apply
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		s.logger.Warn("Failed to clear tunnel deadline: %v", err)
+	}
+
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			tunnel.Upstream.Close()
+			conn.Close()
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// reader may already hold bytes the client sent after the request head
+		io.Copy(tunnel.Upstream, reader)
+		closeBoth()
+	}()
+
+	io.Copy(conn, tunnel.Upstream)
+	closeBoth()
+	<-done
+
+	s.logger.Debug("Tunnel from %s closed", conn.RemoteAddr())
+}
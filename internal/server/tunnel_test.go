@@ -0,0 +1,102 @@
+package server
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// startEchoUpstream starts a TCP listener that echoes everything back
+func startEchoUpstream(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestServerConnectTunnel(t *testing.T) {
+	upstream := startEchoUpstream(t)
+
+	server := startTestServer(t, okHandler)
+	server.SetConnectHandler(ConnectHandler(nil))
+	conn, reader := dialTestServer(t, server)
+
+	// Bytes pipelined right after the head must reach the upstream too
+	raw := "CONNECT " + upstream + " HTTP/1.1\r\nHost: " + upstream + "\r\n\r\nping"
+	if _, err := io.WriteString(conn, raw); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	resp, err := internalhttp.ReadResponseForMethod(reader, pkghttp.MethodConnect)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+	if resp.StatusCode() != pkghttp.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode())
+	}
+	if resp.HasHeader(pkghttp.HeaderContentLength) || resp.HasHeader(pkghttp.HeaderTransferEncoding) {
+		t.Error("CONNECT response must not carry framing headers")
+	}
+
+	if _, err := io.WriteString(conn, "pong"); err != nil {
+		t.Fatalf("Write through tunnel failed: %v", err)
+	}
+
+	echoed := make([]byte, len("pingpong"))
+	if _, err := io.ReadFull(reader, echoed); err != nil {
+		t.Fatalf("Read through tunnel failed: %v", err)
+	}
+	if string(echoed) != "pingpong" {
+		t.Errorf("Expected echoed bytes, got %q", echoed)
+	}
+}
+
+func TestServerConnectDialFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	unreachable := listener.Addr().String()
+	listener.Close()
+
+	server := startTestServer(t, okHandler)
+	server.SetConnectHandler(ConnectHandler(nil))
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "CONNECT "+unreachable+" HTTP/1.1\r\nHost: "+unreachable+"\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", resp.StatusCode())
+	}
+}
+
+func TestServerRejectsConnectWithoutAuthority(t *testing.T) {
+	server := startTestServer(t, okHandler)
+	server.SetConnectHandler(ConnectHandler(nil))
+	conn, reader := dialTestServer(t, server)
+
+	resp, _ := roundTrip(t, conn, reader, "CONNECT /path HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if resp.StatusCode() != pkghttp.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", resp.StatusCode())
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bufio"
+	"strings"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// UpgradeHandler speaks the new protocol on a connection after 101 Switching
+// Protocols. The connection is closed when it returns.
+type UpgradeHandler func(conn pkgtcp.Connection)
+
+// UpgradeResponse is a 101 Switching Protocols response that hands the client
+// connection over to Handler once it is sent
+type UpgradeResponse struct {
+	pkghttp.Response
+	Handler UpgradeHandler
+}
+
+// Upgrade accepts the request to switch the connection to protocol, such as
+// "websocket" or "h2c". Returning its response from a handler sends 101 with
+// the Upgrade and Connection headers, then runs handler on the connection.
+// A request that does not offer protocol is answered with 426 Upgrade Required.
+func Upgrade(req pkghttp.Request, protocol string, handler UpgradeHandler) pkghttp.Response {
+	if !offersUpgrade(req, protocol) {
+		resp := internalhttp.BuildErrorResponse(pkghttp.StatusUpgradeRequired, "")
+		resp.SetHeader(pkghttp.HeaderUpgrade, protocol)
+		resp.SetHeader(pkghttp.HeaderConnection, pkghttp.ConnectionUpgrade)
+		return resp
+	}
+
+	resp := pkghttp.NewResponse(pkghttp.StatusSwitchingProtocols, pkghttp.Version11)
+	resp.SetHeader(pkghttp.HeaderUpgrade, protocol)
+	resp.SetHeader(pkghttp.HeaderConnection, pkghttp.ConnectionUpgrade)
+
+	return &UpgradeResponse{Response: resp, Handler: handler}
+}
+
+// RequestedUpgrades lists the protocols req offers to switch to, in the
+// client's order of preference. Only HTTP/1.1 requests naming "upgrade" in
+// their Connection header can upgrade.
+func RequestedUpgrades(req pkghttp.Request) []string {
+	if req.Version() == pkghttp.Version10 ||
+		!hasConnectionToken(req.GetHeader(pkghttp.HeaderConnection), pkghttp.ConnectionUpgrade) {
+		return nil
+	}
+
+	var protocols []string
+	for _, line := range req.GetHeaders(pkghttp.HeaderUpgrade) {
+		for _, protocol := range strings.Split(line, ",") {
+			if protocol = strings.TrimSpace(protocol); protocol != "" {
+				protocols = append(protocols, protocol)
+			}
+		}
+	}
+	return protocols
+}
+
+// offersUpgrade reports whether req offers to switch to protocol
+func offersUpgrade(req pkghttp.Request, protocol string) bool {
+	for _, offered := range RequestedUpgrades(req) {
+		if strings.EqualFold(offered, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveUpgrade sends the 101 response and gives the connection to the upgrade handler
+func (s *Server) serveUpgrade(conn pkgtcp.Connection, reader *bufio.Reader, writer *bufio.Writer, req pkghttp.Request, upgrade *UpgradeResponse) {
+	// The new protocol starts after the request body, so any unread part is skipped
+	if !closeBody(req.Body()) {
+		return
+	}
+
+	// A 101 response ends at its blank line, so it must not carry framing headers
+	delete(upgrade.Headers(), pkghttp.HeaderContentLength)
+	delete(upgrade.Headers(), pkghttp.HeaderTransferEncoding)
+	if !upgrade.HasHeader(pkghttp.HeaderDate) {
+		upgrade.SetHeader(pkghttp.HeaderDate, common.FormatHTTPDate())
+	}
+	if !upgrade.HasHeader(pkghttp.HeaderServer) {
+		upgrade.SetHeader(pkghttp.HeaderServer, ServerSoftware)
+	}
+
+	if err := internalhttp.WriteResponseHead(writer, upgrade); err != nil {
+		return
+	}
+	if err := writer.Flush(); err != nil {
+		return
+	}
+
+	// The new protocol manages its own timeouts
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		s.logger.Warn("Failed to clear upgrade deadline: %v", err)
+	}
+
+	if upgrade.Handler != nil {
+		upgrade.Handler(&upgradedConn{Connection: conn, reader: reader})
+	}
+
+	s.logger.Debug("Upgraded connection from %s closed", conn.RemoteAddr())
+}
+
+// upgradedConn reads through the server's buffer, which may already hold bytes
+// the client sent in the new protocol right after the request
+type upgradedConn struct {
+	pkgtcp.Connection
+	reader *bufio.Reader
+}
+
+// Read reads buffered bytes first, then from the connection
+func (c *upgradedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
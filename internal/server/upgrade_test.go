@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// echoUpgradeHandler upgrades to a line echo protocol
+func echoUpgradeHandler(req pkghttp.Request) pkghttp.Response {
+	return Upgrade(req, "echo", func(conn pkgtcp.Connection) {
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if _, err := io.WriteString(conn, line); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func TestServerUpgrade(t *testing.T) {
+	server := startTestServer(t, echoUpgradeHandler)
+	conn, reader := dialTestServer(t, server)
+
+	// The first line of the new protocol arrives together with the request
+	raw := "GET /chat HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive, Upgrade\r\nUpgrade: ECHO\r\n\r\nhello\n"
+	resp, _ := roundTrip(t, conn, reader, raw)
+
+	if resp.StatusCode() != pkghttp.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode())
+	}
+	if resp.GetHeader(pkghttp.HeaderUpgrade) != "echo" || resp.GetHeader(pkghttp.HeaderConnection) != pkghttp.ConnectionUpgrade {
+		t.Errorf("Unexpected upgrade headers: Upgrade=%q Connection=%q",
+			resp.GetHeader(pkghttp.HeaderUpgrade), resp.GetHeader(pkghttp.HeaderConnection))
+	}
+
+	if line, err := reader.ReadString('\n'); err != nil || line != "hello\n" {
+		t.Fatalf("Expected the pipelined line echoed, got %q (%v)", line, err)
+	}
+
+	io.WriteString(conn, "again\n")
+	if line, err := reader.ReadString('\n'); err != nil || line != "again\n" {
+		t.Errorf("Expected %q echoed, got %q (%v)", "again\n", line, err)
+	}
+}
+
+func TestServerUpgradeRequired(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "no Upgrade header", raw: "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"},
+		{name: "other protocol", raw: "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"},
+		{name: "Connection lacks upgrade", raw: "GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: echo\r\n\r\n"},
+		{name: "HTTP/1.0", raw: "GET / HTTP/1.0\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startTestServer(t, echoUpgradeHandler)
+			conn, reader := dialTestServer(t, server)
+
+			resp, _ := roundTrip(t, conn, reader, tt.raw)
+			if resp.StatusCode() != pkghttp.StatusUpgradeRequired {
+				t.Errorf("Expected 426, got %d", resp.StatusCode())
+			}
+			if resp.GetHeader(pkghttp.HeaderUpgrade) != "echo" {
+				t.Errorf("Expected Upgrade: echo, got %q", resp.GetHeader(pkghttp.HeaderUpgrade))
+			}
+		})
+	}
+}
+
+func TestRequestedUpgrades(t *testing.T) {
+	req := pkghttp.NewRequest(pkghttp.MethodGet, "/", pkghttp.Version11)
+	req.SetHeader(pkghttp.HeaderConnection, "upgrade")
+	req.AddHeader(pkghttp.HeaderUpgrade, "h2c, websocket")
+	req.AddHeader(pkghttp.HeaderUpgrade, "echo/1.0")
+
+	expected := []string{"h2c", "websocket", "echo/1.0"}
+	if got := RequestedUpgrades(req); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// UploadLimits bounds the files an UploadForm saves
+type UploadLimits struct {
+	// MaxFileSize is the largest file accepted; zero means pkghttp.MaxRequestBodySize
+	MaxFileSize int64
+
+	// MemoryLimit is how much of a file is held in memory before it is spooled
+	// to a temporary file; zero means defaultUploadMemoryLimit
+	MemoryLimit int64
+}
+
+// maxFileSize returns the configured file limit or its default
+func (l UploadLimits) maxFileSize() int64 {
+	if l.MaxFileSize > 0 {
+		return l.MaxFileSize
+	}
+	return pkghttp.MaxRequestBodySize
+}
+
+// memoryLimit returns the configured memory limit or its default
+func (l UploadLimits) memoryLimit() int64 {
+	if l.MemoryLimit > 0 {
+		return l.MemoryLimit
+	}
+	return defaultUploadMemoryLimit
+}
+
+// UploadForm reads the parts of a multipart/form-data request and saves its
+// files, remembering them so they can be removed if the request fails
+type UploadForm struct {
+	reader *multipart.Reader
+	limits UploadLimits
+	saved  []string
+}
+
+// NewUploadForm starts reading the multipart body of req. A request that is
+// not multipart/form-data with a boundary gives a 415 *BindError.
+func NewUploadForm(req pkghttp.Request, limits ...UploadLimits) (*UploadForm, error) {
+	mediaType, params, err := mime.ParseMediaType(req.GetHeader(pkghttp.HeaderContentType))
+	if err != nil || mediaType != pkghttp.MimeTypeMultipartForm || params[multipartBoundaryParam] == "" {
+		return nil, &BindError{Status: pkghttp.StatusUnsupportedMediaType, Message: ErrUnsupportedContentType}
+	}
+	if req.Body() == nil {
+		return nil, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrEmptyBody}
+	}
+
+	form := &UploadForm{reader: multipart.NewReader(req.Body(), params[multipartBoundaryParam])}
+	if len(limits) > 0 {
+		form.limits = limits[0]
+	}
+	return form, nil
+}
+
+// NextPart returns the next part of the form, or io.EOF after the last one
+func (f *UploadForm) NextPart() (*multipart.Part, error) {
+	part, err := f.reader.NextPart()
+	if err != nil && err != io.EOF {
+		return nil, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrInvalidUpload, Cause: err}
+	}
+	return part, err
+}
+
+// SaveUploadedFile writes the content of part to dst and returns its size.
+// Up to the memory limit is read before touching the disk; the rest streams
+// into a temporary file beside dst, which is renamed to dst only once the
+// whole file has arrived within MaxFileSize, so a failed upload leaves
+// nothing behind. A file over the limit gives a 413 *BindError.
+func (f *UploadForm) SaveUploadedFile(part *multipart.Part, dst string) (int64, error) {
+	maxSize := f.limits.maxFileSize()
+
+	var head bytes.Buffer
+	n, err := io.Copy(&head, io.LimitReader(part, f.limits.memoryLimit()))
+	if err != nil {
+		return 0, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrInvalidUpload, Cause: err}
+	}
+	if n > maxSize {
+		return 0, &BindError{Status: pkghttp.StatusRequestEntityTooLarge, Message: ErrUploadTooLarge}
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(dst), uploadTempPattern)
+	if err != nil {
+		return 0, common.IOErrorWithCause(ErrSaveUpload, err)
+	}
+	tempPath := temp.Name()
+
+	size, err := spoolUpload(temp, &head, part, maxSize)
+	if closeErr := temp.Close(); err == nil && closeErr != nil {
+		err = common.IOErrorWithCause(ErrSaveUpload, closeErr)
+	}
+	if err == nil {
+		if renameErr := os.Rename(tempPath, dst); renameErr != nil {
+			err = common.IOErrorWithCause(ErrSaveUpload, renameErr)
+		}
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return 0, err
+	}
+
+	f.saved = append(f.saved, dst)
+	return size, nil
+}
+
+// spoolUpload writes the buffered head and the rest of part to temp,
+// failing once the total passes maxSize
+func spoolUpload(temp *os.File, head *bytes.Buffer, part io.Reader, maxSize int64) (int64, error) {
+	headSize, err := head.WriteTo(temp)
+	if err != nil {
+		return 0, common.IOErrorWithCause(ErrSaveUpload, err)
+	}
+
+	// One byte past the limit is enough to tell the file is too large
+	rest, err := io.Copy(temp, io.LimitReader(part, maxSize-headSize+1))
+	if err != nil {
+		if _, ok := err.(*os.PathError); ok {
+			return 0, common.IOErrorWithCause(ErrSaveUpload, err)
+		}
+		return 0, &BindError{Status: pkghttp.StatusBadRequest, Message: ErrInvalidUpload, Cause: err}
+	}
+	if headSize+rest > maxSize {
+		return 0, &BindError{Status: pkghttp.StatusRequestEntityTooLarge, Message: ErrUploadTooLarge}
+	}
+
+	return headSize + rest, nil
+}
+
+// Cleanup removes every file the form saved
+func (f *UploadForm) Cleanup() {
+	for _, path := range f.saved {
+		os.Remove(path)
+	}
+	f.saved = nil
+}
+
+// HandleUploads adapts a handler that reads a multipart upload form. Files
+// the handler saved are removed automatically when it returns an error
+// status or panics. A request that is not a multipart form is rejected
+// before the handler runs.
+func HandleUploads(handler func(pkghttp.Request, *UploadForm) pkghttp.Response, limits ...UploadLimits) pkghttp.RequestHandler {
+	return func(req pkghttp.Request) pkghttp.Response {
+		form, err := NewUploadForm(req, limits...)
+		if err != nil {
+			return BindErrorResponse(err)
+		}
+
+		succeeded := false
+		defer func() {
+			if !succeeded {
+				form.Cleanup()
+			}
+		}()
+
+		resp := handler(req, form)
+		succeeded = resp != nil && resp.StatusCode() < pkghttp.StatusBadRequest
+		return resp
+	}
+}
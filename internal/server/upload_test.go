@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// newUploadRequest builds a multipart/form-data request carrying one file field
+func newUploadRequest(t *testing.T, content string) pkghttp.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "upload.txt")
+	if err != nil {
+		t.Fatalf("CreateFormFile failed: %v", err)
+	}
+	io.WriteString(part, content)
+	writer.Close()
+
+	req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, "/upload", pkghttp.Version11, &body)
+	req.SetHeader(pkghttp.HeaderContentType, writer.FormDataContentType())
+	req.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(body.Len()))
+	return req
+}
+
+func TestHandleUploads(t *testing.T) {
+	limits := UploadLimits{MaxFileSize: 64, MemoryLimit: 16}
+
+	tests := []struct {
+		name           string
+		content        string
+		failAfterSave  bool
+		expectedStatus pkghttp.StatusCode
+		expectFile     bool
+	}{
+		{name: "held in memory", content: "small", expectedStatus: pkghttp.StatusCreated, expectFile: true},
+		{name: "spooled to disk", content: strings.Repeat("a", 40), expectedStatus: pkghttp.StatusCreated, expectFile: true},
+		{name: "exactly the limit", content: strings.Repeat("a", 64), expectedStatus: pkghttp.StatusCreated, expectFile: true},
+		{name: "over the limit", content: strings.Repeat("a", 65), expectedStatus: pkghttp.StatusRequestEntityTooLarge},
+		{name: "handler error removes the file", content: "small", failAfterSave: true, expectedStatus: pkghttp.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dst := filepath.Join(dir, "saved.txt")
+
+			handler := HandleUploads(func(req pkghttp.Request, form *UploadForm) pkghttp.Response {
+				part, err := form.NextPart()
+				if err != nil {
+					return BindErrorResponse(err)
+				}
+				if _, err := form.SaveUploadedFile(part, dst); err != nil {
+					return BindErrorResponse(err)
+				}
+				if tt.failAfterSave {
+					return pkghttp.NewTextResponse(pkghttp.StatusInternalServerError, pkghttp.Version11, "failed")
+				}
+				return pkghttp.NewResponse(pkghttp.StatusCreated, pkghttp.Version11)
+			}, limits)
+
+			resp := handler(newUploadRequest(t, tt.content))
+			if resp.StatusCode() != tt.expectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.expectedStatus, resp.StatusCode())
+			}
+
+			saved, err := os.ReadFile(dst)
+			if tt.expectFile {
+				if err != nil || string(saved) != tt.content {
+					t.Errorf("Expected saved content of %d bytes, got %d bytes (%v)", len(tt.content), len(saved), err)
+				}
+			} else if !os.IsNotExist(err) {
+				t.Errorf("Expected no file at the destination, got %v", err)
+			}
+
+			// No spooled temporary file is left behind
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if entry.Name() != "saved.txt" {
+					t.Errorf("Unexpected leftover file %s", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestHandleUploadsRejectsNonMultipart(t *testing.T) {
+	handler := HandleUploads(func(req pkghttp.Request, form *UploadForm) pkghttp.Response {
+		t.Error("Handler should not run")
+		return nil
+	})
+
+	resp := handler(newJSONRequest(pkghttp.MimeTypeJSON, `{}`))
+	if resp.StatusCode() != pkghttp.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, got %d", resp.StatusCode())
+	}
+}
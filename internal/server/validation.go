@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// FieldError describes why one field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every field that failed validation
+type ValidationErrors []FieldError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return ErrValidationFailed + ": " + strings.Join(messages, "; ")
+}
+
+// Response renders the errors as a 422 JSON error response listing each field
+func (e ValidationErrors) Response() pkghttp.Response {
+	var body struct {
+		Error struct {
+			Code    pkghttp.StatusCode `json:"code"`
+			Message string             `json:"message"`
+			Fields  []FieldError       `json:"fields"`
+		} `json:"error"`
+	}
+	body.Error.Code = pkghttp.StatusUnprocessableEntity
+	body.Error.Message = ErrValidationFailed
+	body.Error.Fields = e
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return internalhttp.BuildJSONErrorResponse(pkghttp.StatusInternalServerError, "")
+	}
+	return pkghttp.NewJSONResponse(pkghttp.StatusUnprocessableEntity, pkghttp.Version11, string(data))
+}
+
+// Validator checks bound form or JSON values, collecting an error for every
+// field that fails. Only the first failure of each field is kept, so a
+// missing value is not also reported as too short.
+type Validator struct {
+	errors ValidationErrors
+	failed map[string]bool
+}
+
+// NewValidator creates a validator with no errors
+func NewValidator() *Validator {
+	return &Validator{failed: make(map[string]bool)}
+}
+
+// Check records message for field unless ok holds
+func (v *Validator) Check(ok bool, field, message string) *Validator {
+	if ok || v.failed[field] {
+		return v
+	}
+	v.failed[field] = true
+	v.errors = append(v.errors, FieldError{Field: field, Message: message})
+	return v
+}
+
+// Required checks that value is not blank
+func (v *Validator) Required(field, value string) *Validator {
+	return v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// MinLength checks that value has at least min characters
+func (v *Validator) MinLength(field, value string, min int) *Validator {
+	return v.Check(utf8.RuneCountInString(value) >= min, field, fmt.Sprintf("must be at least %d characters", min))
+}
+
+// MaxLength checks that value has at most max characters
+func (v *Validator) MaxLength(field, value string, max int) *Validator {
+	return v.Check(utf8.RuneCountInString(value) <= max, field, fmt.Sprintf("must be at most %d characters", max))
+}
+
+// Matches checks that value matches pattern
+func (v *Validator) Matches(field, value string, pattern *regexp.Regexp) *Validator {
+	return v.Check(pattern.MatchString(value), field, "has an invalid format")
+}
+
+// Range checks that value lies between min and max inclusive
+func (v *Validator) Range(field string, value, min, max float64) *Validator {
+	return v.Check(value >= min && value <= max, field, fmt.Sprintf("must be between %v and %v", min, max))
+}
+
+// Valid reports whether every check passed
+func (v *Validator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+// Err returns the collected errors as ValidationErrors, or nil when every check passed
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return v.errors
+}
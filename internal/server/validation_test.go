@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"testing"
+
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestValidator(t *testing.T) {
+	username := regexp.MustCompile(`^[a-z0-9_]+$`)
+
+	tests := []struct {
+		name     string
+		form     Params
+		expected []string
+	}{
+		{
+			name: "valid",
+			form: Params{"name": {"gopher_01"}, "bio": {"hi"}, "age": {"30"}},
+		},
+		{
+			name:     "missing name is reported once",
+			form:     Params{"age": {"30"}},
+			expected: []string{"name"},
+		},
+		{
+			name:     "every failing field",
+			form:     Params{"name": {"Go Pher"}, "bio": {"far too long"}, "age": {"200"}},
+			expected: []string{"name", "bio", "age"},
+		},
+		{
+			name:     "length counts characters",
+			form:     Params{"name": {"ab"}, "bio": {"ééé"}, "age": {"30"}},
+			expected: []string{"name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, err := tt.form.Float64("age", 0)
+			if err != nil {
+				t.Fatalf("Float64 failed: %v", err)
+			}
+
+			v := NewValidator().
+				Required("name", tt.form.Get("name")).
+				MinLength("name", tt.form.Get("name"), 3).
+				Matches("name", tt.form.Get("name"), username).
+				MaxLength("bio", tt.form.Get("bio"), 5).
+				Range("age", age, 0, 150)
+
+			if v.Valid() != (len(tt.expected) == 0) {
+				t.Errorf("Expected Valid %v, got %v", len(tt.expected) == 0, v.Valid())
+			}
+
+			err = v.Err()
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("Expected ValidationErrors, got %T", err)
+			}
+			if len(fieldErrs) != len(tt.expected) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.expected), fieldErrs)
+			}
+			for i, field := range tt.expected {
+				if fieldErrs[i].Field != field {
+					t.Errorf("Expected error %d for %s, got %s", i, field, fieldErrs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorsResponse(t *testing.T) {
+	err := NewValidator().Required("email", "").Err()
+
+	resp := BindErrorResponse(err)
+	if resp.StatusCode() != pkghttp.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d", resp.StatusCode())
+	}
+
+	body, _ := io.ReadAll(resp.Body())
+	var decoded struct {
+		Error struct {
+			Code   int          `json:"code"`
+			Fields []FieldError `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Response is not valid JSON: %v\n%s", err, body)
+	}
+	if decoded.Error.Code != 422 || len(decoded.Error.Fields) != 1 || decoded.Error.Fields[0].Field != "email" {
+		t.Errorf("Unexpected body %s", body)
+	}
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// WebhookEndpoint is a receiver of webhook events
+type WebhookEndpoint struct {
+	// URL receives events as POST requests
+	URL string
+
+	// Secret signs deliveries so the receiver can check them with VerifySignature
+	Secret []byte
+
+	// Events lists the event types sent to the endpoint; nil means all of them
+	Events []string
+}
+
+// wants reports whether the endpoint subscribes to eventType
+func (e WebhookEndpoint) wants(eventType string) bool {
+	if e.Events == nil {
+		return true
+	}
+	for _, event := range e.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is an event published to the registered endpoints
+type WebhookEvent struct {
+	ID      string
+	Type    string
+	Payload []byte
+}
+
+// WebhookDelivery describes the delivery of an event to one endpoint that
+// failed for good, as passed to the dead-letter hook
+type WebhookDelivery struct {
+	Event    WebhookEvent
+	Endpoint WebhookEndpoint
+	Attempts int
+
+	// StatusCode is the last status the endpoint answered with, or zero when
+	// the last attempt got no response
+	StatusCode pkghttp.StatusCode
+
+	// Err is why the last attempt failed
+	Err error
+}
+
+// WebhookConfig configures a WebhookDispatcher
+type WebhookConfig struct {
+	// Client sends the deliveries
+	Client pkghttp.Client
+
+	// SignatureHeader carries the delivery signature; empty means DefaultSignatureHeader
+	SignatureHeader string
+
+	// MaxAttempts is how often a delivery is tried; zero means DefaultWebhookMaxAttempts
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry, doubling after each
+	// further one; zero means DefaultWebhookInitialBackoff
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between retries; zero means DefaultWebhookMaxBackoff
+	MaxBackoff time.Duration
+
+	// Timeout bounds each attempt; zero means DefaultWebhookTimeout
+	Timeout time.Duration
+
+	// QueueSize is how many deliveries may wait to be sent; zero means DefaultWebhookQueueSize
+	QueueSize int
+
+	// Workers is how many deliveries are sent at once; zero means DefaultWebhookWorkers
+	Workers int
+
+	// DeadLetter is called with each delivery that will not be retried
+	DeadLetter func(WebhookDelivery)
+
+	// Now returns the time deliveries are signed with; defaults to time.Now
+	Now func() time.Time
+}
+
+// WebhookDispatcher delivers events to registered endpoints in the
+// background. Each delivery is a signed JSON POST retried with exponential
+// backoff on network errors, 408, 429 and 5xx responses; other failures and
+// exhausted retries go to the dead-letter hook.
+type WebhookDispatcher struct {
+	config    WebhookConfig
+	endpoints []WebhookEndpoint
+	queue     chan WebhookDelivery
+	ctx       context.Context
+	cancel    context.CancelFunc
+	workers   sync.WaitGroup
+	closed    bool
+	mu        sync.Mutex
+}
+
+// NewWebhookDispatcher creates a dispatcher and starts its workers
+func NewWebhookDispatcher(config WebhookConfig) *WebhookDispatcher {
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = DefaultSignatureHeader
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = DefaultWebhookInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultWebhookMaxBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebhookTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultWebhookQueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultWebhookWorkers
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &WebhookDispatcher{
+		config: config,
+		queue:  make(chan WebhookDelivery, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	d.workers.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go func() {
+			defer d.workers.Done()
+			for delivery := range d.queue {
+				d.deliver(delivery)
+			}
+		}()
+	}
+	return d
+}
+
+// Register adds an endpoint for events published from now on
+func (d *WebhookDispatcher) Register(endpoint WebhookEndpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.endpoints = append(d.endpoints, endpoint)
+}
+
+// Unregister removes the endpoints with the given URL. Deliveries already
+// queued for them are still attempted.
+func (d *WebhookDispatcher) Unregister(endpointURL string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.endpoints[:0]
+	for _, endpoint := range d.endpoints {
+		if endpoint.URL != endpointURL {
+			kept = append(kept, endpoint)
+		}
+	}
+	d.endpoints = kept
+}
+
+// Publish queues an event of eventType, with payload encoded as JSON, for
+// every endpoint subscribed to it and returns the event. It fails when the
+// payload cannot be encoded, the queue lacks room for every subscribed
+// endpoint, or the dispatcher is closed. A failed Publish queues nothing, so
+// it can be retried without duplicate deliveries.
+func (d *WebhookDispatcher) Publish(eventType string, payload interface{}) (WebhookEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return WebhookEvent{}, common.InvalidInputErrorWithCause(ErrInvalidWebhookPayload, err)
+	}
+	id, err := webhookEventID()
+	if err != nil {
+		return WebhookEvent{}, err
+	}
+	event := WebhookEvent{ID: id, Type: eventType, Payload: data}
+
+	// Publishers take the lock exclusively, so the room checked here can only
+	// grow as workers take deliveries before the sends below
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return WebhookEvent{}, common.ServerError(ErrWebhookDispatcherClosed)
+	}
+
+	var deliveries []WebhookDelivery
+	for _, endpoint := range d.endpoints {
+		if endpoint.wants(eventType) {
+			deliveries = append(deliveries, WebhookDelivery{Event: event, Endpoint: endpoint})
+		}
+	}
+	if len(deliveries) > cap(d.queue)-len(d.queue) {
+		return WebhookEvent{}, common.ServerError(ErrWebhookQueueFull)
+	}
+
+	for _, delivery := range deliveries {
+		d.queue <- delivery
+	}
+	return event, nil
+}
+
+// Close stops accepting events and waits for queued deliveries, retries
+// included, to finish. When ctx ends first, pending retries are abandoned
+// to the dead-letter hook and ctx's error is returned.
+func (d *WebhookDispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return common.TimeoutError(ErrWebhookDispatcherClosed + ": " + ctx.Err().Error())
+	}
+}
+
+// deliver sends a delivery until it succeeds, fails for good or runs out of attempts
+func (d *WebhookDispatcher) deliver(delivery WebhookDelivery) {
+	backoff := d.config.InitialBackoff
+	for {
+		delivery.Attempts++
+		status, retry, err := d.attempt(delivery)
+		if err == nil {
+			return
+		}
+		delivery.StatusCode, delivery.Err = status, err
+
+		if !retry || delivery.Attempts >= d.config.MaxAttempts {
+			d.deadLetter(delivery)
+			return
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-d.ctx.Done():
+			timer.Stop()
+			d.deadLetter(delivery)
+			return
+		}
+		backoff = min(backoff*webhookBackoffMultiplier, d.config.MaxBackoff)
+	}
+}
+
+// attempt sends a delivery once, returning the status answered, whether a
+// failure is worth retrying, and the failure
+func (d *WebhookDispatcher) attempt(delivery WebhookDelivery) (pkghttp.StatusCode, bool, error) {
+	target, err := url.Parse(delivery.Endpoint.URL)
+	if err != nil {
+		return 0, false, common.InvalidInputErrorWithCause(ErrInvalidWebhookURL, err)
+	}
+
+	body := delivery.Event.Payload
+	req := pkghttp.NewRequestWithBody(pkghttp.MethodPost, delivery.Endpoint.URL, pkghttp.Version11, bytes.NewReader(body))
+	req.SetHeader(pkghttp.HeaderContentType, pkghttp.MimeTypeJSON)
+	req.SetHeader(pkghttp.HeaderContentLength, strconv.Itoa(len(body)))
+	req.SetHeader(headerWebhookID, delivery.Event.ID)
+	req.SetHeader(headerWebhookEvent, delivery.Event.Type)
+
+	// The receiver verifies against the origin-form target it is sent
+	date := common.FormatHTTPTime(d.config.Now())
+	req.SetHeader(pkghttp.HeaderDate, date)
+	req.SetHeader(d.config.SignatureHeader, Sign(delivery.Endpoint.Secret, pkghttp.MethodPost, target.RequestURI(), date, body))
+
+	ctx, cancel := context.WithTimeout(d.ctx, d.config.Timeout)
+	defer cancel()
+
+	resp, err := d.config.Client.DoWithContext(ctx, req)
+	if err != nil {
+		return 0, d.ctx.Err() == nil, err
+	}
+	if resp.Body() != nil {
+		resp.Body().Close()
+	}
+
+	status := resp.StatusCode()
+	if pkghttp.IsSuccess(status) {
+		return status, false, nil
+	}
+	retry := status == pkghttp.StatusRequestTimeout || status == pkghttp.StatusTooManyRequests || status >= pkghttp.StatusInternalServerError
+	return status, retry, common.ClientError(ErrWebhookRejected + ": " + strconv.Itoa(int(status)))
+}
+
+// deadLetter hands a failed delivery to the dead-letter hook
+func (d *WebhookDispatcher) deadLetter(delivery WebhookDelivery) {
+	if d.config.DeadLetter != nil {
+		d.config.DeadLetter(delivery)
+	}
+}
+
+// webhookEventID returns a random event ID
+func webhookEventID() (string, error) {
+	var buf [webhookIDBytes]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", common.IOErrorWithCause("failed to generate webhook event ID", err)
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/client"
+	internalhttp "github.com/ganyariya/tinyserver/internal/http"
+	pkghttp "github.com/ganyariya/tinyserver/pkg/http"
+)
+
+func TestWebhookDispatcher(t *testing.T) {
+	secret := []byte("hook-secret")
+
+	tests := []struct {
+		name       string
+		failures   int32
+		failStatus pkghttp.StatusCode
+		secret     []byte
+		delivered  bool
+		attempts   int
+		deadStatus pkghttp.StatusCode
+	}{
+		{name: "first try", delivered: true},
+		{name: "retried", failures: 2, failStatus: pkghttp.StatusServiceUnavailable, delivered: true},
+		{name: "rate limited", failures: 1, failStatus: pkghttp.StatusTooManyRequests, delivered: true},
+		{name: "retries exhausted", failures: 10, failStatus: pkghttp.StatusInternalServerError, attempts: 3, deadStatus: pkghttp.StatusInternalServerError},
+		{name: "permanent failure", failures: 10, failStatus: pkghttp.StatusGone, attempts: 1, deadStatus: pkghttp.StatusGone},
+		{name: "bad signature", secret: []byte("wrong"), attempts: 1, deadStatus: pkghttp.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			received := make(chan string, 10)
+			receiver := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+				if calls.Add(1) <= tt.failures {
+					return internalhttp.BuildErrorResponse(tt.failStatus, "")
+				}
+				data, _ := io.ReadAll(req.Body())
+				received <- req.GetHeader(headerWebhookEvent) + " " + string(data)
+				return pkghttp.NewResponse(pkghttp.StatusNoContent, pkghttp.Version11)
+			}, VerifySignature(SignatureConfig{Secrets: [][]byte{secret}}))
+			hookClient := client.NewClient()
+			t.Cleanup(hookClient.CloseIdleConnections)
+
+			dead := make(chan WebhookDelivery, 1)
+			dispatcher := NewWebhookDispatcher(WebhookConfig{
+				Client:         hookClient,
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				DeadLetter:     func(delivery WebhookDelivery) { dead <- delivery },
+			})
+			endpointSecret := secret
+			if tt.secret != nil {
+				endpointSecret = tt.secret
+			}
+			dispatcher.Register(WebhookEndpoint{URL: "http://" + receiver.Addr().String() + "/hooks?v=1", Secret: endpointSecret})
+			dispatcher.Register(WebhookEndpoint{URL: "http://" + receiver.Addr().String() + "/other", Secret: secret, Events: []string{"user.deleted"}})
+
+			event, err := dispatcher.Publish("order.created", map[string]int{"id": 7})
+			if err != nil {
+				t.Fatalf("Publish failed: %v", err)
+			}
+			if err := dispatcher.Close(context.Background()); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			select {
+			case got := <-received:
+				if !tt.delivered || got != `order.created {"id":7}` {
+					t.Errorf("Unexpected delivery %q", got)
+				}
+			default:
+				if tt.delivered {
+					t.Error("Expected the event to be delivered")
+				}
+			}
+
+			select {
+			case delivery := <-dead:
+				if tt.delivered {
+					t.Fatalf("Unexpected dead letter %+v", delivery)
+				}
+				if delivery.Event.ID != event.ID || delivery.Attempts != tt.attempts || delivery.StatusCode != tt.deadStatus {
+					t.Errorf("Expected %d attempts ending in %d, got %d ending in %d", tt.attempts, tt.deadStatus, delivery.Attempts, delivery.StatusCode)
+				}
+			default:
+				if !tt.delivered {
+					t.Error("Expected a dead letter")
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcherClosed(t *testing.T) {
+	dispatcher := NewWebhookDispatcher(WebhookConfig{Client: client.NewClient()})
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := dispatcher.Publish("order.created", nil); err == nil {
+		t.Error("Expected publishing after Close to fail")
+	}
+}
+
+func TestWebhookDispatcherQueueFull(t *testing.T) {
+	var received atomic.Int32
+	receiver := startTestServer(t, func(req pkghttp.Request) pkghttp.Response {
+		received.Add(1)
+		return okHandler(req)
+	})
+	hookClient := client.NewClient()
+	t.Cleanup(hookClient.CloseIdleConnections)
+
+	dispatcher := NewWebhookDispatcher(WebhookConfig{Client: hookClient, QueueSize: 1})
+	t.Cleanup(func() { dispatcher.Close(context.Background()) })
+
+	// Two subscribed endpoints cannot fit in a queue of one
+	url := "http://" + receiver.Addr().String() + "/hooks"
+	dispatcher.Register(WebhookEndpoint{URL: url})
+	dispatcher.Register(WebhookEndpoint{URL: url + "/other"})
+
+	if _, err := dispatcher.Publish("order.created", nil); err == nil {
+		t.Fatal("Expected Publish to report the full queue")
+	}
+	if err := dispatcher.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if n := received.Load(); n != 0 {
+		t.Errorf("Expected a failed Publish to deliver nothing, got %d deliveries", n)
+	}
+}
+
+func TestWebhookDispatcherCloseAbandonsRetries(t *testing.T) {
+	receiver := startTestServer(t, internalErrorHandler)
+	hookClient := client.NewClient()
+	t.Cleanup(hookClient.CloseIdleConnections)
+
+	dead := make(chan WebhookDelivery, 1)
+	dispatcher := NewWebhookDispatcher(WebhookConfig{
+		Client:         hookClient,
+		InitialBackoff: time.Hour,
+		DeadLetter:     func(delivery WebhookDelivery) { dead <- delivery },
+	})
+	dispatcher.Register(WebhookEndpoint{URL: "http://" + receiver.Addr().String() + "/hooks"})
+	if _, err := dispatcher.Publish("order.created", nil); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Close(ctx); err == nil {
+		t.Error("Expected Close to report the abandoned retry")
+	}
+
+	select {
+	case delivery := <-dead:
+		if delivery.Attempts != 1 {
+			t.Errorf("Expected 1 attempt, got %d", delivery.Attempts)
+		}
+	default:
+		t.Error("Expected the abandoned delivery to be dead-lettered")
+	}
+}
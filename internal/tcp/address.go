@@ -0,0 +1,62 @@
+package tcp
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// ParseAddress splits a "unix:///path/to.sock" address into the unix network
+// and socket path; any other address is a TCP host:port
+func ParseAddress(address string) (network, addr string) {
+	if path, ok := strings.CutPrefix(address, unixAddressPrefix); ok {
+		return pkgtcp.NetworkUnix, path
+	}
+	return pkgtcp.NetworkTCP, address
+}
+
+// ValidateAddress checks that address has the form network expects: host:port
+// with a numeric port for TCP, or a socket path for unix
+func ValidateAddress(network, address string) error {
+	switch network {
+	case pkgtcp.NetworkTCP, pkgtcp.NetworkTCP4, pkgtcp.NetworkTCP6:
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return common.InvalidInputErrorWithCause(pkgtcp.ErrMsgInvalidAddress+": "+address, err)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 0 || n > maxPort {
+			return common.InvalidInputError(pkgtcp.ErrMsgInvalidAddress + ": invalid port " + strconv.Quote(port))
+		}
+	case pkgtcp.NetworkUnix:
+		if address == "" || strings.IndexByte(address, 0) != -1 {
+			return common.InvalidInputError(pkgtcp.ErrMsgInvalidAddress + ": invalid socket path " + strconv.Quote(address))
+		}
+		if len(address) > maxUnixSocketPathLength {
+			return common.InvalidInputError(pkgtcp.ErrMsgInvalidAddress + ": socket path longer than " + strconv.Itoa(maxUnixSocketPathLength) + " bytes")
+		}
+	default:
+		return common.InvalidInputError(pkgtcp.ErrMsgUnsupportedNetwork + ": " + network)
+	}
+	return nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a process that
+// exited without closing its listener. A socket something still listens on,
+// or a file that is not a socket, is kept so Listen reports the conflict.
+func removeStaleSocket(path string) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+
+	conn, err := net.DialTimeout(pkgtcp.NetworkUnix, path, staleSocketProbeTimeout)
+	if err == nil {
+		conn.Close()
+		return
+	}
+	os.Remove(path)
+}
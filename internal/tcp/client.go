@@ -0,0 +1,222 @@
+package tcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// tcpClient implements the tcp.Client interface
+type tcpClient struct {
+	dialer         pkgtcp.Dialer
+	network        string
+	address        string
+	timeout        time.Duration
+	conn           pkgtcp.Connection
+	reconnectDelay time.Duration
+	reconnecting   bool
+	stopChan       chan struct{}
+	logger         *common.Logger
+	mu             sync.RWMutex
+	sendMu         sync.Mutex
+	receiveMu      sync.Mutex
+}
+
+// NewClient creates a TCP client that stays disconnected after the connection fails
+func NewClient() pkgtcp.Client {
+	return newClient(NewDialer(), 0)
+}
+
+// NewReconnectingClient creates a TCP client that redials the server every
+// clientReconnectDelay after the connection fails, until Disconnect is called
+func NewReconnectingClient() pkgtcp.Client {
+	return newClient(NewDialer(), clientReconnectDelay)
+}
+
+// newClient creates a client; a zero reconnectDelay disables reconnecting
+func newClient(dialer pkgtcp.Dialer, reconnectDelay time.Duration) *tcpClient {
+	return &tcpClient{
+		dialer:         dialer,
+		reconnectDelay: reconnectDelay,
+		logger:         common.NewDefaultLogger(),
+	}
+}
+
+// Connect establishes a connection to the server at a host:port address, or
+// at a Unix domain socket given as "unix:///path/to.sock". Failed attempts are
+// retried with exponential backoff.
+func (c *tcpClient) Connect(address string) error {
+	return c.ConnectContext(context.Background(), address)
+}
+
+// ConnectContext acts like Connect but stops retrying when ctx is done
+func (c *tcpClient) ConnectContext(ctx context.Context, address string) error {
+	return c.connect(address, pkgtcp.DefaultDialTimeout, func(network, address string) (pkgtcp.Connection, error) {
+		return c.dialer.DialWithRetry(ctx, network, address)
+	})
+}
+
+// ConnectWithTimeout makes a single connection attempt with a timeout
+func (c *tcpClient) ConnectWithTimeout(address string, timeout time.Duration) error {
+	return c.connect(address, timeout, func(network, address string) (pkgtcp.Connection, error) {
+		return c.dialer.DialTimeout(network, address, timeout)
+	})
+}
+
+// connect opens the connection with dial, keeping timeout for reconnects
+func (c *tcpClient) connect(address string, timeout time.Duration, dial func(network, address string) (pkgtcp.Connection, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil || c.reconnecting {
+		return common.ClientError("client is already connected")
+	}
+
+	network, address := ParseAddress(address)
+	conn, err := dial(network, address)
+	if err != nil {
+		return err
+	}
+
+	c.network = network
+	c.address = address
+	c.timeout = timeout
+	c.conn = conn
+	c.stopChan = make(chan struct{})
+	return nil
+}
+
+// Disconnect closes the connection and stops any reconnect attempts
+func (c *tcpClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopChan == nil {
+		return nil
+	}
+	close(c.stopChan)
+	c.stopChan = nil
+	c.reconnecting = false
+
+	conn := c.conn
+	c.conn = nil
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+// IsConnected returns true if the client is connected
+func (c *tcpClient) IsConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn != nil
+}
+
+// Send writes all of data to the server. Concurrent calls do not interleave.
+func (c *tcpClient) Send(data []byte) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	conn := c.GetConnection()
+	if conn == nil {
+		return common.NetworkError(pkgtcp.ErrMsgConnectionClosed)
+	}
+
+	for len(data) > 0 {
+		n, err := conn.Write(data)
+		if err != nil {
+			c.connectionFailed(conn)
+			return common.NetworkErrorWithCause("send failed", err)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Receive reads data from the server. Concurrent calls are served one at a time.
+func (c *tcpClient) Receive(p []byte) (int, error) {
+	c.receiveMu.Lock()
+	defer c.receiveMu.Unlock()
+
+	conn := c.GetConnection()
+	if conn == nil {
+		return 0, common.NetworkError(pkgtcp.ErrMsgConnectionClosed)
+	}
+
+	n, err := conn.Read(p)
+	if err != nil {
+		c.connectionFailed(conn)
+		return n, common.NetworkErrorWithCause("receive failed", err)
+	}
+	return n, nil
+}
+
+// GetConnection returns the underlying connection, or nil while disconnected
+func (c *tcpClient) GetConnection() pkgtcp.Connection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
+
+// connectionFailed drops a broken connection and starts reconnecting if enabled
+func (c *tcpClient) connectionFailed(conn pkgtcp.Connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another call already handled this failure, or Disconnect was called
+	if c.conn != conn {
+		return
+	}
+
+	conn.Close()
+	c.conn = nil
+
+	if c.reconnectDelay <= 0 {
+		c.stopChan = nil
+		return
+	}
+
+	c.logger.Warn("Connection to %s lost, reconnecting", c.address)
+	c.reconnecting = true
+	go c.reconnectLoop(c.network, c.address, c.timeout, c.stopChan)
+}
+
+// reconnectLoop redials address until it succeeds or stop is closed
+func (c *tcpClient) reconnectLoop(network, address string, timeout time.Duration, stop chan struct{}) {
+	timer := time.NewTimer(c.reconnectDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-stop:
+			return
+		}
+
+		conn, err := c.dialer.DialTimeout(network, address, timeout)
+		if err != nil {
+			c.logger.Debug("Reconnect to %s failed: %v", address, err)
+			timer.Reset(c.reconnectDelay)
+			continue
+		}
+
+		c.mu.Lock()
+		select {
+		case <-stop:
+			c.mu.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		c.conn = conn
+		c.reconnecting = false
+		c.mu.Unlock()
+
+		c.logger.Info("Reconnected to %s", address)
+		return
+	}
+}
@@ -0,0 +1,149 @@
+package tcp
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// startEchoListener accepts connections and echoes them back, returning the
+// listener and a channel of the accepted server ends
+func startEchoListener(t *testing.T) (net.Listener, chan net.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+			go io.Copy(conn, conn)
+		}
+	}()
+
+	return listener, accepted
+}
+
+func TestClientSendReceive(t *testing.T) {
+	listener, _ := startEchoListener(t)
+
+	client := NewClient()
+	if client.IsConnected() {
+		t.Error("Client should not be connected initially")
+	}
+	if err := client.Send([]byte("early")); err == nil {
+		t.Error("Send should fail before Connect")
+	}
+
+	if err := client.ConnectWithTimeout(listener.Addr().String(), time.Second); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Disconnect()
+
+	if !client.IsConnected() || client.GetConnection() == nil {
+		t.Error("Client should be connected after Connect")
+	}
+	if err := client.Connect(listener.Addr().String()); err == nil {
+		t.Error("Connect should fail while already connected")
+	}
+
+	message := []byte("hello tinyserver")
+	if err := client.Send(message); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	buffer := make([]byte, len(message))
+	received := 0
+	for received < len(message) {
+		n, err := client.Receive(buffer[received:])
+		if err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+		received += n
+	}
+	if string(buffer) != string(message) {
+		t.Errorf("Expected %q, got %q", message, buffer)
+	}
+
+	if err := client.Disconnect(); err != nil {
+		t.Errorf("Disconnect failed: %v", err)
+	}
+	if client.IsConnected() {
+		t.Error("Client should not be connected after Disconnect")
+	}
+}
+
+func TestClientConnectFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	client := NewClient()
+	if err := client.ConnectWithTimeout(address, time.Second); err == nil {
+		t.Error("Connect should fail when nothing is listening")
+	}
+	if client.IsConnected() {
+		t.Error("Client should not be connected after a failed Connect")
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	tests := []struct {
+		name           string
+		reconnectDelay time.Duration
+		reconnects     bool
+	}{
+		{name: "without reconnect", reconnectDelay: 0, reconnects: false},
+		{name: "with reconnect", reconnectDelay: 10 * time.Millisecond, reconnects: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, accepted := startEchoListener(t)
+
+			client := newClient(NewDialer(), tt.reconnectDelay)
+			if err := client.Connect(listener.Addr().String()); err != nil {
+				t.Fatalf("Connect failed: %v", err)
+			}
+			defer client.Disconnect()
+
+			// The server drops the connection
+			(<-accepted).Close()
+
+			if _, err := client.Receive(make([]byte, 16)); err == nil {
+				t.Fatal("Receive should fail after the server closes the connection")
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for tt.reconnects && !client.IsConnected() && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if client.IsConnected() != tt.reconnects {
+				t.Errorf("Expected connected %v, got %v", tt.reconnects, client.IsConnected())
+			}
+			if !tt.reconnects {
+				return
+			}
+
+			if err := client.Send([]byte("again")); err != nil {
+				t.Fatalf("Send after reconnect failed: %v", err)
+			}
+			buffer := make([]byte, 5)
+			if _, err := io.ReadFull(client.GetConnection(), buffer); err != nil || string(buffer) != "again" {
+				t.Errorf("Expected echo after reconnect, got %q (%v)", buffer, err)
+			}
+		})
+	}
+}
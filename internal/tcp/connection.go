@@ -2,9 +2,12 @@ package tcp
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ganyariya/tinyserver/internal/common"
@@ -13,12 +16,22 @@ import (
 
 // tcpConnection implements the tcp.Connection interface
 type tcpConnection struct {
-	conn   net.Conn
-	reader *bufio.Reader
-	writer *bufio.Writer
-	logger *common.Logger
-	mu     sync.RWMutex
-	closed bool
+	conn         net.Conn
+	reader       *bufio.Reader
+	writer       *bufio.Writer
+	ctx          context.Context
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	logger       *common.Logger
+	mu           sync.RWMutex
+	closed       bool
+	onClose      func() // runs once when the connection is closed
+
+	// Idle tracking, enabled by setIdleTimeout
+	idleTimeout  time.Duration
+	idleTimer    *time.Timer
+	lastActivity atomic.Int64 // UnixNano of the last byte read or written
+	writing      atomic.Int32 // writes in progress count as activity
 }
 
 // NewConnection creates a new TCP connection wrapper
@@ -27,34 +40,124 @@ func NewConnection(conn net.Conn) pkgtcp.Connection {
 		conn:   conn,
 		reader: bufio.NewReaderSize(conn, bufferedReaderSize),
 		writer: bufio.NewWriterSize(conn, bufferedWriterSize),
+		ctx:    context.Background(),
 		logger: common.NewDefaultLogger(),
 	}
 }
 
 // Read reads data from the connection
 func (c *tcpConnection) Read(p []byte) (int, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if c.closed {
+	if c.isClosed() {
 		return 0, common.NetworkError("connection is closed")
 	}
 
-	return c.conn.Read(p)
+	if c.readTimeout > 0 {
+		if err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return 0, common.NetworkErrorWithCause("failed to set read deadline", err)
+		}
+	}
+
+	// The lock is not held during I/O so Close can interrupt a blocked Read
+	n, err := c.conn.Read(p)
+	if n > 0 {
+		c.touch()
+	}
+	return n, err
 }
 
 // Write writes data to the connection
 func (c *tcpConnection) Write(p []byte) (int, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if c.closed {
+	if c.isClosed() {
 		return 0, common.NetworkError("connection is closed")
 	}
 
+	if c.writeTimeout > 0 {
+		if err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return 0, common.NetworkErrorWithCause("failed to set write deadline", err)
+		}
+	}
+
+	c.writing.Add(1)
+	defer c.endWrite()
+
+	// The lock is not held during I/O so Close can interrupt a blocked Write
 	return c.conn.Write(p)
 }
 
+// ReadFrom copies r to the connection. A TCP connection sends a file reader
+// with sendfile, bypassing userspace buffers. The write timeout applies to the
+// whole copy, as it does to a single Write.
+func (c *tcpConnection) ReadFrom(r io.Reader) (int64, error) {
+	if c.isClosed() {
+		return 0, common.NetworkError("connection is closed")
+	}
+
+	if c.writeTimeout > 0 {
+		if err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return 0, common.NetworkErrorWithCause("failed to set write deadline", err)
+		}
+	}
+
+	// Only the ends of the copy count as activity since r may block on a peer
+	c.touch()
+	defer c.touch()
+
+	if readerFrom, ok := c.conn.(io.ReaderFrom); ok {
+		return readerFrom.ReadFrom(r)
+	}
+	return io.Copy(c.conn, r)
+}
+
+// setIdleTimeout closes the connection once no data has moved in either
+// direction for timeout. A write still in progress counts as activity.
+func (c *tcpConnection) setIdleTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.idleTimeout = timeout
+	c.touch()
+	c.idleTimer = time.AfterFunc(timeout, c.checkIdle)
+}
+
+// touch records activity on the connection
+func (c *tcpConnection) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// endWrite records the end of a write as activity
+func (c *tcpConnection) endWrite() {
+	c.touch()
+	c.writing.Add(-1)
+}
+
+// checkIdle closes the connection if it has been idle for the timeout, and
+// otherwise checks again when it could next expire
+func (c *tcpConnection) checkIdle() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+
+	idle := time.Since(time.Unix(0, c.lastActivity.Load()))
+	if c.writing.Load() > 0 || idle < c.idleTimeout {
+		wait := c.idleTimeout - idle
+		if wait <= 0 {
+			wait = c.idleTimeout
+		}
+		c.idleTimer.Reset(wait)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	c.logger.Debug("Closing connection from %s after %v idle", c.conn.RemoteAddr(), c.idleTimeout)
+	c.Close()
+}
+
 // Close closes the connection
 func (c *tcpConnection) Close() error {
 	c.mu.Lock()
@@ -66,6 +169,13 @@ func (c *tcpConnection) Close() error {
 
 	c.closed = true
 
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+	}
+	if c.onClose != nil {
+		defer c.onClose()
+	}
+
 	// Flush any remaining buffered data
 	if c.writer != nil {
 		if err := c.writer.Flush(); err != nil {
@@ -76,6 +186,13 @@ func (c *tcpConnection) Close() error {
 	return c.conn.Close()
 }
 
+// isClosed reports whether Close has been called
+func (c *tcpConnection) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
 // LocalAddr returns the local network address
 func (c *tcpConnection) LocalAddr() net.Addr {
 	return c.conn.LocalAddr()
@@ -86,6 +203,29 @@ func (c *tcpConnection) RemoteAddr() net.Addr {
 	return c.conn.RemoteAddr()
 }
 
+// TLSState returns the negotiated TLS state when the connection runs over TLS
+func (c *tcpConnection) TLSState() (tls.ConnectionState, bool) {
+	tlsConn, ok := c.conn.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	return tlsConn.ConnectionState(), true
+}
+
+// Context returns the context of the server that accepted the connection
+func (c *tcpConnection) Context() context.Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ctx
+}
+
+// setContext ties the connection to the accepting server's lifetime
+func (c *tcpConnection) setContext(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx = ctx
+}
+
 // SetDeadline sets the read and write deadlines
 func (c *tcpConnection) SetDeadline(t time.Time) error {
 	return c.conn.SetDeadline(t)
@@ -191,10 +331,13 @@ func (c *bufferedConnection) WriteLine(data []byte) error {
 	return c.writer.Flush()
 }
 
-// messageConnection provides message-based I/O operations
+// messageConnection provides message-based I/O operations. Bytes read past
+// a delimiter are kept for the next message, so messages that arrive together
+// or split across reads are returned one at a time.
 type messageConnection struct {
 	pkgtcp.Connection
 	delimiter []byte
+	pending   []byte // read from the connection but not yet returned
 	logger    *common.Logger
 }
 
@@ -214,36 +357,81 @@ func (c *messageConnection) ReadMessage() ([]byte, error) {
 
 // ReadMessageWithTimeout reads a message with a timeout
 func (c *messageConnection) ReadMessageWithTimeout(timeout time.Duration) ([]byte, error) {
-	// Set read deadline
-	if err := c.SetReadDeadline(time.Now().Add(timeout)); err != nil {
-		return nil, common.NetworkErrorWithCause("failed to set read deadline", err)
+	length, delimiterLength, err := c.fill(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, length)
+	copy(message, c.pending)
+	c.pending = c.pending[length+delimiterLength:]
+	if len(c.pending) == 0 {
+		c.pending = nil
 	}
 
-	var buffer []byte
+	return message, nil
+}
+
+// PeekMessage returns the next message without consuming it
+func (c *messageConnection) PeekMessage() ([]byte, error) {
+	return c.PeekMessageWithTimeout(common.DefaultTimeout)
+}
+
+// PeekMessageWithTimeout returns the next message without consuming it,
+// reading from the connection for up to timeout if it is not yet buffered.
+// The returned slice is only valid until the next read.
+func (c *messageConnection) PeekMessageWithTimeout(timeout time.Duration) ([]byte, error) {
+	length, _, err := c.fill(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return c.pending[:length:length], nil
+}
+
+// Buffered returns the number of bytes read but not yet returned as messages
+func (c *messageConnection) Buffered() int {
+	return len(c.pending)
+}
+
+// fill reads until pending holds a complete message and returns the lengths
+// of the message and its delimiter. A message cut short by EOF has no delimiter.
+func (c *messageConnection) fill(timeout time.Duration) (int, int, error) {
+	scanned := 0
+	deadlineSet := false
 	readBuffer := make([]byte, messageReadChunkSize)
 
 	for {
-		n, err := c.Read(readBuffer)
-		if err != nil {
-			if err == io.EOF && len(buffer) > 0 {
-				// Return partial message on EOF
-				return buffer, nil
-			}
-			return nil, common.NetworkErrorWithCause("failed to read message chunk", err)
+		// Check for message delimiter, rescanning only where a new match could start
+		if index := findDelimiter(c.pending[scanned:], c.delimiter); index != -1 {
+			return scanned + index, len(c.delimiter), nil
 		}
+		scanned = max(0, len(c.pending)-len(c.delimiter)+1)
 
-		buffer = append(buffer, readBuffer[:n]...)
+		// Check message size limit
+		if len(c.pending) > pkgtcp.MaxMessageSize {
+			return 0, 0, common.ProtocolError("message too large")
+		}
 
-		// Check for message delimiter
-		if delimiterIndex := findDelimiter(buffer, c.delimiter); delimiterIndex != -1 {
-			message := buffer[:delimiterIndex]
-			// Note: In a real implementation, we'd need to handle remaining data
-			return message, nil
+		// The deadline only applies when the message is not already buffered
+		if !deadlineSet {
+			if err := c.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+				return 0, 0, common.NetworkErrorWithCause("failed to set read deadline", err)
+			}
+			deadlineSet = true
 		}
 
-		// Check message size limit
-		if len(buffer) > pkgtcp.MaxMessageSize {
-			return nil, common.ProtocolError("message too large")
+		n, err := c.Read(readBuffer)
+		c.pending = append(c.pending, readBuffer[:n]...)
+		if err != nil {
+			// A message completed by the final read comes first; the error repeats on the next
+			if index := findDelimiter(c.pending[scanned:], c.delimiter); index != -1 {
+				return scanned + index, len(c.delimiter), nil
+			}
+			if err == io.EOF && len(c.pending) > 0 {
+				// Return partial message on EOF
+				return len(c.pending), 0, nil
+			}
+			return 0, 0, common.NetworkErrorWithCause("failed to read message chunk", err)
 		}
 	}
 }
@@ -308,27 +496,3 @@ func matchDelimiter(buffer, delimiter []byte) bool {
 
 	return true
 }
-
-// configureConnection applies optimal TCP settings to a connection
-func configureConnection(conn net.Conn) error {
-	if tcpConn, ok := conn.(*net.TCPConn); ok {
-		// Enable TCP_NODELAY to disable Nagle's algorithm
-		if err := tcpConn.SetNoDelay(tcpNoDelay); err != nil {
-			return common.NetworkErrorWithCause("failed to set TCP_NODELAY", err)
-		}
-
-		// Enable keep-alive
-		if err := tcpConn.SetKeepAlive(tcpKeepAlive); err != nil {
-			return common.NetworkErrorWithCause("failed to set keep-alive", err)
-		}
-
-		// Set keep-alive period
-		if tcpKeepAlive {
-			if err := tcpConn.SetKeepAlivePeriod(tcpKeepAlivePeriod); err != nil {
-				return common.NetworkErrorWithCause("failed to set keep-alive period", err)
-			}
-		}
-	}
-
-	return nil
-}
@@ -1,9 +1,15 @@
 package tcp
 
 import (
+	"crypto/tls"
+	"errors"
+	"io"
 	"net"
+	"strings"
 	"testing"
 	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
 )
 
 func TestNewConnection(t *testing.T) {
@@ -66,6 +72,79 @@ func TestConnectionReadWrite(t *testing.T) {
 	}
 }
 
+func TestConnectionReadFrom(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	serverConn := NewConnection(server)
+	readerFrom, ok := serverConn.(io.ReaderFrom)
+	if !ok {
+		t.Fatal("Expected the connection to implement io.ReaderFrom")
+	}
+
+	testData := "Hello, TinyServer!"
+	go func() {
+		if _, err := readerFrom.ReadFrom(strings.NewReader(testData)); err != nil {
+			t.Errorf("ReadFrom failed: %v", err)
+		}
+		server.Close()
+	}()
+
+	received, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(received) != testData {
+		t.Errorf("Expected %q, got %q", testData, received)
+	}
+}
+
+func TestConnectionReadFromWriteTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	conn := NewConnection(server).(*tcpConnection)
+	conn.writeTimeout = 50 * time.Millisecond
+
+	// Nobody reads the client end, so the copy can only end by timing out
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.ReadFrom(strings.NewReader("stalled"))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("Expected a timeout error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected ReadFrom to honour the write timeout")
+	}
+}
+
+func TestConnectionTLSState(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	plain, ok := NewConnection(server).(pkgtcp.TLSConnection)
+	if !ok {
+		t.Fatal("Expected the connection to implement TLSConnection")
+	}
+	if _, ok := plain.TLSState(); ok {
+		t.Error("Expected no TLS state on a plain connection")
+	}
+
+	secure := NewConnection(tls.Client(client, &tls.Config{ServerName: "example.com"})).(pkgtcp.TLSConnection)
+	if _, ok := secure.TLSState(); !ok {
+		t.Error("Expected TLS state on a TLS connection")
+	}
+}
+
 func TestConnectionClose(t *testing.T) {
 	// Create a test connection using a pipe
 	server, client := net.Pipe()
@@ -93,6 +172,44 @@ func TestConnectionClose(t *testing.T) {
 	}
 }
 
+func TestConnectionCloseInterruptsRead(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	conn := NewConnection(server)
+
+	readDone := make(chan error, 1)
+	go func() {
+		buffer := make([]byte, 10)
+		_, err := conn.Read(buffer)
+		readDone <- err
+	}()
+
+	// Give the reader time to block
+	time.Sleep(10 * time.Millisecond)
+
+	closeDone := make(chan struct{})
+	go func() {
+		conn.Close()
+		close(closeDone)
+	}()
+
+	select {
+	case <-closeDone:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked behind a pending Read")
+	}
+
+	select {
+	case err := <-readDone:
+		if err == nil {
+			t.Error("Pending Read should fail once the connection is closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Pending Read was not interrupted by Close")
+	}
+}
+
 func TestConnectionDeadlines(t *testing.T) {
 	// Create a test connection using a pipe
 	server, client := net.Pipe()
@@ -267,6 +384,111 @@ func TestMessageConnectionWithCustomDelimiter(t *testing.T) {
 	}
 }
 
+func TestMessageConnectionBoundaries(t *testing.T) {
+	tests := []struct {
+		name      string
+		delimiter string
+		chunks    []string
+		expected  []string
+	}{
+		{
+			name:      "coalesced messages",
+			delimiter: "\n",
+			chunks:    []string{"one\ntwo\nthree\n"},
+			expected:  []string{"one", "two", "three"},
+		},
+		{
+			name:      "fragmented messages",
+			delimiter: "\n",
+			chunks:    []string{"he", "llo\nwo", "rld\n"},
+			expected:  []string{"hello", "world"},
+		},
+		{
+			name:      "delimiter split across reads",
+			delimiter: "||",
+			chunks:    []string{"a|", "|b|", "|"},
+			expected:  []string{"a", "b"},
+		},
+		{
+			name:      "empty messages",
+			delimiter: "\n",
+			chunks:    []string{"\n\nlast\n"},
+			expected:  []string{"", "", "last"},
+		},
+		{
+			name:      "partial message at EOF",
+			delimiter: "\n",
+			chunks:    []string{"full\npart"},
+			expected:  []string{"full", "part"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer server.Close()
+
+			msgConn := NewMessageConnection(NewConnection(server))
+			msgConn.SetMessageDelimiter([]byte(tt.delimiter))
+
+			go func() {
+				defer client.Close()
+				for _, chunk := range tt.chunks {
+					client.Write([]byte(chunk))
+				}
+			}()
+
+			for i, expected := range tt.expected {
+				message, err := msgConn.ReadMessageWithTimeout(time.Second)
+				if err != nil {
+					t.Fatalf("ReadMessage %d failed: %v", i, err)
+				}
+				if string(message) != expected {
+					t.Errorf("Message %d: expected %q, got %q", i, expected, string(message))
+				}
+			}
+
+			if message, err := msgConn.ReadMessageWithTimeout(time.Second); err == nil {
+				t.Errorf("Expected an error after the last message, got %q", string(message))
+			}
+		})
+	}
+}
+
+func TestMessageConnectionPeek(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	msgConn := NewMessageConnection(NewConnection(server))
+
+	go client.Write([]byte("first\nsecond\n"))
+
+	for i := 0; i < 2; i++ {
+		message, err := msgConn.PeekMessageWithTimeout(time.Second)
+		if err != nil {
+			t.Fatalf("PeekMessage %d failed: %v", i, err)
+		}
+		if string(message) != "first" {
+			t.Errorf("PeekMessage %d: expected %q, got %q", i, "first", string(message))
+		}
+	}
+
+	for _, expected := range []string{"first", "second"} {
+		message, err := msgConn.ReadMessageWithTimeout(time.Second)
+		if err != nil {
+			t.Fatalf("ReadMessage failed: %v", err)
+		}
+		if string(message) != expected {
+			t.Errorf("Expected %q, got %q", expected, string(message))
+		}
+	}
+
+	if buffered := msgConn.Buffered(); buffered != 0 {
+		t.Errorf("Expected nothing buffered, got %d bytes", buffered)
+	}
+}
+
 func TestFindDelimiter(t *testing.T) {
 	tests := []struct {
 		buffer    []byte
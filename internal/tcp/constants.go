@@ -1,6 +1,9 @@
 package tcp
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+)
 
 // Internal TCP implementation constants
 
@@ -46,6 +49,28 @@ const (
 	serverConnectionQueueSize = 1000
 )
 
+// Address settings
+const (
+	// unixAddressPrefix marks a Unix domain socket address such as unix:///tmp/app.sock
+	unixAddressPrefix = "unix://"
+
+	// maxPort is the largest TCP port number
+	maxPort = 65535
+
+	// maxUnixSocketPathLength is the longest socket path every platform
+	// accepts (sun_path is 104 bytes on BSD and macOS, 108 on Linux)
+	maxUnixSocketPathLength = 104
+
+	// staleSocketProbeTimeout bounds the dial checking whether a socket file is still served
+	staleSocketProbeTimeout = 100 * time.Millisecond
+)
+
+// TLS settings
+const (
+	// tlsMinVersion is the oldest TLS version servers and dialers accept
+	tlsMinVersion = tls.VersionTLS12
+)
+
 // Client implementation settings
 const (
 	// clientConnectRetries is the number of connection retries
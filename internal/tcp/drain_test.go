@@ -0,0 +1,108 @@
+package tcp
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+func TestServerShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   pkgtcp.ConnectionHandler
+		deadline  time.Duration
+		expectErr bool
+		expected  string
+	}{
+		{
+			name: "handler finishes on drain signal",
+			handler: func(conn pkgtcp.Connection) {
+				<-conn.(pkgtcp.ContextConnection).Context().Done()
+				conn.Write([]byte("bye"))
+			},
+			deadline: time.Second,
+			expected: "bye",
+		},
+		{
+			name: "straggler is closed at the deadline",
+			handler: func(conn pkgtcp.Connection) {
+				io.Copy(io.Discard, conn)
+			},
+			deadline:  50 * time.Millisecond,
+			expectErr: true,
+			expected:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewServer("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("NewServer failed: %v", err)
+			}
+			entered := make(chan struct{})
+			server.SetHandler(func(conn pkgtcp.Connection) {
+				close(entered)
+				tt.handler(conn)
+			})
+			if err := server.Start(); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+
+			client, err := net.Dial("tcp", server.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer client.Close()
+			<-entered
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+
+			start := time.Now()
+			err = server.Shutdown(ctx)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Shutdown took %v", elapsed)
+			}
+			if server.IsRunning() {
+				t.Error("Server should not be running after Shutdown")
+			}
+
+			client.SetReadDeadline(time.Now().Add(time.Second))
+			data, err := io.ReadAll(client)
+			if err != nil {
+				t.Errorf("Expected the server to close the connection, got %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestServerShutdownStopsAccepting(t *testing.T) {
+	server, err := NewServer("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server.SetHandler(func(conn pkgtcp.Connection) {})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	address := server.Addr().String()
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if conn, err := net.DialTimeout("tcp", address, time.Second); err == nil {
+		conn.Close()
+		t.Error("Expected dial to fail after Shutdown")
+	}
+}
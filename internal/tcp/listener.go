@@ -1,6 +1,8 @@
 package tcp
 
 import (
+	"context"
+	"crypto/tls"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -13,11 +15,14 @@ import (
 // tcpListener implements the tcp.Listener interface
 type tcpListener struct {
 	listener   net.Listener
+	options    *options
 	logger     *common.Logger
 	mu         sync.RWMutex
 	closed     int32 // atomic
 	closeChan  chan struct{}
 	acceptChan chan acceptResult
+	acceptRate *tokenBucket  // nil when the accept rate is unlimited
+	slots      chan struct{} // holds one token per open connection when limited
 }
 
 // acceptResult represents the result of an accept operation
@@ -26,8 +31,30 @@ type acceptResult struct {
 	err  error
 }
 
-// NewListener creates a new TCP listener
-func NewListener(network, address string) (pkgtcp.Listener, error) {
+// NewListener creates a listener on a tcp, tcp4, tcp6 or unix network. A unix
+// socket file left by a listener that was not closed is replaced.
+func NewListener(network, address string, opts ...Option) (pkgtcp.Listener, error) {
+	return newListener(network, address, newOptions(opts...))
+}
+
+// newListener creates a listener whose connections carry o, serving TLS when
+// o has a TLS config
+func newListener(network, address string, o *options) (*tcpListener, error) {
+	if err := ValidateAddress(network, address); err != nil {
+		return nil, err
+	}
+	if o.tlsConfig != nil {
+		if err := validateServerTLSConfig(o.tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	if o.acceptRate < 0 || o.maxAccepted < 0 {
+		return nil, common.InvalidInputError("accept limits must not be negative")
+	}
+	if network == pkgtcp.NetworkUnix {
+		removeStaleSocket(address)
+	}
+
 	listener, err := net.Listen(network, address)
 	if err != nil {
 		return nil, common.NetworkErrorWithCause("failed to create listener", err)
@@ -35,10 +62,17 @@ func NewListener(network, address string) (pkgtcp.Listener, error) {
 
 	tcpListener := &tcpListener{
 		listener:   listener,
-		logger:     common.NewDefaultLogger(),
+		options:    o,
+		logger:     o.logger,
 		closeChan:  make(chan struct{}),
 		acceptChan: make(chan acceptResult, 1),
 	}
+	if o.acceptRate > 0 {
+		tcpListener.acceptRate = newTokenBucket(o.acceptRate, o.acceptBurst)
+	}
+	if o.maxAccepted > 0 {
+		tcpListener.slots = make(chan struct{}, o.maxAccepted)
+	}
 
 	// Start the accept goroutine
 	go tcpListener.acceptLoop()
@@ -83,12 +117,21 @@ func (l *tcpListener) Addr() net.Addr {
 
 // acceptLoop runs in a separate goroutine to handle accept operations
 func (l *tcpListener) acceptLoop() {
+	admitted := false
 	for {
 		// Check if we're closed
 		if atomic.LoadInt32(&l.closed) == 1 {
 			return
 		}
 
+		// Wait for the accept limits once per accepted connection
+		if !admitted {
+			if !l.admit() {
+				return
+			}
+			admitted = true
+		}
+
 		// Set accept timeout to allow periodic checks
 		if tcpListener, ok := l.listener.(*net.TCPListener); ok {
 			tcpListener.SetDeadline(time.Now().Add(listenerAcceptTimeout))
@@ -120,12 +163,21 @@ func (l *tcpListener) acceptLoop() {
 		}
 
 		// Configure the connection for optimal performance
-		if err := configureConnection(conn); err != nil {
+		if err := l.options.configureConnection(conn); err != nil {
 			l.logger.Warn("Failed to configure connection: %v", err)
 		}
 
+		// The handshake runs on first use, in the handler's goroutine
+		if l.options.tlsConfig != nil {
+			conn = tls.Server(conn, l.options.tlsConfig)
+		}
+
 		// Wrap the connection
-		tcpConn := NewConnection(conn)
+		tcpConn := l.options.wrap(conn)
+		if l.slots != nil {
+			tcpConn.onClose = l.release
+		}
+		admitted = false
 
 		l.logger.Debug("Accepted connection from %s", conn.RemoteAddr())
 
@@ -133,12 +185,48 @@ func (l *tcpListener) acceptLoop() {
 		select {
 		case l.acceptChan <- acceptResult{tcpConn, nil}:
 		case <-l.closeChan:
-			conn.Close()
+			tcpConn.Close()
 			return
 		}
 	}
 }
 
+// admit waits until the accept limits allow another connection, reporting
+// false if the listener is closed first
+func (l *tcpListener) admit() bool {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			l.logger.Warn("Connection limit of %d reached on %s, pausing accept", cap(l.slots), l.listener.Addr())
+			select {
+			case l.slots <- struct{}{}:
+			case <-l.closeChan:
+				return false
+			}
+		}
+	}
+
+	if l.acceptRate != nil {
+		if wait := l.acceptRate.reserve(time.Now()); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-l.closeChan:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// release frees the slot of a closed connection
+func (l *tcpListener) release() {
+	<-l.slots
+}
+
 // connectionFactory implements the tcp.ConnectionFactory interface
 type connectionFactory struct {
 	logger *common.Logger
@@ -168,81 +256,125 @@ func (f *connectionFactory) WrapConnection(conn net.Conn) pkgtcp.Connection {
 
 // tcpDialer implements the tcp.Dialer interface
 type tcpDialer struct {
-	dialer *net.Dialer
-	logger *common.Logger
+	options *options
+	logger  *common.Logger
 }
 
-// NewDialer creates a new TCP dialer
-func NewDialer() pkgtcp.Dialer {
-	return &tcpDialer{
-		dialer: &net.Dialer{
-			Timeout:   pkgtcp.DefaultDialTimeout,
-			KeepAlive: pkgtcp.DefaultKeepAlive,
-		},
-		logger: common.NewDefaultLogger(),
+// NewDialer creates a new TCP dialer. With WithTLS, connections complete a
+// TLS handshake before they are returned.
+func NewDialer(opts ...Option) pkgtcp.Dialer {
+	o := newOptions(opts...)
+	d := &tcpDialer{options: o, logger: o.logger}
+	if o.tlsConfig != nil {
+		return &tlsDialer{tcpDialer: d, config: o.tlsConfig}
 	}
+	return d
 }
 
 // Dial connects to the address on the named network
 func (d *tcpDialer) Dial(network, address string) (pkgtcp.Connection, error) {
-	conn, err := d.dialer.Dial(network, address)
+	conn, err := d.dialTimeout(network, address, d.options.dialTimeout)
 	if err != nil {
 		return nil, common.NetworkErrorWithCause("dial failed", err)
 	}
 
-	// Configure the connection for optimal performance
-	if err := configureConnection(conn); err != nil {
-		d.logger.Warn("Failed to configure connection: %v", err)
-	}
-
 	d.logger.Debug("Connected to %s", address)
 
-	return NewConnection(conn), nil
+	return d.options.wrap(conn), nil
 }
 
 // DialTimeout acts like Dial but takes a timeout
 func (d *tcpDialer) DialTimeout(network, address string, timeout time.Duration) (pkgtcp.Connection, error) {
-	dialer := &net.Dialer{
-		Timeout:   timeout,
-		KeepAlive: pkgtcp.DefaultKeepAlive,
+	conn, err := d.dialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, common.NetworkErrorWithCause("dial with timeout failed", err)
 	}
 
-	conn, err := dialer.Dial(network, address)
+	d.logger.Debug("Connected to %s with timeout %v", address, timeout)
+
+	return d.options.wrap(conn), nil
+}
+
+// dialTimeout opens a connection within timeout and applies the socket options
+func (d *tcpDialer) dialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	if err := ValidateAddress(network, address); err != nil {
+		return nil, err
+	}
+
+	conn, err := d.options.netDialer(timeout).Dial(network, address)
 	if err != nil {
-		return nil, common.NetworkErrorWithCause("dial with timeout failed", err)
+		return nil, err
 	}
 
 	// Configure the connection for optimal performance
-	if err := configureConnection(conn); err != nil {
+	if err := d.options.configureConnection(conn); err != nil {
 		d.logger.Warn("Failed to configure connection: %v", err)
 	}
 
-	d.logger.Debug("Connected to %s with timeout %v", address, timeout)
-
-	return NewConnection(conn), nil
+	return conn, nil
 }
 
 // tcpServer implements the tcp.Server interface
 type tcpServer struct {
 	listener pkgtcp.Listener
 	handler  pkgtcp.ConnectionHandler
+	config   ServerConfig
+	handoff  chan pkgtcp.Connection
+	queue    chan pkgtcp.Connection
+	workers  int // owned by the accept loop
+	active   map[pkgtcp.Connection]struct{}
+	activeMu sync.Mutex
 	logger   *common.Logger
 	mu       sync.RWMutex
 	running  bool
 	stopChan chan struct{}
 	wg       sync.WaitGroup
+
+	drainCtx    context.Context
+	cancelDrain context.CancelFunc
 }
 
-// NewServer creates a new TCP server
-func NewServer(network, address string) (pkgtcp.Server, error) {
-	listener, err := NewListener(network, address)
+// NewServer creates a new TCP server with DefaultServerConfig limits
+func NewServer(network, address string, opts ...Option) (pkgtcp.Server, error) {
+	return NewServerWithConfig(network, address, DefaultServerConfig(), opts...)
+}
+
+// NewServerWithConfig creates a new TCP server that handles at most
+// config.MaxConnections connections at once. Options override config.
+func NewServerWithConfig(network, address string, config ServerConfig, opts ...Option) (pkgtcp.Server, error) {
+	o := newOptions(opts...)
+	if o.maxConnections != 0 {
+		config.MaxConnections = o.maxConnections
+	}
+	if o.hasIdleTimeout {
+		config.IdleTimeout = o.idleTimeout
+	}
+	if o.tlsConfig == nil {
+		o.tlsConfig = config.TLSConfig
+	}
+
+	if config.MaxConnections <= 0 {
+		return nil, common.InvalidInputError("max connections must be positive")
+	}
+	if config.QueueSize < 0 {
+		return nil, common.InvalidInputError("queue size must not be negative")
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = serverShutdownTimeout
+	}
+
+	listener, err := newListener(network, address, o)
 	if err != nil {
 		return nil, err
 	}
 
 	return &tcpServer{
 		listener: listener,
-		logger:   common.NewDefaultLogger(),
+		config:   config,
+		handoff:  make(chan pkgtcp.Connection),
+		queue:    make(chan pkgtcp.Connection, config.QueueSize),
+		active:   make(map[pkgtcp.Connection]struct{}),
+		logger:   o.logger,
 		stopChan: make(chan struct{}),
 	}, nil
 }
@@ -261,6 +393,7 @@ func (s *tcpServer) Start() error {
 	}
 
 	s.running = true
+	s.drainCtx, s.cancelDrain = context.WithCancel(context.Background())
 	s.logger.Info("Starting TCP server on %s", s.listener.Addr())
 
 	// Start accepting connections
@@ -270,25 +403,36 @@ func (s *tcpServer) Start() error {
 	return nil
 }
 
-// Stop stops the server
+// Stop stops the server, giving in-flight connections the configured drain
+// timeout to finish before they are closed
 func (s *tcpServer) Stop() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.DrainTimeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
 
+// Shutdown stops accepting connections and cancels the context of accepted
+// ones, then waits for their handlers to return. Connections still open when
+// ctx is done are closed, and Shutdown reports the deadline.
+func (s *tcpServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
 	if !s.running {
+		s.mu.Unlock()
 		return nil
 	}
 
 	s.logger.Info("Stopping TCP server")
 	s.running = false
 
-	// Signal stop
+	// Signal stop and tell handlers to wrap up
 	close(s.stopChan)
+	s.cancelDrain()
 
 	// Close the listener
 	if err := s.listener.Close(); err != nil {
 		s.logger.Warn("Error closing listener: %v", err)
 	}
+	s.mu.Unlock()
 
 	// Wait for all goroutines to finish
 	done := make(chan struct{})
@@ -296,16 +440,26 @@ func (s *tcpServer) Stop() error {
 		s.wg.Wait()
 		close(done)
 	}()
+	defer s.closeQueued()
 
-	// Wait with timeout
 	select {
 	case <-done:
 		s.logger.Info("TCP server stopped successfully")
-	case <-time.After(serverShutdownTimeout):
+		return nil
+	case <-ctx.Done():
+	}
+
+	closed := s.closeActive()
+	s.logger.Warn("TCP server drain deadline exceeded, closed %d connections", closed)
+
+	// Handlers see their connection fail and return promptly
+	select {
+	case <-done:
+	case <-time.After(connectionCloseTimeout):
 		s.logger.Warn("TCP server shutdown timeout")
 	}
 
-	return nil
+	return common.TimeoutErrorWithCause("server shutdown deadline exceeded", ctx.Err())
 }
 
 // IsRunning returns true if the server is running
@@ -349,17 +503,23 @@ func (s *tcpServer) acceptLoop() {
 			}
 		}
 
-		// Handle connection in a separate goroutine
-		s.wg.Add(1)
-		go s.handleConnection(conn)
+		s.dispatch(conn)
 	}
 }
 
 // handleConnection handles a single connection
 func (s *tcpServer) handleConnection(conn pkgtcp.Connection) {
-	defer s.wg.Done()
 	defer conn.Close()
 
+	if c, ok := conn.(interface{ setContext(context.Context) }); ok {
+		c.setContext(s.drainCtx)
+	}
+	if c, ok := conn.(interface{ setIdleTimeout(time.Duration) }); ok && s.config.IdleTimeout > 0 {
+		c.setIdleTimeout(s.config.IdleTimeout)
+	}
+	s.trackActive(conn, true)
+	defer s.trackActive(conn, false)
+
 	remoteAddr := conn.RemoteAddr().String()
 	s.logger.Info("Handling connection from %s", remoteAddr)
 
@@ -368,3 +528,27 @@ func (s *tcpServer) handleConnection(conn pkgtcp.Connection) {
 
 	s.logger.Info("Connection from %s closed", remoteAddr)
 }
+
+// trackActive records whether a handler is running for conn
+func (s *tcpServer) trackActive(conn pkgtcp.Connection, active bool) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+
+	if active {
+		s.active[conn] = struct{}{}
+	} else {
+		delete(s.active, conn)
+	}
+}
+
+// closeActive closes every connection a handler is still running for,
+// returning how many were closed
+func (s *tcpServer) closeActive() int {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+
+	for conn := range s.active {
+		conn.Close()
+	}
+	return len(s.active)
+}
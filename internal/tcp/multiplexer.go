@@ -0,0 +1,254 @@
+package tcp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// connectionMultiplexer implements the tcp.ConnectionMultiplexer interface
+type connectionMultiplexer struct {
+	conns           []pkgtcp.Connection
+	dead            map[pkgtcp.Connection]bool
+	writeTimeout    time.Duration
+	cleanupInterval time.Duration
+	logger          *common.Logger
+	mu              sync.RWMutex
+	broadcastMu     sync.Mutex
+	closed          bool
+	stopChan        chan struct{}
+	wg              sync.WaitGroup
+}
+
+// NewConnectionMultiplexer creates a multiplexer that closes and removes dead
+// connections every multiplexerCleanupInterval
+func NewConnectionMultiplexer() pkgtcp.ConnectionMultiplexer {
+	return newConnectionMultiplexer(multiplexerBroadcastTimeout, multiplexerCleanupInterval)
+}
+
+// newConnectionMultiplexer creates a multiplexer with the given timings
+func newConnectionMultiplexer(writeTimeout, cleanupInterval time.Duration) *connectionMultiplexer {
+	m := &connectionMultiplexer{
+		dead:            make(map[pkgtcp.Connection]bool),
+		writeTimeout:    writeTimeout,
+		cleanupInterval: cleanupInterval,
+		logger:          common.NewDefaultLogger(),
+		stopChan:        make(chan struct{}),
+	}
+
+	m.wg.Add(1)
+	go m.cleanupLoop()
+
+	return m
+}
+
+// AddConnection adds a connection to be multiplexed
+func (m *connectionMultiplexer) AddConnection(conn pkgtcp.Connection) error {
+	if conn == nil {
+		return common.InvalidInputError("connection is nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return common.NetworkError(pkgtcp.ErrMsgMultiplexerClosed)
+	}
+	if m.indexOf(conn) != -1 {
+		return common.InvalidInputError(pkgtcp.ErrMsgDuplicateConnection)
+	}
+
+	m.conns = append(m.conns, conn)
+	return nil
+}
+
+// RemoveConnection removes a connection from multiplexing without closing it
+func (m *connectionMultiplexer) RemoveConnection(conn pkgtcp.Connection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(conn)
+	if i == -1 {
+		return common.InvalidInputError(pkgtcp.ErrMsgConnectionNotFound)
+	}
+
+	m.conns = append(m.conns[:i], m.conns[i+1:]...)
+	delete(m.dead, conn)
+	return nil
+}
+
+// Broadcast sends data to all live connections concurrently. Each write is
+// bounded by the write timeout, so one slow peer cannot stall the others;
+// connections that fail are skipped from then on and closed at the next cleanup.
+func (m *connectionMultiplexer) Broadcast(data []byte) error {
+	// Serialize broadcasts so messages are not interleaved on a connection
+	m.broadcastMu.Lock()
+	defer m.broadcastMu.Unlock()
+
+	m.mu.RLock()
+	closed := m.closed
+	m.mu.RUnlock()
+	if closed {
+		return common.NetworkError(pkgtcp.ErrMsgMultiplexerClosed)
+	}
+
+	conns := m.GetConnections()
+	errs := make([]error, len(conns))
+
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		wg.Add(1)
+		go func(i int, conn pkgtcp.Connection) {
+			defer wg.Done()
+			errs[i] = m.write(conn, data)
+		}(i, conn)
+	}
+	wg.Wait()
+
+	var failed int
+	var firstErr error
+	m.mu.Lock()
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = err
+		}
+		if m.indexOf(conns[i]) != -1 {
+			m.dead[conns[i]] = true
+		}
+	}
+	m.mu.Unlock()
+
+	if failed > 0 {
+		message := fmt.Sprintf("%s for %d of %d connections", pkgtcp.ErrMsgBroadcastFailed, failed, len(conns))
+		return common.NetworkErrorWithCause(message, firstErr)
+	}
+	return nil
+}
+
+// write sends data to one connection within the write timeout
+func (m *connectionMultiplexer) write(conn pkgtcp.Connection, data []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(m.writeTimeout)); err != nil {
+		return err
+	}
+	defer conn.SetWriteDeadline(time.Time{})
+
+	_, err := conn.Write(data)
+	return err
+}
+
+// GetConnections returns a snapshot of the live connections in the order they were added
+func (m *connectionMultiplexer) GetConnections() []pkgtcp.Connection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conns := make([]pkgtcp.Connection, 0, len(m.conns))
+	for _, conn := range m.conns {
+		if !m.isDead(conn) {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// GetConnectionCount returns the number of live connections
+func (m *connectionMultiplexer) GetConnectionCount() int {
+	return len(m.GetConnections())
+}
+
+// Close stops the cleanup loop and closes all connections
+func (m *connectionMultiplexer) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	conns := m.conns
+	m.conns = nil
+	m.dead = make(map[pkgtcp.Connection]bool)
+	m.mu.Unlock()
+
+	close(m.stopChan)
+	m.wg.Wait()
+
+	var firstErr error
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// cleanupLoop periodically drops dead connections until the multiplexer closes
+func (m *connectionMultiplexer) cleanupLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.cleanup()
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// cleanup closes and removes connections that failed a broadcast or were closed elsewhere
+func (m *connectionMultiplexer) cleanup() {
+	m.mu.Lock()
+	var removed []pkgtcp.Connection
+	live := m.conns[:0]
+	for _, conn := range m.conns {
+		if m.isDead(conn) {
+			removed = append(removed, conn)
+			continue
+		}
+		live = append(live, conn)
+	}
+	for i := len(live); i < len(m.conns); i++ {
+		m.conns[i] = nil
+	}
+	m.conns = live
+	m.dead = make(map[pkgtcp.Connection]bool)
+	m.mu.Unlock()
+
+	for _, conn := range removed {
+		conn.Close()
+	}
+	if len(removed) > 0 {
+		m.logger.Debug("Removed %d dead connections", len(removed))
+	}
+}
+
+// isDead reports whether conn failed a broadcast or has been closed.
+// The caller must hold m.mu.
+func (m *connectionMultiplexer) isDead(conn pkgtcp.Connection) bool {
+	if m.dead[conn] {
+		return true
+	}
+	if closer, ok := conn.(interface{ isClosed() bool }); ok {
+		return closer.isClosed()
+	}
+	return false
+}
+
+// indexOf returns the position of conn, or -1. The caller must hold m.mu.
+func (m *connectionMultiplexer) indexOf(conn pkgtcp.Connection) int {
+	for i, c := range m.conns {
+		if c == conn {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,151 @@
+package tcp
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// newPipeConnection returns a wrapped server end and the raw client end of a pipe
+func newPipeConnection(t *testing.T) (pkgtcp.Connection, net.Conn) {
+	t.Helper()
+
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	return NewConnection(server), client
+}
+
+func TestMultiplexerAddRemove(t *testing.T) {
+	mux := NewConnectionMultiplexer()
+	defer mux.Close()
+
+	first, _ := newPipeConnection(t)
+	second, _ := newPipeConnection(t)
+
+	tests := []struct {
+		name      string
+		op        func() error
+		expectErr bool
+		expected  int
+	}{
+		{name: "add first", op: func() error { return mux.AddConnection(first) }, expected: 1},
+		{name: "add second", op: func() error { return mux.AddConnection(second) }, expected: 2},
+		{name: "add duplicate", op: func() error { return mux.AddConnection(first) }, expectErr: true, expected: 2},
+		{name: "add nil", op: func() error { return mux.AddConnection(nil) }, expectErr: true, expected: 2},
+		{name: "remove first", op: func() error { return mux.RemoveConnection(first) }, expected: 1},
+		{name: "remove unknown", op: func() error { return mux.RemoveConnection(first) }, expectErr: true, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op()
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if count := mux.GetConnectionCount(); count != tt.expected {
+				t.Errorf("Expected %d connections, got %d", tt.expected, count)
+			}
+		})
+	}
+
+	if conns := mux.GetConnections(); len(conns) != 1 || conns[0] != second {
+		t.Errorf("Expected only the second connection to remain, got %v", conns)
+	}
+}
+
+func TestMultiplexerBroadcast(t *testing.T) {
+	mux := NewConnectionMultiplexer()
+	defer mux.Close()
+
+	message := []byte("hello everyone\n")
+	received := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		conn, peer := newPipeConnection(t)
+		if err := mux.AddConnection(conn); err != nil {
+			t.Fatalf("AddConnection failed: %v", err)
+		}
+		go func() {
+			buffer := make([]byte, len(message))
+			if _, err := io.ReadFull(peer, buffer); err != nil {
+				received <- err.Error()
+				return
+			}
+			received <- string(buffer)
+		}()
+	}
+
+	if err := mux.Broadcast(message); err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := <-received; got != string(message) {
+			t.Errorf("Expected %q, got %q", message, got)
+		}
+	}
+}
+
+func TestMultiplexerDeadConnectionCleanup(t *testing.T) {
+	mux := newConnectionMultiplexer(20*time.Millisecond, 50*time.Millisecond)
+	defer mux.Close()
+
+	live, livePeer := newPipeConnection(t)
+	stalled, _ := newPipeConnection(t)
+	mux.AddConnection(live)
+	mux.AddConnection(stalled)
+	go io.Copy(io.Discard, livePeer)
+
+	// The stalled peer never reads, so its write times out
+	if err := mux.Broadcast([]byte("ping")); err == nil {
+		t.Fatal("Expected broadcast to report the stalled connection")
+	}
+	if conns := mux.GetConnections(); len(conns) != 1 || conns[0] != live {
+		t.Errorf("Expected only the live connection after a failed write, got %v", conns)
+	}
+	if err := mux.Broadcast([]byte("ping")); err != nil {
+		t.Errorf("Expected broadcast to skip the dead connection, got %v", err)
+	}
+
+	tracked := func() int {
+		mux.mu.RLock()
+		defer mux.mu.RUnlock()
+		return len(mux.conns)
+	}
+	deadline := time.Now().Add(time.Second)
+	for tracked() > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if remaining := tracked(); remaining != 1 {
+		t.Errorf("Expected cleanup to remove the dead connection, %d remain", remaining)
+	}
+	if _, err := stalled.Write([]byte("x")); err == nil {
+		t.Error("Expected cleanup to close the dead connection")
+	}
+}
+
+func TestMultiplexerClose(t *testing.T) {
+	mux := NewConnectionMultiplexer()
+
+	conn, _ := newPipeConnection(t)
+	mux.AddConnection(conn)
+
+	if err := mux.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := conn.Write([]byte("x")); err == nil {
+		t.Error("Expected Close to close multiplexed connections")
+	}
+	if err := mux.AddConnection(conn); err == nil {
+		t.Error("Expected AddConnection to fail after Close")
+	}
+	if err := mux.Broadcast([]byte("x")); err == nil {
+		t.Error("Expected Broadcast to fail after Close")
+	}
+}
@@ -0,0 +1,210 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// Option tunes a server, listener or dialer. Options that do not apply to
+// what is being created are ignored.
+type Option func(*options)
+
+// options collects the settings Option functions change
+type options struct {
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	dialTimeout    time.Duration
+	retries        int
+	retryDelay     time.Duration
+	keepAlive      time.Duration
+	noDelay        bool
+	receiveBuffer  int
+	sendBuffer     int
+	maxConnections int
+	idleTimeout    time.Duration
+	hasIdleTimeout bool
+	acceptRate     float64
+	acceptBurst    int
+	maxAccepted    int
+	tlsConfig      *tls.Config
+	logger         *common.Logger
+}
+
+// newOptions returns the defaults with opts applied
+func newOptions(opts ...Option) *options {
+	o := &options{
+		dialTimeout: pkgtcp.DefaultDialTimeout,
+		retries:     clientConnectRetries,
+		retryDelay:  clientRetryDelay,
+		keepAlive:   tcpKeepAlivePeriod,
+		noDelay:     tcpNoDelay,
+		logger:      common.NewDefaultLogger(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithReadTimeout bounds every Read on the connections created, replacing
+// any read deadline set before it. Zero leaves reads to the caller's deadlines.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout bounds every Write on the connections created, replacing
+// any write deadline set before it. Zero leaves writes to the caller's deadlines.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithDialTimeout sets how long a dialer's Dial may take to connect
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithRetry sets how many times a dialer's DialWithRetry redials after a
+// failure and the delay before the first retry, which doubles on each further
+// one. Zero retries makes a single attempt.
+func WithRetry(retries int, delay time.Duration) Option {
+	return func(o *options) {
+		o.retries = retries
+		o.retryDelay = delay
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive period; zero or less disables keep-alives
+func WithKeepAlive(period time.Duration) Option {
+	return func(o *options) {
+		o.keepAlive = period
+	}
+}
+
+// WithNoDelay sets TCP_NODELAY. It is on by default, sending small writes
+// immediately instead of coalescing them (Nagle's algorithm).
+func WithNoDelay(noDelay bool) Option {
+	return func(o *options) {
+		o.noDelay = noDelay
+	}
+}
+
+// WithBufferSizes sets the kernel receive and send buffer sizes of each
+// socket; zero keeps the system default
+func WithBufferSizes(receive, send int) Option {
+	return func(o *options) {
+		o.receiveBuffer = receive
+		o.sendBuffer = send
+	}
+}
+
+// WithMaxConnections sets how many connections a server handles at once
+func WithMaxConnections(max int) Option {
+	return func(o *options) {
+		o.maxConnections = max
+	}
+}
+
+// WithIdleTimeout sets how long a server keeps a connection on which no data
+// moves; zero disables the idle timeout
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+		o.hasIdleTimeout = true
+	}
+}
+
+// WithAcceptRate caps how fast a listener accepts connections to rate per
+// second, allowing bursts of up to burst connections. Connections beyond the
+// rate wait in the kernel backlog. Zero rate leaves accepting unlimited.
+func WithAcceptRate(rate float64, burst int) Option {
+	return func(o *options) {
+		o.acceptRate = rate
+		o.acceptBurst = burst
+	}
+}
+
+// WithMaxAccepted pauses a listener's accepting while max of its connections
+// are open, resuming as they are closed. Zero leaves accepting unlimited.
+func WithMaxAccepted(max int) Option {
+	return func(o *options) {
+		o.maxAccepted = max
+	}
+}
+
+// WithTLS serves or dials TLS with config
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithLogger sets the logger used for connection events
+func WithLogger(logger *common.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// netDialer returns a dialer that connects within timeout
+func (o *options) netDialer(timeout time.Duration) *net.Dialer {
+	keepAlive := o.keepAlive
+	if keepAlive <= 0 {
+		// net.Dialer treats zero as "use the default", so disabling takes a negative value
+		keepAlive = -1
+	}
+	return &net.Dialer{Timeout: timeout, KeepAlive: keepAlive}
+}
+
+// wrap turns a configured net.Conn into a Connection carrying the options
+func (o *options) wrap(conn net.Conn) *tcpConnection {
+	c := NewConnection(conn).(*tcpConnection)
+	c.readTimeout = o.readTimeout
+	c.writeTimeout = o.writeTimeout
+	c.logger = o.logger
+	return c
+}
+
+// configureConnection applies the socket options to a connection
+func (o *options) configureConnection(conn net.Conn) error {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	// TCP_NODELAY disables Nagle's algorithm
+	if err := tcpConn.SetNoDelay(o.noDelay); err != nil {
+		return common.NetworkErrorWithCause("failed to set TCP_NODELAY", err)
+	}
+
+	if err := tcpConn.SetKeepAlive(o.keepAlive > 0); err != nil {
+		return common.NetworkErrorWithCause("failed to set keep-alive", err)
+	}
+	if o.keepAlive > 0 {
+		if err := tcpConn.SetKeepAlivePeriod(o.keepAlive); err != nil {
+			return common.NetworkErrorWithCause("failed to set keep-alive period", err)
+		}
+	}
+
+	if o.receiveBuffer > 0 {
+		if err := tcpConn.SetReadBuffer(o.receiveBuffer); err != nil {
+			return common.NetworkErrorWithCause("failed to set receive buffer", err)
+		}
+	}
+	if o.sendBuffer > 0 {
+		if err := tcpConn.SetWriteBuffer(o.sendBuffer); err != nil {
+			return common.NetworkErrorWithCause("failed to set send buffer", err)
+		}
+	}
+
+	return nil
+}
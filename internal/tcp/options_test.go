@@ -0,0 +1,193 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+func TestOptionsConfigureConnection(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "defaults"},
+		{name: "keep-alive disabled", opts: []Option{WithKeepAlive(0)}},
+		{name: "custom keep-alive", opts: []Option{WithKeepAlive(time.Minute)}},
+		{name: "nagle enabled", opts: []Option{WithNoDelay(false)}},
+		{name: "buffer sizes", opts: []Option{WithBufferSizes(32*1024, 16*1024)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := NewListener("tcp", "127.0.0.1:0", tt.opts...)
+			if err != nil {
+				t.Fatalf("NewListener failed: %v", err)
+			}
+			defer listener.Close()
+
+			conn, err := NewDialer(tt.opts...).DialTimeout("tcp", listener.Addr().String(), time.Second)
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			accepted, err := listener.Accept()
+			if err != nil {
+				t.Fatalf("Accept failed: %v", err)
+			}
+			accepted.Close()
+		})
+	}
+}
+
+func TestWithReadTimeout(t *testing.T) {
+	listener, err := NewListener("tcp", "127.0.0.1:0", WithReadTimeout(30*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewListener failed: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+
+	// The timeout replaces the far deadline set here
+	conn.SetReadDeadline(time.Now().Add(time.Hour))
+
+	start := time.Now()
+	_, err = conn.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Fatalf("Expected a read timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Read timed out after %v", elapsed)
+	}
+}
+
+func TestWithMaxConnections(t *testing.T) {
+	config := ServerConfig{MaxConnections: 5, Overflow: OverflowClose}
+	release := make(chan struct{})
+
+	server, err := NewServerWithConfig("tcp", "127.0.0.1:0", config, WithMaxConnections(1))
+	if err != nil {
+		t.Fatalf("NewServerWithConfig failed: %v", err)
+	}
+	server.SetHandler(func(conn pkgtcp.Connection) {
+		conn.Write([]byte("ok"))
+		<-release
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+	defer close(release)
+
+	first, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer first.Close()
+	if _, err := io.ReadFull(first, make([]byte, 2)); err != nil {
+		t.Fatalf("First connection was not handled: %v", err)
+	}
+
+	second, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer second.Close()
+	if got := readReply(t, second, time.Second); got != "" {
+		t.Errorf("Expected the option to limit the server to one connection, got %q", got)
+	}
+}
+
+func TestWithLoggerAndTLS(t *testing.T) {
+	logger := common.NewLogger(common.LogLevelError, os.Stderr)
+
+	listener, err := NewListener("tcp", "127.0.0.1:0", WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewListener failed: %v", err)
+	}
+	defer listener.Close()
+	if listener.(*tcpListener).logger != logger {
+		t.Error("Expected the listener to use the given logger")
+	}
+
+	if _, ok := NewDialer(WithTLS(&tls.Config{})).(*tlsDialer); !ok {
+		t.Error("Expected WithTLS to create a TLS dialer")
+	}
+	if _, err := NewListener("tcp", "127.0.0.1:0", WithTLS(&tls.Config{})); err == nil {
+		t.Error("Expected WithTLS without a certificate to be rejected")
+	}
+}
+
+func TestWithIdleTimeout(t *testing.T) {
+	const idleTimeout = 80 * time.Millisecond
+
+	server, err := NewServer("tcp", "127.0.0.1:0", WithIdleTimeout(idleTimeout))
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server.SetHandler(func(conn pkgtcp.Connection) {
+		io.Copy(conn, conn)
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+
+	t.Run("silent connection is closed", func(t *testing.T) {
+		conn, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+
+		start := time.Now()
+		if got := readReply(t, conn, 2*time.Second); got != "" {
+			t.Errorf("Expected no data, got %q", got)
+		}
+		if elapsed := time.Since(start); elapsed < idleTimeout || elapsed > time.Second {
+			t.Errorf("Connection closed after %v, want about %v", elapsed, idleTimeout)
+		}
+	})
+
+	t.Run("activity resets the timeout", func(t *testing.T) {
+		conn, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+
+		// Stay active for several idle periods
+		for i := 0; i < 8; i++ {
+			if _, err := conn.Write([]byte("x")); err != nil {
+				t.Fatalf("Write %d failed: %v", i, err)
+			}
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			if _, err := io.ReadFull(conn, make([]byte, 1)); err != nil {
+				t.Fatalf("Echo %d failed: %v", i, err)
+			}
+			time.Sleep(idleTimeout / 4)
+		}
+
+		if got := readReply(t, conn, 2*time.Second); got != "" {
+			t.Errorf("Expected no data after going silent, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// OverflowPolicy decides what happens to a connection accepted while every
+// worker is busy and the queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowQueue stops accepting until a worker frees up, leaving new
+	// connections in the kernel backlog
+	OverflowQueue OverflowPolicy = iota
+
+	// OverflowReject passes the connection to the reject handler, which can
+	// tell the client the server is busy, then closes it
+	OverflowReject
+
+	// OverflowClose closes the connection immediately
+	OverflowClose
+)
+
+// ServerConfig bounds how many connections a server handles at once
+type ServerConfig struct {
+	// MaxConnections is the number of workers, and so of connections
+	// handled concurrently
+	MaxConnections int
+
+	// QueueSize is how many accepted connections may wait for a worker
+	QueueSize int
+
+	// Overflow applies once the workers are busy and the queue is full
+	Overflow OverflowPolicy
+
+	// RejectHandler writes a busy notice for OverflowReject. It runs under
+	// a write deadline and the connection is closed afterwards.
+	RejectHandler pkgtcp.ConnectionHandler
+
+	// TLSConfig serves TLS on accepted connections when set
+	TLSConfig *tls.Config
+
+	// IdleTimeout closes connections that send and receive nothing for
+	// that long; zero disables it
+	IdleTimeout time.Duration
+
+	// DrainTimeout is how long Stop waits for in-flight connections before
+	// closing them; zero means serverShutdownTimeout
+	DrainTimeout time.Duration
+}
+
+// DefaultServerConfig returns the limits NewServer uses
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		MaxConnections: pkgtcp.DefaultMaxConnections,
+		QueueSize:      serverConnectionQueueSize,
+		Overflow:       OverflowQueue,
+		IdleTimeout:    pkgtcp.DefaultServerIdleTimeout,
+		DrainTimeout:   serverShutdownTimeout,
+	}
+}
+
+// dispatch hands an accepted connection to an idle worker, starts a new
+// worker while under the limit, or queues the connection. Workers are started
+// lazily and live until the server stops, so a full pool always drains the queue.
+func (s *tcpServer) dispatch(conn pkgtcp.Connection) {
+	select {
+	case s.handoff <- conn:
+		return
+	default:
+	}
+
+	if s.workers < s.config.MaxConnections {
+		s.workers++
+		s.wg.Add(1)
+		go s.worker(conn)
+		return
+	}
+
+	if s.config.Overflow == OverflowQueue {
+		select {
+		case s.queue <- conn:
+		case <-s.stopChan:
+			conn.Close()
+		}
+		return
+	}
+
+	select {
+	case s.queue <- conn:
+		return
+	default:
+	}
+
+	s.logger.Warn("Connection from %s dropped: %s", conn.RemoteAddr(), pkgtcp.ErrMsgMaxConnectionsReached)
+	if s.config.Overflow == OverflowReject && s.config.RejectHandler != nil {
+		s.wg.Add(1)
+		go s.reject(conn)
+		return
+	}
+	conn.Close()
+}
+
+// worker handles connections until the server stops. Connections still queued
+// at that point are closed unserved.
+func (s *tcpServer) worker(conn pkgtcp.Connection) {
+	defer s.wg.Done()
+
+	for {
+		s.handleConnection(conn)
+
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+
+		select {
+		case conn = <-s.queue:
+		default:
+			select {
+			case conn = <-s.queue:
+			case conn = <-s.handoff:
+			case <-s.stopChan:
+				return
+			}
+		}
+	}
+}
+
+// reject runs the reject handler on a connection the server has no room for
+func (s *tcpServer) reject(conn pkgtcp.Connection) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(connectionCloseTimeout))
+	s.config.RejectHandler(conn)
+}
+
+// closeQueued closes connections still waiting for a worker after stop
+func (s *tcpServer) closeQueued() {
+	for {
+		select {
+		case conn := <-s.queue:
+			conn.Close()
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package tcp
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// startLimitedServer starts a server with one worker whose handler writes
+// "ok" and then blocks until release is closed
+func startLimitedServer(t *testing.T, config ServerConfig, release chan struct{}) pkgtcp.Server {
+	t.Helper()
+
+	server, err := NewServerWithConfig("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("NewServerWithConfig failed: %v", err)
+	}
+	server.SetHandler(func(conn pkgtcp.Connection) {
+		conn.Write([]byte("ok"))
+		<-release
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	return server
+}
+
+// readReply returns what the server sends on conn before closing it or the timeout
+func readReply(t *testing.T, conn net.Conn, timeout time.Duration) string {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	data, _ := io.ReadAll(conn)
+	return string(data)
+}
+
+func TestServerOverflowPolicies(t *testing.T) {
+	busy := func(conn pkgtcp.Connection) { conn.Write([]byte("busy")) }
+
+	tests := []struct {
+		name     string
+		config   ServerConfig
+		expected string
+	}{
+		{name: "reject", config: ServerConfig{MaxConnections: 1, Overflow: OverflowReject, RejectHandler: busy}, expected: "busy"},
+		{name: "close", config: ServerConfig{MaxConnections: 1, Overflow: OverflowClose}, expected: ""},
+		{name: "reject without handler closes", config: ServerConfig{MaxConnections: 1, Overflow: OverflowReject}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+			server := startLimitedServer(t, tt.config, release)
+
+			first, err := net.Dial("tcp", server.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer first.Close()
+			buffer := make([]byte, 2)
+			if _, err := io.ReadFull(first, buffer); err != nil {
+				t.Fatalf("First connection was not handled: %v", err)
+			}
+
+			second, err := net.Dial("tcp", server.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer second.Close()
+
+			if got := readReply(t, second, time.Second); got != tt.expected {
+				t.Errorf("Expected %q for the overflowing connection, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServerOverflowQueue(t *testing.T) {
+	release := make(chan struct{})
+	server := startLimitedServer(t, ServerConfig{MaxConnections: 1, QueueSize: 1}, release)
+
+	first, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer first.Close()
+	buffer := make([]byte, 2)
+	if _, err := io.ReadFull(first, buffer); err != nil {
+		t.Fatalf("First connection was not handled: %v", err)
+	}
+
+	second, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer second.Close()
+
+	// The queued connection waits while the only worker is busy
+	second.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _ := second.Read(buffer); n != 0 {
+		t.Fatal("Queued connection was handled before a worker was free")
+	}
+
+	close(release)
+	second.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(second, buffer); err != nil || string(buffer) != "ok" {
+		t.Errorf("Expected queued connection to be handled, got %q (%v)", buffer, err)
+	}
+}
+
+func TestNewServerWithConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ServerConfig
+	}{
+		{name: "no workers", config: ServerConfig{}},
+		{name: "negative queue", config: ServerConfig{MaxConnections: 1, QueueSize: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewServerWithConfig("tcp", "127.0.0.1:0", tt.config); err == nil {
+				t.Error("Expected invalid config to be rejected")
+			}
+		})
+	}
+}
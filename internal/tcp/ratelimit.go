@@ -0,0 +1,34 @@
+package tcp
+
+import "time"
+
+// tokenBucket limits how often an event happens. It holds up to burst tokens,
+// refilled at rate tokens per second, and each event takes one. It is not
+// safe for concurrent use; the accept loop is its only user.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket. A burst below one is treated as one.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := &tokenBucket{rate: rate, burst: float64(max(burst, 1))}
+	b.tokens = b.burst
+	return b
+}
+
+// reserve takes a token at now and returns how long to wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,158 @@
+package tcp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		at    []time.Duration
+		want  []time.Duration
+	}{
+		{
+			name:  "burst is free",
+			rate:  10,
+			burst: 3,
+			at:    []time.Duration{0, 0, 0},
+			want:  []time.Duration{0, 0, 0},
+		},
+		{
+			name:  "beyond the burst waits",
+			rate:  10,
+			burst: 1,
+			at:    []time.Duration{0, 0, 0},
+			want:  []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:  "tokens refill over time",
+			rate:  10,
+			burst: 1,
+			at:    []time.Duration{0, 100 * time.Millisecond, 150 * time.Millisecond},
+			want:  []time.Duration{0, 0, 50 * time.Millisecond},
+		},
+		{
+			name:  "refill is capped at the burst",
+			rate:  10,
+			burst: 2,
+			at:    []time.Duration{0, time.Minute, time.Minute, time.Minute},
+			want:  []time.Duration{0, 0, 0, 100 * time.Millisecond},
+		},
+		{
+			name:  "zero burst allows one",
+			rate:  10,
+			burst: 0,
+			at:    []time.Duration{0, 0},
+			want:  []time.Duration{0, 100 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := newTokenBucket(tt.rate, tt.burst)
+			for i, offset := range tt.at {
+				got := bucket.reserve(start.Add(offset))
+				if diff := got - tt.want[i]; diff < -time.Millisecond || diff > time.Millisecond {
+					t.Errorf("reserve %d: expected wait %v, got %v", i, tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithAcceptRate(t *testing.T) {
+	listener, err := NewListener("tcp", "127.0.0.1:0", WithAcceptRate(20, 1))
+	if err != nil {
+		t.Fatalf("NewListener failed: %v", err)
+	}
+	defer listener.Close()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		client, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial %d failed: %v", i, err)
+		}
+		defer client.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("Accept %d failed: %v", i, err)
+		}
+		defer conn.Close()
+	}
+
+	// The first connection uses the burst, the next two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected accepting to be rate limited, took %v", elapsed)
+	}
+}
+
+func TestWithMaxAccepted(t *testing.T) {
+	listener, err := NewListener("tcp", "127.0.0.1:0", WithMaxAccepted(1))
+	if err != nil {
+		t.Fatalf("NewListener failed: %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		client, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial %d failed: %v", i, err)
+		}
+		defer client.Close()
+	}
+
+	first := <-accepted
+	select {
+	case conn := <-accepted:
+		conn.Close()
+		t.Fatal("Expected accepting to pause while the first connection is open")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("Expected accepting to resume after the first connection closed")
+	}
+}
+
+func TestAcceptLimitValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "negative rate", opt: WithAcceptRate(-1, 1)},
+		{name: "negative max accepted", opt: WithMaxAccepted(-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := NewListener("tcp", "127.0.0.1:0", tt.opt)
+			if err == nil {
+				listener.Close()
+				t.Fatal("Expected an error")
+			}
+		})
+	}
+}
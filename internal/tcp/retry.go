@@ -0,0 +1,111 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// DialWithRetry connects like Dial, redialing after failures with exponential
+// backoff until the retries set by WithRetry run out or ctx is done
+func (d *tcpDialer) DialWithRetry(ctx context.Context, network, address string) (pkgtcp.Connection, error) {
+	return dialWithRetry(ctx, d.options, address, func(timeout time.Duration) (pkgtcp.Connection, error) {
+		return d.DialTimeout(network, address, timeout)
+	})
+}
+
+// DialWithRetry connects and handshakes like Dial, redialing after failures
+// with exponential backoff until the retries set by WithRetry run out or ctx is done
+func (d *tlsDialer) DialWithRetry(ctx context.Context, network, address string) (pkgtcp.Connection, error) {
+	return dialWithRetry(ctx, d.options, address, func(timeout time.Duration) (pkgtcp.Connection, error) {
+		return d.DialTimeout(network, address, timeout)
+	})
+}
+
+// dialWithRetry calls dial until it succeeds, fails in a way retrying cannot
+// fix, runs out of retries or ctx is done. Each attempt gets the dial timeout,
+// cut short by the ctx deadline.
+func dialWithRetry(ctx context.Context, o *options, address string, dial func(time.Duration) (pkgtcp.Connection, error)) (pkgtcp.Connection, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		timeout := o.dialTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = min(timeout, time.Until(deadline))
+		}
+		if err := ctx.Err(); err != nil || timeout <= 0 {
+			return nil, dialCanceled(ctx, address, attempt, lastErr)
+		}
+
+		conn, err := dial(timeout)
+		if err == nil {
+			return conn, nil
+		}
+		if !retryable(err) {
+			return nil, err
+		}
+		lastErr = err
+
+		if attempt >= o.retries {
+			return nil, common.NetworkErrorWithCause(fmt.Sprintf("dial to %s failed after %d attempts", address, attempt+1), err)
+		}
+
+		delay := backoffDelay(o.retryDelay, attempt)
+		o.logger.Debug("Dial to %s failed, retrying in %v: %v", address, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, dialCanceled(ctx, address, attempt+1, lastErr)
+		}
+	}
+}
+
+// dialCanceled reports a retried dial stopped by its context
+func dialCanceled(ctx context.Context, address string, attempts int, lastErr error) error {
+	cause := ctx.Err()
+	if cause == nil {
+		cause = context.DeadlineExceeded
+	}
+	message := fmt.Sprintf("dial to %s stopped after %d attempts", address, attempts)
+	if lastErr != nil {
+		message += fmt.Sprintf(" (last error: %v)", lastErr)
+	}
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return common.TimeoutErrorWithCause(message, cause)
+	}
+	return common.NetworkErrorWithCause(message, cause)
+}
+
+// retryable reports whether dialing again could succeed after err. Invalid
+// addresses and certificates the server presents are not retried.
+func retryable(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if tsErr, ok := e.(*common.TinyServerError); ok && tsErr.Type == common.ErrorTypeInvalidInput {
+			return false
+		}
+	}
+
+	var certErr *tls.CertificateVerificationError
+	return !errors.As(err, &certErr)
+}
+
+// backoffDelay returns the wait before retry number attempt, counted from
+// zero: initial multiplied by retryBackoffMultiplier per attempt and capped at
+// maxRetryDelay, with jitter spreading it over its upper half so clients that
+// failed together do not redial together
+func backoffDelay(initial time.Duration, attempt int) time.Duration {
+	delay := float64(initial) * math.Pow(retryBackoffMultiplier, float64(attempt))
+	delay = min(delay, float64(maxRetryDelay))
+
+	half := delay / 2
+	return time.Duration(half + rand.Float64()*half)
+}
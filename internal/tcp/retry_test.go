@@ -0,0 +1,162 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		maximum time.Duration
+	}{
+		{attempt: 0, maximum: time.Second},
+		{attempt: 1, maximum: 2 * time.Second},
+		{attempt: 3, maximum: 8 * time.Second},
+		{attempt: 10, maximum: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := backoffDelay(time.Second, tt.attempt)
+			if delay < tt.maximum/2 || delay > tt.maximum {
+				t.Fatalf("Attempt %d: expected a delay in [%v, %v], got %v", tt.attempt, tt.maximum/2, tt.maximum, delay)
+			}
+		}
+	}
+}
+
+func TestDialWithRetryAttempts(t *testing.T) {
+	refused := common.NetworkErrorWithCause("dial failed", errors.New("connection refused"))
+
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "first attempt succeeds", retries: 3, errs: nil, wantCalls: 1},
+		{name: "succeeds after failures", retries: 3, errs: []error{refused, refused}, wantCalls: 3},
+		{name: "retries run out", retries: 2, errs: []error{refused, refused, refused, refused}, wantCalls: 3, wantErr: true},
+		{name: "no retries", retries: 0, errs: []error{refused}, wantCalls: 1, wantErr: true},
+		{
+			name:      "invalid address is not retried",
+			retries:   3,
+			errs:      []error{common.NetworkErrorWithCause("dial failed", common.InvalidInputError("bad address"))},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "certificate error is not retried",
+			retries:   3,
+			errs:      []error{common.NetworkErrorWithCause("TLS handshake failed", &tls.CertificateVerificationError{Err: errors.New("unknown authority")})},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(WithRetry(tt.retries, time.Millisecond))
+			calls := 0
+			dial := func(time.Duration) (pkgtcp.Connection, error) {
+				calls++
+				if calls <= len(tt.errs) {
+					return nil, tt.errs[calls-1]
+				}
+				conn, peer := net.Pipe()
+				t.Cleanup(func() { peer.Close() })
+				return NewConnection(conn), nil
+			}
+
+			conn, err := dialWithRetry(context.Background(), o, "127.0.0.1:1", dial)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if conn != nil {
+				conn.Close()
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d dial attempts, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestDialWithRetryContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	dialer := NewDialer(WithRetry(100, 20*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = dialer.DialWithRetry(ctx, "tcp", address)
+	if err == nil {
+		t.Fatal("Expected DialWithRetry to fail when nothing is listening")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the context to stop retrying, took %v", elapsed)
+	}
+
+	var tsErr *common.TinyServerError
+	if !errors.As(err, &tsErr) || tsErr.Type != common.ErrorTypeTimeout {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the error to wrap the context error, got %v", err)
+	}
+}
+
+func TestClientConnectRetries(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	// Start listening only after the first attempts have failed
+	started := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			started <- nil
+			return
+		}
+		started <- listener
+	}()
+
+	client := newClient(NewDialer(WithRetry(10, 20*time.Millisecond)), 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = client.ConnectContext(ctx, address)
+	if listener := <-started; listener != nil {
+		defer listener.Close()
+	} else {
+		t.Skip("Could not listen on the released address again")
+	}
+	if err != nil {
+		t.Fatalf("ConnectContext failed: %v", err)
+	}
+	defer client.Disconnect()
+
+	if !client.IsConnected() {
+		t.Error("Client should be connected once the server is up")
+	}
+}
@@ -0,0 +1,93 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// LoadTLSConfig builds a server TLS configuration from a PEM certificate
+// chain and private key
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, common.InvalidInputErrorWithCause("failed to load TLS certificate", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tlsMinVersion,
+	}, nil
+}
+
+// NewTLSListener creates a listener whose connections speak TLS. Accepted
+// connections are the same pkgtcp.Connection as plain ones and also implement
+// pkgtcp.TLSConnection; the handshake runs on their first read or write.
+func NewTLSListener(network, address string, config *tls.Config, opts ...Option) (pkgtcp.Listener, error) {
+	if err := validateServerTLSConfig(config); err != nil {
+		return nil, err
+	}
+	return NewListener(network, address, append(opts, WithTLS(config))...)
+}
+
+// validateServerTLSConfig checks that config can present a certificate
+func validateServerTLSConfig(config *tls.Config) error {
+	if config == nil {
+		return common.InvalidInputError("TLS config is nil")
+	}
+	if len(config.Certificates) == 0 && config.GetCertificate == nil && config.GetConfigForClient == nil {
+		return common.InvalidInputError("TLS config has no certificate")
+	}
+	return nil
+}
+
+// tlsDialer implements the tcp.Dialer interface over TLS
+type tlsDialer struct {
+	*tcpDialer
+	config *tls.Config
+}
+
+// NewTLSDialer creates a dialer that completes a TLS handshake before
+// returning connections. The server name defaults to the dialed host when
+// config leaves it empty.
+func NewTLSDialer(config *tls.Config, opts ...Option) pkgtcp.Dialer {
+	if config == nil {
+		config = &tls.Config{MinVersion: tlsMinVersion}
+	}
+	return NewDialer(append(opts, WithTLS(config))...)
+}
+
+// Dial connects to the address on the named network and performs the handshake
+func (d *tlsDialer) Dial(network, address string) (pkgtcp.Connection, error) {
+	return d.DialTimeout(network, address, d.options.dialTimeout)
+}
+
+// DialTimeout acts like Dial but bounds both the connect and the handshake by timeout
+func (d *tlsDialer) DialTimeout(network, address string, timeout time.Duration) (pkgtcp.Connection, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := d.dialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, common.NetworkErrorWithCause("dial with timeout failed", err)
+	}
+
+	config := d.config.Clone()
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, common.NetworkErrorWithCause("TLS handshake failed", err)
+	}
+
+	d.logger.Debug("Connected to %s over TLS", address)
+
+	return d.options.wrap(tlsConn), nil
+}
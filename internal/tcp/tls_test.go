@@ -0,0 +1,173 @@
+package tcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// newTestCertificate creates a self-signed certificate for localhost, returning
+// it with its DER encoding and key
+func newTestCertificate(t *testing.T) (tls.Certificate, []byte, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, der, key
+}
+
+func TestTLSServerAndDialer(t *testing.T) {
+	cert, der, _ := newTestCertificate(t)
+	roots := x509.NewCertPool()
+	parsed, _ := x509.ParseCertificate(der)
+	roots.AddCert(parsed)
+
+	config := DefaultServerConfig()
+	config.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server, err := NewServerWithConfig("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("NewServerWithConfig failed: %v", err)
+	}
+
+	serverTLS := make(chan bool, 1)
+	server.SetHandler(func(conn pkgtcp.Connection) {
+		buffer := make([]byte, 5)
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			return
+		}
+		_, ok := conn.(pkgtcp.TLSConnection).TLSState()
+		serverTLS <- ok
+		conn.Write(buffer)
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+
+	_, port, _ := net.SplitHostPort(server.Addr().String())
+
+	tests := []struct {
+		name      string
+		address   string
+		expectErr bool
+	}{
+		{name: "trusted certificate", address: net.JoinHostPort("localhost", port)},
+		{name: "name mismatch", address: net.JoinHostPort("127.0.0.2", port), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := NewTLSDialer(&tls.Config{RootCAs: roots})
+			conn, err := dialer.DialTimeout("tcp", tt.address, time.Second)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			state, ok := conn.(pkgtcp.TLSConnection).TLSState()
+			if !ok || !state.HandshakeComplete {
+				t.Error("Expected a completed TLS handshake on the client")
+			}
+
+			if _, err := conn.Write([]byte("hello")); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			buffer := make([]byte, 5)
+			if _, err := io.ReadFull(conn, buffer); err != nil || string(buffer) != "hello" {
+				t.Errorf("Expected echo over TLS, got %q (%v)", buffer, err)
+			}
+			if !<-serverTLS {
+				t.Error("Expected the server connection to report TLS state")
+			}
+		})
+	}
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	_, der, key := newTestCertificate(t)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	tests := []struct {
+		name      string
+		certFile  string
+		keyFile   string
+		expectErr bool
+	}{
+		{name: "valid pair", certFile: certFile, keyFile: keyFile},
+		{name: "missing key", certFile: certFile, keyFile: filepath.Join(dir, "missing.pem"), expectErr: true},
+		{name: "swapped files", certFile: keyFile, keyFile: certFile, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadTLSConfig(tt.certFile, tt.keyFile)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if err == nil && len(config.Certificates) != 1 {
+				t.Errorf("Expected one certificate, got %d", len(config.Certificates))
+			}
+		})
+	}
+}
+
+func TestNewTLSListenerValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *tls.Config
+	}{
+		{name: "nil config", config: nil},
+		{name: "no certificate", config: &tls.Config{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTLSListener("tcp", "127.0.0.1:0", tt.config); err == nil {
+				t.Error("Expected invalid TLS config to be rejected")
+			}
+		})
+	}
+}
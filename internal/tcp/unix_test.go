@@ -0,0 +1,133 @@
+package tcp
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pkgtcp "github.com/ganyariya/tinyserver/pkg/tcp"
+)
+
+// newSocketPath returns a socket path in a short temporary directory, since
+// socket paths are limited to about a hundred bytes
+func newSocketPath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "tcp")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "test.sock")
+}
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		network   string
+		address   string
+		expectErr bool
+	}{
+		{name: "tcp host port", network: "tcp", address: "localhost:8080"},
+		{name: "tcp any port", network: "tcp", address: ":0"},
+		{name: "tcp6 literal", network: "tcp6", address: "[::1]:443"},
+		{name: "tcp missing port", network: "tcp", address: "localhost", expectErr: true},
+		{name: "tcp named port", network: "tcp", address: "localhost:http", expectErr: true},
+		{name: "tcp port out of range", network: "tcp4", address: "127.0.0.1:70000", expectErr: true},
+		{name: "unix path", network: "unix", address: "/tmp/app.sock"},
+		{name: "unix empty path", network: "unix", address: "", expectErr: true},
+		{name: "unix path too long", network: "unix", address: "/" + strings.Repeat("a", 200), expectErr: true},
+		{name: "unsupported network", network: "udp", address: "localhost:53", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddress(tt.network, tt.address)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address         string
+		expectedNetwork string
+		expectedAddress string
+	}{
+		{address: "localhost:8080", expectedNetwork: "tcp", expectedAddress: "localhost:8080"},
+		{address: "unix:///tmp/app.sock", expectedNetwork: "unix", expectedAddress: "/tmp/app.sock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			network, address := ParseAddress(tt.address)
+			if network != tt.expectedNetwork || address != tt.expectedAddress {
+				t.Errorf("Expected %s %s, got %s %s", tt.expectedNetwork, tt.expectedAddress, network, address)
+			}
+		})
+	}
+}
+
+func TestUnixServerAndClient(t *testing.T) {
+	path := newSocketPath(t)
+
+	server, err := NewServer(pkgtcp.NetworkUnix, path)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server.SetHandler(func(conn pkgtcp.Connection) {
+		io.Copy(conn, conn)
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+
+	if network := server.Addr().Network(); network != pkgtcp.NetworkUnix {
+		t.Errorf("Expected network %q, got %q", pkgtcp.NetworkUnix, network)
+	}
+
+	client := NewClient()
+	if err := client.ConnectWithTimeout("unix://"+path, time.Second); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Disconnect()
+
+	if err := client.Send([]byte("local")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	buffer := make([]byte, 5)
+	if _, err := io.ReadFull(client.GetConnection(), buffer); err != nil || string(buffer) != "local" {
+		t.Errorf("Expected echo over the socket, got %q (%v)", buffer, err)
+	}
+}
+
+func TestUnixListenerStaleSocket(t *testing.T) {
+	path := newSocketPath(t)
+
+	// A listener that is not unlinked leaves its socket file behind
+	stale, err := net.Listen(pkgtcp.NetworkUnix, path)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := NewListener(pkgtcp.NetworkUnix, path)
+	if err != nil {
+		t.Fatalf("Expected the stale socket to be replaced, got %v", err)
+	}
+	defer listener.Close()
+
+	// A socket that is still served must not be taken over
+	if second, err := NewListener(pkgtcp.NetworkUnix, path); err == nil {
+		second.Close()
+		t.Error("Expected listening on a served socket to fail")
+	}
+}
@@ -0,0 +1,123 @@
+package udp
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+// udpClient implements the udp.Client interface
+type udpClient struct {
+	network   string
+	conn      *net.UDPConn
+	mu        sync.RWMutex
+	receiveMu sync.Mutex
+}
+
+// NewClient creates a UDP client on the given network
+func NewClient(network string) (pkgudp.Client, error) {
+	if err := validateNetwork(network); err != nil {
+		return nil, err
+	}
+	return &udpClient{network: network}, nil
+}
+
+// Connect sets the peer. The socket only accepts datagrams from it, so
+// replies from anyone else are dropped by the kernel.
+func (c *udpClient) Connect(address string) error {
+	remote, err := net.ResolveUDPAddr(c.network, address)
+	if err != nil {
+		return common.InvalidInputErrorWithCause(pkgudp.ErrMsgInvalidAddress+": "+address, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		return common.ClientError("client is already connected")
+	}
+
+	conn, err := net.DialUDP(c.network, nil, remote)
+	if err != nil {
+		return common.NetworkErrorWithCause("dial failed", err)
+	}
+	c.conn = conn
+	return nil
+}
+
+// Disconnect closes the socket
+func (c *udpClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// IsConnected returns true if the client has a peer
+func (c *udpClient) IsConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn != nil
+}
+
+// Send sends data as one datagram
+func (c *udpClient) Send(data []byte) error {
+	if len(data) > pkgudp.MaxDatagramSize {
+		return common.InvalidInputError(pkgudp.ErrMsgDatagramTooLarge)
+	}
+
+	conn := c.connection()
+	if conn == nil {
+		return common.NetworkError(pkgudp.ErrMsgNotConnected)
+	}
+
+	if _, err := conn.Write(data); err != nil {
+		return common.NetworkErrorWithCause("send failed", err)
+	}
+	return nil
+}
+
+// Receive reads one datagram, waiting at most DefaultReceiveTimeout
+func (c *udpClient) Receive(p []byte) (int, error) {
+	return c.ReceiveWithTimeout(p, pkgudp.DefaultReceiveTimeout)
+}
+
+// ReceiveWithTimeout reads one datagram, waiting at most timeout. A datagram
+// larger than p is truncated.
+func (c *udpClient) ReceiveWithTimeout(p []byte, timeout time.Duration) (int, error) {
+	c.receiveMu.Lock()
+	defer c.receiveMu.Unlock()
+
+	conn := c.connection()
+	if conn == nil {
+		return 0, common.NetworkError(pkgudp.ErrMsgNotConnected)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, common.NetworkErrorWithCause("failed to set read deadline", err)
+	}
+
+	n, err := conn.Read(p)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return 0, common.TimeoutErrorWithCause("receive timed out", err)
+		}
+		return 0, common.NetworkErrorWithCause("receive failed", err)
+	}
+	return n, nil
+}
+
+// connection returns the socket, or nil while disconnected
+func (c *udpClient) connection() *net.UDPConn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
+}
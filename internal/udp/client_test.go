@@ -0,0 +1,72 @@
+package udp
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+func TestNewClientNetwork(t *testing.T) {
+	tests := []struct {
+		network   string
+		expectErr bool
+	}{
+		{network: "udp"},
+		{network: "udp4"},
+		{network: "udp6"},
+		{network: "tcp", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			_, err := NewClient(tt.network)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	client, err := NewClient(pkgudp.NetworkUDP)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if err := client.Send([]byte("x")); err == nil {
+		t.Error("Send should fail before Connect")
+	}
+	if err := client.Connect("not an address"); err == nil {
+		t.Error("Connect should reject an invalid address")
+	}
+
+	// A socket that never replies
+	silent, err := net.ListenPacket(pkgudp.NetworkUDP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	defer silent.Close()
+
+	if err := client.Connect(silent.LocalAddr().String()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Disconnect()
+
+	if err := client.Connect(silent.LocalAddr().String()); err == nil {
+		t.Error("Connect should fail while already connected")
+	}
+	if err := client.Send([]byte(strings.Repeat("x", pkgudp.MaxDatagramSize+1))); err == nil {
+		t.Error("Send should reject a payload larger than a datagram")
+	}
+
+	_, err = client.ReceiveWithTimeout(make([]byte, 16), 20*time.Millisecond)
+	var tsErr *common.TinyServerError
+	if !errors.As(err, &tsErr) || tsErr.Type != common.ErrorTypeTimeout {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package udp
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+// udpConn implements the udp.PacketConn interface
+type udpConn struct {
+	conn   net.PacketConn
+	mu     sync.RWMutex
+	closed bool
+}
+
+// ListenPacket opens a UDP socket bound to address
+func ListenPacket(network, address string) (pkgudp.PacketConn, error) {
+	if err := validateNetwork(network); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, common.NetworkErrorWithCause("failed to listen", err)
+	}
+
+	return NewPacketConn(conn), nil
+}
+
+// NewPacketConn wraps a net.PacketConn
+func NewPacketConn(conn net.PacketConn) pkgudp.PacketConn {
+	return &udpConn{conn: conn}
+}
+
+// ReadFrom reads one datagram, returning its size and sender. A datagram
+// larger than p is truncated.
+func (c *udpConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if c.isClosed() {
+		return 0, nil, common.NetworkError(pkgudp.ErrMsgConnectionClosed)
+	}
+
+	// The lock is not held during I/O so Close can interrupt a blocked read
+	return c.conn.ReadFrom(p)
+}
+
+// WriteTo sends one datagram to addr
+func (c *udpConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.isClosed() {
+		return 0, common.NetworkError(pkgudp.ErrMsgConnectionClosed)
+	}
+	if len(p) > pkgudp.MaxDatagramSize {
+		return 0, common.InvalidInputError(pkgudp.ErrMsgDatagramTooLarge)
+	}
+
+	return c.conn.WriteTo(p, addr)
+}
+
+// Close closes the socket
+func (c *udpConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	return c.conn.Close()
+}
+
+// isClosed reports whether Close has been called
+func (c *udpConn) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// LocalAddr returns the local network address
+func (c *udpConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetDeadline sets the read and write deadlines
+func (c *udpConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future ReadFrom calls
+func (c *udpConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future WriteTo calls
+func (c *udpConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// validateNetwork checks that network is a UDP network
+func validateNetwork(network string) error {
+	switch network {
+	case pkgudp.NetworkUDP, pkgudp.NetworkUDP4, pkgudp.NetworkUDP6:
+		return nil
+	default:
+		return common.InvalidInputError("unsupported network: " + network)
+	}
+}
@@ -0,0 +1,14 @@
+package udp
+
+import "time"
+
+// Internal UDP implementation constants
+
+// Server implementation settings
+const (
+	// serverReadBufferSize fits the largest datagram
+	serverReadBufferSize = 65536
+
+	// serverShutdownTimeout is the timeout for handlers to finish on shutdown
+	serverShutdownTimeout = 30 * time.Second
+)
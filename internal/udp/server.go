@@ -0,0 +1,153 @@
+package udp
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ganyariya/tinyserver/internal/common"
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+// udpServer implements the udp.Server interface
+type udpServer struct {
+	conn     pkgudp.PacketConn
+	handler  pkgudp.PacketHandler
+	logger   *common.Logger
+	mu       sync.RWMutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a new UDP server bound to address
+func NewServer(network, address string) (pkgudp.Server, error) {
+	conn, err := ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &udpServer{
+		conn:     conn,
+		logger:   common.NewDefaultLogger(),
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// Start starts the server
+func (s *udpServer) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return common.ServerError("server is already running")
+	}
+
+	if s.handler == nil {
+		return common.ServerError("no packet handler set")
+	}
+
+	s.running = true
+	s.logger.Info("Starting UDP server on %s", s.conn.LocalAddr())
+
+	s.wg.Add(1)
+	go s.readLoop()
+
+	return nil
+}
+
+// Stop closes the socket and waits for running handlers
+func (s *udpServer) Stop() error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.logger.Info("Stopping UDP server")
+	s.running = false
+	close(s.stopChan)
+
+	if err := s.conn.Close(); err != nil {
+		s.logger.Warn("Error closing socket: %v", err)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("UDP server stopped successfully")
+	case <-time.After(serverShutdownTimeout):
+		s.logger.Warn("UDP server shutdown timeout")
+	}
+
+	return nil
+}
+
+// IsRunning returns true if the server is running
+func (s *udpServer) IsRunning() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.running
+}
+
+// Addr returns the server's listening address
+func (s *udpServer) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// SetHandler sets the datagram handler function
+func (s *udpServer) SetHandler(handler pkgudp.PacketHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = handler
+}
+
+// readLoop reads datagrams and hands each to the handler in its own goroutine
+func (s *udpServer) readLoop() {
+	defer s.wg.Done()
+
+	buffer := make([]byte, serverReadBufferSize)
+	for {
+		n, addr, err := s.conn.ReadFrom(buffer)
+		if err != nil {
+			select {
+			case <-s.stopChan:
+				return
+			default:
+				s.logger.Error("Read error: %v", err)
+				continue
+			}
+		}
+
+		// The buffer is reused, so the handler gets its own copy
+		packet := pkgudp.Packet{Data: append([]byte(nil), buffer[:n]...), Addr: addr}
+
+		s.wg.Add(1)
+		go s.handlePacket(packet)
+	}
+}
+
+// handlePacket runs the handler for one datagram, surviving a panic
+func (s *udpServer) handlePacket(packet pkgudp.Packet) {
+	defer s.wg.Done()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logger.Error("Panic handling datagram from %s: %v", packet.Addr, recovered)
+		}
+	}()
+
+	s.handler(s.conn, packet)
+}
+
+// EchoHandler returns a handler that sends every datagram back to its sender
+func EchoHandler() pkgudp.PacketHandler {
+	return func(conn pkgudp.PacketConn, packet pkgudp.Packet) {
+		conn.WriteTo(packet.Data, packet.Addr)
+	}
+}
@@ -0,0 +1,143 @@
+package udp
+
+import (
+	"testing"
+	"time"
+
+	pkgudp "github.com/ganyariya/tinyserver/pkg/udp"
+)
+
+// startEchoServer starts an echo server on a free local port
+func startEchoServer(t *testing.T) pkgudp.Server {
+	t.Helper()
+
+	server, err := NewServer(pkgudp.NetworkUDP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server.SetHandler(EchoHandler())
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	return server
+}
+
+// newConnectedClient returns a client connected to server
+func newConnectedClient(t *testing.T, server pkgudp.Server) pkgudp.Client {
+	t.Helper()
+
+	client, err := NewClient(pkgudp.NetworkUDP)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Connect(server.Addr().String()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect() })
+
+	return client
+}
+
+func TestEchoServer(t *testing.T) {
+	server := startEchoServer(t)
+	client := newConnectedClient(t, server)
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "short message", payload: "hello"},
+		{name: "binary payload", payload: "\x00\x01\xff"},
+		{name: "empty datagram", payload: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.Send([]byte(tt.payload)); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+
+			buffer := make([]byte, 64)
+			n, err := client.ReceiveWithTimeout(buffer, time.Second)
+			if err != nil {
+				t.Fatalf("Receive failed: %v", err)
+			}
+			if got := string(buffer[:n]); got != tt.payload {
+				t.Errorf("Expected %q, got %q", tt.payload, got)
+			}
+		})
+	}
+}
+
+func TestServerKeepsDatagramBoundaries(t *testing.T) {
+	server := startEchoServer(t)
+	client := newConnectedClient(t, server)
+
+	for _, message := range []string{"first", "second"} {
+		if err := client.Send([]byte(message)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	// Replies may arrive in either order, but never merged
+	received := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		buffer := make([]byte, 64)
+		n, err := client.ReceiveWithTimeout(buffer, time.Second)
+		if err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+		received[string(buffer[:n])] = true
+	}
+	if !received["first"] || !received["second"] {
+		t.Errorf("Expected two separate datagrams, got %v", received)
+	}
+}
+
+func TestServerSurvivesHandlerPanic(t *testing.T) {
+	server, err := NewServer(pkgudp.NetworkUDP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if err := server.Start(); err == nil {
+		t.Error("Start should fail without handler")
+	}
+
+	echo := EchoHandler()
+	server.SetHandler(func(conn pkgudp.PacketConn, packet pkgudp.Packet) {
+		if string(packet.Data) == "panic" {
+			panic("boom")
+		}
+		echo(conn, packet)
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+
+	client := newConnectedClient(t, server)
+	client.Send([]byte("panic"))
+	client.Send([]byte("still alive"))
+
+	buffer := make([]byte, 64)
+	n, err := client.ReceiveWithTimeout(buffer, time.Second)
+	if err != nil || string(buffer[:n]) != "still alive" {
+		t.Errorf("Expected the server to keep serving, got %q (%v)", buffer[:n], err)
+	}
+}
+
+func TestServerStop(t *testing.T) {
+	server := startEchoServer(t)
+
+	if err := server.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if server.IsRunning() {
+		t.Error("Server should not be running after Stop")
+	}
+	if err := server.Stop(); err != nil {
+		t.Errorf("Second Stop failed: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package http
+
+import "io"
+
+// nopCloser gives a plain reader the io.ReadCloser semantics bodies have
+type nopCloser struct {
+	io.Reader
+}
+
+// Close does nothing
+func (nopCloser) Close() error {
+	return nil
+}
+
+// readCloser returns body as an io.ReadCloser, adding a no-op Close when it
+// has none. A nil reader stays nil so callers can test for a missing body.
+func readCloser(body io.Reader) io.ReadCloser {
+	switch body := body.(type) {
+	case nil:
+		return nil
+	case io.ReadCloser:
+		return body
+	default:
+		return nopCloser{body}
+	}
+}
+
+// BodyReader returns the reader a body was set from, removing the no-op Close
+// added to readers without one, so callers can look for methods such as Seek
+// or Len on the original reader
+func BodyReader(body io.Reader) io.Reader {
+	if nop, ok := body.(nopCloser); ok {
+		return nop.Reader
+	}
+	return body
+}
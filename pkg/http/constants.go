@@ -7,6 +7,8 @@ const (
 	// 1xx Informational
 	StatusContinue           StatusCode = 100
 	StatusSwitchingProtocols StatusCode = 101
+	StatusProcessing         StatusCode = 102
+	StatusEarlyHints         StatusCode = 103
 
 	// 2xx Success
 	StatusOK                   StatusCode = 200
@@ -91,27 +93,36 @@ const (
 	HeaderContentLocation                 = "Content-Location"
 	HeaderContentRange                    = "Content-Range"
 	HeaderContentType                     = "Content-Type"
+	HeaderCookie                          = "Cookie"
+	HeaderContentMD5                      = "Content-MD5"
 	HeaderDate                            = "Date"
+	HeaderDigest                          = "Digest"
 	HeaderETag                            = "ETag"
 	HeaderExpect                          = "Expect"
 	HeaderExpires                         = "Expires"
 	HeaderFrom                            = "From"
 	HeaderHost                            = "Host"
+	HeaderIdempotencyKey                  = "Idempotency-Key"
 	HeaderIfMatch                         = "If-Match"
 	HeaderIfModifiedSince                 = "If-Modified-Since"
 	HeaderIfNoneMatch                     = "If-None-Match"
 	HeaderIfRange                         = "If-Range"
 	HeaderIfUnmodifiedSince               = "If-Unmodified-Since"
+	HeaderKeepAlive                       = "Keep-Alive"
+	HeaderLastEventID                     = "Last-Event-ID"
 	HeaderLastModified                    = "Last-Modified"
+	HeaderLink                            = "Link"
 	HeaderLocation                        = "Location"
 	HeaderMaxForwards                     = "Max-Forwards"
 	HeaderPragma                          = "Pragma"
 	HeaderProxyAuthenticate               = "Proxy-Authenticate"
 	HeaderProxyAuthorization              = "Proxy-Authorization"
+	HeaderProxyConnection                 = "Proxy-Connection"
 	HeaderRange                           = "Range"
 	HeaderReferer                         = "Referer"
 	HeaderRetryAfter                      = "Retry-After"
 	HeaderServer                          = "Server"
+	HeaderSetCookie                       = "Set-Cookie"
 	HeaderTE                              = "TE"
 	HeaderTrailer                         = "Trailer"
 	HeaderTransferEncoding                = "Transfer-Encoding"
@@ -119,6 +130,7 @@ const (
 	HeaderUserAgent                       = "User-Agent"
 	HeaderVary                            = "Vary"
 	HeaderVia                             = "Via"
+	HeaderWantDigest                      = "Want-Digest"
 	HeaderWarning                         = "Warning"
 	HeaderWWWAuthenticate                 = "WWW-Authenticate"
 	HeaderXForwardedFor                   = "X-Forwarded-For"
@@ -135,14 +147,35 @@ const (
 	HeaderContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
 )
 
+// Common header values
+const (
+	// ConnectionClose asks the peer to close the connection after the message
+	ConnectionClose = "close"
+
+	// ConnectionKeepAlive asks the peer to keep the connection open
+	ConnectionKeepAlive = "keep-alive"
+
+	// ConnectionUpgrade marks the Upgrade header as applying to this connection
+	ConnectionUpgrade = "Upgrade"
+
+	// ExpectContinue asks the server to confirm with 100 Continue before the body is sent
+	ExpectContinue = "100-continue"
+
+	// TransferEncodingChunked is the chunked transfer coding
+	TransferEncodingChunked = "chunked"
+)
+
 // Common MIME types
 const (
 	MimeTypeJSON                  = "application/json"
+	MimeTypeNDJSON                = "application/x-ndjson"
 	MimeTypeXML                   = "application/xml"
 	MimeTypeForm                  = "application/x-www-form-urlencoded"
 	MimeTypeMultipartForm         = "multipart/form-data"
+	MimeTypeMultipartByteRanges   = "multipart/byteranges"
 	MimeTypeOctetStream           = "application/octet-stream"
 	MimeTypeTextPlain             = "text/plain"
+	MimeTypeEventStream           = "text/event-stream"
 	MimeTypeTextHTML              = "text/html"
 	MimeTypeTextCSS               = "text/css"
 	MimeTypeTextJavaScript        = "text/javascript"
@@ -171,6 +204,10 @@ const (
 	// DefaultServerReadTimeout is the default read timeout for HTTP server
 	DefaultServerReadTimeout = 10 * time.Second
 
+	// DefaultServerReadHeaderTimeout is how long the HTTP server allows for a
+	// request line and headers once their first byte arrives
+	DefaultServerReadHeaderTimeout = 5 * time.Second
+
 	// DefaultServerWriteTimeout is the default write timeout for HTTP server
 	DefaultServerWriteTimeout = 10 * time.Second
 
@@ -189,6 +226,15 @@ const (
 	// DefaultHTTPSPort is the default HTTPS port
 	DefaultHTTPSPort = 443
 
+	// SchemeHTTP is the URI scheme for plain HTTP
+	SchemeHTTP = "http"
+
+	// SchemeHTTPS is the URI scheme for HTTP over TLS
+	SchemeHTTPS = "https"
+
+	// SchemeUnix is the address scheme for Unix domain sockets, as in "unix:///path/to.sock"
+	SchemeUnix = "unix"
+
 	// MaxHeaderSize is the maximum size of HTTP headers
 	MaxHeaderSize = 1 << 20 // 1MB
 
@@ -205,128 +251,6 @@ const (
 	HTTPVersionPrefix = "HTTP/"
 )
 
-// StatusText returns the status text for the given status code
-func StatusText(code StatusCode) string {
-	switch code {
-	case StatusContinue:
-		return "Continue"
-	case StatusSwitchingProtocols:
-		return "Switching Protocols"
-	case StatusOK:
-		return "OK"
-	case StatusCreated:
-		return "Created"
-	case StatusAccepted:
-		return "Accepted"
-	case StatusNonAuthoritativeInfo:
-		return "Non-Authoritative Information"
-	case StatusNoContent:
-		return "No Content"
-	case StatusResetContent:
-		return "Reset Content"
-	case StatusPartialContent:
-		return "Partial Content"
-	case StatusMultipleChoices:
-		return "Multiple Choices"
-	case StatusMovedPermanently:
-		return "Moved Permanently"
-	case StatusFound:
-		return "Found"
-	case StatusSeeOther:
-		return "See Other"
-	case StatusNotModified:
-		return "Not Modified"
-	case StatusUseProxy:
-		return "Use Proxy"
-	case StatusTemporaryRedirect:
-		return "Temporary Redirect"
-	case StatusPermanentRedirect:
-		return "Permanent Redirect"
-	case StatusBadRequest:
-		return "Bad Request"
-	case StatusUnauthorized:
-		return "Unauthorized"
-	case StatusPaymentRequired:
-		return "Payment Required"
-	case StatusForbidden:
-		return "Forbidden"
-	case StatusNotFound:
-		return "Not Found"
-	case StatusMethodNotAllowed:
-		return "Method Not Allowed"
-	case StatusNotAcceptable:
-		return "Not Acceptable"
-	case StatusProxyAuthRequired:
-		return "Proxy Authentication Required"
-	case StatusRequestTimeout:
-		return "Request Timeout"
-	case StatusConflict:
-		return "Conflict"
-	case StatusGone:
-		return "Gone"
-	case StatusLengthRequired:
-		return "Length Required"
-	case StatusPreconditionFailed:
-		return "Precondition Failed"
-	case StatusRequestEntityTooLarge:
-		return "Request Entity Too Large"
-	case StatusRequestURITooLong:
-		return "Request URI Too Long"
-	case StatusUnsupportedMediaType:
-		return "Unsupported Media Type"
-	case StatusRequestedRangeNotSatisfiable:
-		return "Requested Range Not Satisfiable"
-	case StatusExpectationFailed:
-		return "Expectation Failed"
-	case StatusTeapot:
-		return "I'm a teapot"
-	case StatusMisdirectedRequest:
-		return "Misdirected Request"
-	case StatusUnprocessableEntity:
-		return "Unprocessable Entity"
-	case StatusLocked:
-		return "Locked"
-	case StatusFailedDependency:
-		return "Failed Dependency"
-	case StatusTooEarly:
-		return "Too Early"
-	case StatusUpgradeRequired:
-		return "Upgrade Required"
-	case StatusPreconditionRequired:
-		return "Precondition Required"
-	case StatusTooManyRequests:
-		return "Too Many Requests"
-	case StatusRequestHeaderFieldsTooLarge:
-		return "Request Header Fields Too Large"
-	case StatusUnavailableForLegalReasons:
-		return "Unavailable For Legal Reasons"
-	case StatusInternalServerError:
-		return "Internal Server Error"
-	case StatusNotImplemented:
-		return "Not Implemented"
-	case StatusBadGateway:
-		return "Bad Gateway"
-	case StatusServiceUnavailable:
-		return "Service Unavailable"
-	case StatusGatewayTimeout:
-		return "Gateway Timeout"
-	case StatusHTTPVersionNotSupported:
-		return "HTTP Version Not Supported"
-	case StatusVariantAlsoNegotiates:
-		return "Variant Also Negotiates"
-	case StatusInsufficientStorage:
-		return "Insufficient Storage"
-	case StatusLoopDetected:
-		return "Loop Detected"
-	case StatusNotExtended:
-		return "Not Extended"
-	case StatusNetworkAuthenticationRequired:
-		return "Network Authentication Required"
-	default:
-		return "Unknown Status Code"
-	}
-}
-
 // IsInformational returns true if the status code is informational (1xx)
 func IsInformational(code StatusCode) bool {
 	return code >= 100 && code < 200
@@ -0,0 +1,134 @@
+package http
+
+import "sort"
+
+// headerSpellings maps the MIME-style form of header names whose conventional
+// spelling differs from it back to that spelling
+var headerSpellings = map[string]string{
+	"Etag":             HeaderETag,
+	"Last-Event-Id":    HeaderLastEventID,
+	"Te":               HeaderTE,
+	"Www-Authenticate": HeaderWWWAuthenticate,
+	"X-Real-Ip":        HeaderXRealIP,
+	"X-Request-Id":     HeaderXRequestID,
+	"X-Csrf-Token":     HeaderXCSRFToken,
+	"X-Xss-Protection": HeaderXXSSProtection,
+}
+
+// CanonicalHeaderKey returns the canonical form of a header name: the first
+// letter and any letter following a hyphen in upper case, the rest in lower
+// case, so "content-type" becomes "Content-Type". Well-known names keep their
+// conventional spelling, such as "ETag". Names containing characters outside
+// letters, digits and hyphens are returned unchanged.
+func CanonicalHeaderKey(name string) string {
+	canonical := make([]byte, len(name))
+	upper := true
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			if upper {
+				c -= 'a' - 'A'
+			}
+		case c >= 'A' && c <= 'Z':
+			if !upper {
+				c += 'a' - 'A'
+			}
+		case c >= '0' && c <= '9' || c == '-':
+		default:
+			return name
+		}
+
+		canonical[i] = c
+		upper = c == '-'
+	}
+
+	if spelling, ok := headerSpellings[string(canonical)]; ok {
+		return spelling
+	}
+	return string(canonical)
+}
+
+// Get returns the first value of the named header, matched case-insensitively
+func (h Header) Get(name string) string {
+	values := h[CanonicalHeaderKey(name)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Values returns all values of the named header
+func (h Header) Values(name string) []string {
+	return h[CanonicalHeaderKey(name)]
+}
+
+// Has reports whether the named header is present
+func (h Header) Has(name string) bool {
+	_, exists := h[CanonicalHeaderKey(name)]
+	return exists
+}
+
+// Set replaces the values of the named header with value
+func (h Header) Set(name, value string) {
+	h[CanonicalHeaderKey(name)] = []string{value}
+}
+
+// Add appends value to the named header
+func (h Header) Add(name, value string) {
+	key := CanonicalHeaderKey(name)
+	h[key] = append(h[key], value)
+}
+
+// Del removes the named header
+func (h Header) Del(name string) {
+	delete(h, CanonicalHeaderKey(name))
+}
+
+// framingHeaders are serialized after every other header, in this order, so
+// the headers that delimit the body always sit in the same place
+var framingHeaders = []string{HeaderContentLength, HeaderTransferEncoding}
+
+// OrderedNames returns the names present in h in serialization order: the
+// names in order first, then any others sorted, and Content-Length and
+// Transfer-Encoding last. Names in order that h no longer holds are skipped.
+func (h Header) OrderedNames(order []string) []string {
+	names := make([]string, 0, len(h))
+	seen := make(map[string]bool, len(h))
+	for _, name := range framingHeaders {
+		seen[name] = true
+	}
+
+	for _, name := range order {
+		if _, ok := h[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	var rest []string
+	for name := range h {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	names = append(names, rest...)
+
+	for _, name := range framingHeaders {
+		if _, ok := h[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// trackHeaderName records name in order the first time h gains it
+func trackHeaderName(order []string, h Header, name string) []string {
+	if h.Has(name) {
+		return order
+	}
+	return append(order, CanonicalHeaderKey(name))
+}
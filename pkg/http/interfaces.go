@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"io"
 	"net"
 	"time"
@@ -24,6 +25,8 @@ const (
 	MethodOptions Method = "OPTIONS"
 	// MethodPatch represents HTTP PATCH method
 	MethodPatch Method = "PATCH"
+	// MethodConnect represents HTTP CONNECT method
+	MethodConnect Method = "CONNECT"
 )
 
 // Version represents HTTP version
@@ -39,7 +42,8 @@ const (
 // StatusCode represents HTTP status codes
 type StatusCode int
 
-// Header represents HTTP headers as key-value pairs
+// Header represents HTTP headers as key-value pairs, keyed by canonical name.
+// Use its methods, or the Request and Response accessors, for case-insensitive access.
 type Header map[string][]string
 
 // Request represents an HTTP request
@@ -56,12 +60,28 @@ type Request interface {
 	// Headers returns the request headers
 	Headers() Header
 
-	// Body returns the request body reader
-	Body() io.Reader
+	// HeaderNames returns the header names in the order they are written
+	HeaderNames() []string
 
-	// QueryParams returns query parameters
+	// Body returns the request body, or nil when there is none. For a request
+	// read by the server the body streams from the connection; the server
+	// closes it once the response is written, discarding whatever the handler
+	// left unread so the connection can serve the next request. Handlers may
+	// close it early but must not keep it after returning.
+	Body() io.ReadCloser
+
+	// QueryParams returns query parameters, keeping the first value of repeated keys
 	QueryParams() map[string]string
 
+	// QueryValues returns every value of each query parameter
+	QueryValues() map[string][]string
+
+	// QueryParam returns the first value of the named query parameter
+	QueryParam(string) string
+
+	// QueryParamsAll returns all values of the named query parameter
+	QueryParamsAll(string) []string
+
 	// SetMethod sets the HTTP method
 	SetMethod(Method)
 
@@ -77,7 +97,8 @@ type Request interface {
 	// AddHeader adds a header value
 	AddHeader(string, string)
 
-	// SetBody sets the request body
+	// SetBody sets the request body, adding a no-op Close to readers without
+	// one. The caller becomes responsible for closing the body it replaces.
 	SetBody(io.Reader)
 
 	// ContentLength returns the content length
@@ -94,6 +115,21 @@ type Request interface {
 
 	// HasHeader checks if a header exists
 	HasHeader(string) bool
+
+	// Context returns the request-scoped context
+	Context() context.Context
+
+	// SetContext replaces the request-scoped context
+	SetContext(context.Context)
+
+	// PathParam returns the value captured for a named route segment
+	PathParam(string) string
+
+	// PathParams returns all captured route parameters
+	PathParams() map[string]string
+
+	// SetPathParams sets the captured route parameters
+	SetPathParams(map[string]string)
 }
 
 // Response represents an HTTP response
@@ -107,8 +143,14 @@ type Response interface {
 	// Headers returns the response headers
 	Headers() Header
 
-	// Body returns the response body reader
-	Body() io.Reader
+	// HeaderNames returns the header names in the order they are written
+	HeaderNames() []string
+
+	// Body returns the response body, or nil when there is none. The server
+	// closes a handler's response body after writing it. A client response
+	// body belongs to the caller, who must close it to release the connection;
+	// unread bytes are discarded on Close so the connection can be reused.
+	Body() io.ReadCloser
 
 	// SetStatusCode sets the HTTP status code
 	SetStatusCode(StatusCode)
@@ -122,7 +164,8 @@ type Response interface {
 	// AddHeader adds a header value
 	AddHeader(string, string)
 
-	// SetBody sets the response body
+	// SetBody sets the response body, adding a no-op Close to readers without
+	// one. The caller becomes responsible for closing the body it replaces.
 	SetBody(io.Reader)
 
 	// ContentLength returns the content length
@@ -167,6 +210,12 @@ type ResponseBuilder interface {
 	// BuildJSON builds a JSON response
 	BuildJSON(StatusCode, interface{}) Response
 
+	// BuildXML builds an XML response
+	BuildXML(StatusCode, interface{}) Response
+
+	// BuildEncoded builds a response encoded with the codec registered for a media type
+	BuildEncoded(StatusCode, string, interface{}) Response
+
 	// BuildError builds an error response
 	BuildError(StatusCode, string) Response
 
@@ -174,6 +223,12 @@ type ResponseBuilder interface {
 	BuildFile(StatusCode, string) Response
 }
 
+// Flusher is implemented by body writers that can push buffered data to the client
+type Flusher interface {
+	// Flush sends any buffered data to the client
+	Flush() error
+}
+
 // RequestHandler handles HTTP requests
 type RequestHandler func(Request) Response
 
@@ -206,6 +261,10 @@ type Server interface {
 	// Stop stops the HTTP server
 	Stop() error
 
+	// Shutdown stops accepting connections and lets requests in flight
+	// finish until ctx is done
+	Shutdown(ctx context.Context) error
+
 	// IsRunning returns true if the server is running
 	IsRunning() bool
 
@@ -239,6 +298,9 @@ type Client interface {
 	// Do sends a custom request
 	Do(Request) (Response, error)
 
+	// DoWithContext sends a custom request that is aborted when the context ends
+	DoWithContext(context.Context, Request) (Response, error)
+
 	// SetTimeout sets the request timeout
 	SetTimeout(time.Duration)
 
@@ -246,6 +308,23 @@ type Client interface {
 	SetHeader(string, string)
 }
 
+// Transport performs a single HTTP exchange on behalf of a client
+type Transport interface {
+	// RoundTrip sends a request and returns its response without following redirects
+	RoundTrip(context.Context, Request) (Response, error)
+}
+
+// RoundTripFunc adapts a function to the Transport interface
+type RoundTripFunc func(context.Context, Request) (Response, error)
+
+// RoundTrip calls f
+func (f RoundTripFunc) RoundTrip(ctx context.Context, req Request) (Response, error) {
+	return f(ctx, req)
+}
+
+// InterceptorFunc wraps a client round trip, as MiddlewareFunc wraps a handler
+type InterceptorFunc func(RoundTripFunc) RoundTripFunc
+
 // MessageWriter writes HTTP messages to connections
 type MessageWriter interface {
 	// WriteRequest writes an HTTP request
@@ -0,0 +1,155 @@
+package mime
+
+import (
+	"bytes"
+	stdmime "mime"
+	"strings"
+
+	"github.com/ganyariya/tinyserver/pkg/http"
+)
+
+// SniffLength is how many leading bytes DetectContentType considers
+const SniffLength = 512
+
+// extensionTypes maps lowercase file extensions to media types
+var extensionTypes = map[string]string{
+	".json":   http.MimeTypeJSON,
+	".ndjson": http.MimeTypeNDJSON,
+	".xml":    http.MimeTypeXML,
+	".txt":    http.MimeTypeTextPlain,
+	".html":   http.MimeTypeTextHTML,
+	".htm":    http.MimeTypeTextHTML,
+	".css":    http.MimeTypeTextCSS,
+	".js":     http.MimeTypeTextJavaScript,
+	".mjs":    http.MimeTypeTextJavaScript,
+	".jpg":    http.MimeTypeImageJPEG,
+	".jpeg":   http.MimeTypeImageJPEG,
+	".png":    http.MimeTypeImagePNG,
+	".gif":    http.MimeTypeImageGIF,
+	".svg":    http.MimeTypeImageSVG,
+	".webp":   http.MimeTypeImageWebP,
+	".mp4":    http.MimeTypeVideoMP4,
+	".webm":   http.MimeTypeVideoWebM,
+	".mp3":    http.MimeTypeAudioMP3,
+	".wav":    http.MimeTypeAudioWAV,
+	".ogg":    http.MimeTypeAudioOGG,
+	".woff":   http.MimeTypeFontWOFF,
+	".woff2":  http.MimeTypeFontWOFF2,
+	".ttf":    http.MimeTypeFontTTF,
+	".otf":    http.MimeTypeFontOTF,
+}
+
+// TypeByExtension returns the media type for a file extension such as ".png",
+// with or without the leading dot, or "" when the extension is unknown.
+// Extensions outside the package table fall back to the system MIME table.
+func TypeByExtension(ext string) string {
+	if ext == "" {
+		return ""
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	ext = strings.ToLower(ext)
+
+	if mediaType, ok := extensionTypes[ext]; ok {
+		return mediaType
+	}
+	return stdmime.TypeByExtension(ext)
+}
+
+// signature identifies a media type by bytes at a fixed offset. When mask is
+// set it is ANDed with the content first, so zeroed positions match any byte.
+type signature struct {
+	offset    int
+	pattern   []byte
+	mask      []byte
+	mediaType string
+}
+
+// signatures are checked in order against the sniffed prefix
+var signatures = []signature{
+	{pattern: []byte("\x89PNG\r\n\x1a\n"), mediaType: http.MimeTypeImagePNG},
+	{pattern: []byte("\xff\xd8\xff"), mediaType: http.MimeTypeImageJPEG},
+	{pattern: []byte("GIF87a"), mediaType: http.MimeTypeImageGIF},
+	{pattern: []byte("GIF89a"), mediaType: http.MimeTypeImageGIF},
+	{pattern: []byte("RIFF\x00\x00\x00\x00WEBP"), mask: []byte("\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff"), mediaType: http.MimeTypeImageWebP},
+	{pattern: []byte("RIFF\x00\x00\x00\x00WAVE"), mask: []byte("\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff"), mediaType: http.MimeTypeAudioWAV},
+	{offset: 4, pattern: []byte("ftyp"), mediaType: http.MimeTypeVideoMP4},
+	{pattern: []byte("\x1a\x45\xdf\xa3"), mediaType: http.MimeTypeVideoWebM},
+	{pattern: []byte("OggS\x00"), mediaType: http.MimeTypeAudioOGG},
+	{pattern: []byte("ID3"), mediaType: http.MimeTypeAudioMP3},
+	{pattern: []byte("wOFF"), mediaType: http.MimeTypeFontWOFF},
+	{pattern: []byte("wOF2"), mediaType: http.MimeTypeFontWOFF2},
+	{pattern: []byte("OTTO"), mediaType: http.MimeTypeFontOTF},
+	{pattern: []byte("\x00\x01\x00\x00\x00"), mediaType: http.MimeTypeFontTTF},
+}
+
+// markupPrefixes identify text formats by their first tag, matched
+// case-insensitively after leading whitespace
+var markupPrefixes = []struct {
+	prefix    string
+	mediaType string
+}{
+	{prefix: "<!doctype html", mediaType: http.MimeTypeTextHTML},
+	{prefix: "<html", mediaType: http.MimeTypeTextHTML},
+	{prefix: "<head", mediaType: http.MimeTypeTextHTML},
+	{prefix: "<body", mediaType: http.MimeTypeTextHTML},
+	{prefix: "<svg", mediaType: http.MimeTypeImageSVG},
+	{prefix: "<?xml", mediaType: http.MimeTypeXML},
+}
+
+// DetectContentType guesses the media type of content from at most its first
+// SniffLength bytes: binary formats by their magic numbers, markup by its
+// opening tag, and anything else without control bytes as plain text. Content
+// it cannot classify is application/octet-stream.
+func DetectContentType(data []byte) string {
+	if len(data) > SniffLength {
+		data = data[:SniffLength]
+	}
+
+	for _, sig := range signatures {
+		if sig.matches(data) {
+			return sig.mediaType
+		}
+	}
+
+	text := strings.ToLower(string(bytes.TrimLeft(data, "\t\n\f\r ")))
+	for _, markup := range markupPrefixes {
+		if strings.HasPrefix(text, markup.prefix) {
+			return markup.mediaType
+		}
+	}
+
+	if isText(data) {
+		return http.MimeTypeTextPlain
+	}
+	return http.MimeTypeOctetStream
+}
+
+// matches reports whether data carries the signature
+func (s signature) matches(data []byte) bool {
+	if len(data) < s.offset+len(s.pattern) {
+		return false
+	}
+
+	for i, b := range s.pattern {
+		actual := data[s.offset+i]
+		if s.mask != nil {
+			actual &= s.mask[i]
+		}
+		if actual != b {
+			return false
+		}
+	}
+	return true
+}
+
+// isText reports whether data holds no control bytes other than whitespace
+func isText(data []byte) bool {
+	for _, b := range data {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != 0x1b {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Escapes CleanPath leaves encoded: decoding a separator would split a
+// segment, and decoding a percent sign would make "%252F" read as "%2F"
+const (
+	escapedSlash     = "%2F"
+	escapedBackslash = "%5C"
+	escapedPercent   = "%25"
+)
+
+// CleanPath percent-decodes an origin-form path and removes its dot segments,
+// so "/a/%2E%2E/b%20c" becomes "/b c". Encoded slashes, backslashes and
+// percent signs (%2F, %5C, %25) stay encoded, in uppercase, so "/a%2Fb" is one
+// segment and never matches what "/a/b" does, and "/a%252Fb" stays distinct
+// from "/a%2Fb". A trailing slash is kept. It reports false for
+// malformed escapes and for paths that decode to a NUL byte. Targets not
+// starting with "/" are only decoded.
+func CleanPath(p string) (string, bool) {
+	decoded, ok := decodePath(p)
+	if !ok {
+		return "", false
+	}
+
+	if !strings.HasPrefix(decoded, "/") {
+		return decoded, true
+	}
+
+	trailingSlash := strings.HasSuffix(decoded, "/") ||
+		strings.HasSuffix(decoded, "/.") || strings.HasSuffix(decoded, "/..")
+
+	cleaned := path.Clean(decoded)
+	if trailingSlash && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned, true
+}
+
+// decodePath percent-decodes p except for the escapes of path separators and
+// of the percent sign
+func decodePath(p string) (string, bool) {
+	var b strings.Builder
+	start := 0
+	for i := 0; i+2 < len(p); i++ {
+		if p[i] != '%' {
+			continue
+		}
+		escape := strings.ToUpper(p[i : i+3])
+		if escape != escapedSlash && escape != escapedBackslash && escape != escapedPercent {
+			continue
+		}
+
+		part, err := url.PathUnescape(p[start:i])
+		if err != nil {
+			return "", false
+		}
+		b.WriteString(part)
+		b.WriteString(escape)
+		start = i + 3
+		i += 2
+	}
+
+	part, err := url.PathUnescape(p[start:])
+	if err != nil {
+		return "", false
+	}
+	b.WriteString(part)
+
+	decoded := b.String()
+	return decoded, strings.IndexByte(decoded, 0) < 0
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// standardMethods are the methods known without registration
+var standardMethods = []Method{
+	MethodGet, MethodPost, MethodPut, MethodDelete,
+	MethodHead, MethodOptions, MethodPatch, MethodConnect,
+}
+
+// statusText holds the reason phrase of every known status code
+var statusText = map[StatusCode]string{
+	StatusContinue:                      "Continue",
+	StatusSwitchingProtocols:            "Switching Protocols",
+	StatusProcessing:                    "Processing",
+	StatusEarlyHints:                    "Early Hints",
+	StatusOK:                            "OK",
+	StatusCreated:                       "Created",
+	StatusAccepted:                      "Accepted",
+	StatusNonAuthoritativeInfo:          "Non-Authoritative Information",
+	StatusNoContent:                     "No Content",
+	StatusResetContent:                  "Reset Content",
+	StatusPartialContent:                "Partial Content",
+	StatusMultipleChoices:               "Multiple Choices",
+	StatusMovedPermanently:              "Moved Permanently",
+	StatusFound:                         "Found",
+	StatusSeeOther:                      "See Other",
+	StatusNotModified:                   "Not Modified",
+	StatusUseProxy:                      "Use Proxy",
+	StatusTemporaryRedirect:             "Temporary Redirect",
+	StatusPermanentRedirect:             "Permanent Redirect",
+	StatusBadRequest:                    "Bad Request",
+	StatusUnauthorized:                  "Unauthorized",
+	StatusPaymentRequired:               "Payment Required",
+	StatusForbidden:                     "Forbidden",
+	StatusNotFound:                      "Not Found",
+	StatusMethodNotAllowed:              "Method Not Allowed",
+	StatusNotAcceptable:                 "Not Acceptable",
+	StatusProxyAuthRequired:             "Proxy Authentication Required",
+	StatusRequestTimeout:                "Request Timeout",
+	StatusConflict:                      "Conflict",
+	StatusGone:                          "Gone",
+	StatusLengthRequired:                "Length Required",
+	StatusPreconditionFailed:            "Precondition Failed",
+	StatusRequestEntityTooLarge:         "Request Entity Too Large",
+	StatusRequestURITooLong:             "Request URI Too Long",
+	StatusUnsupportedMediaType:          "Unsupported Media Type",
+	StatusRequestedRangeNotSatisfiable:  "Requested Range Not Satisfiable",
+	StatusExpectationFailed:             "Expectation Failed",
+	StatusTeapot:                        "I'm a teapot",
+	StatusMisdirectedRequest:            "Misdirected Request",
+	StatusUnprocessableEntity:           "Unprocessable Entity",
+	StatusLocked:                        "Locked",
+	StatusFailedDependency:              "Failed Dependency",
+	StatusTooEarly:                      "Too Early",
+	StatusUpgradeRequired:               "Upgrade Required",
+	StatusPreconditionRequired:          "Precondition Required",
+	StatusTooManyRequests:               "Too Many Requests",
+	StatusRequestHeaderFieldsTooLarge:   "Request Header Fields Too Large",
+	StatusUnavailableForLegalReasons:    "Unavailable For Legal Reasons",
+	StatusInternalServerError:           "Internal Server Error",
+	StatusNotImplemented:                "Not Implemented",
+	StatusBadGateway:                    "Bad Gateway",
+	StatusServiceUnavailable:            "Service Unavailable",
+	StatusGatewayTimeout:                "Gateway Timeout",
+	StatusHTTPVersionNotSupported:       "HTTP Version Not Supported",
+	StatusVariantAlsoNegotiates:         "Variant Also Negotiates",
+	StatusInsufficientStorage:           "Insufficient Storage",
+	StatusLoopDetected:                  "Loop Detected",
+	StatusNotExtended:                   "Not Extended",
+	StatusNetworkAuthenticationRequired: "Network Authentication Required",
+}
+
+// registry guards the extension methods and the status text table
+var registry = struct {
+	sync.RWMutex
+	methods map[Method]bool
+}{methods: make(map[Method]bool)}
+
+// RegisterMethod makes an extension method, such as WebDAV's PROPFIND or
+// MKCOL, known to the parser and router. It panics unless method is a valid
+// token. Register methods before serving requests.
+func RegisterMethod(method Method) {
+	if !isToken(string(method)) {
+		panic("http: invalid method " + string(method))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.methods[method] = true
+}
+
+// IsKnownMethod reports whether method is a standard or registered method
+func IsKnownMethod(method Method) bool {
+	for _, standard := range standardMethods {
+		if method == standard {
+			return true
+		}
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.methods[method]
+}
+
+// ExtensionMethods returns the registered extension methods in alphabetical order
+func ExtensionMethods() []Method {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	methods := make([]Method, 0, len(registry.methods))
+	for method := range registry.methods {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i] < methods[j] })
+
+	return methods
+}
+
+// RegisterStatus sets the reason phrase written for code, adding a custom
+// status or renaming a standard one. It panics unless code is in the 100-599
+// range the parser accepts and text is a single line.
+func RegisterStatus(code StatusCode, text string) {
+	if code < 100 || code >= 600 || strings.ContainsAny(text, "\r\n") {
+		panic("http: invalid status registration")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	statusText[code] = text
+}
+
+// StatusText returns the status text for the given status code
+func StatusText(code StatusCode) string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if text, ok := statusText[code]; ok {
+		return text
+	}
+	return "Unknown Status Code"
+}
+
+// isToken reports whether s is a non-empty RFC 7230 token
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !((r >= 'a' && r <= 'z') ||
+			(r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') ||
+			strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/url"
@@ -10,35 +11,36 @@ import (
 
 // HTTPRequest implements the Request interface
 type HTTPRequest struct {
-	method      Method
-	path        string
-	version     Version
-	headers     Header
-	body        io.Reader
-	queryParams map[string]string
-	remoteAddr  net.Addr
+	method     Method
+	path       string
+	version    Version
+	headers    Header
+	order      []string
+	body       io.ReadCloser
+	query      url.Values
+	remoteAddr net.Addr
+	ctx        context.Context
+	pathParams map[string]string
 }
 
 // NewRequest creates a new HTTP request
 func NewRequest(method Method, path string, version Version) Request {
 	return &HTTPRequest{
-		method:      method,
-		path:        path,
-		version:     version,
-		headers:     make(Header),
-		queryParams: make(map[string]string),
+		method:  method,
+		path:    path,
+		version: version,
+		headers: make(Header),
 	}
 }
 
 // NewRequestWithBody creates a new HTTP request with body
 func NewRequestWithBody(method Method, path string, version Version, body io.Reader) Request {
 	req := &HTTPRequest{
-		method:      method,
-		path:        path,
-		version:     version,
-		headers:     make(Header),
-		queryParams: make(map[string]string),
-		body:        body,
+		method:  method,
+		path:    path,
+		version: version,
+		headers: make(Header),
+		body:    readCloser(body),
 	}
 	return req
 }
@@ -66,18 +68,49 @@ func (r *HTTPRequest) Headers() Header {
 	return r.headers
 }
 
-// Body returns the request body reader
-func (r *HTTPRequest) Body() io.Reader {
+// HeaderNames returns the header names in the order they were first added,
+// with any set directly on Headers() sorted after them and the framing
+// headers last
+func (r *HTTPRequest) HeaderNames() []string {
+	return r.Headers().OrderedNames(r.order)
+}
+
+// Body returns the request body, or nil when there is none
+func (r *HTTPRequest) Body() io.ReadCloser {
 	return r.body
 }
 
-// QueryParams returns query parameters
+// QueryParams returns query parameters, keeping the first value of repeated keys
 func (r *HTTPRequest) QueryParams() map[string]string {
-	if r.queryParams == nil {
-		r.queryParams = make(map[string]string)
-		r.parseQueryParams()
+	params := make(map[string]string)
+	for key, values := range r.QueryValues() {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	return params
+}
+
+// QueryValues returns every value of each query parameter
+func (r *HTTPRequest) QueryValues() map[string][]string {
+	if r.query == nil {
+		r.query = parseQuery(r.path)
 	}
-	return r.queryParams
+	return r.query
+}
+
+// QueryParam returns the first value of the named query parameter
+func (r *HTTPRequest) QueryParam(name string) string {
+	values := r.QueryValues()[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// QueryParamsAll returns all values of the named query parameter
+func (r *HTTPRequest) QueryParamsAll(name string) []string {
+	return r.QueryValues()[name]
 }
 
 // SetMethod sets the HTTP method
@@ -88,9 +121,8 @@ func (r *HTTPRequest) SetMethod(method Method) {
 // SetPath sets the request path
 func (r *HTTPRequest) SetPath(path string) {
 	r.path = path
-	// Re-parse query parameters when path changes
-	r.queryParams = make(map[string]string)
-	r.parseQueryParams()
+	// Re-parse query parameters on next access
+	r.query = nil
 }
 
 // SetVersion sets the HTTP version
@@ -103,7 +135,8 @@ func (r *HTTPRequest) SetHeader(name, value string) {
 	if r.headers == nil {
 		r.headers = make(Header)
 	}
-	r.headers[name] = []string{value}
+	r.order = trackHeaderName(r.order, r.headers, name)
+	r.headers.Set(name, value)
 }
 
 // AddHeader adds a header value
@@ -111,12 +144,13 @@ func (r *HTTPRequest) AddHeader(name, value string) {
 	if r.headers == nil {
 		r.headers = make(Header)
 	}
-	r.headers[name] = append(r.headers[name], value)
+	r.order = trackHeaderName(r.order, r.headers, name)
+	r.headers.Add(name, value)
 }
 
-// SetBody sets the request body
+// SetBody sets the request body, adding a no-op Close to readers without one
 func (r *HTTPRequest) SetBody(body io.Reader) {
-	r.body = body
+	r.body = readCloser(body)
 }
 
 // ContentLength returns the content length
@@ -148,39 +182,48 @@ func (r *HTTPRequest) SetRemoteAddr(addr net.Addr) {
 	r.remoteAddr = addr
 }
 
-// parseQueryParams parses query parameters from the path
-func (r *HTTPRequest) parseQueryParams() {
-	if r.queryParams == nil {
-		r.queryParams = make(map[string]string)
+// Context returns the request-scoped context
+func (r *HTTPRequest) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
 	}
+	return r.ctx
+}
 
-	if r.path == "" {
-		return
-	}
+// SetContext replaces the request-scoped context
+func (r *HTTPRequest) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
 
-	// Find query string separator
-	queryIndex := strings.Index(r.path, "?")
-	if queryIndex == -1 {
-		return
-	}
+// PathParam returns the value captured for a named route segment
+func (r *HTTPRequest) PathParam(name string) string {
+	return r.pathParams[name]
+}
 
-	queryString := r.path[queryIndex+1:]
-	if queryString == "" {
-		return
+// PathParams returns all captured route parameters
+func (r *HTTPRequest) PathParams() map[string]string {
+	if r.pathParams == nil {
+		r.pathParams = make(map[string]string)
 	}
+	return r.pathParams
+}
 
-	// Parse query string
-	params, err := url.ParseQuery(queryString)
-	if err != nil {
-		return
-	}
+// SetPathParams sets the captured route parameters
+func (r *HTTPRequest) SetPathParams(params map[string]string) {
+	r.pathParams = params
+}
 
-	// Convert url.Values to map[string]string (take first value for each key)
-	for key, values := range params {
-		if len(values) > 0 {
-			r.queryParams[key] = values[0]
-		}
+// parseQuery parses the query parameters of path. Malformed pairs are skipped.
+func parseQuery(path string) url.Values {
+	// Find query string separator
+	queryIndex := strings.Index(path, "?")
+	if queryIndex == -1 {
+		return url.Values{}
 	}
+
+	// ParseQuery keeps the pairs it could decode alongside its error
+	params, _ := url.ParseQuery(path[queryIndex+1:])
+	return params
 }
 
 // GetHeader returns the first value of the header
@@ -189,12 +232,7 @@ func (r *HTTPRequest) GetHeader(name string) string {
 		return ""
 	}
 
-	values, exists := r.headers[name]
-	if !exists || len(values) == 0 {
-		return ""
-	}
-
-	return values[0]
+	return r.headers.Get(name)
 }
 
 // GetHeaders returns all values for the header
@@ -203,7 +241,7 @@ func (r *HTTPRequest) GetHeaders(name string) []string {
 		return nil
 	}
 
-	return r.headers[name]
+	return r.headers.Values(name)
 }
 
 // HasHeader checks if a header exists
@@ -212,45 +250,53 @@ func (r *HTTPRequest) HasHeader(name string) bool {
 		return false
 	}
 
-	_, exists := r.headers[name]
-	return exists
+	return r.headers.Has(name)
 }
 
-// PathWithoutQuery returns the path without query string
+// PathWithoutQuery returns the path without query string, percent-decoded and
+// with dot segments removed as by CleanPath. A path that cannot be decoded is
+// returned as sent.
 func (r *HTTPRequest) PathWithoutQuery() string {
 	if r.path == "" {
 		return ""
 	}
 
-	queryIndex := strings.Index(r.path, "?")
-	if queryIndex == -1 {
-		return r.path
+	path := r.path
+	if queryIndex := strings.Index(path, "?"); queryIndex != -1 {
+		path = path[:queryIndex]
 	}
 
-	return r.path[:queryIndex]
+	if cleaned, ok := CleanPath(path); ok {
+		return cleaned
+	}
+	return path
 }
 
 // Clone creates a copy of the request
 func (r *HTTPRequest) Clone() Request {
 	clone := &HTTPRequest{
-		method:      r.method,
-		path:        r.path,
-		version:     r.version,
-		headers:     make(Header),
-		queryParams: make(map[string]string),
-		body:        r.body,
-		remoteAddr:  r.remoteAddr,
+		method:     r.method,
+		path:       r.path,
+		version:    r.version,
+		headers:    make(Header),
+		body:       r.body,
+		remoteAddr: r.remoteAddr,
+		ctx:        r.ctx,
 	}
 
 	// Deep copy headers
+	clone.order = append([]string(nil), r.order...)
 	for name, values := range r.headers {
 		clone.headers[name] = make([]string, len(values))
 		copy(clone.headers[name], values)
 	}
 
-	// Deep copy query params
-	for key, value := range r.queryParams {
-		clone.queryParams[key] = value
+	// Deep copy path params
+	if r.pathParams != nil {
+		clone.pathParams = make(map[string]string, len(r.pathParams))
+		for key, value := range r.pathParams {
+			clone.pathParams[key] = value
+		}
 	}
 
 	return clone
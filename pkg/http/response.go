@@ -13,7 +13,8 @@ type httpResponse struct {
 	statusCode StatusCode
 	version    Version
 	headers    Header
-	body       io.Reader
+	order      []string
+	body       io.ReadCloser
 }
 
 // NewResponse creates a new HTTP response
@@ -31,7 +32,7 @@ func NewResponseWithBody(statusCode StatusCode, version Version, body io.Reader)
 		statusCode: statusCode,
 		version:    version,
 		headers:    make(Header),
-		body:       body,
+		body:       readCloser(body),
 	}
 	return resp
 }
@@ -42,7 +43,7 @@ func NewTextResponse(statusCode StatusCode, version Version, text string) Respon
 		statusCode: statusCode,
 		version:    version,
 		headers:    make(Header),
-		body:       strings.NewReader(text),
+		body:       readCloser(strings.NewReader(text)),
 	}
 
 	// Set content type and length
@@ -58,7 +59,7 @@ func NewHTMLResponse(statusCode StatusCode, version Version, html string) Respon
 		statusCode: statusCode,
 		version:    version,
 		headers:    make(Header),
-		body:       strings.NewReader(html),
+		body:       readCloser(strings.NewReader(html)),
 	}
 
 	// Set content type and length
@@ -74,7 +75,7 @@ func NewJSONResponse(statusCode StatusCode, version Version, json string) Respon
 		statusCode: statusCode,
 		version:    version,
 		headers:    make(Header),
-		body:       strings.NewReader(json),
+		body:       readCloser(strings.NewReader(json)),
 	}
 
 	// Set content type and length
@@ -102,8 +103,15 @@ func (r *httpResponse) Headers() Header {
 	return r.headers
 }
 
-// Body returns the response body reader
-func (r *httpResponse) Body() io.Reader {
+// HeaderNames returns the header names in the order they were first added,
+// with any set directly on Headers() sorted after them and the framing
+// headers last
+func (r *httpResponse) HeaderNames() []string {
+	return r.Headers().OrderedNames(r.order)
+}
+
+// Body returns the response body, or nil when there is none
+func (r *httpResponse) Body() io.ReadCloser {
 	return r.body
 }
 
@@ -122,7 +130,8 @@ func (r *httpResponse) SetHeader(name, value string) {
 	if r.headers == nil {
 		r.headers = make(Header)
 	}
-	r.headers[name] = []string{value}
+	r.order = trackHeaderName(r.order, r.headers, name)
+	r.headers.Set(name, value)
 }
 
 // AddHeader adds a header value
@@ -130,12 +139,13 @@ func (r *httpResponse) AddHeader(name, value string) {
 	if r.headers == nil {
 		r.headers = make(Header)
 	}
-	r.headers[name] = append(r.headers[name], value)
+	r.order = trackHeaderName(r.order, r.headers, name)
+	r.headers.Add(name, value)
 }
 
-// SetBody sets the response body
+// SetBody sets the response body, adding a no-op Close to readers without one
 func (r *httpResponse) SetBody(body io.Reader) {
-	r.body = body
+	r.body = readCloser(body)
 }
 
 // ContentLength returns the content length
@@ -176,8 +186,8 @@ func (r *httpResponse) WriteTo(w io.Writer) (int64, error) {
 
 	// Write headers
 	if r.headers != nil {
-		for name, values := range r.headers {
-			for _, value := range values {
+		for _, name := range r.HeaderNames() {
+			for _, value := range r.headers[name] {
 				headerLine := fmt.Sprintf("%s%s%s%s",
 					name,
 					HTTPHeaderSeparator,
@@ -218,12 +228,7 @@ func (r *httpResponse) GetHeader(name string) string {
 		return ""
 	}
 
-	values, exists := r.headers[name]
-	if !exists || len(values) == 0 {
-		return ""
-	}
-
-	return values[0]
+	return r.headers.Get(name)
 }
 
 // GetHeaders returns all values for the header
@@ -232,7 +237,7 @@ func (r *httpResponse) GetHeaders(name string) []string {
 		return nil
 	}
 
-	return r.headers[name]
+	return r.headers.Values(name)
 }
 
 // HasHeader checks if a header exists
@@ -241,8 +246,7 @@ func (r *httpResponse) HasHeader(name string) bool {
 		return false
 	}
 
-	_, exists := r.headers[name]
-	return exists
+	return r.headers.Has(name)
 }
 
 // SetContentType sets the Content-Type header
@@ -272,6 +276,7 @@ func (r *httpResponse) Clone() Response {
 	}
 
 	// Deep copy headers
+	clone.order = append([]string(nil), r.order...)
 	for name, values := range r.headers {
 		clone.headers[name] = make([]string, len(values))
 		copy(clone.headers[name], values)
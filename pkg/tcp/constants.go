@@ -12,6 +12,9 @@ const (
 
 	// NetworkTCP6 represents TCP over IPv6
 	NetworkTCP6 = "tcp6"
+
+	// NetworkUnix represents Unix domain stream sockets
+	NetworkUnix = "unix"
 )
 
 // Default ports
@@ -130,6 +133,9 @@ const (
 	// ErrMsgInvalidAddress indicates an invalid address
 	ErrMsgInvalidAddress = "invalid address"
 
+	// ErrMsgUnsupportedNetwork indicates a network other than tcp, tcp4, tcp6 or unix
+	ErrMsgUnsupportedNetwork = "unsupported network"
+
 	// ErrMsgListenerClosed indicates the listener is closed
 	ErrMsgListenerClosed = "listener is closed"
 
@@ -144,4 +150,16 @@ const (
 
 	// ErrMsgInvalidMessageFormat indicates invalid message format
 	ErrMsgInvalidMessageFormat = "invalid message format"
+
+	// ErrMsgMultiplexerClosed indicates the multiplexer is closed
+	ErrMsgMultiplexerClosed = "multiplexer is closed"
+
+	// ErrMsgConnectionNotFound indicates a connection that is not multiplexed
+	ErrMsgConnectionNotFound = "connection not found"
+
+	// ErrMsgDuplicateConnection indicates a connection that is already multiplexed
+	ErrMsgDuplicateConnection = "connection already added"
+
+	// ErrMsgBroadcastFailed indicates a broadcast that some connections did not receive
+	ErrMsgBroadcastFailed = "broadcast failed"
 )
@@ -1,6 +1,8 @@
 package tcp
 
 import (
+	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"time"
@@ -52,6 +54,10 @@ type Dialer interface {
 
 	// DialTimeout acts like Dial but takes a timeout
 	DialTimeout(network, address string, timeout time.Duration) (Connection, error)
+
+	// DialWithRetry acts like Dial but redials failed attempts with
+	// exponential backoff until its retries run out or ctx is done
+	DialWithRetry(ctx context.Context, network, address string) (Connection, error)
 }
 
 // Server represents a TCP server interface
@@ -59,9 +65,13 @@ type Server interface {
 	// Start starts the server
 	Start() error
 
-	// Stop stops the server
+	// Stop stops the server, draining connections for the configured timeout
 	Stop() error
 
+	// Shutdown stops accepting, waits for in-flight connections until ctx
+	// is done, then closes the rest
+	Shutdown(ctx context.Context) error
+
 	// IsRunning returns true if the server is running
 	IsRunning() bool
 
@@ -77,10 +87,13 @@ type ConnectionHandler func(Connection)
 
 // Client represents a TCP client interface
 type Client interface {
-	// Connect establishes a connection to the server
+	// Connect establishes a connection to the server, retrying with backoff
 	Connect(address string) error
 
-	// ConnectWithTimeout establishes a connection with a timeout
+	// ConnectContext acts like Connect but stops retrying when ctx is done
+	ConnectContext(ctx context.Context, address string) error
+
+	// ConnectWithTimeout makes a single connection attempt with a timeout
 	ConnectWithTimeout(address string, timeout time.Duration) error
 
 	// Disconnect closes the connection
@@ -174,6 +187,23 @@ type MessageWriter interface {
 	SetMessageDelimiter([]byte)
 }
 
+// TLSConnection is a connection that may run over TLS
+type TLSConnection interface {
+	Connection
+
+	// TLSState returns the negotiated TLS state, or false for a plain connection
+	TLSState() (tls.ConnectionState, bool)
+}
+
+// ContextConnection is a connection accepted by a server
+type ContextConnection interface {
+	Connection
+
+	// Context is cancelled when the server starts draining, telling the
+	// handler to finish up
+	Context() context.Context
+}
+
 // BufferedConnection provides buffered I/O operations
 type BufferedConnection interface {
 	Connection
@@ -0,0 +1,51 @@
+package udp
+
+import "time"
+
+// Network protocols
+const (
+	// NetworkUDP represents the UDP network protocol
+	NetworkUDP = "udp"
+
+	// NetworkUDP4 represents UDP over IPv4
+	NetworkUDP4 = "udp4"
+
+	// NetworkUDP6 represents UDP over IPv6
+	NetworkUDP6 = "udp6"
+)
+
+// Default ports
+const (
+	// DefaultEchoPort is the default port for echo server
+	DefaultEchoPort = 8081
+)
+
+// Datagram settings
+const (
+	// MaxDatagramSize is the largest payload a UDP datagram over IPv4 can carry
+	MaxDatagramSize = 65507
+
+	// DefaultReceiveTimeout is the default time to wait for a datagram
+	DefaultReceiveTimeout = 5 * time.Second
+)
+
+// Server settings
+const (
+	// DefaultServerWriteTimeout is the default timeout for sending a reply
+	DefaultServerWriteTimeout = 5 * time.Second
+)
+
+// Error messages specific to UDP operations
+const (
+	// ErrMsgConnectionClosed indicates the socket is closed
+	ErrMsgConnectionClosed = "socket is closed"
+
+	// ErrMsgNotConnected indicates a client that has no peer yet
+	ErrMsgNotConnected = "client is not connected"
+
+	// ErrMsgDatagramTooLarge indicates a payload that does not fit in one datagram
+	ErrMsgDatagramTooLarge = "datagram too large"
+
+	// ErrMsgInvalidAddress indicates an invalid address
+	ErrMsgInvalidAddress = "invalid address"
+)
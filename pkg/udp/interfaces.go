@@ -0,0 +1,81 @@
+package udp
+
+import (
+	"net"
+	"time"
+)
+
+// PacketConn represents a UDP socket that sends and receives whole datagrams
+type PacketConn interface {
+	// ReadFrom reads one datagram, returning its size and sender
+	ReadFrom([]byte) (int, net.Addr, error)
+
+	// WriteTo sends one datagram to addr
+	WriteTo([]byte, net.Addr) (int, error)
+
+	// Close closes the socket
+	Close() error
+
+	// LocalAddr returns the local network address
+	LocalAddr() net.Addr
+
+	// SetDeadline sets the read and write deadlines
+	SetDeadline(time.Time) error
+
+	// SetReadDeadline sets the deadline for future ReadFrom calls
+	SetReadDeadline(time.Time) error
+
+	// SetWriteDeadline sets the deadline for future WriteTo calls
+	SetWriteDeadline(time.Time) error
+}
+
+// Packet is a datagram received by a server
+type Packet struct {
+	// Data is the payload, owned by the handler
+	Data []byte
+
+	// Addr is the sender
+	Addr net.Addr
+}
+
+// PacketHandler handles one datagram, replying through conn if it wants to
+type PacketHandler func(conn PacketConn, packet Packet)
+
+// Server represents a UDP server interface
+type Server interface {
+	// Start starts the server
+	Start() error
+
+	// Stop stops the server
+	Stop() error
+
+	// IsRunning returns true if the server is running
+	IsRunning() bool
+
+	// Addr returns the server's listening address
+	Addr() net.Addr
+
+	// SetHandler sets the datagram handler function
+	SetHandler(PacketHandler)
+}
+
+// Client represents a UDP client interface that exchanges datagrams with one peer
+type Client interface {
+	// Connect sets the peer datagrams are sent to and accepted from
+	Connect(address string) error
+
+	// Disconnect closes the socket
+	Disconnect() error
+
+	// IsConnected returns true if the client has a peer
+	IsConnected() bool
+
+	// Send sends data as one datagram
+	Send([]byte) error
+
+	// Receive reads one datagram, waiting at most DefaultReceiveTimeout
+	Receive([]byte) (int, error)
+
+	// ReceiveWithTimeout reads one datagram, waiting at most timeout
+	ReceiveWithTimeout([]byte, time.Duration) (int, error)
+}